@@ -60,10 +60,18 @@ type getACLBuilder struct {
 }
 
 func (b *getACLBuilder) ForPath(givenPath string) ([]zk.ACL, error) {
+	if err := b.client.checkStarted(); err != nil {
+		return nil, err
+	}
+
+	if err := b.client.validateSchema(givenPath, SCHEMA_GET_ACL); err != nil {
+		return nil, err
+	}
+
 	adjustedPath := b.client.fixForNamespace(givenPath, false)
 
 	if b.backgrounding.inBackground {
-		go b.pathInBackground(adjustedPath, givenPath)
+		b.client.goSafely("getACLBuilder.pathInBackground", func() { b.pathInBackground(adjustedPath, givenPath) })
 
 		return nil, nil
 	} else {
@@ -78,24 +86,22 @@ func (b *getACLBuilder) pathInBackground(path string, givenPath string) {
 
 	acls, err := b.pathInForeground(path)
 
-	if b.backgrounding.callback != nil {
-		event := &curatorEvent{
-			eventType: GET_ACL,
-			err:       err,
-			path:      b.client.unfixForNamespace(path),
-			acls:      acls,
-			stat:      b.stat,
-			context:   b.backgrounding.context,
-		}
+	event := &curatorEvent{
+		eventType: GET_ACL,
+		err:       err,
+		path:      b.client.unfixForNamespace(path),
+		acls:      acls,
+		stat:      b.stat,
+		context:   b.backgrounding.context,
+	}
 
-		if err != nil {
-			event.path = givenPath
-		}
+	if err != nil {
+		event.path = givenPath
+	}
 
-		event.name = GetNodeFromPath(event.path)
+	event.name = GetNodeFromPath(event.path)
 
-		b.backgrounding.callback(b.client, event)
-	}
+	b.client.processBackgroundEvent(b.backgrounding.callback, event)
 }
 
 func (b *getACLBuilder) pathInForeground(path string) ([]zk.ACL, error) {
@@ -162,10 +168,18 @@ type setACLBuilder struct {
 }
 
 func (b *setACLBuilder) ForPath(givenPath string) (*zk.Stat, error) {
+	if err := b.client.checkStarted(); err != nil {
+		return nil, err
+	}
+
+	if err := b.client.validateSetACLSchema(givenPath, &b.acling); err != nil {
+		return nil, err
+	}
+
 	adjustedPath := b.client.fixForNamespace(givenPath, false)
 
 	if b.backgrounding.inBackground {
-		go b.pathInBackground(adjustedPath, givenPath)
+		b.client.goSafely("setACLBuilder.pathInBackground", func() { b.pathInBackground(adjustedPath, givenPath) })
 
 		return nil, nil
 	} else {
@@ -180,24 +194,22 @@ func (b *setACLBuilder) pathInBackground(path string, givenPath string) {
 
 	stat, err := b.pathInForeground(path)
 
-	if b.backgrounding.callback != nil {
-		event := &curatorEvent{
-			eventType: SET_ACL,
-			err:       err,
-			path:      b.client.unfixForNamespace(path),
-			acls:      b.acling.getAclList(path),
-			stat:      stat,
-			context:   b.backgrounding.context,
-		}
+	event := &curatorEvent{
+		eventType: SET_ACL,
+		err:       err,
+		path:      b.client.unfixForNamespace(path),
+		acls:      b.acling.getAclList(path),
+		stat:      stat,
+		context:   b.backgrounding.context,
+	}
 
-		if err != nil {
-			event.path = givenPath
-		}
+	if err != nil {
+		event.path = givenPath
+	}
 
-		event.name = GetNodeFromPath(event.path)
+	event.name = GetNodeFromPath(event.path)
 
-		b.backgrounding.callback(b.client, event)
-	}
+	b.client.processBackgroundEvent(b.backgrounding.callback, event)
 }
 
 func (b *setACLBuilder) pathInForeground(path string) (*zk.Stat, error) {
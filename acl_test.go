@@ -31,8 +31,9 @@ func (s *GetAclBuilderTestSuite) TestGetACL() {
 }
 
 func (s *GetAclBuilderTestSuite) TestNamespace() {
-	s.WithNamespace("parent", func(builder *CuratorFrameworkBuilder, client CuratorFramework, conn *mockConn, stat *zk.Stat, acls []zk.ACL) {
+	s.WithNamespace("parent", func(builder *CuratorFrameworkBuilder, client CuratorFramework, conn *mockConn, aclProvider *mockACLProvider, stat *zk.Stat, acls []zk.ACL) {
 		conn.On("Exists", "/parent").Return(false, nil, nil).Once()
+		aclProvider.On("GetAclForPath", "/parent").Return(OPEN_ACL_UNSAFE).Once()
 		conn.On("Create", "/parent", []byte{}, int32(PERSISTENT), OPEN_ACL_UNSAFE).Return("/parent", nil).Once()
 		conn.On("GetACL", "/parent/child").Return(READ_ACL_UNSAFE, stat, nil).Once()
 
@@ -90,9 +91,33 @@ func (s *SetAclBuilderTestSuite) TestGetACL() {
 	})
 }
 
+func (s *SetAclBuilderTestSuite) TestAclProviderFallback() {
+	s.With(func(client CuratorFramework, conn *mockConn, aclProvider *mockACLProvider, acls []zk.ACL, stat *zk.Stat) {
+		// use the ACLs of the path if no explicit ACLs
+		aclProvider.On("GetAclForPath", "/node").Return(acls).Once()
+		conn.On("SetACL", "/node", acls, int32(AnyVersion)).Return(stat, nil).Once()
+
+		nodeStat, err := client.SetACL().ForPath("/node")
+
+		assert.Equal(s.T(), stat, nodeStat)
+		assert.NoError(s.T(), err)
+
+		// use the default ACLs if the path has no ACLs
+		aclProvider.On("GetAclForPath", "/other").Return(nil).Once()
+		aclProvider.On("GetDefaultAcl").Return(READ_ACL_UNSAFE).Once()
+		conn.On("SetACL", "/other", READ_ACL_UNSAFE, int32(AnyVersion)).Return(stat, nil).Once()
+
+		nodeStat, err = client.SetACL().ForPath("/other")
+
+		assert.Equal(s.T(), stat, nodeStat)
+		assert.NoError(s.T(), err)
+	})
+}
+
 func (s *SetAclBuilderTestSuite) TestNamespace() {
-	s.WithNamespace("parent", func(builder *CuratorFrameworkBuilder, client CuratorFramework, conn *mockConn, version int32, stat *zk.Stat, acls []zk.ACL) {
+	s.WithNamespace("parent", func(builder *CuratorFrameworkBuilder, client CuratorFramework, conn *mockConn, aclProvider *mockACLProvider, version int32, stat *zk.Stat, acls []zk.ACL) {
 		conn.On("Exists", "/parent").Return(false, nil, nil).Once()
+		aclProvider.On("GetAclForPath", "/parent").Return(OPEN_ACL_UNSAFE).Once()
 		conn.On("Create", "/parent", []byte{}, int32(PERSISTENT), OPEN_ACL_UNSAFE).Return("/parent", nil).Once()
 		conn.On("SetACL", "/parent/child", acls, version).Return(stat, nil).Once()
 
@@ -1,6 +1,8 @@
 package curator
 
 import (
+	"errors"
+
 	"github.com/samuel/go-zookeeper/zk"
 )
 
@@ -20,6 +22,12 @@ var (
 	ErrClosing                 = zk.ErrClosing
 	ErrNothing                 = zk.ErrNothing
 	ErrSessionMoved            = zk.ErrSessionMoved
+	ErrTTLNotSupported         = errors.New("TTL nodes are not supported by the connection")
+	ErrContainerNotSupported   = errors.New("container nodes are not supported by the connection")
+	ErrConflictingCreateMode   = errors.New("the create mode cannot be set with the protected ephemeral sequential mode")
+	ErrConflictingTTLMode      = errors.New("the TTL can only be set with the persistent create modes")
+	ErrReconfigNotSupported    = errors.New("the reconfiguration is not supported by the connection")
+	ErrConflictingReconfig     = errors.New("the members cannot be replaced with the joining or leaving servers")
 )
 
 var (
@@ -31,6 +39,9 @@ var (
 
 const AnyVersion int32 = -1
 
+// The node which stores the dynamic config of the ensemble (ZooKeeper 3.5+)
+const ZOOKEEPER_CONFIG_NODE = "/zookeeper/config"
+
 type CreateMode int32
 
 const (
@@ -38,10 +49,24 @@ const (
 	PERSISTENT_SEQUENTIAL            = zk.FlagSequence
 	EPHEMERAL                        = zk.FlagEphemeral
 	EPHEMERAL_SEQUENTIAL             = zk.FlagEphemeral + zk.FlagSequence
+
+	// The container node will be deleted by the server once its last child was deleted (ZooKeeper 3.5+),
+	// it could only be created through a ContainerConnection.
+	CONTAINER CreateMode = 4
+
+	// The TTL nodes require ZooKeeper 3.6+
+	PERSISTENT_WITH_TTL            CreateMode = 5
+	PERSISTENT_SEQUENTIAL_WITH_TTL CreateMode = 6
 )
 
-func (m CreateMode) IsSequential() bool { return (m & zk.FlagSequence) == zk.FlagSequence }
-func (m CreateMode) IsEphemeral() bool  { return (m & zk.FlagEphemeral) == zk.FlagEphemeral }
+func (m CreateMode) IsSequential() bool {
+	return m == PERSISTENT_SEQUENTIAL || m == EPHEMERAL_SEQUENTIAL || m == PERSISTENT_SEQUENTIAL_WITH_TTL
+}
+func (m CreateMode) IsEphemeral() bool { return m == EPHEMERAL || m == EPHEMERAL_SEQUENTIAL }
+func (m CreateMode) IsContainer() bool { return m == CONTAINER }
+func (m CreateMode) IsTTL() bool {
+	return m == PERSISTENT_WITH_TTL || m == PERSISTENT_SEQUENTIAL_WITH_TTL
+}
 
 // Called when the async background operation completes
 type BackgroundCallback func(client CuratorFramework, event CuratorEvent) error
@@ -53,6 +78,32 @@ type backgrounding struct {
 }
 
 type watching struct {
-	watcher Watcher
-	watched bool
+	watcher      Watcher
+	watched      bool
+	filters      []WatchEventFilter
+	registration *WatchRegistration
+}
+
+// Deliver the events of the watch set on the path, and register it if required
+func (w *watching) watch(client *curatorFramework, path string, watcherType WatcherType, events <-chan zk.Event) {
+	if events == nil {
+		return
+	}
+
+	var removed <-chan struct{}
+
+	if w.registration != nil {
+		removed = w.registration.register(client, path, watcherType)
+	}
+
+	client.watchEvents(events, w.getWatcher(), removed)
+}
+
+// Return the watcher which only receives the events accepted by the filters
+func (w *watching) getWatcher() Watcher {
+	if w.watcher == nil || len(w.filters) == 0 {
+		return w.watcher
+	}
+
+	return NewFilteredWatcher(w.watcher, w.filters...)
 }
@@ -0,0 +1,244 @@
+package curator
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/samuel/go-zookeeper/zk"
+)
+
+const (
+	// The max number of the concurrent operations of a batch
+	DEFAULT_BATCH_CONCURRENCY = 10
+
+	// The max size of a request accepted by ZooKeeper, the default jute.maxbuffer of the server
+	DEFAULT_MAX_REQUEST_SIZE = 0xfffff
+)
+
+var ErrBatchTooLarge = errors.New("the batch exceeds the max request size of ZooKeeper")
+
+type BatchGetDataBuilder interface {
+	// Get the data of the nodes concurrently, the per-node errors (e.g. zk.ErrNoNode) are returned in the error map,
+	// the error is only returned if the batch can't proceed, e.g. the connection is lost.
+	ForPaths(paths ...string) (map[string][]byte, map[string]error, error)
+
+	// Cause the data to be de-compressed using the configured compression provider
+	Decompressed() BatchGetDataBuilder
+
+	// Limit the number of the concurrent reads, the default is DEFAULT_BATCH_CONCURRENCY
+	WithConcurrency(concurrency int) BatchGetDataBuilder
+
+	// Abort the batch and return ctx.Err() once the context is done
+	WithContext(ctx context.Context) BatchGetDataBuilder
+}
+
+type batchGetDataBuilder struct {
+	client      *curatorFramework
+	decompress  bool
+	concurrency int
+	ctx         context.Context
+}
+
+func (b *batchGetDataBuilder) ForPaths(paths ...string) (map[string][]byte, map[string]error, error) {
+	if err := b.client.checkStarted(); err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithCancel(b.ctx)
+
+	defer cancel()
+
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	var fatal error
+
+	results := make(map[string][]byte)
+	errs := make(map[string]error)
+	semaphore := make(chan struct{}, b.concurrency)
+
+	for _, path := range paths {
+		select {
+		case semaphore <- struct{}{}:
+		case <-ctx.Done():
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+
+		go func(path string) {
+			defer func() { <-semaphore; wg.Done() }()
+
+			builder := b.client.GetData().WithContext(ctx)
+
+			if b.decompress {
+				builder = builder.Decompressed()
+			}
+
+			data, err := builder.ForPath(path)
+
+			lock.Lock()
+			defer lock.Unlock()
+
+			if err == nil {
+				results[path] = data
+			} else if isFatalBatchError(err) {
+				if fatal == nil {
+					fatal = err
+				}
+
+				cancel() // stop the other reads
+			} else {
+				errs[path] = err
+			}
+		}(path)
+	}
+
+	wg.Wait()
+
+	if fatal == nil {
+		fatal = b.ctx.Err()
+	}
+
+	return results, errs, fatal
+}
+
+// The errors which abort the batch, instead of failing the operation on one node
+func isFatalBatchError(err error) bool {
+	switch err {
+	case zk.ErrConnectionClosed, zk.ErrSessionExpired, zk.ErrSessionMoved, zk.ErrClosing, zk.ErrNoServer, ErrClientNotStarted:
+		return true
+	}
+
+	var netErr net.Error
+
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr)
+}
+
+func (b *batchGetDataBuilder) Decompressed() BatchGetDataBuilder {
+	b.decompress = true
+
+	return b
+}
+
+func (b *batchGetDataBuilder) WithConcurrency(concurrency int) BatchGetDataBuilder {
+	if concurrency > 0 {
+		b.concurrency = concurrency
+	}
+
+	return b
+}
+
+func (b *batchGetDataBuilder) WithContext(ctx context.Context) BatchGetDataBuilder {
+	b.ctx = ctx
+
+	return b
+}
+
+type BatchCreateBuilder interface {
+	// Add a node to create in the batch
+	Add(path string, data []byte, mode CreateMode, acls []zk.ACL) BatchCreateBuilder
+
+	// Set the max request size configured by the jute.maxbuffer of the server, the default is DEFAULT_MAX_REQUEST_SIZE
+	WithMaxRequestSize(size int) BatchCreateBuilder
+
+	// Create all the nodes atomically with a single request,
+	// return the created paths without the namespace in the order of the nodes added.
+	Commit() ([]string, error)
+}
+
+type batchCreateRequest struct {
+	path string
+	data []byte
+	mode CreateMode
+	acls []zk.ACL
+}
+
+type batchCreateBuilder struct {
+	client         *curatorFramework
+	requests       []*batchCreateRequest
+	maxRequestSize int
+}
+
+func (b *batchCreateBuilder) Add(path string, data []byte, mode CreateMode, acls []zk.ACL) BatchCreateBuilder {
+	b.requests = append(b.requests, &batchCreateRequest{path, data, mode, acls})
+
+	return b
+}
+
+func (b *batchCreateBuilder) WithMaxRequestSize(size int) BatchCreateBuilder {
+	b.maxRequestSize = size
+
+	return b
+}
+
+func (b *batchCreateBuilder) Commit() ([]string, error) {
+	if err := b.client.checkStarted(); err != nil {
+		return nil, err
+	}
+
+	if b.estimateRequestSize() > b.maxRequestSize {
+		return nil, ErrBatchTooLarge
+	}
+
+	var transaction Transaction = b.client.InTransaction()
+
+	for _, req := range b.requests {
+		builder := transaction.Create().WithMode(req.mode)
+
+		if req.acls != nil {
+			builder = builder.WithACL(req.acls...)
+		}
+
+		transaction = builder.ForPathWithData(req.path, req.data).And()
+	}
+
+	final, ok := transaction.(TransactionFinal)
+
+	if !ok {
+		return nil, ErrEmptyTransaction
+	}
+
+	results, err := final.Commit()
+
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, len(results))
+
+	for i, result := range results {
+		paths[i] = result.ResultPath
+	}
+
+	return paths, nil
+}
+
+// Estimate the size of the multi request in the wire format
+func (b *batchCreateBuilder) estimateRequestSize() int {
+	size := multiHeaderSize
+
+	for _, req := range b.requests {
+		size += estimateCreateRequestSize(b.client.fixForNamespace(req.path, false), req.data, req.acls)
+	}
+
+	return size
+}
+
+// The size of the header of each operation and of the end of a multi request: the type, done flag and error
+const multiHeaderSize = 4 + 1 + 4
+
+// Estimate the size of a create operation of a multi request in the wire format
+func estimateCreateRequestSize(path string, data []byte, acls []zk.ACL) int {
+	size := multiHeaderSize + 4 + len(path) + 4 + len(data) + 4 + 4
+
+	for _, acl := range acls {
+		size += 4 + 4 + len(acl.Scheme) + 4 + len(acl.ID)
+	}
+
+	return size
+}
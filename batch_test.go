@@ -0,0 +1,137 @@
+package curator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/samuel/go-zookeeper/zk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchGetData(t *testing.T) {
+	zookeeper := NewFakeZookeeper()
+
+	assert.NoError(t, zookeeper.Start())
+
+	defer zookeeper.Close()
+
+	builder := &CuratorFrameworkBuilder{
+		ZookeeperDialer:   zookeeper,
+		Namespace:         "app",
+		SessionTimeout:    DEFAULT_SESSION_TIMEOUT,
+		ConnectionTimeout: DEFAULT_CONNECTION_TIMEOUT,
+		RetryPolicy:       NewRetryOneTime(0),
+	}
+
+	client := builder.ConnectString(zookeeper.ConnectString()).Build()
+
+	_, _, err := client.BatchGetData().ForPaths("/node")
+
+	assert.Equal(t, ErrClientNotStarted, err)
+	assert.NoError(t, client.Start())
+
+	defer client.Close()
+
+	var paths []string
+
+	for i := 0; i < 20; i++ {
+		path := fmt.Sprintf("/node-%d", i)
+
+		_, err := client.Create().Compressed().ForPathWithData(path, []byte(path))
+
+		assert.NoError(t, err)
+
+		paths = append(paths, path)
+	}
+
+	data, errs, err := client.BatchGetData().Decompressed().WithConcurrency(3).ForPaths(append(paths, "/missing")...)
+
+	assert.NoError(t, err)
+	assert.Len(t, data, 20)
+
+	for _, path := range paths {
+		assert.Equal(t, []byte(path), data[path])
+	}
+
+	// the per-node errors
+	assert.Equal(t, map[string]error{"/missing": zk.ErrNoNode}, errs)
+
+	// abort the batch
+	ctx, cancel := context.WithCancel(context.Background())
+
+	cancel()
+
+	_, _, err = client.BatchGetData().WithContext(ctx).ForPaths(paths...)
+
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestBatchCreate(t *testing.T) {
+	zookeeper := NewFakeZookeeper()
+
+	assert.NoError(t, zookeeper.Start())
+
+	defer zookeeper.Close()
+
+	builder := &CuratorFrameworkBuilder{
+		ZookeeperDialer:   zookeeper,
+		Namespace:         "app",
+		SessionTimeout:    DEFAULT_SESSION_TIMEOUT,
+		ConnectionTimeout: DEFAULT_CONNECTION_TIMEOUT,
+		RetryPolicy:       NewRetryOneTime(0),
+	}
+
+	client := builder.ConnectString(zookeeper.ConnectString()).Build()
+
+	assert.NoError(t, client.Start())
+
+	defer client.Close()
+
+	acls := zk.DigestACL(zk.PermAll, "user", "password")
+
+	paths, err := client.BatchCreate().
+		Add("/parent", []byte("parent"), PERSISTENT, nil).
+		Add("/parent/seq-", []byte("seq"), PERSISTENT_SEQUENTIAL, nil).
+		Add("/parent/secret", nil, PERSISTENT, acls).
+		Commit()
+
+	assert.Equal(t, []string{"/parent", "/parent/seq-0000000000", "/parent/secret"}, paths)
+	assert.NoError(t, err)
+
+	data, err := client.GetData().ForPath("/parent/seq-0000000000")
+
+	assert.Equal(t, []byte("seq"), data)
+	assert.NoError(t, err)
+
+	nodeACLs, err := client.GetACL().ForPath("/parent/secret")
+
+	assert.Equal(t, acls, nodeACLs)
+	assert.NoError(t, err)
+
+	// roll back the whole batch
+	_, err = client.BatchCreate().
+		Add("/other", nil, PERSISTENT, nil).
+		Add("/parent", nil, PERSISTENT, nil).
+		Commit()
+
+	assert.Equal(t, zk.ErrNodeExists, err)
+
+	stat, err := client.CheckExists().ForPath("/other")
+
+	assert.Nil(t, stat)
+	assert.NoError(t, err)
+
+	// validate the request size
+	_, err = client.BatchCreate().Add("/large", make([]byte, DEFAULT_MAX_REQUEST_SIZE), PERSISTENT, nil).Commit()
+
+	assert.Equal(t, ErrBatchTooLarge, err)
+
+	_, err = client.BatchCreate().WithMaxRequestSize(64).Add("/a", make([]byte, 32), PERSISTENT, nil).Add("/b", make([]byte, 32), PERSISTENT, nil).Commit()
+
+	assert.Equal(t, ErrBatchTooLarge, err)
+
+	_, err = client.BatchCreate().Commit()
+
+	assert.Equal(t, ErrEmptyTransaction, err)
+}
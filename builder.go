@@ -1,6 +1,9 @@
 package curator
 
 import (
+	"context"
+	"time"
+
 	"github.com/samuel/go-zookeeper/zk"
 )
 
@@ -18,11 +21,30 @@ type CreateBuilder interface {
 	// Causes any parent nodes to get created if they haven't already been
 	CreatingParentsIfNeeded() CreateBuilder
 
+	// Set the data of the node with any version if it already exists
+	OrSetData() CreateBuilder
+
+	// Return the path instead of ErrNodeExists if the node already exists, the existing data is kept.
+	// OrSetData takes precedence, the data will be set if both are used.
+	Idempotent() CreateBuilder
+
 	// CreateModable[T]
 	//
 	// Set a create mode - the default is CreateMode.PERSISTENT
 	WithMode(mode CreateMode) CreateBuilder
 
+	// Protect the node name with a UUID prefix,
+	// so that the node could be found again if the connection is lost before the response of the creation is received
+	WithProtection() CreateBuilder
+
+	// Create a protected EPHEMERAL_SEQUENTIAL node, which is the recommended way to create the lock or leader nodes
+	WithProtectedEphemeralSequential() CreateBuilder
+
+	// Create a TTL node (ZooKeeper 3.6+) which will be deleted once the TTL elapsed if it has no children and has not been modified,
+	// the mode is changed to PERSISTENT_WITH_TTL or PERSISTENT_SEQUENTIAL_WITH_TTL when the node is created,
+	// ErrConflictingTTLMode is returned for the other modes.
+	WithTTL(ttl time.Duration) CreateBuilder
+
 	// ACLable[T]
 	//
 	// Set an ACL list
@@ -46,6 +68,16 @@ type CreateBuilder interface {
 
 	// Perform the action in the background
 	InBackgroundWithCallbackAndContext(callback BackgroundCallback, context interface{}) CreateBuilder
+
+	// Contextable[T]
+	//
+	// Abort the operation and return ctx.Err() once the context is done
+	WithContext(ctx context.Context) CreateBuilder
+
+	// Retryable[T]
+	//
+	// Use the retry policy instead of the default one of the framework for this operation
+	WithRetryPolicy(retryPolicy RetryPolicy) CreateBuilder
 }
 
 type CheckExistsBuilder interface {
@@ -62,6 +94,12 @@ type CheckExistsBuilder interface {
 	// Set a watcher for the operation
 	UsingWatcher(watcher Watcher) CheckExistsBuilder
 
+	// Only deliver the watched events accepted by all the filters to the watcher
+	WithWatchEventFilter(filters ...WatchEventFilter) CheckExistsBuilder
+
+	// Have the operation set a watch and store its registration, which could be used to remove the watch
+	StoringWatchRegistrationIn(registration *WatchRegistration) CheckExistsBuilder
+
 	// Backgroundable[T]
 	//
 	// Perform the action in the background
@@ -75,6 +113,11 @@ type CheckExistsBuilder interface {
 
 	// Perform the action in the background
 	InBackgroundWithCallbackAndContext(callback BackgroundCallback, context interface{}) CheckExistsBuilder
+
+	// Contextable[T]
+	//
+	// Abort the operation and return ctx.Err() once the context is done
+	WithContext(ctx context.Context) CheckExistsBuilder
 }
 
 type DeleteBuilder interface {
@@ -88,6 +131,15 @@ type DeleteBuilder interface {
 	// Will also delete children if they exist.
 	DeletingChildrenIfNeeded() DeleteBuilder
 
+	// Same as DeletingChildrenIfNeeded, the descendants are recursively deleted before the node
+	DeleteChildrenIfNeeded() DeleteBuilder
+
+	// Quietly[T]
+	//
+	// Return nil instead of ErrNoNode if the node doesn't exist,
+	// the other errors (e.g. ErrBadVersion or the connection errors) are still returned
+	Quietly() DeleteBuilder
+
 	// Versionable[T]
 	//
 	// Use the given version (the default is -1)
@@ -106,6 +158,16 @@ type DeleteBuilder interface {
 
 	// Perform the action in the background
 	InBackgroundWithCallbackAndContext(callback BackgroundCallback, context interface{}) DeleteBuilder
+
+	// Contextable[T]
+	//
+	// Abort the operation and return ctx.Err() once the context is done
+	WithContext(ctx context.Context) DeleteBuilder
+
+	// Retryable[T]
+	//
+	// Use the retry policy instead of the default one of the framework for this operation
+	WithRetryPolicy(retryPolicy RetryPolicy) DeleteBuilder
 }
 
 type GetDataBuilder interface {
@@ -119,6 +181,10 @@ type GetDataBuilder interface {
 	// Cause the data to be de-compressed using the configured compression provider
 	Decompressed() GetDataBuilder
 
+	// Store whether the data has actually been de-compressed,
+	// the uncompressed data is returned as is if the compression provider is a CompressionDetector
+	StoringDecompressedIn(decompressed *bool) GetDataBuilder
+
 	// Statable[T]
 	//
 	// Have the operation fill the provided stat object
@@ -132,6 +198,12 @@ type GetDataBuilder interface {
 	// Set a watcher for the operation
 	UsingWatcher(watcher Watcher) GetDataBuilder
 
+	// Only deliver the watched events accepted by all the filters to the watcher
+	WithWatchEventFilter(filters ...WatchEventFilter) GetDataBuilder
+
+	// Have the operation set a watch and store its registration, which could be used to remove the watch
+	StoringWatchRegistrationIn(registration *WatchRegistration) GetDataBuilder
+
 	// Backgroundable[T]
 	//
 	// Perform the action in the background
@@ -145,6 +217,25 @@ type GetDataBuilder interface {
 
 	// Perform the action in the background
 	InBackgroundWithCallbackAndContext(callback BackgroundCallback, context interface{}) GetDataBuilder
+
+	// Contextable[T]
+	//
+	// Abort the operation and return ctx.Err() once the context is done
+	WithContext(ctx context.Context) GetDataBuilder
+
+	// Retryable[T]
+	//
+	// Use the retry policy instead of the default one of the framework for this operation
+	WithRetryPolicy(retryPolicy RetryPolicy) GetDataBuilder
+
+	// Cacheable[T]
+	//
+	// Return the data from the cache set by WithCache if it's available, which may be stale.
+	// The cache is skipped by the watched or background reads.
+	EventuallyConsistent() GetDataBuilder
+
+	// Set the cache used by the eventually consistent reads
+	WithCache(cache NodeDataCache) GetDataBuilder
 }
 
 type SetDataBuilder interface {
@@ -179,6 +270,16 @@ type SetDataBuilder interface {
 
 	// Perform the action in the background
 	InBackgroundWithCallbackAndContext(callback BackgroundCallback, context interface{}) SetDataBuilder
+
+	// Contextable[T]
+	//
+	// Abort the operation and return ctx.Err() once the context is done
+	WithContext(ctx context.Context) SetDataBuilder
+
+	// Retryable[T]
+	//
+	// Use the retry policy instead of the default one of the framework for this operation
+	WithRetryPolicy(retryPolicy RetryPolicy) SetDataBuilder
 }
 
 type GetChildrenBuilder interface {
@@ -192,6 +293,14 @@ type GetChildrenBuilder interface {
 	// Have the operation fill the provided stat object
 	StoringStatIn(stat *zk.Stat) GetChildrenBuilder
 
+	// Same as StoringStatIn, the stat of the parent node is stored in the provided object
+	WithStat(stat *zk.Stat) GetChildrenBuilder
+
+	// Versionable[T]
+	//
+	// Return a VersionMismatchError instead of the children if the version of the parent node doesn't match
+	OfVersion(version int32) GetChildrenBuilder
+
 	// Watchable[T]
 	//
 	// Have the operation set a watch
@@ -200,6 +309,12 @@ type GetChildrenBuilder interface {
 	// Set a watcher for the operation
 	UsingWatcher(watcher Watcher) GetChildrenBuilder
 
+	// Only deliver the watched events accepted by all the filters to the watcher
+	WithWatchEventFilter(filters ...WatchEventFilter) GetChildrenBuilder
+
+	// Have the operation set a watch and store its registration, which could be used to remove the watch
+	StoringWatchRegistrationIn(registration *WatchRegistration) GetChildrenBuilder
+
 	// Backgroundable[T]
 	//
 	// Perform the action in the background
@@ -213,6 +328,16 @@ type GetChildrenBuilder interface {
 
 	// Perform the action in the background
 	InBackgroundWithCallbackAndContext(callback BackgroundCallback, context interface{}) GetChildrenBuilder
+
+	// Contextable[T]
+	//
+	// Abort the operation and return ctx.Err() once the context is done
+	WithContext(ctx context.Context) GetChildrenBuilder
+
+	// Retryable[T]
+	//
+	// Use the retry policy instead of the default one of the framework for this operation
+	WithRetryPolicy(retryPolicy RetryPolicy) GetChildrenBuilder
 }
 
 type GetACLBuilder interface {
@@ -1,21 +1,46 @@
 package curator
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/samuel/go-zookeeper/zk"
 )
 
+// The error returned when the version of the node doesn't match the expected one
+type VersionMismatchError struct {
+	Path     string
+	Expected int32
+	Actual   int32
+}
+
+func (e *VersionMismatchError) Error() string {
+	return fmt.Sprintf("version mismatch at path `%s`, expected %d but got %d", e.Path, e.Expected, e.Actual)
+}
+
 type getChildrenBuilder struct {
 	client        *curatorFramework
 	backgrounding backgrounding
 	stat          *zk.Stat
+	version       int32
 	watching      watching
+	ctx           context.Context
+	retryPolicy   RetryPolicy
 }
 
 func (b *getChildrenBuilder) ForPath(givenPath string) ([]string, error) {
+	if err := b.client.checkStarted(); err != nil {
+		return nil, err
+	}
+
+	if err := b.client.validateSchema(givenPath, SCHEMA_GET_CHILDREN); err != nil {
+		return nil, err
+	}
+
 	adjustedPath := b.client.fixForNamespace(givenPath, false)
 
 	if b.backgrounding.inBackground {
-		go b.pathInBackground(adjustedPath, givenPath)
+		b.client.goSafely("getChildrenBuilder.pathInBackground", func() { b.pathInBackground(adjustedPath, givenPath) })
 
 		return nil, nil
 	}
@@ -34,30 +59,28 @@ func (b *getChildrenBuilder) pathInBackground(adjustedPath, givenPath string) {
 
 	children, err := b.pathInForeground(adjustedPath)
 
-	if b.backgrounding.callback != nil {
-		event := &curatorEvent{
-			eventType: CHILDREN,
-			err:       err,
-			path:      b.client.unfixForNamespace(adjustedPath),
-			children:  children,
-			stat:      b.stat,
-			context:   b.backgrounding.context,
-		}
+	event := &curatorEvent{
+		eventType: CHILDREN,
+		err:       err,
+		path:      b.client.unfixForNamespace(adjustedPath),
+		children:  children,
+		stat:      b.stat,
+		context:   b.backgrounding.context,
+	}
 
-		if err != nil {
-			event.path = givenPath
-		}
+	if err != nil {
+		event.path = givenPath
+	}
 
-		event.name = GetNodeFromPath(event.path)
+	event.name = GetNodeFromPath(event.path)
 
-		b.backgrounding.callback(b.client, event)
-	}
+	b.client.processBackgroundEvent(b.backgrounding.callback, event)
 }
 
 func (b *getChildrenBuilder) pathInForeground(path string) ([]string, error) {
 	zkClient := b.client.ZookeeperClient()
 
-	result, err := zkClient.NewRetryLoop().CallWithRetry(func() (interface{}, error) {
+	result, err := callWithRetryContext(b.ctx, b.client.newRetryLoop(b.retryPolicy), func() (interface{}, error) {
 		if conn, err := zkClient.Conn(); err != nil {
 			return nil, err
 		} else {
@@ -69,9 +92,7 @@ func (b *getChildrenBuilder) pathInForeground(path string) ([]string, error) {
 			if b.watching.watched || b.watching.watcher != nil {
 				children, stat, events, err = conn.ChildrenW(path)
 
-				if events != nil && b.watching.watcher != nil {
-					go NewWatchers(b.watching.watcher).Watch(events)
-				}
+				b.watching.watch(b.client, path, WATCHER_TYPE_CHILDREN, events)
 			} else {
 				children, stat, err = conn.Children(path)
 			}
@@ -90,6 +111,11 @@ func (b *getChildrenBuilder) pathInForeground(path string) ([]string, error) {
 
 	children, _ := result.([]string)
 
+	// the stat is returned with the children in the same response, so the check is atomic with the listing
+	if err == nil && b.version != AnyVersion && b.stat != nil && b.stat.Version != b.version {
+		return nil, &VersionMismatchError{b.client.unfixForNamespace(path), b.version, b.stat.Version}
+	}
+
 	return children, err
 }
 
@@ -99,6 +125,16 @@ func (b *getChildrenBuilder) StoringStatIn(stat *zk.Stat) GetChildrenBuilder {
 	return b
 }
 
+func (b *getChildrenBuilder) WithStat(stat *zk.Stat) GetChildrenBuilder {
+	return b.StoringStatIn(stat)
+}
+
+func (b *getChildrenBuilder) OfVersion(version int32) GetChildrenBuilder {
+	b.version = version
+
+	return b
+}
+
 func (b *getChildrenBuilder) Watched() GetChildrenBuilder {
 	b.watching.watched = true
 
@@ -111,6 +147,19 @@ func (b *getChildrenBuilder) UsingWatcher(watcher Watcher) GetChildrenBuilder {
 	return b
 }
 
+func (b *getChildrenBuilder) WithWatchEventFilter(filters ...WatchEventFilter) GetChildrenBuilder {
+	b.watching.filters = append(b.watching.filters, filters...)
+
+	return b
+}
+
+func (b *getChildrenBuilder) StoringWatchRegistrationIn(registration *WatchRegistration) GetChildrenBuilder {
+	b.watching.watched = true
+	b.watching.registration = registration
+
+	return b
+}
+
 func (b *getChildrenBuilder) InBackground() GetChildrenBuilder {
 	b.backgrounding = backgrounding{inBackground: true}
 
@@ -134,3 +183,15 @@ func (b *getChildrenBuilder) InBackgroundWithCallbackAndContext(callback Backgro
 
 	return b
 }
+
+func (b *getChildrenBuilder) WithContext(ctx context.Context) GetChildrenBuilder {
+	b.ctx = ctx
+
+	return b
+}
+
+func (b *getChildrenBuilder) WithRetryPolicy(retryPolicy RetryPolicy) GetChildrenBuilder {
+	b.retryPolicy = retryPolicy
+
+	return b
+}
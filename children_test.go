@@ -27,12 +27,32 @@ func (s *GetChildrenBuilderTestSuite) TestGetChildren() {
 
 		assert.Equal(s.T(), []string{"child"}, children)
 		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), *stat, parentStat)
+	})
+}
+
+func (s *GetChildrenBuilderTestSuite) TestWithStat() {
+	s.With(func(client CuratorFramework, conn *mockConn) {
+		stat := &zk.Stat{Czxid: 123, NumChildren: 2, Cversion: 5}
+
+		conn.On("Children", "/parent").Return([]string{"a", "b"}, stat, nil).Once()
+
+		var parentStat zk.Stat
+
+		children, err := client.GetChildren().WithStat(&parentStat).ForPath("/parent")
+
+		assert.Equal(s.T(), []string{"a", "b"}, children)
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), int64(123), parentStat.Czxid)
+		assert.Equal(s.T(), int32(2), parentStat.NumChildren)
+		assert.Equal(s.T(), int32(5), parentStat.Cversion)
 	})
 }
 
 func (s *GetChildrenBuilderTestSuite) TestNamespace() {
-	s.WithNamespace("parent", func(builder *CuratorFrameworkBuilder, client CuratorFramework, conn *mockConn, stat *zk.Stat, acls []zk.ACL) {
+	s.WithNamespace("parent", func(builder *CuratorFrameworkBuilder, client CuratorFramework, conn *mockConn, aclProvider *mockACLProvider, stat *zk.Stat, acls []zk.ACL) {
 		conn.On("Exists", "/parent").Return(false, nil, nil).Once()
+		aclProvider.On("GetAclForPath", "/parent").Return(OPEN_ACL_UNSAFE).Once()
 		conn.On("Create", "/parent", []byte{}, int32(PERSISTENT), OPEN_ACL_UNSAFE).Return("/parent", nil).Once()
 		conn.On("Children", "/parent/child").Return([]string{"node"}, stat, nil).Once()
 
@@ -97,3 +117,22 @@ func (s *GetChildrenBuilderTestSuite) TestWatcher() {
 		}
 	})
 }
+
+func (s *GetChildrenBuilderTestSuite) TestOfVersion() {
+	s.With(func(client CuratorFramework, conn *mockConn) {
+		stat := &zk.Stat{Version: 3}
+
+		conn.On("Children", "/parent").Return([]string{"child"}, stat, nil).Twice()
+
+		children, err := client.GetChildren().OfVersion(3).ForPath("/parent")
+
+		assert.Equal(s.T(), []string{"child"}, children)
+		assert.NoError(s.T(), err)
+
+		children, err = client.GetChildren().OfVersion(2).ForPath("/parent")
+
+		assert.Nil(s.T(), children)
+		assert.Equal(s.T(), &VersionMismatchError{"/parent", 2, 3}, err)
+		assert.EqualError(s.T(), err, "version mismatch at path `/parent`, expected 2 but got 3")
+	})
+}
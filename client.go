@@ -1,9 +1,14 @@
 package curator
 
 import (
+	"crypto/tls"
+	"encoding/binary"
 	"errors"
 	"log"
+	"net"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/samuel/go-zookeeper/zk"
@@ -63,6 +68,30 @@ type ZookeeperConnection interface {
 	Sync(path string) (string, error)
 }
 
+// The connection which is able to create the TTL nodes (ZooKeeper 3.6+)
+type TTLConnection interface {
+	// Create a node with the given path, which will be deleted once the TTL (in milliseconds) elapsed
+	// if it has no children and has not been modified.
+	CreateTTL(path string, data []byte, flags int32, acl []zk.ACL, ttl int64) (string, error)
+}
+
+// The connection which is able to create the container nodes (ZooKeeper 3.5+) with the createContainer request.
+//
+// go-zookeeper doesn't send it, the servers create a persistent node for the CONTAINER mode of its Create.
+type ContainerConnection interface {
+	// Create a container node, which will be deleted by the server once its last child was deleted
+	CreateContainer(path string, data []byte, acl []zk.ACL) (string, error)
+}
+
+// The connection which is able to reconfigure the ensemble dynamically (ZooKeeper 3.5+)
+type ReconfigConnection interface {
+	// Replace the members of the ensemble, the version is the expected config version or -1 to skip the check
+	Reconfig(members []string, version int64) (*zk.Stat, error)
+
+	// Add the joining servers to the ensemble and remove the leaving servers from it
+	IncrementalReconfig(joining, leaving []string, version int64) (*zk.Stat, error)
+}
+
 // Allocate a new ZooKeeper connection
 type ZookeeperDialer interface {
 	Dial(connString string, sessionTimeout time.Duration, canBeReadOnly bool) (ZookeeperConnection, <-chan zk.Event, error)
@@ -87,12 +116,88 @@ type DefaultZookeeperDialer struct {
 }
 
 func (d *DefaultZookeeperDialer) Dial(connString string, sessionTimeout time.Duration, canBeReadOnly bool) (ZookeeperConnection, <-chan zk.Event, error) {
-	return zk.ConnectWithDialer(strings.Split(connString, ","), sessionTimeout, d.Dialer)
+	dialer := d.Dialer
+
+	if dialer == nil {
+		dialer = net.DialTimeout
+	}
+
+	conn := &negotiatingConn{}
+
+	zkConn, events, err := zk.ConnectWithDialer(strings.Split(connString, ","), sessionTimeout, func(network, address string, timeout time.Duration) (net.Conn, error) {
+		netConn, err := dialer(network, address, timeout)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &connectResponseReader{Conn: netConn, negotiated: conn.setSessionTimeout}, nil
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	conn.Conn = zkConn
+
+	return conn, events, nil
+}
+
+// The connection of DefaultZookeeperDialer, which reports the session timeout negotiated with the server
+type negotiatingConn struct {
+	*zk.Conn
+
+	sessionTimeout int64
+}
+
+func (c *negotiatingConn) SessionTimeout() time.Duration {
+	return time.Duration(atomic.LoadInt64(&c.sessionTimeout))
+}
+
+func (c *negotiatingConn) setSessionTimeout(timeout time.Duration) {
+	atomic.StoreInt64(&c.sessionTimeout, int64(timeout))
+}
+
+// Parse the negotiated session timeout from the connect response, the first frame sent by the server,
+// which starts with the frame length, the protocol version and the timeout in milliseconds (int32 each).
+type connectResponseReader struct {
+	net.Conn
+
+	header     [12]byte
+	read       int
+	negotiated func(timeout time.Duration)
+}
+
+func (r *connectResponseReader) Read(b []byte) (n int, err error) {
+	n, err = r.Conn.Read(b)
+
+	if r.read < len(r.header) {
+		r.read += copy(r.header[r.read:], b[:n])
+
+		if r.read == len(r.header) {
+			r.negotiated(time.Duration(int32(binary.BigEndian.Uint32(r.header[8:]))) * time.Millisecond)
+		}
+	}
+
+	return
+}
+
+// Create a dialer which encrypts the connections with TLS.
+//
+// The config is used on each dial, the certificates could be rotated with its GetClientCertificate callback.
+func NewTLSZookeeperDialer(tlsConfig *tls.Config) ZookeeperDialer {
+	return &DefaultZookeeperDialer{Dialer: newTLSDialer(tlsConfig)}
+}
+
+func newTLSDialer(tlsConfig *tls.Config) zk.Dialer {
+	return func(network, address string, timeout time.Duration) (net.Conn, error) {
+		return tls.DialWithDialer(&net.Dialer{Timeout: timeout}, network, address, tlsConfig)
+	}
 }
 
 // A wrapper around Zookeeper that takes care of some low-level housekeeping
 type CuratorZookeeperClient interface {
-	// Return the managed ZK connection.
+	// Return the managed ZK connection, each operation on it is traced with the TracerDriver.
 	Conn() (ZookeeperConnection, error)
 
 	// Return the current retry policy
@@ -101,6 +206,9 @@ type CuratorZookeeperClient interface {
 	// Return a new retry loop. All operations should be performed in a retry loop
 	NewRetryLoop() RetryLoop
 
+	// Return a new retry loop which uses the given retry policy instead of the default one
+	NewRetryLoopWithPolicy(retryPolicy RetryPolicy) RetryLoop
+
 	// Returns true if the client is current connected
 	Connected() bool
 
@@ -115,6 +223,46 @@ type CuratorZookeeperClient interface {
 
 	// Start a new tracer
 	StartTracer(name string) Tracer
+
+	// Add the authorization to the current connection, it will be re-applied to the new sessions
+	AddAuth(scheme string, auth []byte) error
+
+	// Return the session timeout negotiated by the most recent session, or 0 if no session has been established.
+	//
+	// The connections of DefaultZookeeperDialer report the timeout of the connect response,
+	// the requested timeout is returned if the connection doesn't implement SessionTimeoutConnection.
+	GetLastNegotiatedSessionTimeout() time.Duration
+
+	// Return the ID of the current session, or 0 if no session has been established
+	GetSessionId() int64
+
+	// Return the password of the current session, or nil if no session has been established or the connection doesn't report it
+	GetSessionPassword() []byte
+}
+
+// The client which knows the ACLProvider of its framework
+type ACLProviderClient interface {
+	AclProvider() ACLProvider
+}
+
+// The connection which is able to report the session timeout negotiated with the server
+type SessionTimeoutConnection interface {
+	SessionTimeout() time.Duration
+}
+
+// The connection which is able to report the ID of its session
+type SessionIdConnection interface {
+	SessionID() int64
+}
+
+// The connection which is able to report the password of its session, go-zookeeper doesn't expose it
+type SessionPasswordConnection interface {
+	SessionPassword() []byte
+}
+
+// The connection which is able to report the address of the server it's connected to
+type ServerConnection interface {
+	Server() string
 }
 
 type curatorZookeeperClient struct {
@@ -123,6 +271,14 @@ type curatorZookeeperClient struct {
 	started      AtomicBool
 	TracerDriver TracerDriver
 	retryPolicy  RetryPolicy
+	authLock     sync.Mutex
+	authInfos    []AuthInfo
+	authConn     ZookeeperConnection
+	aclProvider  ACLProvider
+
+	negotiatedSessionTimeout int64
+	sessionId                int64
+	sessionPassword          atomic.Value // []byte
 }
 
 func NewCuratorZookeeperClient(zookeeperDialer ZookeeperDialer, ensembleProvider EnsembleProvider, sessionTimeout, connectionTimeout time.Duration,
@@ -136,29 +292,136 @@ func NewCuratorZookeeperClient(zookeeperDialer ZookeeperDialer, ensembleProvider
 		zookeeperDialer = &DefaultZookeeperDialer{}
 	}
 
+	tracer := newDefaultTracerDriver()
+
+	c := &curatorZookeeperClient{
+		TracerDriver: tracer,
+		retryPolicy:  retryPolicy,
+		authInfos:    append([]AuthInfo(nil), authInfos...),
+	}
+
 	dialer := NewZookeeperDialer(func(connString string, sessionTimeout time.Duration, canBeReadOnly bool) (conn ZookeeperConnection, events <-chan zk.Event, err error) {
 		conn, events, err = zookeeperDialer.Dial(connString, sessionTimeout, canBeReadOnly)
 
 		if err == nil && conn != nil {
-			for _, authInfo := range authInfos {
-				if err := conn.AddAuth(authInfo.Scheme, authInfo.Auth); err != nil {
-					conn.Close()
+			c.authLock.Lock()
+			c.authConn = conn
+			c.authLock.Unlock()
+
+			// the session may have been established before the connection is returned
+			c.storeSession(conn)
 
-					return nil, nil, err
-				}
+			if err := c.applyAuth(conn); err != nil {
+				conn.Close()
+
+				return nil, nil, err
 			}
 		}
 
 		return
 	})
 
-	tracer := newDefaultTracerDriver()
+	// the authorization is applied to each new connection by the dialer,
+	// the connection re-submits it itself once the session has been re-established.
+	sessionWatcher := NewWatcher(func(event *zk.Event) {
+		if event.Type == zk.EventSession && event.State == zk.StateHasSession {
+			c.authLock.Lock()
+			conn := c.authConn
+			c.authLock.Unlock()
 
-	return &curatorZookeeperClient{
-		state:        newConnectionState(dialer, ensembleProvider, sessionTimeout, connectionTimeout, watcher, tracer, canReadOnly),
-		TracerDriver: tracer,
-		retryPolicy:  retryPolicy,
+			atomic.StoreInt64(&c.negotiatedSessionTimeout, int64(negotiatedSessionTimeout(conn, sessionTimeout)))
+
+			c.storeSession(conn)
+		}
+
+		if event.Type == zk.EventSession && event.State == zk.StateExpired {
+			c.storeSession(nil)
+		}
+
+		if watcher != nil {
+			watcher.process(event)
+		}
+	})
+
+	c.state = newConnectionState(dialer, ensembleProvider, sessionTimeout, connectionTimeout, sessionWatcher, tracer, canReadOnly)
+
+	return c
+}
+
+func (c *curatorZookeeperClient) AddAuth(scheme string, auth []byte) error {
+	c.authLock.Lock()
+	c.authInfos = append(c.authInfos, AuthInfo{scheme, auth})
+	c.authLock.Unlock()
+
+	if !c.started.Load() {
+		return nil // will be applied once connected
 	}
+
+	conn, err := c.state.Conn()
+
+	if err != nil {
+		return err
+	}
+
+	return conn.AddAuth(scheme, auth)
+}
+
+func (c *curatorZookeeperClient) AclProvider() ACLProvider {
+	return c.aclProvider
+}
+
+func (c *curatorZookeeperClient) GetLastNegotiatedSessionTimeout() time.Duration {
+	return time.Duration(atomic.LoadInt64(&c.negotiatedSessionTimeout))
+}
+
+func (c *curatorZookeeperClient) GetSessionId() int64 {
+	return atomic.LoadInt64(&c.sessionId)
+}
+
+func (c *curatorZookeeperClient) GetSessionPassword() []byte {
+	password, _ := c.sessionPassword.Load().([]byte)
+
+	return password
+}
+
+// Save the session credentials reported by the connection, or reset them if the connection is nil
+func (c *curatorZookeeperClient) storeSession(conn ZookeeperConnection) {
+	var sessionId int64
+	var password []byte
+
+	if conn, ok := conn.(SessionIdConnection); ok {
+		sessionId = conn.SessionID()
+	}
+
+	if conn, ok := conn.(SessionPasswordConnection); ok {
+		password = conn.SessionPassword()
+	}
+
+	atomic.StoreInt64(&c.sessionId, sessionId)
+	c.sessionPassword.Store(password)
+}
+
+// The negotiated session timeout if the connection reports it, otherwise the requested one
+func negotiatedSessionTimeout(conn ZookeeperConnection, sessionTimeout time.Duration) time.Duration {
+	if conn, ok := conn.(SessionTimeoutConnection); ok && conn.SessionTimeout() > 0 {
+		return conn.SessionTimeout()
+	}
+
+	return sessionTimeout
+}
+
+func (c *curatorZookeeperClient) applyAuth(conn ZookeeperConnection) error {
+	c.authLock.Lock()
+	authInfos := c.authInfos
+	c.authLock.Unlock()
+
+	for _, authInfo := range authInfos {
+		if err := conn.AddAuth(authInfo.Scheme, authInfo.Auth); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (c *curatorZookeeperClient) Start() error {
@@ -191,6 +454,10 @@ func (c *curatorZookeeperClient) NewRetryLoop() RetryLoop {
 	return newRetryLoop(c.retryPolicy, c.TracerDriver)
 }
 
+func (c *curatorZookeeperClient) NewRetryLoopWithPolicy(retryPolicy RetryPolicy) RetryLoop {
+	return newRetryLoop(retryPolicy, c.TracerDriver)
+}
+
 func (c *curatorZookeeperClient) StartTracer(name string) Tracer {
 	return newTimeTracer(name, c.TracerDriver)
 }
@@ -200,7 +467,13 @@ func (c *curatorZookeeperClient) Conn() (ZookeeperConnection, error) {
 		return nil, errors.New("Client is not started")
 	}
 
-	return c.state.Conn()
+	conn, err := c.state.Conn()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return newTracingConnection(conn, c.TracerDriver), nil
 }
 
 func (c *curatorZookeeperClient) InstanceIndex() int64 {
@@ -0,0 +1,140 @@
+package curator
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/samuel/go-zookeeper/zk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTLSZookeeperDialer(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+
+	defer server.Close()
+
+	tlsConfig := server.Client().Transport.(*http.Transport).TLSClientConfig
+
+	dialer := NewTLSZookeeperDialer(tlsConfig).(*DefaultZookeeperDialer)
+
+	conn, err := dialer.Dialer("tcp", server.Listener.Addr().String(), time.Second)
+
+	if assert.NoError(t, err) {
+		defer conn.Close()
+
+		if tlsConn, ok := conn.(*tls.Conn); assert.True(t, ok) {
+			assert.True(t, tlsConn.ConnectionState().HandshakeComplete)
+		}
+	}
+
+	// the TLS dialer is only used when no dialer is given
+	builder := &CuratorFrameworkBuilder{TLSConfig: tlsConfig}
+
+	assert.IsType(t, &DefaultZookeeperDialer{}, builder.zookeeperDialer())
+
+	builder.ZookeeperDialer = &mockZookeeperDialer{}
+
+	assert.Equal(t, builder.ZookeeperDialer, builder.zookeeperDialer())
+}
+
+type sessionTimeoutConn struct {
+	ZookeeperConnection
+
+	timeout time.Duration
+}
+
+func (c *sessionTimeoutConn) SessionTimeout() time.Duration { return c.timeout }
+
+func TestLastNegotiatedSessionTimeout(t *testing.T) {
+	newMockContainer().Test(t, func(builder *CuratorFrameworkBuilder, client CuratorFramework, ensembleProvider *mockEnsembleProvider, events chan zk.Event) {
+		zkClient := client.ZookeeperClient()
+
+		assert.Equal(t, time.Duration(0), zkClient.GetLastNegotiatedSessionTimeout())
+
+		ensembleProvider.On("ConnectionString").Return("connStr").Once()
+
+		events <- zk.Event{Type: zk.EventSession, State: zk.StateHasSession}
+
+		for !zkClient.Connected() {
+			time.Sleep(time.Millisecond)
+		}
+
+		assert.Equal(t, builder.SessionTimeout, zkClient.GetLastNegotiatedSessionTimeout())
+	})
+
+	assert.Equal(t, 5*time.Second, negotiatedSessionTimeout(&sessionTimeoutConn{timeout: 5 * time.Second}, time.Minute))
+	assert.Equal(t, time.Minute, negotiatedSessionTimeout(&mockConn{}, time.Minute))
+	assert.Equal(t, time.Minute, negotiatedSessionTimeout(&negotiatingConn{}, time.Minute))
+}
+
+func TestConnectResponseReader(t *testing.T) {
+	client, server := net.Pipe()
+
+	defer client.Close()
+
+	var negotiated time.Duration
+
+	r := &connectResponseReader{Conn: client, negotiated: func(timeout time.Duration) { negotiated = timeout }}
+
+	// the length, protocol version, timeout (ms) and session ID of the connect response
+	resp := []byte{0, 0, 0, 16, 0, 0, 0, 0, 0, 0, 0x9c, 0x40, 0, 0, 0, 0, 0, 0, 0, 1}
+
+	go func() {
+		server.Write(resp[:6])
+		server.Write(resp[6:])
+		server.Close()
+	}()
+
+	buf, err := io.ReadAll(r)
+
+	assert.Equal(t, resp, buf)
+	assert.NoError(t, err)
+	assert.Equal(t, 40*time.Second, negotiated)
+}
+
+func TestSessionCredentials(t *testing.T) {
+	zookeeper := NewFakeZookeeper()
+
+	assert.NoError(t, zookeeper.Start())
+
+	defer zookeeper.Close()
+
+	builder := &CuratorFrameworkBuilder{
+		ZookeeperDialer:   zookeeper,
+		SessionTimeout:    DEFAULT_SESSION_TIMEOUT,
+		ConnectionTimeout: DEFAULT_CONNECTION_TIMEOUT,
+		RetryPolicy:       NewRetryOneTime(0),
+	}
+
+	client := builder.ConnectString(zookeeper.ConnectString()).Build()
+	zkClient := client.ZookeeperClient()
+
+	assert.Equal(t, int64(0), zkClient.GetSessionId())
+	assert.Nil(t, zkClient.GetSessionPassword())
+
+	assert.NoError(t, client.Start())
+
+	defer client.Close()
+
+	assert.NoError(t, zkClient.BlockUntilConnectedOrTimedOut())
+
+	// the ephemeral nodes are owned by the session
+	_, err := client.Create().WithMode(EPHEMERAL).ForPath("/node")
+
+	assert.NoError(t, err)
+
+	stat, err := client.CheckExists().ForPath("/node")
+
+	assert.NoError(t, err)
+	require.NotNil(t, stat)
+	assert.NotEqual(t, int64(0), zkClient.GetSessionId())
+	assert.Equal(t, stat.EphemeralOwner, zkClient.GetSessionId())
+	assert.Equal(t, []byte(fmt.Sprintf("fake-password-%d", stat.EphemeralOwner)), zkClient.GetSessionPassword())
+}
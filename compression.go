@@ -6,12 +6,14 @@ import (
 	"io/ioutil"
 
 	"github.com/bkaradzic/go-lz4"
+	"github.com/golang/snappy"
 )
 
 var (
 	CompressionProviders = map[string]CompressionProvider{
-		"gzip": NewGzipCompressionProvider(),
-		"lz4":  NewLZ4CompressionProvider(),
+		"gzip":   NewGzipCompressionProvider(),
+		"lz4":    NewLZ4CompressionProvider(),
+		"snappy": NewSnappyCompressionProvider(),
 	}
 )
 
@@ -21,6 +23,12 @@ type CompressionProvider interface {
 	Decompress(path string, compressedData []byte) ([]byte, error)
 }
 
+// A compression provider which can tell whether the data has been compressed by itself,
+// so that the data written by an older writer without compression could be returned as is.
+type CompressionDetector interface {
+	IsCompressed(data []byte) bool
+}
+
 type GzipCompressionProvider struct {
 	level int
 }
@@ -47,6 +55,10 @@ func (c *GzipCompressionProvider) Compress(path string, data []byte) ([]byte, er
 	}
 }
 
+func (c *GzipCompressionProvider) IsCompressed(data []byte) bool {
+	return len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b
+}
+
 func (c *GzipCompressionProvider) Decompress(path string, compressedData []byte) ([]byte, error) {
 	buf := bytes.NewBuffer(compressedData)
 
@@ -72,3 +84,40 @@ func (c *LZ4CompressionProvider) Compress(path string, data []byte) ([]byte, err
 func (c *LZ4CompressionProvider) Decompress(path string, compressedData []byte) ([]byte, error) {
 	return lz4.Decode(nil, compressedData)
 }
+
+// The stream identifier chunk which starts the snappy framing format
+var snappyMagicChunk = []byte("\xff\x06\x00\x00sNaPpY")
+
+// Compress the data with the snappy framing format,
+// the data without the snappy stream identifier will be returned unchanged when decompressing.
+type SnappyCompressionProvider struct{}
+
+func NewSnappyCompressionProvider() *SnappyCompressionProvider {
+	return &SnappyCompressionProvider{}
+}
+
+func (c *SnappyCompressionProvider) Compress(path string, data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	writer := snappy.NewBufferedWriter(&buf)
+
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	} else if err := writer.Close(); err != nil {
+		return nil, err
+	} else {
+		return buf.Bytes(), nil
+	}
+}
+
+func (c *SnappyCompressionProvider) IsCompressed(data []byte) bool {
+	return bytes.HasPrefix(data, snappyMagicChunk)
+}
+
+func (c *SnappyCompressionProvider) Decompress(path string, compressedData []byte) ([]byte, error) {
+	if !bytes.HasPrefix(compressedData, snappyMagicChunk) {
+		return compressedData, nil // written without compression
+	}
+
+	return ioutil.ReadAll(snappy.NewReader(bytes.NewReader(compressedData)))
+}
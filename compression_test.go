@@ -1,7 +1,12 @@
 package curator
 
 import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"math/rand"
 	"testing"
+	"testing/quick"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -22,6 +27,105 @@ func TestGzipCompressionProvider(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestGzipCompressionRoundTrip(t *testing.T) {
+	for _, level := range []int{gzip.NoCompression, gzip.BestSpeed, gzip.DefaultCompression, gzip.BestCompression} {
+		p := NewGzipCompressionProviderWithLevel(level)
+
+		assert.NoError(t, quick.Check(func(data []byte) bool {
+			compressed, err := p.Compress("/node", data)
+
+			if err != nil {
+				return false
+			}
+
+			decompressed, err := p.Decompress("/node", compressed)
+
+			return err == nil && bytes.Equal(data, decompressed)
+		}, nil), "level %d", level)
+	}
+
+	// the invalid level is reported when compressing
+	_, err := NewGzipCompressionProviderWithLevel(gzip.BestCompression+1).Compress("/node", []byte("data"))
+
+	assert.Error(t, err)
+
+	// the corrupted data is reported when decompressing
+	_, err = NewGzipCompressionProvider().Decompress("/node", []byte("data"))
+
+	assert.Error(t, err)
+}
+
+var benchmarkDataSizes = []int{1 << 10, 64 << 10, 1 << 20}
+
+func benchmarkData(size int) []byte {
+	data := make([]byte, size)
+
+	// half random, half repeated, to be somewhat compressible
+	rand.New(rand.NewSource(int64(size))).Read(data[:size/2])
+
+	for i := size / 2; i < size; i++ {
+		data[i] = byte(i % 16)
+	}
+
+	return data
+}
+
+func BenchmarkRawWrite(b *testing.B) {
+	for _, size := range benchmarkDataSizes {
+		data := benchmarkData(size)
+
+		b.Run(fmt.Sprintf("%dKB", size>>10), func(b *testing.B) {
+			b.SetBytes(int64(size))
+
+			for i := 0; i < b.N; i++ {
+				var buf bytes.Buffer
+
+				buf.Write(data)
+			}
+		})
+	}
+}
+
+func BenchmarkGzipCompress(b *testing.B) {
+	p := NewGzipCompressionProvider()
+
+	for _, size := range benchmarkDataSizes {
+		data := benchmarkData(size)
+
+		b.Run(fmt.Sprintf("%dKB", size>>10), func(b *testing.B) {
+			b.SetBytes(int64(size))
+
+			for i := 0; i < b.N; i++ {
+				if _, err := p.Compress("/node", data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGzipDecompress(b *testing.B) {
+	p := NewGzipCompressionProvider()
+
+	for _, size := range benchmarkDataSizes {
+		compressed, err := p.Compress("/node", benchmarkData(size))
+
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(fmt.Sprintf("%dKB", size>>10), func(b *testing.B) {
+			b.SetBytes(int64(size))
+
+			for i := 0; i < b.N; i++ {
+				if _, err := p.Decompress("/node", compressed); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
 func TestLZ4CompressionProvider(t *testing.T) {
 	p := NewLZ4CompressionProvider()
 
@@ -37,3 +141,55 @@ func TestLZ4CompressionProvider(t *testing.T) {
 	assert.Equal(t, "data", string(data))
 	assert.NoError(t, err)
 }
+
+func TestSnappyCompressionProvider(t *testing.T) {
+	p := NewSnappyCompressionProvider()
+
+	assert.NotNil(t, p)
+
+	data, err := p.Compress("/node", []byte("data"))
+
+	assert.True(t, bytes.HasPrefix(data, snappyMagicChunk))
+	assert.NoError(t, err)
+
+	data, err = p.Decompress("/node", data)
+
+	assert.Equal(t, "data", string(data))
+	assert.NoError(t, err)
+
+	// the uncompressed data is returned unchanged
+	data, err = p.Decompress("/node", []byte("data"))
+
+	assert.Equal(t, "data", string(data))
+	assert.NoError(t, err)
+
+	assert.NoError(t, quick.Check(func(data []byte) bool {
+		compressed, err := p.Compress("/node", data)
+
+		if err != nil {
+			return false
+		}
+
+		decompressed, err := p.Decompress("/node", compressed)
+
+		return err == nil && bytes.Equal(data, decompressed)
+	}, nil))
+}
+
+func TestCompressionDetector(t *testing.T) {
+	for name, p := range map[string]CompressionProvider{
+		"gzip":   NewGzipCompressionProvider(),
+		"snappy": NewSnappyCompressionProvider(),
+	} {
+		detector, ok := p.(CompressionDetector)
+
+		assert.True(t, ok, name)
+
+		compressed, err := p.Compress("/node", []byte("data"))
+
+		assert.NoError(t, err, name)
+		assert.True(t, detector.IsCompressed(compressed), name)
+		assert.False(t, detector.IsCompressed([]byte("data")), name)
+		assert.False(t, detector.IsCompressed(nil), name)
+	}
+}
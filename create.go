@@ -1,16 +1,34 @@
 package curator
 
 import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/samuel/go-zookeeper/zk"
 )
 
+// The prefix of the protected nodes, followed by the protected id
+const PROTECTED_PREFIX = "_c_"
+
 type createBuilder struct {
 	client                *curatorFramework
 	createMode            CreateMode
+	modeSet               bool // the mode was set explicitly
 	backgrounding         backgrounding
 	createParentsIfNeeded bool
 	compress              bool
 	acling                acling
+	ctx                   context.Context
+	retryPolicy           RetryPolicy
+	doProtected           bool
+	protectedId           string
+	protectedSequential   bool // WithProtectedEphemeralSequential was used
+	ttl                   time.Duration
+	setDataIfExists       bool
+	idempotent            bool
 }
 
 func (b *createBuilder) ForPath(path string) (string, error) {
@@ -18,6 +36,30 @@ func (b *createBuilder) ForPath(path string) (string, error) {
 }
 
 func (b *createBuilder) ForPathWithData(givenPath string, payload []byte) (string, error) {
+	if err := b.client.checkStarted(); err != nil {
+		return "", err
+	}
+
+	if b.modeSet && b.protectedSequential {
+		return "", ErrConflictingCreateMode
+	}
+
+	if mode, err := b.resolveMode(); err != nil {
+		return "", err
+	} else {
+		b.createMode = mode
+	}
+
+	if err := b.client.validateCreateSchema(givenPath, b.createMode, &b.acling); err != nil {
+		return "", err
+	}
+
+	if data, err := b.client.mutateCreate(givenPath, payload); err != nil {
+		return "", err
+	} else {
+		payload = data
+	}
+
 	if b.compress {
 		if data, err := b.client.compressionProvider.Compress(givenPath, payload); err != nil {
 			return "", err
@@ -26,10 +68,10 @@ func (b *createBuilder) ForPathWithData(givenPath string, payload []byte) (strin
 		}
 	}
 
-	adjustedPath := b.client.fixForNamespace(givenPath, b.createMode.IsSequential())
+	adjustedPath := b.adjustPath(b.client.fixForNamespace(givenPath, b.createMode.IsSequential()))
 
 	if b.backgrounding.inBackground {
-		go b.pathInBackground(adjustedPath, payload, givenPath)
+		b.client.goSafely("createBuilder.pathInBackground", func() { b.pathInBackground(adjustedPath, payload, givenPath) })
 
 		return b.client.unfixForNamespace(adjustedPath), nil
 	} else {
@@ -46,41 +88,62 @@ func (b *createBuilder) pathInBackground(path string, payload []byte, givenPath
 
 	createdPath, err := b.pathInForeground(path, payload)
 
-	if b.backgrounding.callback != nil {
-		event := &curatorEvent{
-			eventType: CREATE,
-			err:       err,
-			path:      createdPath,
-			data:      payload,
-			acls:      b.acling.getAclList(path),
-			context:   b.backgrounding.context,
-		}
+	event := &curatorEvent{
+		eventType: CREATE,
+		err:       err,
+		path:      b.client.unfixForNamespace(createdPath),
+		data:      payload,
+		acls:      b.acling.getAclList(path),
+		context:   b.backgrounding.context,
+	}
 
-		if err != nil {
-			event.path = givenPath
-		}
+	if err != nil {
+		event.path = givenPath
+	}
 
-		event.name = GetNodeFromPath(event.path)
+	event.name = GetNodeFromPath(event.path)
 
-		b.backgrounding.callback(b.client, event)
-	}
+	b.client.processBackgroundEvent(b.backgrounding.callback, event)
 }
 
 func (b *createBuilder) pathInForeground(path string, payload []byte) (string, error) {
 	zkClient := b.client.ZookeeperClient()
 
-	result, err := zkClient.NewRetryLoop().CallWithRetry(func() (interface{}, error) {
+	// the create and the set of the data are traced as a single operation
+	tracer := zkClient.StartTracer("create")
+
+	defer tracer.Commit()
+
+	firstTime := true
+
+	result, err := callWithRetryContext(b.ctx, b.client.newRetryLoop(b.retryPolicy), func() (interface{}, error) {
 		if conn, err := zkClient.Conn(); err != nil {
 			return nil, err
 		} else {
-			createdPath, err := conn.Create(path, payload, int32(b.createMode), b.acling.getAclList(path))
+			// the node may have been created before the connection was lost
+			if b.doProtected && !firstTime {
+				if createdPath, err := b.findProtectedNode(conn, path); err != nil || len(createdPath) > 0 {
+					return createdPath, err
+				}
+			}
+
+			firstTime = false
+
+			createdPath, err := b.createNode(conn, path, payload)
+
+			if err == zk.ErrNodeExists && b.setDataIfExists {
+				// the node may be deleted before the data is set, the error of the set will be returned
+				if _, err := conn.Set(path, payload, AnyVersion); err != nil {
+					return "", err
+				}
 
-			if err == zk.ErrNoNode && b.createParentsIfNeeded {
+				return path, nil
+			} else if err == zk.ErrNoNode && b.createParentsIfNeeded {
 				if err := MakeDirs(conn, path, false, b.acling.aclProvider); err != nil {
 					return "", err
 				}
 
-				return conn.Create(path, payload, int32(b.createMode), b.acling.getAclList(path))
+				return b.createNode(conn, path, payload)
 			} else {
 				return createdPath, err
 			}
@@ -89,9 +152,108 @@ func (b *createBuilder) pathInForeground(path string, payload []byte) (string, e
 
 	createdPath, _ := result.(string)
 
+	// OrSetData wins, the data should be overwritten instead of silently keeping the existing one
+	if err == zk.ErrNodeExists && b.idempotent && !b.setDataIfExists {
+		return path, nil
+	}
+
 	return createdPath, err
 }
 
+// Apply the TTL to the create mode, only the persistent nodes could have a TTL
+func (b *createBuilder) resolveMode() (CreateMode, error) {
+	if b.ttl == 0 {
+		return b.createMode, nil
+	}
+
+	switch b.createMode {
+	case PERSISTENT, PERSISTENT_WITH_TTL:
+		return PERSISTENT_WITH_TTL, nil
+	case PERSISTENT_SEQUENTIAL, PERSISTENT_SEQUENTIAL_WITH_TTL:
+		return PERSISTENT_SEQUENTIAL_WITH_TTL, nil
+	default:
+		return b.createMode, ErrConflictingTTLMode
+	}
+}
+
+func (b *createBuilder) createNode(conn ZookeeperConnection, path string, payload []byte) (string, error) {
+	if b.createMode.IsContainer() {
+		return createContainer(conn, path, payload, b.acling.getAclList(path))
+	}
+
+	if !b.createMode.IsTTL() {
+		return conn.Create(path, payload, int32(b.createMode), b.acling.getAclList(path))
+	}
+
+	if ttlConn, ok := conn.(TTLConnection); !ok {
+		return "", ErrTTLNotSupported
+	} else {
+		return ttlConn.CreateTTL(path, payload, int32(b.createMode), b.acling.getAclList(path), int64(b.ttl/time.Millisecond))
+	}
+}
+
+// Add the protected prefix and id to the node name
+func (b *createBuilder) adjustPath(path string) string {
+	if !b.doProtected {
+		return path
+	}
+
+	pathAndNode, _ := SplitPath(path)
+
+	return JoinPath(pathAndNode.Path, PROTECTED_PREFIX+b.protectedId+"-"+pathAndNode.Node)
+}
+
+// Search the parent for a node created with the protected id
+func (b *createBuilder) findProtectedNode(conn ZookeeperConnection, path string) (string, error) {
+	pathAndNode, _ := SplitPath(path)
+
+	children, _, err := conn.Children(pathAndNode.Path)
+
+	if err == zk.ErrNoNode {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+
+	prefix := PROTECTED_PREFIX + b.protectedId
+
+	for _, child := range children {
+		if strings.HasPrefix(child, prefix) {
+			return JoinPath(pathAndNode.Path, child), nil
+		}
+	}
+
+	return "", nil
+}
+
+func (b *createBuilder) WithProtection() CreateBuilder {
+	b.doProtected = true
+	b.protectedId = newProtectedId()
+
+	return b
+}
+
+func (b *createBuilder) WithProtectedEphemeralSequential() CreateBuilder {
+	b.createMode = EPHEMERAL_SEQUENTIAL
+	b.protectedSequential = true
+
+	return b.WithProtection()
+}
+
+// Generate a random (version 4) UUID
+func newProtectedId() string {
+	var uuid [16]byte
+
+	if _, err := rand.Read(uuid[:]); err != nil {
+		panic(fmt.Sprintf("fail to generate protected id, %s", err))
+	}
+
+	uuid[6] = (uuid[6] & 0x0f) | 0x40
+	uuid[8] = (uuid[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:])
+}
+
 func (b *createBuilder) CreatingParentsIfNeeded() CreateBuilder {
 	b.createParentsIfNeeded = true
 
@@ -100,6 +262,25 @@ func (b *createBuilder) CreatingParentsIfNeeded() CreateBuilder {
 
 func (b *createBuilder) WithMode(mode CreateMode) CreateBuilder {
 	b.createMode = mode
+	b.modeSet = true
+
+	return b
+}
+
+func (b *createBuilder) OrSetData() CreateBuilder {
+	b.setDataIfExists = true
+
+	return b
+}
+
+func (b *createBuilder) Idempotent() CreateBuilder {
+	b.idempotent = true
+
+	return b
+}
+
+func (b *createBuilder) WithTTL(ttl time.Duration) CreateBuilder {
+	b.ttl = ttl
 
 	return b
 }
@@ -139,3 +320,15 @@ func (b *createBuilder) InBackgroundWithCallbackAndContext(callback BackgroundCa
 
 	return b
 }
+
+func (b *createBuilder) WithContext(ctx context.Context) CreateBuilder {
+	b.ctx = ctx
+
+	return b
+}
+
+func (b *createBuilder) WithRetryPolicy(retryPolicy RetryPolicy) CreateBuilder {
+	b.retryPolicy = retryPolicy
+
+	return b
+}
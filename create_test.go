@@ -1,11 +1,14 @@
 package curator
 
 import (
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/samuel/go-zookeeper/zk"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -28,9 +31,22 @@ func (s *CreateBuilderTestSuite) TestCreate() {
 	})
 }
 
+func (s *CreateBuilderTestSuite) TestDefaultData() {
+	s.WithOptions([]BuildOption{WithNamespace("parent"), WithDefaultData([]byte("empty"))}, func(client CuratorFramework, conn *mockConn, acls []zk.ACL) {
+		conn.On("Exists", "/parent").Return(true, nil, nil).Once()
+		conn.On("Create", "/parent/node", []byte("empty"), int32(PERSISTENT), acls).Return("/parent/node", nil).Once()
+
+		path, err := client.Create().WithACL(acls...).ForPath("/node")
+
+		assert.Equal(s.T(), "/node", path)
+		assert.NoError(s.T(), err)
+	})
+}
+
 func (s *CreateBuilderTestSuite) TestNamespace() {
-	s.WithNamespace("parent", func(builder *CuratorFrameworkBuilder, client CuratorFramework, conn *mockConn, acls []zk.ACL) {
+	s.WithNamespace("parent", func(builder *CuratorFrameworkBuilder, client CuratorFramework, conn *mockConn, aclProvider *mockACLProvider, acls []zk.ACL) {
 		conn.On("Exists", "/parent").Return(false, nil, nil).Once()
+		aclProvider.On("GetAclForPath", "/parent").Return(OPEN_ACL_UNSAFE).Once()
 		conn.On("Create", "/parent", []byte{}, int32(PERSISTENT), OPEN_ACL_UNSAFE).Return("/parent", nil).Once()
 		conn.On("Create", "/parent/child", builder.DefaultData, int32(EPHEMERAL), acls).Return("/parent/child", nil).Once()
 
@@ -41,6 +57,46 @@ func (s *CreateBuilderTestSuite) TestNamespace() {
 	})
 }
 
+func (s *CreateBuilderTestSuite) TestNamespacePaths() {
+	tests := []struct {
+		namespace    string
+		mode         CreateMode
+		path         string
+		adjustedPath string
+		createdPath  string
+		expected     string
+	}{
+		{"parent", PERSISTENT_SEQUENTIAL, "/child-", "/parent/child-", "/parent/child-0000000001", "/child-0000000001"},
+		{"parent/sub", PERSISTENT_SEQUENTIAL, "/child-", "/parent/sub/child-", "/parent/sub/child-0000000001", "/child-0000000001"},
+		{"parent/sub", PERSISTENT, "/a/b", "/parent/sub/a/b", "/parent/sub/a/b", "/a/b"},
+		{"parent", PERSISTENT, "/", "/parent", "/parent", "/"},
+	}
+
+	for _, test := range tests {
+		s.WithNamespace(test.namespace, func(client CuratorFramework, conn *mockConn, wg *sync.WaitGroup, data []byte, acls []zk.ACL) {
+			conn.On("Exists", mock.Anything).Return(true, nil, nil)
+			conn.On("Create", test.adjustedPath, data, int32(test.mode), acls).Return(test.createdPath, nil).Twice()
+
+			path, err := client.Create().WithMode(test.mode).WithACL(acls...).ForPathWithData(test.path, data)
+
+			assert.Equal(s.T(), test.expected, path, "namespace=%s path=%s", test.namespace, test.path)
+			assert.NoError(s.T(), err)
+
+			_, err = client.Create().WithMode(test.mode).WithACL(acls...).InBackgroundWithCallback(
+				func(client CuratorFramework, event CuratorEvent) error {
+					defer wg.Done()
+
+					assert.Equal(s.T(), test.expected, event.Path(), "namespace=%s path=%s", test.namespace, test.path)
+					assert.NoError(s.T(), event.Err())
+
+					return nil
+				}).ForPathWithData(test.path, data)
+
+			assert.NoError(s.T(), err)
+		})
+	}
+}
+
 func (s *CreateBuilderTestSuite) TestBackground() {
 	s.WithNamespace("parent", func(client CuratorFramework, conn *mockConn, wg *sync.WaitGroup, data []byte, acls []zk.ACL) {
 		ctxt := "context"
@@ -97,3 +153,158 @@ func (s *CreateBuilderTestSuite) TestCreateParents() {
 		assert.Equal(s.T(), err, zk.ErrAPIError)
 	})
 }
+
+func (s *CreateBuilderTestSuite) TestProtectedEphemeralSequential() {
+	s.With(func(builder *CuratorFrameworkBuilder, client CuratorFramework, conn *mockConn, retryPolicy *mockRetryPolicy, acls []zk.ACL) {
+		var protectedNode string
+
+		isProtected := mock.MatchedBy(func(path string) bool {
+			return strings.HasPrefix(path, "/parent/"+PROTECTED_PREFIX) && strings.HasSuffix(path, "-lock-")
+		})
+
+		conn.On("Create", isProtected, builder.DefaultData, int32(EPHEMERAL_SEQUENTIAL), acls).Return("", zk.ErrSessionExpired).Run(func(args mock.Arguments) {
+			protectedNode = GetNodeFromPath(args.String(0)) + "0000000001"
+
+			conn.On("Children", "/parent").Return([]string{"other", protectedNode}, nil, nil).Once()
+		}).Once()
+		retryPolicy.On("AllowRetry", 0, mock.AnythingOfType("time.Duration"), mock.Anything).Return(true).Once()
+
+		path, err := client.Create().WithProtectedEphemeralSequential().WithACL(acls...).ForPath("/parent/lock-")
+
+		assert.Equal(s.T(), "/parent/"+protectedNode, path)
+		assert.NoError(s.T(), err)
+		assert.Regexp(s.T(), "^_c_[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}-lock-0000000001$", protectedNode)
+	})
+}
+
+func (s *CreateBuilderTestSuite) TestWithMode() {
+	s.With(func(builder *CuratorFrameworkBuilder, client CuratorFramework, conn *mockConn, acls []zk.ACL) {
+		conn.On("Create", "/node", builder.DefaultData, int32(PERSISTENT), acls).Return("/node", nil).Once()
+		conn.On("CreateContainer", "/container", builder.DefaultData, acls).Return("/container", nil).Once()
+
+		path, err := client.Create().WithACL(acls...).ForPath("/node")
+
+		assert.Equal(s.T(), "/node", path)
+		assert.NoError(s.T(), err)
+
+		path, err = client.Create().WithMode(CONTAINER).WithACL(acls...).ForPath("/container")
+
+		assert.Equal(s.T(), "/container", path)
+		assert.NoError(s.T(), err)
+
+		// the connection doesn't support the container nodes
+		b := client.Create().WithMode(CONTAINER).WithACL(acls...).(*createBuilder)
+
+		path, err = b.createNode(struct{ ZookeeperConnection }{conn}, "/container", builder.DefaultData)
+
+		assert.Empty(s.T(), path)
+		assert.Equal(s.T(), ErrContainerNotSupported, err)
+
+		// the explicit mode conflicts with the protected ephemeral sequential mode
+		_, err = client.Create().WithMode(EPHEMERAL).WithProtectedEphemeralSequential().ForPath("/lock-")
+
+		assert.Equal(s.T(), ErrConflictingCreateMode, err)
+
+		_, err = client.Create().WithProtectedEphemeralSequential().WithMode(EPHEMERAL_SEQUENTIAL).ForPath("/lock-")
+
+		assert.Equal(s.T(), ErrConflictingCreateMode, err)
+	})
+}
+
+func (s *CreateBuilderTestSuite) TestWithTTL() {
+	s.With(func(builder *CuratorFrameworkBuilder, client CuratorFramework, conn *mockConn, acls []zk.ACL) {
+		conn.On("CreateTTL", "/node", builder.DefaultData, int32(PERSISTENT_WITH_TTL), acls, int64(1500)).Return("/node", nil).Once()
+		conn.On("CreateTTL", "/seq-", builder.DefaultData, int32(PERSISTENT_SEQUENTIAL_WITH_TTL), acls, int64(60000)).Return("/seq-0000000001", nil).Once()
+		conn.On("CreateTTL", "/seq-", builder.DefaultData, int32(PERSISTENT_SEQUENTIAL_WITH_TTL), acls, int64(60000)).Return("/seq-0000000002", nil).Once()
+
+		path, err := client.Create().WithTTL(1500 * time.Millisecond).WithACL(acls...).ForPath("/node")
+
+		assert.Equal(s.T(), "/node", path)
+		assert.NoError(s.T(), err)
+
+		path, err = client.Create().WithMode(PERSISTENT_SEQUENTIAL).WithTTL(time.Minute).WithACL(acls...).ForPath("/seq-")
+
+		assert.Equal(s.T(), "/seq-0000000001", path)
+		assert.NoError(s.T(), err)
+
+		// the mode set after the TTL keeps it
+		path, err = client.Create().WithTTL(time.Minute).WithMode(PERSISTENT_SEQUENTIAL).WithACL(acls...).ForPath("/seq-")
+
+		assert.Equal(s.T(), "/seq-0000000002", path)
+		assert.NoError(s.T(), err)
+
+		// the ephemeral nodes can't have a TTL
+		_, err = client.Create().WithTTL(time.Minute).WithMode(EPHEMERAL).ForPath("/node")
+
+		assert.Equal(s.T(), ErrConflictingTTLMode, err)
+
+		_, err = client.Create().WithMode(EPHEMERAL_SEQUENTIAL).WithTTL(time.Minute).ForPath("/seq-")
+
+		assert.Equal(s.T(), ErrConflictingTTLMode, err)
+
+		// the connection doesn't support the TTL nodes
+		b := client.Create().WithMode(PERSISTENT_WITH_TTL).WithTTL(time.Minute).WithACL(acls...).(*createBuilder)
+
+		path, err = b.createNode(struct{ ZookeeperConnection }{conn}, "/node", builder.DefaultData)
+
+		assert.Empty(s.T(), path)
+		assert.Equal(s.T(), ErrTTLNotSupported, err)
+	})
+}
+
+func (s *CreateBuilderTestSuite) TestIdempotent() {
+	s.WithNamespace("parent", func(client CuratorFramework, conn *mockConn, data []byte, stat *zk.Stat, acls []zk.ACL) {
+		conn.On("Exists", "/parent").Return(true, nil, nil).Once()
+		conn.On("Create", "/parent/node", data, int32(PERSISTENT), acls).Return("", zk.ErrNodeExists).Times(3)
+
+		_, err := client.Create().WithACL(acls...).ForPathWithData("/node", data)
+
+		assert.Equal(s.T(), zk.ErrNodeExists, err)
+
+		path, err := client.Create().Idempotent().WithACL(acls...).ForPathWithData("/node", data)
+
+		assert.Equal(s.T(), "/node", path)
+		assert.NoError(s.T(), err)
+
+		// OrSetData wins and overwrites the data
+		conn.On("Set", "/parent/node", data, AnyVersion).Return(stat, nil).Once()
+
+		path, err = client.Create().Idempotent().OrSetData().WithACL(acls...).ForPathWithData("/node", data)
+
+		assert.Equal(s.T(), "/node", path)
+		assert.NoError(s.T(), err)
+	})
+}
+
+func (s *CreateBuilderTestSuite) TestOrSetData() {
+	s.With(func(client CuratorFramework, conn *mockConn, data []byte, stat *zk.Stat, acls []zk.ACL) {
+		tracer := &mockTracerDriver{}
+
+		client.ZookeeperClient().(*curatorZookeeperClient).TracerDriver = tracer
+
+		conn.On("Create", "/node", data, int32(PERSISTENT), acls).Return("", zk.ErrNodeExists).Twice()
+		conn.On("Set", "/node", data, AnyVersion).Return(stat, nil).Once()
+		tracer.On("AddTime", "create", mock.Anything).Return().Twice()
+		tracer.On("AddTime", TRACE_CREATE, mock.Anything).Return().Twice()
+		tracer.On("AddCount", TRACE_CREATE, 1).Return().Twice()
+		tracer.On("AddCount", TRACE_CREATE+TRACE_ERROR_SUFFIX, 1).Return().Twice()
+		tracer.On("AddTime", TRACE_SET, mock.Anything).Return().Twice()
+		tracer.On("AddCount", TRACE_SET, 1).Return().Twice()
+		tracer.On("AddCount", TRACE_SET+TRACE_ERROR_SUFFIX, 1).Return().Once()
+
+		path, err := client.Create().OrSetData().WithACL(acls...).ForPathWithData("/node", data)
+
+		assert.Equal(s.T(), "/node", path)
+		assert.NoError(s.T(), err)
+
+		// the node is deleted between the create and the set
+		conn.On("Set", "/node", data, AnyVersion).Return(nil, zk.ErrNoNode).Once()
+
+		path, err = client.Create().OrSetData().WithACL(acls...).ForPathWithData("/node", data)
+
+		assert.Empty(s.T(), path)
+		assert.Equal(s.T(), zk.ErrNoNode, err)
+
+		tracer.AssertExpectations(s.T())
+	})
+}
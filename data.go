@@ -1,22 +1,47 @@
 package curator
 
 import (
+	"context"
+
 	"github.com/samuel/go-zookeeper/zk"
 )
 
+// The cache of the nodes used by the eventually consistent reads, e.g. recipes.NodeCache
+type NodeDataCache interface {
+	// Return the cached data and stat of the node, or false if the node is not cached or the cache is not current
+	GetCachedData(path string) ([]byte, *zk.Stat, bool)
+}
+
 type getDataBuilder struct {
-	client        *curatorFramework
-	backgrounding backgrounding
-	decompress    bool
-	stat          *zk.Stat
-	watching      watching
+	client               *curatorFramework
+	backgrounding        backgrounding
+	decompress           bool
+	decompressed         *bool
+	stat                 *zk.Stat
+	watching             watching
+	ctx                  context.Context
+	retryPolicy          RetryPolicy
+	eventuallyConsistent bool
+	cache                NodeDataCache
 }
 
 func (b *getDataBuilder) ForPath(givenPath string) ([]byte, error) {
+	if err := b.client.checkStarted(); err != nil {
+		return nil, err
+	}
+
+	if err := b.client.validateSchema(givenPath, SCHEMA_GET_DATA); err != nil {
+		return nil, err
+	}
+
 	adjustedPath := b.client.fixForNamespace(givenPath, false)
 
+	if data, ok := b.pathInCache(givenPath); ok {
+		return b.processData(adjustedPath, data)
+	}
+
 	if b.backgrounding.inBackground {
-		go b.pathInBackground(adjustedPath, givenPath)
+		b.client.goSafely("getDataBuilder.pathInBackground", func() { b.pathInBackground(adjustedPath, givenPath) })
 
 		return nil, nil
 	}
@@ -35,30 +60,48 @@ func (b *getDataBuilder) pathInBackground(adjustedPath, givenPath string) {
 
 	data, err := b.pathInForeground(adjustedPath)
 
-	if b.backgrounding.callback != nil {
-		event := &curatorEvent{
-			eventType: GET_DATA,
-			err:       err,
-			path:      b.client.unfixForNamespace(adjustedPath),
-			data:      data,
-			stat:      b.stat,
-			context:   b.backgrounding.context,
-		}
+	event := &curatorEvent{
+		eventType: GET_DATA,
+		err:       err,
+		path:      b.client.unfixForNamespace(adjustedPath),
+		data:      data,
+		stat:      b.stat,
+		context:   b.backgrounding.context,
+	}
 
-		if err != nil {
-			event.path = givenPath
-		}
+	if err != nil {
+		event.path = givenPath
+	}
 
-		event.name = GetNodeFromPath(event.path)
+	event.name = GetNodeFromPath(event.path)
 
-		b.backgrounding.callback(b.client, event)
+	b.client.processBackgroundEvent(b.backgrounding.callback, event)
+}
+
+// Read the raw data from the cache, unless a watch should be set or the read is in the background,
+// the caller should process it as the data read from the server
+func (b *getDataBuilder) pathInCache(givenPath string) ([]byte, bool) {
+	if !b.eventuallyConsistent || b.cache == nil || b.backgrounding.inBackground || b.watching.watched || b.watching.watcher != nil {
+		return nil, false
 	}
+
+	data, stat, ok := b.cache.GetCachedData(givenPath)
+
+	if ok && stat != nil {
+		if b.stat != nil {
+			*b.stat = *stat
+		} else {
+			b.stat = stat
+		}
+	}
+
+	return data, ok
 }
 
 func (b *getDataBuilder) pathInForeground(path string) ([]byte, error) {
 	zkClient := b.client.ZookeeperClient()
 
-	result, err := zkClient.NewRetryLoop().CallWithRetry(func() (interface{}, error) {
+	result, err := callWithRetryContext(b.ctx, b.client.newRetryLoop(b.retryPolicy), func() (interface{}, error) {
 		if conn, err := zkClient.Conn(); err != nil {
 			return nil, err
 		} else {
@@ -70,9 +113,7 @@ func (b *getDataBuilder) pathInForeground(path string) ([]byte, error) {
 			if b.watching.watched || b.watching.watcher != nil {
 				data, stat, events, err = conn.GetW(path)
 
-				if events != nil && b.watching.watcher != nil {
-					go NewWatchers(b.watching.watcher).Watch(events)
-				}
+				b.watching.watch(b.client, path, WATCHER_TYPE_DATA, events)
 			} else {
 				data, stat, err = conn.Get(path)
 			}
@@ -85,12 +126,8 @@ func (b *getDataBuilder) pathInForeground(path string) ([]byte, error) {
 				}
 			}
 
-			if b.decompress {
-				if payload, err := b.client.compressionProvider.Decompress(path, data); err != nil {
-					return nil, err
-				} else {
-					data = payload
-				}
+			if err == nil {
+				data, err = b.processData(path, data)
 			}
 
 			return data, err
@@ -102,12 +139,40 @@ func (b *getDataBuilder) pathInForeground(path string) ([]byte, error) {
 	return data, err
 }
 
+// Decompress the data if asked, and apply the read hooks, whether it was read from the server or the cache
+func (b *getDataBuilder) processData(path string, data []byte) ([]byte, error) {
+	decompressed := false
+
+	if b.decompress {
+		if detector, ok := b.client.compressionProvider.(CompressionDetector); ok && !detector.IsCompressed(data) {
+			// written without compression
+		} else if payload, err := b.client.compressionProvider.Decompress(path, data); err != nil {
+			return nil, err
+		} else {
+			data = payload
+			decompressed = true
+		}
+	}
+
+	if b.decompressed != nil {
+		*b.decompressed = decompressed
+	}
+
+	return b.client.mutateGet(b.client.unfixForNamespace(path), data)
+}
+
 func (b *getDataBuilder) Decompressed() GetDataBuilder {
 	b.decompress = true
 
 	return b
 }
 
+func (b *getDataBuilder) StoringDecompressedIn(decompressed *bool) GetDataBuilder {
+	b.decompressed = decompressed
+
+	return b
+}
+
 func (b *getDataBuilder) StoringStatIn(stat *zk.Stat) GetDataBuilder {
 	b.stat = stat
 
@@ -126,6 +191,19 @@ func (b *getDataBuilder) UsingWatcher(watcher Watcher) GetDataBuilder {
 	return b
 }
 
+func (b *getDataBuilder) WithWatchEventFilter(filters ...WatchEventFilter) GetDataBuilder {
+	b.watching.filters = append(b.watching.filters, filters...)
+
+	return b
+}
+
+func (b *getDataBuilder) StoringWatchRegistrationIn(registration *WatchRegistration) GetDataBuilder {
+	b.watching.watched = true
+	b.watching.registration = registration
+
+	return b
+}
+
 func (b *getDataBuilder) InBackground() GetDataBuilder {
 	b.backgrounding = backgrounding{inBackground: true}
 
@@ -150,11 +228,37 @@ func (b *getDataBuilder) InBackgroundWithCallbackAndContext(callback BackgroundC
 	return b
 }
 
+func (b *getDataBuilder) WithContext(ctx context.Context) GetDataBuilder {
+	b.ctx = ctx
+
+	return b
+}
+
+func (b *getDataBuilder) WithRetryPolicy(retryPolicy RetryPolicy) GetDataBuilder {
+	b.retryPolicy = retryPolicy
+
+	return b
+}
+
+func (b *getDataBuilder) EventuallyConsistent() GetDataBuilder {
+	b.eventuallyConsistent = true
+
+	return b
+}
+
+func (b *getDataBuilder) WithCache(cache NodeDataCache) GetDataBuilder {
+	b.cache = cache
+
+	return b
+}
+
 type setDataBuilder struct {
 	client        *curatorFramework
 	backgrounding backgrounding
 	version       int32
 	compress      bool
+	ctx           context.Context
+	retryPolicy   RetryPolicy
 }
 
 func (b *setDataBuilder) ForPath(path string) (*zk.Stat, error) {
@@ -162,6 +266,20 @@ func (b *setDataBuilder) ForPath(path string) (*zk.Stat, error) {
 }
 
 func (b *setDataBuilder) ForPathWithData(givenPath string, payload []byte) (*zk.Stat, error) {
+	if err := b.client.checkStarted(); err != nil {
+		return nil, err
+	}
+
+	if err := b.client.validateSchema(givenPath, SCHEMA_SET_DATA); err != nil {
+		return nil, err
+	}
+
+	if data, err := b.client.mutateSet(givenPath, payload); err != nil {
+		return nil, err
+	} else {
+		payload = data
+	}
+
 	if b.compress {
 		if data, err := b.client.compressionProvider.Compress(givenPath, payload); err != nil {
 			return nil, err
@@ -173,7 +291,7 @@ func (b *setDataBuilder) ForPathWithData(givenPath string, payload []byte) (*zk.
 	adjustedPath := b.client.fixForNamespace(givenPath, false)
 
 	if b.backgrounding.inBackground {
-		go b.pathInBackground(adjustedPath, payload, givenPath)
+		b.client.goSafely("setDataBuilder.pathInBackground", func() { b.pathInBackground(adjustedPath, payload, givenPath) })
 
 		return nil, nil
 	} else {
@@ -188,30 +306,28 @@ func (b *setDataBuilder) pathInBackground(path string, payload []byte, givenPath
 
 	stat, err := b.pathInForeground(path, payload)
 
-	if b.backgrounding.callback != nil {
-		event := &curatorEvent{
-			eventType: SET_DATA,
-			err:       err,
-			path:      b.client.unfixForNamespace(path),
-			data:      payload,
-			stat:      stat,
-			context:   b.backgrounding.context,
-		}
+	event := &curatorEvent{
+		eventType: SET_DATA,
+		err:       err,
+		path:      b.client.unfixForNamespace(path),
+		data:      payload,
+		stat:      stat,
+		context:   b.backgrounding.context,
+	}
 
-		if err != nil {
-			event.path = givenPath
-		}
+	if err != nil {
+		event.path = givenPath
+	}
 
-		event.name = GetNodeFromPath(event.path)
+	event.name = GetNodeFromPath(event.path)
 
-		b.backgrounding.callback(b.client, event)
-	}
+	b.client.processBackgroundEvent(b.backgrounding.callback, event)
 }
 
 func (b *setDataBuilder) pathInForeground(path string, payload []byte) (*zk.Stat, error) {
 	zkClient := b.client.ZookeeperClient()
 
-	result, err := zkClient.NewRetryLoop().CallWithRetry(func() (interface{}, error) {
+	result, err := callWithRetryContext(b.ctx, b.client.newRetryLoop(b.retryPolicy), func() (interface{}, error) {
 		if conn, err := zkClient.Conn(); err != nil {
 			return nil, err
 		} else {
@@ -259,3 +375,15 @@ func (b *setDataBuilder) InBackgroundWithCallbackAndContext(callback BackgroundC
 
 	return b
 }
+
+func (b *setDataBuilder) WithContext(ctx context.Context) SetDataBuilder {
+	b.ctx = ctx
+
+	return b
+}
+
+func (b *setDataBuilder) WithRetryPolicy(retryPolicy RetryPolicy) SetDataBuilder {
+	b.retryPolicy = retryPolicy
+
+	return b
+}
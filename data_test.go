@@ -1,11 +1,14 @@
 package curator
 
 import (
+	"context"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/samuel/go-zookeeper/zk"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -32,6 +35,108 @@ func (s *GetDataBuilderTestSuite) TestGetData() {
 	})
 }
 
+func (s *GetDataBuilderTestSuite) TestStoringStatIn() {
+	s.With(func(client CuratorFramework, conn *mockConn, wg *sync.WaitGroup, data []byte, stat *zk.Stat) {
+		events := make(chan zk.Event)
+
+		defer close(events)
+
+		conn.On("GetW", "/node").Return(data, stat, events, nil).Once()
+		conn.On("Get", "/other").Return(data, stat, nil).Once()
+
+		// the data and the stat are returned by a single round-trip
+		var stat2 zk.Stat
+
+		data2, err := client.GetData().StoringStatIn(&stat2).Watched().ForPath("/node")
+
+		assert.Equal(s.T(), data, data2)
+		assert.Equal(s.T(), *stat, stat2)
+		assert.NoError(s.T(), err)
+
+		var stat3 zk.Stat
+
+		_, err = client.GetData().StoringStatIn(&stat3).InBackgroundWithCallback(func(client CuratorFramework, event CuratorEvent) error {
+			defer wg.Done()
+
+			assert.Equal(s.T(), *stat, stat3)
+			assert.Equal(s.T(), &stat3, event.Stat())
+
+			return nil
+		}).ForPath("/other")
+
+		assert.NoError(s.T(), err)
+	})
+}
+
+func (s *GetDataBuilderTestSuite) TestDecompressed() {
+	s.WithPrepare(func(builder *CuratorFrameworkBuilder) {
+		builder.CompressionProvider = NewGzipCompressionProvider()
+	}, func(client CuratorFramework, conn *mockConn, data []byte, stat *zk.Stat) {
+		compressed, err := NewGzipCompressionProvider().Compress("/node", data)
+
+		assert.NoError(s.T(), err)
+
+		conn.On("Get", "/node").Return(compressed, stat, nil).Once()
+		conn.On("Get", "/legacy").Return(data, stat, nil).Once()
+
+		decompressed := false
+
+		data2, err := client.GetData().Decompressed().StoringDecompressedIn(&decompressed).ForPath("/node")
+
+		assert.Equal(s.T(), data, data2)
+		assert.NoError(s.T(), err)
+		assert.True(s.T(), decompressed)
+
+		// the data written without compression is returned as is
+		data2, err = client.GetData().Decompressed().StoringDecompressedIn(&decompressed).ForPath("/legacy")
+
+		assert.Equal(s.T(), data, data2)
+		assert.NoError(s.T(), err)
+		assert.False(s.T(), decompressed)
+	})
+}
+
+func (s *GetDataBuilderTestSuite) TestWithContext() {
+	s.With(func(client CuratorFramework, conn *mockConn) {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		cancel()
+
+		data, err := client.GetData().WithContext(ctx).ForPath("/node")
+
+		assert.Nil(s.T(), data)
+		assert.Equal(s.T(), context.Canceled, err)
+	})
+}
+
+func (s *GetDataBuilderTestSuite) TestWithRetryPolicy() {
+	s.With(func(client CuratorFramework, conn *mockConn, data []byte, stat *zk.Stat) {
+		retryPolicy := &mockRetryPolicy{log: s.T().Logf}
+
+		conn.On("Get", "/node").Return(nil, nil, zk.ErrSessionExpired).Once()
+		conn.On("Get", "/node").Return(data, stat, nil).Once()
+		retryPolicy.On("AllowRetry", 0, mock.AnythingOfType("time.Duration"), mock.Anything).Return(true).Once()
+
+		data2, err := client.GetData().WithRetryPolicy(retryPolicy).ForPath("/node")
+
+		assert.Equal(s.T(), data, data2)
+		assert.NoError(s.T(), err)
+
+		retryPolicy.AssertExpectations(s.T())
+	})
+}
+
+func (s *GetDataBuilderTestSuite) TestRetriesExhausted() {
+	s.WithRetryPolicy(NewRetryNTimes(2, 0, 0), func(client CuratorFramework, conn *mockConn) {
+		conn.On("Get", "/node").Return(nil, nil, zk.ErrSessionExpired).Times(3)
+
+		data, err := client.GetData().ForPath("/node")
+
+		assert.Nil(s.T(), data)
+		assert.Equal(s.T(), zk.ErrSessionExpired, err)
+	})
+}
+
 func (s *GetDataBuilderTestSuite) TestNamespace() {
 	s.WithNamespace("parent", func(client CuratorFramework, conn *mockConn, data []byte, stat *zk.Stat) {
 		conn.On("Exists", "/parent").Return(true, nil, nil).Once()
@@ -69,6 +174,28 @@ func (s *GetDataBuilderTestSuite) TestBackground() {
 	})
 }
 
+func (s *GetDataBuilderTestSuite) TestBackgroundListener() {
+	s.With(func(client CuratorFramework, conn *mockConn, wg *sync.WaitGroup, data []byte, stat *zk.Stat) {
+		conn.On("Get", "/node").Return(data, stat, nil).Once()
+
+		client.AddCuratorListener(NewCuratorListener(func(client CuratorFramework, event CuratorEvent) error {
+			if event.Type() == GET_DATA {
+				defer wg.Done()
+
+				assert.Equal(s.T(), "/node", event.Path())
+				assert.Equal(s.T(), data, event.Data())
+				assert.NoError(s.T(), event.Err())
+			}
+
+			return nil
+		}))
+
+		_, err := client.GetData().InBackground().ForPath("/node")
+
+		assert.NoError(s.T(), err)
+	})
+}
+
 func (s *GetDataBuilderTestSuite) TestWatcher() {
 	s.With(func(client CuratorFramework, conn *mockConn, wg *sync.WaitGroup, data []byte, stat *zk.Stat) {
 		events := make(chan zk.Event)
@@ -95,6 +222,137 @@ func (s *GetDataBuilderTestSuite) TestWatcher() {
 	})
 }
 
+func (s *GetDataBuilderTestSuite) TestWatchEventFilter() {
+	s.With(func(client CuratorFramework, conn *mockConn, wg *sync.WaitGroup, data []byte, stat *zk.Stat) {
+		events := make(chan zk.Event)
+
+		defer close(events)
+
+		conn.On("GetW", "/node").Return(data, stat, events, nil).Once()
+
+		_, err := client.GetData().UsingWatcher(NewWatcher(func(event *zk.Event) {
+			defer wg.Done()
+
+			assert.Equal(s.T(), zk.EventNodeDataChanged, event.Type)
+		})).WithWatchEventFilter(WatchEventTypes(zk.EventNodeDataChanged)).ForPath("/node")
+
+		assert.NoError(s.T(), err)
+
+		// the filtered event won't block the following events
+		events <- zk.Event{Type: zk.EventNodeChildrenChanged, Path: "/node"}
+		events <- zk.Event{Type: zk.EventNodeDataChanged, Path: "/node"}
+	})
+}
+
+func (s *GetDataBuilderTestSuite) TestNamespaceWatcher() {
+	s.WithNamespace("parent", func(client CuratorFramework, conn *mockConn, wg *sync.WaitGroup, data []byte, stat *zk.Stat) {
+		events := make(chan zk.Event)
+
+		defer close(events)
+
+		conn.On("Exists", "/parent").Return(true, nil, nil).Once()
+		conn.On("GetW", "/parent/child").Return(data, stat, events, nil).Once()
+
+		_, err := client.GetData().UsingWatcher(NewWatcher(func(event *zk.Event) {
+			defer wg.Done()
+
+			assert.Equal(s.T(), zk.EventNodeDataChanged, event.Type)
+			assert.Equal(s.T(), "/child", event.Path)
+		})).ForPath("/child")
+
+		assert.NoError(s.T(), err)
+
+		events <- zk.Event{
+			Type: zk.EventNodeDataChanged,
+			Path: "/parent/child",
+		}
+	})
+}
+
+func (s *GetDataBuilderTestSuite) TestEventBus() {
+	bus := NewEventBus()
+	events := bus.Subscribe()
+
+	defer bus.Close()
+
+	s.WithPrepare(func(builder *CuratorFrameworkBuilder) {
+		builder.EventBus = bus
+	}, func(client CuratorFramework, conn *mockConn, data []byte, stat *zk.Stat) {
+		watchEvents := make(chan zk.Event, 1)
+
+		conn.On("GetW", "/node").Return(data, stat, watchEvents, nil).Once()
+
+		_, err := client.GetData().Watched().ForPath("/node")
+
+		assert.NoError(s.T(), err)
+
+		watchEvents <- zk.Event{Type: zk.EventNodeDataChanged, Path: "/node"}
+
+		close(watchEvents)
+
+		assert.Equal(s.T(), zk.Event{Type: zk.EventNodeDataChanged, Path: "/node"}, <-events)
+	})
+}
+
+func (s *GetDataBuilderTestSuite) TestWatchedEvents() {
+	s.With(func(client CuratorFramework, conn *mockConn, data []byte, stat *zk.Stat) {
+		events := client.WatchedEvents()
+		registered := make(chan zk.Event, 1)
+
+		client.AddWatchedEventChannel(registered)
+
+		watchEvents := make(chan zk.Event, 1)
+
+		conn.On("GetW", "/node").Return(data, stat, watchEvents, nil).Once()
+
+		_, err := client.GetData().Watched().ForPath("/node")
+
+		assert.NoError(s.T(), err)
+
+		watchEvents <- zk.Event{Type: zk.EventNodeDataChanged, Path: "/node"}
+
+		close(watchEvents)
+
+		assert.Equal(s.T(), zk.Event{Type: zk.EventNodeDataChanged, Path: "/node"}, <-events)
+		assert.Equal(s.T(), zk.Event{Type: zk.EventNodeDataChanged, Path: "/node"}, <-registered)
+	})
+}
+
+func (s *GetDataBuilderTestSuite) TestWatchRegistration() {
+	s.WithNamespace("parent", func(client CuratorFramework, conn *mockConn, data []byte, stat *zk.Stat) {
+		var registration WatchRegistration
+
+		assert.Equal(s.T(), ErrWatchNotRegistered, registration.Remove())
+
+		watchEvents := make(chan zk.Event, 1)
+		watched := make(chan *zk.Event, 1)
+
+		conn.On("Exists", "/parent").Return(true, nil, nil).Once()
+		conn.On("GetW", "/parent/node").Return(data, stat, watchEvents, nil).Once()
+		conn.On("RemoveWatch", "/parent/node", WATCHER_TYPE_DATA).Return(nil).Once()
+
+		_, err := client.GetData().UsingWatcher(NewWatcher(func(event *zk.Event) { watched <- event })).
+			StoringWatchRegistrationIn(&registration).ForPath("/node")
+
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), "/parent/node", registration.Path())
+
+		assert.NoError(s.T(), registration.Remove())
+		assert.NoError(s.T(), registration.Remove())
+
+		// the events are not delivered once the watch is removed
+		watchEvents <- zk.Event{Type: zk.EventNodeDataChanged, Path: "/parent/node"}
+
+		close(watchEvents)
+
+		select {
+		case event := <-watched:
+			s.Failf("unexpected event", "%v", event)
+		case <-time.After(10 * time.Millisecond):
+		}
+	})
+}
+
 type SetDataBuilderTestSuite struct {
 	mockContainerTestSuite
 }
@@ -152,3 +410,109 @@ func (s *SetDataBuilderTestSuite) TestBackground() {
 		assert.NoError(s.T(), err)
 	})
 }
+
+type stubNodeDataCache map[string][]byte
+
+func (c stubNodeDataCache) GetCachedData(path string) ([]byte, *zk.Stat, bool) {
+	data, ok := c[path]
+
+	return data, &zk.Stat{Version: 42}, ok
+}
+
+func TestEventuallyConsistentGetData(t *testing.T) {
+	zookeeper := NewFakeZookeeper()
+
+	assert.NoError(t, zookeeper.Start())
+
+	defer zookeeper.Close()
+
+	builder := &CuratorFrameworkBuilder{
+		ZookeeperDialer:   zookeeper,
+		SessionTimeout:    DEFAULT_SESSION_TIMEOUT,
+		ConnectionTimeout: DEFAULT_CONNECTION_TIMEOUT,
+		RetryPolicy:       NewRetryOneTime(0),
+	}
+
+	client := builder.ConnectString(zookeeper.ConnectString()).Build()
+
+	assert.NoError(t, client.Start())
+
+	defer client.Close()
+
+	for _, path := range []string{"/hot", "/cold"} {
+		_, err := client.Create().ForPathWithData(path, []byte("fresh"))
+
+		assert.NoError(t, err)
+	}
+
+	cache := stubNodeDataCache{"/hot": []byte("stale")}
+
+	// return the cached data
+	var stat zk.Stat
+
+	data, err := client.GetData().EventuallyConsistent().WithCache(cache).StoringStatIn(&stat).ForPath("/hot")
+
+	assert.Equal(t, []byte("stale"), data)
+	assert.Equal(t, int32(42), stat.Version)
+	assert.NoError(t, err)
+
+	// fall through to the server if not cached
+	data, err = client.GetData().EventuallyConsistent().WithCache(cache).ForPath("/cold")
+
+	assert.Equal(t, []byte("fresh"), data)
+	assert.NoError(t, err)
+
+	// the cache is only used when opted in, or no watch should be set
+	data, err = client.GetData().WithCache(cache).ForPath("/hot")
+
+	assert.Equal(t, []byte("fresh"), data)
+	assert.NoError(t, err)
+
+	data, err = client.GetData().EventuallyConsistent().WithCache(cache).Watched().ForPath("/hot")
+
+	assert.Equal(t, []byte("fresh"), data)
+	assert.NoError(t, err)
+}
+
+func TestEventuallyConsistentGetDataProcessed(t *testing.T) {
+	zookeeper := NewFakeZookeeper()
+
+	assert.NoError(t, zookeeper.Start())
+
+	defer zookeeper.Close()
+
+	builder := &CuratorFrameworkBuilder{
+		ZookeeperDialer:     zookeeper,
+		SessionTimeout:      DEFAULT_SESSION_TIMEOUT,
+		ConnectionTimeout:   DEFAULT_CONNECTION_TIMEOUT,
+		RetryPolicy:         NewRetryOneTime(0),
+		CompressionProvider: NewGzipCompressionProvider(),
+		NodeReadHooks:       []NodeReadHook{&tagHook{"a"}},
+	}
+
+	client := builder.ConnectString(zookeeper.ConnectString()).Build()
+
+	assert.NoError(t, client.Start())
+
+	defer client.Close()
+
+	compressed, err := NewGzipCompressionProvider().Compress("/hot", []byte("a+stale"))
+
+	assert.NoError(t, err)
+
+	cache := stubNodeDataCache{"/hot": compressed, "/plain": []byte("a*plain")}
+
+	// the cached data is decompressed and passed to the read hooks like the data read from the server
+	decompressed := false
+
+	data, err := client.GetData().Decompressed().StoringDecompressedIn(&decompressed).EventuallyConsistent().WithCache(cache).ForPath("/hot")
+
+	assert.Equal(t, []byte("stale"), data)
+	assert.True(t, decompressed)
+	assert.NoError(t, err)
+
+	data, err = client.GetData().EventuallyConsistent().WithCache(cache).ForPath("/plain")
+
+	assert.Equal(t, []byte("plain"), data)
+	assert.NoError(t, err)
+}
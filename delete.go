@@ -1,6 +1,8 @@
 package curator
 
 import (
+	"context"
+
 	"github.com/samuel/go-zookeeper/zk"
 )
 
@@ -8,14 +10,25 @@ type deleteBuilder struct {
 	client                   *curatorFramework
 	backgrounding            backgrounding
 	deletingChildrenIfNeeded bool
+	quietly                  bool
 	version                  int32
+	ctx                      context.Context
+	retryPolicy              RetryPolicy
 }
 
 func (b *deleteBuilder) ForPath(givenPath string) error {
+	if err := b.client.checkStarted(); err != nil {
+		return err
+	}
+
+	if err := b.client.validateSchema(givenPath, SCHEMA_DELETE); err != nil {
+		return err
+	}
+
 	adjustedPath := b.client.fixForNamespace(givenPath, false)
 
 	if b.backgrounding.inBackground {
-		go b.pathInBackground(adjustedPath, givenPath)
+		b.client.goSafely("deleteBuilder.pathInBackground", func() { b.pathInBackground(adjustedPath, givenPath) })
 
 		return nil
 	} else {
@@ -30,50 +43,106 @@ func (b *deleteBuilder) pathInBackground(path string, givenPath string) {
 
 	err := b.pathInForeground(path, givenPath)
 
-	if b.backgrounding.callback != nil {
-		event := &curatorEvent{
-			eventType: DELETE,
-			err:       err,
-			path:      b.client.unfixForNamespace(path),
-			context:   b.backgrounding.context,
-		}
+	event := &curatorEvent{
+		eventType: DELETE,
+		err:       err,
+		path:      b.client.unfixForNamespace(path),
+		context:   b.backgrounding.context,
+	}
 
-		if err != nil {
-			event.path = givenPath
-		}
+	if err != nil {
+		event.path = givenPath
+	}
 
-		event.name = GetNodeFromPath(event.path)
+	event.name = GetNodeFromPath(event.path)
 
-		b.backgrounding.callback(b.client, event)
-	}
+	b.client.processBackgroundEvent(b.backgrounding.callback, event)
 }
 
 func (b *deleteBuilder) pathInForeground(path string, givenPath string) error {
-	zkClient := b.client.ZookeeperClient()
+	err := b.deleteNode(path, b.version)
 
-	_, err := zkClient.NewRetryLoop().CallWithRetry(func() (interface{}, error) {
-		conn, err := zkClient.Conn()
+	if err == zk.ErrNotEmpty && b.deletingChildrenIfNeeded {
+		if err = b.deleteChildren(path); err == nil {
+			err = b.deleteNode(path, b.version)
+		}
+	}
 
-		if err == nil {
-			err = conn.Delete(path, b.version)
+	if err == zk.ErrNoNode && b.quietly {
+		return nil
+	}
 
-			if err == zk.ErrNotEmpty && b.deletingChildrenIfNeeded {
-				err = DeleteChildren(conn, path, true)
-			}
-		}
+	return err
+}
 
-		return nil, err
+func (b *deleteBuilder) deleteNode(path string, version int32) error {
+	zkClient := b.client.ZookeeperClient()
+
+	_, err := callWithRetryContext(b.ctx, b.client.newRetryLoop(b.retryPolicy), func() (interface{}, error) {
+		if conn, err := zkClient.Conn(); err != nil {
+			return nil, err
+		} else {
+			return nil, conn.Delete(path, version)
+		}
 	})
 
 	return err
 }
 
+// Recursively delete the descendants of the node, the children are always deleted before their parent.
+// It isn't atomic, the first error will stop the deletion.
+func (b *deleteBuilder) deleteChildren(path string) error {
+	zkClient := b.client.ZookeeperClient()
+
+	result, err := callWithRetryContext(b.ctx, b.client.newRetryLoop(b.retryPolicy), func() (interface{}, error) {
+		if conn, err := zkClient.Conn(); err != nil {
+			return nil, err
+		} else {
+			children, _, err := conn.Children(path)
+
+			return children, err
+		}
+	})
+
+	if err == zk.ErrNoNode {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	children, _ := result.([]string)
+
+	for _, child := range children {
+		childPath := JoinPath(path, child)
+
+		if err := b.deleteChildren(childPath); err != nil {
+			return err
+		}
+
+		if err := b.deleteNode(childPath, AnyVersion); err != nil && err != zk.ErrNoNode {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (b *deleteBuilder) DeletingChildrenIfNeeded() DeleteBuilder {
 	b.deletingChildrenIfNeeded = true
 
 	return b
 }
 
+func (b *deleteBuilder) DeleteChildrenIfNeeded() DeleteBuilder {
+	return b.DeletingChildrenIfNeeded()
+}
+
+func (b *deleteBuilder) Quietly() DeleteBuilder {
+	b.quietly = true
+
+	return b
+}
+
 func (b *deleteBuilder) WithVersion(version int32) DeleteBuilder {
 	b.version = version
 
@@ -103,3 +172,15 @@ func (b *deleteBuilder) InBackgroundWithCallbackAndContext(callback BackgroundCa
 
 	return b
 }
+
+func (b *deleteBuilder) WithContext(ctx context.Context) DeleteBuilder {
+	b.ctx = ctx
+
+	return b
+}
+
+func (b *deleteBuilder) WithRetryPolicy(retryPolicy RetryPolicy) DeleteBuilder {
+	b.retryPolicy = retryPolicy
+
+	return b
+}
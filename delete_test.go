@@ -6,6 +6,7 @@ import (
 
 	"github.com/samuel/go-zookeeper/zk"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -33,6 +34,19 @@ func (s *DeleteBuilderTestSuite) TestDeleteWithVersion() {
 	})
 }
 
+func (s *DeleteBuilderTestSuite) TestQuietly() {
+	s.With(func(client CuratorFramework, conn *mockConn, version int32) {
+		conn.On("Delete", "/node", AnyVersion).Return(zk.ErrNoNode).Twice()
+		conn.On("Delete", "/node", version).Return(zk.ErrBadVersion).Once()
+
+		assert.Equal(s.T(), zk.ErrNoNode, client.Delete().ForPath("/node"))
+		assert.NoError(s.T(), client.Delete().Quietly().ForPath("/node"))
+
+		// only ErrNoNode is suppressed
+		assert.Equal(s.T(), zk.ErrBadVersion, client.Delete().Quietly().WithVersion(version).ForPath("/node"))
+	})
+}
+
 func (s *DeleteBuilderTestSuite) TestNamespace() {
 	s.WithNamespace("parent", func(client CuratorFramework, conn *mockConn) {
 		conn.On("Exists", "/parent").Return(true, nil, nil).Once()
@@ -75,3 +89,35 @@ func (s *DeleteBuilderTestSuite) TestDeletingChildren() {
 		assert.NoError(s.T(), client.Delete().DeletingChildrenIfNeeded().ForPath("/parent"))
 	})
 }
+
+func (s *DeleteBuilderTestSuite) TestDeleteChildrenOrdering() {
+	s.With(func(client CuratorFramework, conn *mockConn) {
+		var deleted []string
+
+		recordDeleted := func(args mock.Arguments) { deleted = append(deleted, args.String(0)) }
+
+		conn.On("Delete", "/parent", int32(3)).Return(zk.ErrNotEmpty).Once()
+		conn.On("Children", "/parent").Return([]string{"a", "b"}, nil, nil).Once()
+		conn.On("Children", "/parent/a").Return([]string{"c"}, nil, nil).Once()
+		conn.On("Children", "/parent/a/c").Return([]string{}, nil, nil).Once()
+		conn.On("Children", "/parent/b").Return(nil, nil, zk.ErrNoNode).Once()
+		conn.On("Delete", "/parent/a/c", AnyVersion).Return(nil).Run(recordDeleted).Once()
+		conn.On("Delete", "/parent/a", AnyVersion).Return(nil).Run(recordDeleted).Once()
+		conn.On("Delete", "/parent/b", AnyVersion).Return(zk.ErrNoNode).Run(recordDeleted).Once()
+		conn.On("Delete", "/parent", int32(3)).Return(nil).Run(recordDeleted).Once()
+
+		assert.NoError(s.T(), client.Delete().DeleteChildrenIfNeeded().WithVersion(3).ForPath("/parent"))
+		assert.Equal(s.T(), []string{"/parent/a/c", "/parent/a", "/parent/b", "/parent"}, deleted)
+	})
+}
+
+func (s *DeleteBuilderTestSuite) TestDeleteChildrenFirstError() {
+	s.With(func(client CuratorFramework, conn *mockConn) {
+		conn.On("Delete", "/parent", AnyVersion).Return(zk.ErrNotEmpty).Once()
+		conn.On("Children", "/parent").Return([]string{"a", "b"}, nil, nil).Once()
+		conn.On("Children", "/parent/a").Return([]string{}, nil, nil).Once()
+		conn.On("Delete", "/parent/a", AnyVersion).Return(zk.ErrNoAuth).Once()
+
+		assert.Equal(s.T(), zk.ErrNoAuth, client.Delete().DeleteChildrenIfNeeded().ForPath("/parent"))
+	})
+}
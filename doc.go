@@ -33,7 +33,7 @@ Curator connection instances (CuratorFramework) are allocated from the CuratorFr
 
 This will create a connection to a ZooKeeper cluster using default values. The only thing that you need to specify is the retry policy. For most cases, you should use:
 
-	retryPolicy := curator.NewExponentialBackoffRetry(time.Second, 3, 15*time.Second)
+	retryPolicy := curator.NewExponentialBackoffRetry(time.Second, 15*time.Second, 3)
 
 	client := curator.NewClient(connString, retryPolicy)
 
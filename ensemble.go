@@ -1,5 +1,14 @@
 package curator
 
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/samuel/go-zookeeper/zk"
+)
+
 // Abstraction that provides the ZooKeeper connection string
 type EnsembleProvider interface {
 	// Curator will call this method when CuratorZookeeperClient.Start() is called
@@ -26,3 +35,347 @@ func (p *FixedEnsembleProvider) Start() error { return nil }
 func (p *FixedEnsembleProvider) Close() error { return nil }
 
 func (p *FixedEnsembleProvider) ConnectionString() string { return p.connectString }
+
+const DEFAULT_PROBE_INTERVAL = 30 * time.Second
+
+// Ensemble provider that periodically measures the round-trip time of each ensemble with Sync("/")
+// and returns the connection string of the lowest-latency one
+type LatencyAwareEnsembleProvider struct {
+	connectStrings []string // The connection strings of the candidate ensembles
+	dialer         ZookeeperDialer
+	interval       time.Duration // The interval between two rounds of probes
+	timeout        time.Duration // The maximum time to wait for a probe
+	state          State
+	lock           sync.RWMutex
+	current        string
+	done           chan struct{}
+	wg             sync.WaitGroup
+}
+
+func NewLatencyAwareEnsembleProvider(connectStrings []string, dialer ZookeeperDialer, interval time.Duration) *LatencyAwareEnsembleProvider {
+	if dialer == nil {
+		dialer = &DefaultZookeeperDialer{}
+	}
+
+	if interval <= 0 {
+		interval = DEFAULT_PROBE_INTERVAL
+	}
+
+	p := &LatencyAwareEnsembleProvider{
+		connectStrings: connectStrings,
+		dialer:         dialer,
+		interval:       interval,
+		timeout:        DEFAULT_CONNECTION_TIMEOUT,
+		done:           make(chan struct{}),
+	}
+
+	if len(connectStrings) > 0 {
+		p.current = connectStrings[0]
+	}
+
+	return p
+}
+
+func (p *LatencyAwareEnsembleProvider) Start() error {
+	if len(p.connectStrings) == 0 {
+		return errors.New("no ensemble to probe")
+	}
+
+	if !p.state.Change(LATENT, STARTED) {
+		return errors.New("Cannot be started more than once")
+	}
+
+	p.probe()
+
+	p.wg.Add(1)
+
+	go func() {
+		defer p.wg.Done()
+
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				p.probe()
+			case <-p.done:
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (p *LatencyAwareEnsembleProvider) Close() error {
+	if p.state.Change(STARTED, STOPPED) {
+		close(p.done)
+
+		p.wg.Wait()
+	}
+
+	return nil
+}
+
+func (p *LatencyAwareEnsembleProvider) ConnectionString() string {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.current
+}
+
+// Probe all the ensembles concurrently, and switch to the fastest one if any of them responded
+func (p *LatencyAwareEnsembleProvider) probe() {
+	latencies := make([]time.Duration, len(p.connectStrings))
+
+	var wg sync.WaitGroup
+
+	for i, connectString := range p.connectStrings {
+		wg.Add(1)
+
+		go func(i int, connectString string) {
+			defer wg.Done()
+
+			latencies[i] = p.measure(connectString)
+		}(i, connectString)
+	}
+
+	wg.Wait()
+
+	best := -1
+
+	for i, latency := range latencies {
+		if latency >= 0 && (best < 0 || latency < latencies[best]) {
+			best = i
+		}
+	}
+
+	if best >= 0 {
+		p.lock.Lock()
+		p.current = p.connectStrings[best]
+		p.lock.Unlock()
+	}
+}
+
+// Return the round-trip time of Sync("/"), or a negative value if the ensemble is unreachable
+func (p *LatencyAwareEnsembleProvider) measure(connectString string) time.Duration {
+	conn, _, err := p.dialer.Dial(connectString, p.timeout, false)
+
+	if err != nil {
+		return -1
+	}
+
+	defer conn.Close()
+
+	result := make(chan error, 1)
+	started := time.Now()
+
+	go func() {
+		_, err := conn.Sync(PATH_SEPARATOR)
+
+		result <- err
+	}()
+
+	select {
+	case err := <-result:
+		if err != nil {
+			return -1
+		}
+
+		return time.Since(started)
+	case <-time.After(p.timeout):
+		return -1
+	case <-p.done:
+		return -1
+	}
+}
+
+const DEFAULT_CONFIG_RETRY_INTERVAL = time.Second
+
+// Ensemble provider that reads the connection string from a node of the bootstrap ensemble, and follows its changes.
+//
+// The node contains either a connection string or the dynamic config of ZooKeeper 3.5+ (e.g. /zookeeper/config),
+// the bootstrap connection is kept even if the node points to another ensemble.
+type DynamicEnsembleProvider struct {
+	bootstrapConn string // The connection string of the ensemble which stores the config node
+	configPath    string // The path of the config node
+	dialer        ZookeeperDialer
+	timeout       time.Duration // The session timeout of the bootstrap connection
+	interval      time.Duration // The interval to retry after failing to read the node
+	state         State
+	conn          ZookeeperConnection
+	lock          sync.RWMutex
+	current       string
+	done          chan struct{}
+	wg            sync.WaitGroup
+}
+
+func NewDynamicEnsembleProvider(bootstrapConn string, configPath string) *DynamicEnsembleProvider {
+	return NewDynamicEnsembleProviderWithDialer(bootstrapConn, configPath, nil)
+}
+
+func NewDynamicEnsembleProviderWithDialer(bootstrapConn string, configPath string, dialer ZookeeperDialer) *DynamicEnsembleProvider {
+	if dialer == nil {
+		dialer = &DefaultZookeeperDialer{}
+	}
+
+	return &DynamicEnsembleProvider{
+		bootstrapConn: bootstrapConn,
+		configPath:    configPath,
+		dialer:        dialer,
+		timeout:       DEFAULT_SESSION_TIMEOUT,
+		interval:      DEFAULT_CONFIG_RETRY_INTERVAL,
+		current:       bootstrapConn,
+		done:          make(chan struct{}),
+	}
+}
+
+func (p *DynamicEnsembleProvider) Start() error {
+	if !p.state.Change(LATENT, STARTED) {
+		return errors.New("Cannot be started more than once")
+	}
+
+	conn, _, err := p.dialer.Dial(p.bootstrapConn, p.timeout, false)
+
+	if err != nil {
+		p.state.Change(STARTED, LATENT)
+
+		return err
+	}
+
+	p.conn = conn
+
+	events, err := p.read()
+
+	if err != nil {
+		conn.Close()
+
+		p.conn = nil
+		p.state.Change(STARTED, LATENT)
+
+		return err
+	}
+
+	p.wg.Add(1)
+
+	go func() {
+		defer p.wg.Done()
+
+		p.watch(events)
+	}()
+
+	return nil
+}
+
+func (p *DynamicEnsembleProvider) Close() error {
+	if p.state.Change(STARTED, STOPPED) {
+		close(p.done)
+
+		p.wg.Wait()
+
+		if p.conn != nil {
+			p.conn.Close()
+		}
+	}
+
+	return nil
+}
+
+func (p *DynamicEnsembleProvider) ConnectionString() string {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.current
+}
+
+// Re-read the node whenever it changed, until the provider is closed
+func (p *DynamicEnsembleProvider) watch(events <-chan zk.Event) {
+	for {
+		select {
+		case <-events:
+		case <-p.done:
+			return
+		}
+
+		for {
+			var err error
+
+			if events, err = p.read(); err == nil {
+				break
+			}
+
+			select {
+			case <-time.After(p.interval):
+			case <-p.done:
+				return
+			}
+		}
+	}
+}
+
+// Read the node and set a watch on it, the bootstrap connection string is used until the node is created
+func (p *DynamicEnsembleProvider) read() (<-chan zk.Event, error) {
+	data, _, events, err := p.conn.GetW(p.configPath)
+
+	if err == zk.ErrNoNode {
+		var exists bool
+
+		if exists, _, events, err = p.conn.ExistsW(p.configPath); err == nil && exists {
+			return p.read() // created in between
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if connectString := parseEnsembleConfig(data); len(connectString) > 0 {
+		p.lock.Lock()
+		p.current = connectString
+		p.lock.Unlock()
+	}
+
+	return events, nil
+}
+
+// Parse the connection string from the node data, which may be the dynamic config of ZooKeeper 3.5+
+//
+//	server.1=host1:2888:3888:participant;0.0.0.0:2181
+//	server.2=host2:2888:3888:participant;2181
+//	version=100000000
+func parseEnsembleConfig(data []byte) string {
+	config := strings.TrimSpace(string(data))
+
+	if !strings.Contains(config, "server.") {
+		return config
+	}
+
+	var hosts []string
+
+	for _, line := range strings.Split(config, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+
+		if !ok || !strings.HasPrefix(key, "server.") {
+			continue
+		}
+
+		server, client, ok := strings.Cut(value, ";")
+
+		if !ok {
+			continue // no client port
+		}
+
+		host, _, _ := strings.Cut(server, ":")
+
+		if clientHost, clientPort, ok := strings.Cut(client, ":"); !ok {
+			client = host + ":" + clientHost
+		} else if clientHost == "0.0.0.0" || clientHost == "" {
+			client = host + ":" + clientPort
+		}
+
+		hosts = append(hosts, client)
+	}
+
+	return strings.Join(hosts, ",")
+}
@@ -1,7 +1,9 @@
 package curator
 
 import (
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -17,3 +19,105 @@ func TestFixedEnsembleProvider(t *testing.T) {
 
 	assert.NoError(t, p.Close())
 }
+
+func TestLatencyAwareEnsembleProvider(t *testing.T) {
+	dialer := &mockZookeeperDialer{log: t.Logf}
+	slow := &mockConn{log: t.Logf}
+	fast := &mockConn{log: t.Logf}
+
+	dialer.On("Dial", "slow", DEFAULT_CONNECTION_TIMEOUT, false).Return(slow, nil, nil).Once()
+	dialer.On("Dial", "fast", DEFAULT_CONNECTION_TIMEOUT, false).Return(fast, nil, nil).Once()
+	dialer.On("Dial", "down", DEFAULT_CONNECTION_TIMEOUT, false).Return(nil, nil, errors.New("unreachable")).Once()
+
+	slow.On("Sync", "/").Return("/", nil).After(50 * time.Millisecond).Once()
+	slow.On("Close").Return().Once()
+	fast.On("Sync", "/").Return("/", nil).Once()
+	fast.On("Close").Return().Once()
+
+	p := NewLatencyAwareEnsembleProvider([]string{"slow", "fast", "down"}, dialer, time.Hour)
+
+	assert.NotNil(t, p)
+	assert.Equal(t, "slow", p.ConnectionString())
+
+	assert.NoError(t, p.Start())
+	assert.Error(t, p.Start())
+
+	assert.Equal(t, "fast", p.ConnectionString())
+
+	assert.NoError(t, p.Close())
+
+	dialer.AssertExpectations(t)
+	slow.AssertExpectations(t)
+	fast.AssertExpectations(t)
+
+	// the provider stays latent if it failed to start
+	p = NewLatencyAwareEnsembleProvider(nil, dialer, time.Hour)
+
+	assert.EqualError(t, p.Start(), "no ensemble to probe")
+	assert.EqualError(t, p.Start(), "no ensemble to probe")
+	assert.NoError(t, p.Close())
+}
+
+func TestDynamicEnsembleProvider(t *testing.T) {
+	zookeeper := NewFakeZookeeper()
+
+	assert.NoError(t, zookeeper.Start())
+
+	defer zookeeper.Close()
+
+	conn, _, err := zookeeper.Dial(zookeeper.ConnectString(), DEFAULT_SESSION_TIMEOUT, false)
+
+	assert.NoError(t, err)
+
+	defer conn.Close()
+
+	p := NewDynamicEnsembleProviderWithDialer(zookeeper.ConnectString(), "/ensemble", zookeeper)
+
+	// use the bootstrap ensemble until the node is created
+	assert.NoError(t, p.Start())
+	assert.Error(t, p.Start())
+
+	assert.Equal(t, zookeeper.ConnectString(), p.ConnectionString())
+
+	waitFor := func(connectString string) {
+		for i := 0; i < 100 && p.ConnectionString() != connectString; i++ {
+			time.Sleep(time.Millisecond)
+		}
+
+		assert.Equal(t, connectString, p.ConnectionString())
+	}
+
+	_, err = conn.Create("/ensemble", []byte("host1:2181,host2:2181"), 0, OPEN_ACL_UNSAFE)
+
+	assert.NoError(t, err)
+
+	waitFor("host1:2181,host2:2181")
+
+	// the dynamic config of another ensemble
+	_, err = conn.Set("/ensemble", []byte("server.1=host3:2888:3888:participant;0.0.0.0:2181\nserver.2=host4:2888:3888:observer;2182\nversion=100000000"), AnyVersion)
+
+	assert.NoError(t, err)
+
+	waitFor("host3:2181,host4:2182")
+
+	assert.NoError(t, p.Close())
+
+	// fail to connect the bootstrap ensemble
+	p = NewDynamicEnsembleProviderWithDialer("localhost:2181", "/ensemble", zookeeper)
+
+	assert.Error(t, p.Start())
+
+	// could be started again after failing
+	err = p.Start()
+
+	assert.Error(t, err)
+	assert.NotEqual(t, "Cannot be started more than once", err.Error())
+	assert.NoError(t, p.Close())
+}
+
+func TestParseEnsembleConfig(t *testing.T) {
+	assert.Equal(t, "", parseEnsembleConfig(nil))
+	assert.Equal(t, "host1:2181", parseEnsembleConfig([]byte("host1:2181\n")))
+	assert.Equal(t, "host1:2181,10.0.0.2:2181", parseEnsembleConfig([]byte(
+		"server.1=host1:2888:3888:participant;2181\nserver.2=host2:2888:3888;10.0.0.2:2181\nserver.3=host3:2888:3888\nversion=1")))
+}
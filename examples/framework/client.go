@@ -11,7 +11,7 @@ func CreateSimple(connString string) curator.CuratorFramework {
 	// the first retry will wait 1 second,
 	// the second will wait up to 2 seconds,
 	// the third will wait up to 4 seconds.
-	retryPolicy := curator.NewExponentialBackoffRetry(time.Second, 3, 15*time.Second)
+	retryPolicy := curator.NewExponentialBackoffRetry(time.Second, 15*time.Second, 3)
 
 	// The simplest way to get a CuratorFramework instance. This will use default values.
 	// The only required arguments are the connection string and the retry policy
@@ -240,7 +240,7 @@ type ZkLiveTree struct {
 }
 
 func NewZkTree(hosts []string, base string) (*ZkLiveTree, error) {
-	client := curator.NewClient(hosts[0], curator.NewRetryNTimes(3, time.Second))
+	client := curator.NewClient(hosts[0], curator.NewRetryNTimes(3, time.Second, time.Second))
 
 	if err := client.Start(); err != nil {
 		return nil, err
@@ -1,6 +1,8 @@
 package curator
 
 import (
+	"context"
+
 	"github.com/samuel/go-zookeeper/zk"
 )
 
@@ -8,13 +10,23 @@ type checkExistsBuilder struct {
 	client        *curatorFramework
 	backgrounding backgrounding
 	watching      watching
+	ctx           context.Context
 }
 
 func (b *checkExistsBuilder) ForPath(givenPath string) (*zk.Stat, error) {
-	adjustedPath := b.client.fixForNamespace(givenPath, false)
+	if err := b.client.checkStarted(); err != nil {
+		return nil, err
+	}
+
+	if err := b.client.validateSchema(givenPath, SCHEMA_CHECK_EXISTS); err != nil {
+		return nil, err
+	}
+
+	// checking the existence should never create the missing namespace node
+	adjustedPath := b.client.namespace.fixForNamespaceWithoutEnsure(givenPath, false)
 
 	if b.backgrounding.inBackground {
-		go b.pathInBackground(adjustedPath)
+		b.client.goSafely("checkExistsBuilder.pathInBackground", func() { b.pathInBackground(adjustedPath) })
 
 		return nil, nil
 	} else {
@@ -29,24 +41,22 @@ func (b *checkExistsBuilder) pathInBackground(path string) {
 
 	stat, err := b.pathInForeground(path)
 
-	if b.backgrounding.callback != nil {
-		event := &curatorEvent{
-			eventType: EXISTS,
-			err:       err,
-			path:      b.client.unfixForNamespace(path),
-			stat:      stat,
-			name:      GetNodeFromPath(path),
-			context:   b.backgrounding.context,
-		}
-
-		b.backgrounding.callback(b.client, event)
+	event := &curatorEvent{
+		eventType: EXISTS,
+		err:       err,
+		path:      b.client.unfixForNamespace(path),
+		stat:      stat,
+		name:      GetNodeFromPath(path),
+		context:   b.backgrounding.context,
 	}
+
+	b.client.processBackgroundEvent(b.backgrounding.callback, event)
 }
 
 func (b *checkExistsBuilder) pathInForeground(path string) (*zk.Stat, error) {
 	zkClient := b.client.ZookeeperClient()
 
-	result, err := zkClient.NewRetryLoop().CallWithRetry(func() (interface{}, error) {
+	result, err := callWithRetryContext(b.ctx, zkClient.NewRetryLoop(), func() (interface{}, error) {
 		if conn, err := zkClient.Conn(); err != nil {
 			return nil, err
 		} else {
@@ -58,9 +68,7 @@ func (b *checkExistsBuilder) pathInForeground(path string) (*zk.Stat, error) {
 			if b.watching.watched || b.watching.watcher != nil {
 				exists, stat, events, err = conn.ExistsW(path)
 
-				if events != nil && b.watching.watcher != nil {
-					go NewWatchers(b.watching.watcher).Watch(events)
-				}
+				b.watching.watch(b.client, path, WATCHER_TYPE_DATA, events)
 			} else {
 				exists, stat, err = conn.Exists(path)
 			}
@@ -92,6 +100,19 @@ func (b *checkExistsBuilder) UsingWatcher(watcher Watcher) CheckExistsBuilder {
 	return b
 }
 
+func (b *checkExistsBuilder) WithWatchEventFilter(filters ...WatchEventFilter) CheckExistsBuilder {
+	b.watching.filters = append(b.watching.filters, filters...)
+
+	return b
+}
+
+func (b *checkExistsBuilder) StoringWatchRegistrationIn(registration *WatchRegistration) CheckExistsBuilder {
+	b.watching.watched = true
+	b.watching.registration = registration
+
+	return b
+}
+
 func (b *checkExistsBuilder) InBackground() CheckExistsBuilder {
 	b.backgrounding = backgrounding{inBackground: true}
 
@@ -115,3 +136,9 @@ func (b *checkExistsBuilder) InBackgroundWithCallbackAndContext(callback Backgro
 
 	return b
 }
+
+func (b *checkExistsBuilder) WithContext(ctx context.Context) CheckExistsBuilder {
+	b.ctx = ctx
+
+	return b
+}
@@ -48,7 +48,7 @@ func (s *CheckExistsBuilderTestSuite) TestCheckExists() {
 
 func (s *CheckExistsBuilderTestSuite) TestNamespace() {
 	s.WithNamespace("parent", func(client CuratorFramework, conn *mockConn) {
-		conn.On("Exists", "/parent").Return(true, nil, nil).Once()
+		// the missing namespace node won't be created
 		conn.On("Exists", "/parent/child").Return(false, nil, nil).Once()
 
 		stat, err := client.CheckExists().ForPath("/child")
@@ -62,7 +62,6 @@ func (s *CheckExistsBuilderTestSuite) TestBackground() {
 	s.WithNamespace("parent", func(client CuratorFramework, conn *mockConn, wg *sync.WaitGroup, stat *zk.Stat) {
 		ctxt := "context"
 
-		conn.On("Exists", "/parent").Return(true, nil, nil).Once()
 		conn.On("Exists", "/parent/child").Return(true, stat, nil).Once()
 
 		stat, err := client.CheckExists().InBackgroundWithCallbackAndContext(
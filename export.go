@@ -0,0 +1,102 @@
+package curator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/samuel/go-zookeeper/zk"
+)
+
+// The record of a node written by CuratorFramework.Export, one per line
+type ExportedNode struct {
+	Path      string   `json:"path"` // relative to the exported root
+	Data      []byte   `json:"data"`
+	ACLs      []zk.ACL `json:"acls"`
+	Ephemeral bool     `json:"ephemeral"`
+}
+
+type importOptions struct {
+	ignoreErrors bool
+}
+
+type ImportOption func(options *importOptions)
+
+// Report the errors to the unhandled error listeners and keep importing the other nodes
+func IgnoreErrors() ImportOption {
+	return func(options *importOptions) { options.ignoreErrors = true }
+}
+
+func (c *curatorFramework) Export(path string, w io.Writer) error {
+	encoder := json.NewEncoder(w)
+
+	// breadth-first, so the parents are always imported before the children
+	return c.WalkTree(path, NewTreeVisitor(func(nodePath string, data []byte, stat *zk.Stat) error {
+		acls, err := c.GetACL().ForPath(nodePath)
+
+		if err == zk.ErrNoNode {
+			return nil // deleted while walking the tree
+		} else if err != nil {
+			return err
+		}
+
+		return encoder.Encode(&ExportedNode{
+			Path:      JoinPath(PATH_SEPARATOR, strings.TrimPrefix(nodePath, strings.TrimSuffix(path, PATH_SEPARATOR))),
+			Data:      data,
+			ACLs:      acls,
+			Ephemeral: stat.EphemeralOwner != 0,
+		})
+	}))
+}
+
+func (c *curatorFramework) Import(path string, r io.Reader, options ...ImportOption) error {
+	if err := c.checkStarted(); err != nil {
+		return err
+	}
+
+	var opts importOptions
+
+	for _, option := range options {
+		option(&opts)
+	}
+
+	decoder := json.NewDecoder(r)
+
+	for {
+		var node ExportedNode
+
+		if err := decoder.Decode(&node); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err // the following records can't be read
+		}
+
+		if err := c.importNode(JoinPath(path, node.Path), &node); err != nil {
+			if !opts.ignoreErrors {
+				return err
+			}
+
+			c.logError(fmt.Errorf("fail to import the node `%s`, %w", node.Path, err))
+		}
+	}
+}
+
+// Create the node or update the existing one, the ephemeral nodes are imported as the persistent nodes
+func (c *curatorFramework) importNode(path string, node *ExportedNode) error {
+	_, err := c.Create().CreatingParentsIfNeeded().WithACL(node.ACLs...).ForPathWithData(path, node.Data)
+
+	if err != zk.ErrNodeExists {
+		return err
+	}
+
+	if _, err = c.SetData().ForPathWithData(path, node.Data); err != nil {
+		return err
+	}
+
+	if len(node.ACLs) > 0 {
+		_, err = c.SetACL().WithACL(node.ACLs...).ForPath(path)
+	}
+
+	return err
+}
@@ -0,0 +1,92 @@
+package curator
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/samuel/go-zookeeper/zk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportImport(t *testing.T) {
+	zookeeper := NewFakeZookeeper()
+
+	assert.NoError(t, zookeeper.Start())
+
+	defer zookeeper.Close()
+
+	builder := &CuratorFrameworkBuilder{
+		ZookeeperDialer:   zookeeper,
+		Namespace:         "app",
+		SessionTimeout:    DEFAULT_SESSION_TIMEOUT,
+		ConnectionTimeout: DEFAULT_CONNECTION_TIMEOUT,
+		RetryPolicy:       NewRetryOneTime(0),
+	}
+
+	client := builder.ConnectString(zookeeper.ConnectString()).Build()
+
+	assert.NoError(t, client.Start())
+
+	defer client.Close()
+
+	_, err := client.Create().CreatingParentsIfNeeded().ForPathWithData("/src/a/x", []byte("x"))
+
+	assert.NoError(t, err)
+
+	_, err = client.Create().WithMode(EPHEMERAL).WithACL(zk.DigestACL(zk.PermAll, "user", "password")...).ForPathWithData("/src/e", []byte("e"))
+
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+
+	assert.NoError(t, client.Export("/src", &buf))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+
+	assert.Len(t, lines, 4)
+	assert.Equal(t, `{"path":"/a/x","data":"eA==","acls":[{"Perms":31,"Scheme":"world","ID":"anyone"}],"ephemeral":false}`, lines[3])
+	assert.Contains(t, lines, `{"path":"/e","data":"ZQ==","acls":[{"Perms":31,"Scheme":"digest","ID":"user:tpUq/4Pn5A64fVZyQ0gOJ8ZWqkY="}],"ephemeral":true}`)
+
+	// restore the subtree
+	assert.NoError(t, client.Import("/restored", bytes.NewReader(buf.Bytes())))
+
+	diff, err := client.Diff("/src", "/restored")
+
+	assert.True(t, diff.IsEmpty())
+	assert.NoError(t, err)
+
+	// update the existing nodes
+	_, err = client.SetData().ForPathWithData("/restored/a/x", []byte("changed"))
+
+	assert.NoError(t, err)
+
+	assert.NoError(t, client.Import("/restored", bytes.NewReader(buf.Bytes())))
+
+	data, err := client.GetData().ForPath("/restored/a/x")
+
+	assert.Equal(t, []byte("x"), data)
+	assert.NoError(t, err)
+
+	// stop at the first error unless ignored
+	records := `{"path":"/bad//node","data":null,"acls":null,"ephemeral":false}
+{"path":"/good","data":"Z29vZA==","acls":null,"ephemeral":false}
+`
+
+	assert.Error(t, client.Import("/partial", strings.NewReader(records)))
+
+	stat, err := client.CheckExists().ForPath("/partial/good")
+
+	assert.Nil(t, stat)
+	assert.NoError(t, err)
+
+	assert.NoError(t, client.Import("/partial", strings.NewReader(records), IgnoreErrors()))
+
+	data, err = client.GetData().ForPath("/partial/good")
+
+	assert.Equal(t, []byte("good"), data)
+	assert.NoError(t, err)
+
+	// the malformed stream
+	assert.Error(t, client.Import("/partial", strings.NewReader("{"), IgnoreErrors()))
+}
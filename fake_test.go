@@ -0,0 +1,358 @@
+package curator
+
+import (
+	"testing"
+
+	"github.com/flier/curator.go/internal/fakezk"
+	"github.com/samuel/go-zookeeper/zk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type FakeZookeeperTestSuite struct {
+	suite.Suite
+
+	zookeeper *FakeZookeeper
+	conn      ZookeeperConnection
+	events    <-chan zk.Event
+}
+
+func TestFakeZookeeper(t *testing.T) {
+	suite.Run(t, new(FakeZookeeperTestSuite))
+}
+
+func (s *FakeZookeeperTestSuite) SetupTest() {
+	s.zookeeper = NewFakeZookeeper()
+
+	s.Require().NoError(s.zookeeper.Start())
+
+	conn, events, err := s.zookeeper.Dial(s.zookeeper.ConnectString(), DEFAULT_SESSION_TIMEOUT, false)
+
+	s.Require().NoError(err)
+
+	s.conn = conn
+	s.events = events
+}
+
+func (s *FakeZookeeperTestSuite) TearDownTest() {
+	s.zookeeper.Close()
+}
+
+func (s *FakeZookeeperTestSuite) TestDial() {
+	assert.Equal(s.T(), zk.Event{Type: zk.EventSession, State: zk.StateConnected}, <-s.events)
+	assert.Equal(s.T(), zk.Event{Type: zk.EventSession, State: zk.StateHasSession}, <-s.events)
+
+	_, _, err := s.zookeeper.Dial("localhost:2181", DEFAULT_SESSION_TIMEOUT, false)
+
+	assert.Error(s.T(), err)
+
+	s.zookeeper.Close()
+
+	assert.Equal(s.T(), zk.Event{Type: zk.EventSession, State: zk.StateDisconnected}, <-s.events)
+
+	_, ok := <-s.events
+
+	assert.False(s.T(), ok)
+
+	_, _, err = s.zookeeper.Dial(s.zookeeper.ConnectString(), DEFAULT_SESSION_TIMEOUT, false)
+
+	assert.Equal(s.T(), fakezk.ErrNotStarted, err)
+
+	_, err = s.conn.Create("/node", nil, int32(PERSISTENT), zk.WorldACL(zk.PermAll))
+
+	assert.Equal(s.T(), zk.ErrConnectionClosed, err)
+}
+
+func (s *FakeZookeeperTestSuite) TestCreate() {
+	acls := zk.WorldACL(zk.PermAll)
+
+	path, err := s.conn.Create("/parent", []byte("data"), int32(PERSISTENT), acls)
+
+	assert.Equal(s.T(), "/parent", path)
+	assert.NoError(s.T(), err)
+
+	_, err = s.conn.Create("/parent", nil, int32(PERSISTENT), acls)
+
+	assert.Equal(s.T(), zk.ErrNodeExists, err)
+
+	_, err = s.conn.Create("/missing/child", nil, int32(PERSISTENT), acls)
+
+	assert.Equal(s.T(), zk.ErrNoNode, err)
+
+	_, err = s.conn.Create("invalid", nil, int32(PERSISTENT), acls)
+
+	assert.Equal(s.T(), zk.ErrInvalidPath, err)
+
+	_, err = s.conn.Create("/node", nil, int32(PERSISTENT), nil)
+
+	assert.Equal(s.T(), zk.ErrInvalidACL, err)
+
+	path, err = s.conn.Create("/parent/seq-", nil, int32(PERSISTENT_SEQUENTIAL), acls)
+
+	assert.Equal(s.T(), "/parent/seq-0000000000", path)
+	assert.NoError(s.T(), err)
+
+	path, err = s.conn.Create("/parent/", nil, int32(EPHEMERAL_SEQUENTIAL), acls)
+
+	assert.Equal(s.T(), "/parent/0000000001", path)
+	assert.NoError(s.T(), err)
+
+	_, err = s.conn.Create("/parent/0000000001/child", nil, int32(PERSISTENT), acls)
+
+	assert.Equal(s.T(), zk.ErrNoChildrenForEphemerals, err)
+
+	data, stat, err := s.conn.Get("/parent")
+
+	assert.Equal(s.T(), []byte("data"), data)
+	assert.Equal(s.T(), int32(4), stat.DataLength)
+	assert.Equal(s.T(), int32(2), stat.NumChildren)
+	assert.Equal(s.T(), int32(2), stat.Cversion)
+	assert.NoError(s.T(), err)
+
+	children, _, err := s.conn.Children("/parent")
+
+	assert.Equal(s.T(), []string{"0000000001", "seq-0000000000"}, children)
+	assert.NoError(s.T(), err)
+}
+
+func (s *FakeZookeeperTestSuite) TestSetAndDelete() {
+	acls := zk.WorldACL(zk.PermAll)
+
+	s.conn.Create("/node", []byte("data"), int32(PERSISTENT), acls)
+	s.conn.Create("/node/child", nil, int32(PERSISTENT), acls)
+
+	stat, err := s.conn.Set("/node", []byte("new"), 0)
+
+	assert.Equal(s.T(), int32(1), stat.Version)
+	assert.NoError(s.T(), err)
+
+	_, err = s.conn.Set("/node", []byte("new"), 0)
+
+	assert.Equal(s.T(), zk.ErrBadVersion, err)
+
+	assert.Equal(s.T(), zk.ErrNotEmpty, s.conn.Delete("/node", AnyVersion))
+	assert.Equal(s.T(), zk.ErrBadVersion, s.conn.Delete("/node/child", 3))
+	assert.NoError(s.T(), s.conn.Delete("/node/child", 0))
+	assert.NoError(s.T(), s.conn.Delete("/node", 1))
+	assert.Equal(s.T(), zk.ErrNoNode, s.conn.Delete("/node", AnyVersion))
+
+	exists, stat, err := s.conn.Exists("/node")
+
+	assert.False(s.T(), exists)
+	assert.Nil(s.T(), stat)
+	assert.NoError(s.T(), err)
+}
+
+func (s *FakeZookeeperTestSuite) TestACL() {
+	s.conn.Create("/node", nil, int32(PERSISTENT), zk.WorldACL(zk.PermAll))
+
+	stat, err := s.conn.SetACL("/node", zk.WorldACL(zk.PermRead), 0)
+
+	assert.Equal(s.T(), int32(1), stat.Aversion)
+	assert.NoError(s.T(), err)
+
+	_, err = s.conn.SetACL("/node", zk.WorldACL(zk.PermRead), 0)
+
+	assert.Equal(s.T(), zk.ErrBadVersion, err)
+
+	acls, _, err := s.conn.GetACL("/node")
+
+	assert.Equal(s.T(), zk.WorldACL(zk.PermRead), acls)
+	assert.NoError(s.T(), err)
+}
+
+func (s *FakeZookeeperTestSuite) TestWatches() {
+	_, _, existsEvents, err := s.conn.ExistsW("/node")
+
+	assert.NoError(s.T(), err)
+
+	_, _, childrenEvents, err := s.conn.ChildrenW("/")
+
+	assert.NoError(s.T(), err)
+
+	s.conn.Create("/node", nil, int32(PERSISTENT), zk.WorldACL(zk.PermAll))
+
+	assert.Equal(s.T(), zk.Event{Type: zk.EventNodeCreated, State: zk.StateHasSession, Path: "/node"}, <-existsEvents)
+	assert.Equal(s.T(), zk.Event{Type: zk.EventNodeChildrenChanged, State: zk.StateHasSession, Path: "/"}, <-childrenEvents)
+
+	// the watches are triggered only once
+	_, ok := <-existsEvents
+
+	assert.False(s.T(), ok)
+
+	_, _, dataEvents, err := s.conn.GetW("/node")
+
+	assert.NoError(s.T(), err)
+
+	// fan out the event to all the sessions
+	other, _, err := s.zookeeper.Dial(s.zookeeper.ConnectString(), DEFAULT_SESSION_TIMEOUT, false)
+
+	assert.NoError(s.T(), err)
+
+	_, _, otherEvents, err := other.GetW("/node")
+
+	assert.NoError(s.T(), err)
+
+	other.Set("/node", []byte("data"), AnyVersion)
+
+	assert.Equal(s.T(), zk.Event{Type: zk.EventNodeDataChanged, State: zk.StateHasSession, Path: "/node"}, <-dataEvents)
+	assert.Equal(s.T(), zk.Event{Type: zk.EventNodeDataChanged, State: zk.StateHasSession, Path: "/node"}, <-otherEvents)
+
+	_, _, dataEvents, _ = s.conn.GetW("/node")
+
+	other.Close()
+
+	s.conn.Delete("/node", AnyVersion)
+
+	assert.Equal(s.T(), zk.Event{Type: zk.EventNodeDeleted, State: zk.StateHasSession, Path: "/node"}, <-dataEvents)
+}
+
+func (s *FakeZookeeperTestSuite) TestEphemeral() {
+	other, _, err := s.zookeeper.Dial(s.zookeeper.ConnectString(), DEFAULT_SESSION_TIMEOUT, false)
+
+	assert.NoError(s.T(), err)
+
+	_, err = other.Create("/ephemeral", nil, int32(EPHEMERAL), zk.WorldACL(zk.PermAll))
+
+	assert.NoError(s.T(), err)
+
+	_, stat, events, err := s.conn.ExistsW("/ephemeral")
+
+	assert.Equal(s.T(), other.(*fakeConn).SessionID(), stat.EphemeralOwner)
+	assert.NoError(s.T(), err)
+
+	_, _, otherEvents, err := other.GetW("/ephemeral")
+
+	assert.NoError(s.T(), err)
+
+	other.Close()
+
+	assert.Equal(s.T(), zk.Event{Type: zk.EventNodeDeleted, State: zk.StateHasSession, Path: "/ephemeral"}, <-events)
+	assert.Equal(s.T(), zk.Event{Type: zk.EventNodeDeleted, State: zk.StateHasSession, Path: "/ephemeral"}, <-otherEvents)
+
+	exists, _, err := s.conn.Exists("/ephemeral")
+
+	assert.False(s.T(), exists)
+	assert.NoError(s.T(), err)
+}
+
+func (s *FakeZookeeperTestSuite) TestContainer() {
+	acls := zk.WorldACL(zk.PermAll)
+
+	_, err := s.conn.(ContainerConnection).CreateContainer("/container", nil, acls)
+
+	assert.NoError(s.T(), err)
+
+	_, err = s.conn.Create("/container/first", nil, int32(PERSISTENT), acls)
+
+	assert.NoError(s.T(), err)
+
+	_, err = s.conn.Create("/container/second", nil, int32(PERSISTENT), acls)
+
+	assert.NoError(s.T(), err)
+
+	assert.NoError(s.T(), s.conn.Delete("/container/first", AnyVersion))
+
+	exists, _, err := s.conn.Exists("/container")
+
+	assert.True(s.T(), exists)
+	assert.NoError(s.T(), err)
+
+	// the container is deleted with its last child
+	assert.NoError(s.T(), s.conn.Delete("/container/second", AnyVersion))
+
+	exists, _, err = s.conn.Exists("/container")
+
+	assert.False(s.T(), exists)
+	assert.NoError(s.T(), err)
+
+	// the CONTAINER mode of the create request makes a persistent node
+	_, err = s.conn.Create("/persistent", nil, int32(CONTAINER), acls)
+
+	assert.NoError(s.T(), err)
+
+	_, err = s.conn.Create("/persistent/child", nil, int32(PERSISTENT), acls)
+
+	assert.NoError(s.T(), err)
+	assert.NoError(s.T(), s.conn.Delete("/persistent/child", AnyVersion))
+
+	exists, _, err = s.conn.Exists("/persistent")
+
+	assert.True(s.T(), exists)
+	assert.NoError(s.T(), err)
+}
+
+func (s *FakeZookeeperTestSuite) TestMulti() {
+	acls := zk.WorldACL(zk.PermAll)
+
+	responses, err := s.conn.Multi(
+		&zk.CreateRequest{Path: "/node", Data: []byte("data"), Acl: acls},
+		&zk.SetDataRequest{Path: "/node", Data: []byte("new"), Version: 0},
+		&zk.CheckVersionRequest{Path: "/node", Version: 1},
+	)
+
+	assert.NoError(s.T(), err)
+
+	if assert.Len(s.T(), responses, 3) {
+		assert.Equal(s.T(), "/node", responses[0].String)
+		assert.Equal(s.T(), int32(1), responses[1].Stat.Version)
+	}
+
+	// all the operations are rolled back if any of them failed
+	_, err = s.conn.Multi(
+		&zk.DeleteRequest{Path: "/node", Version: AnyVersion},
+		&zk.CreateRequest{Path: "/other", Acl: acls},
+		&zk.CheckVersionRequest{Path: "/node", Version: 1},
+	)
+
+	assert.Equal(s.T(), zk.ErrNoNode, err)
+
+	data, _, err := s.conn.Get("/node")
+
+	assert.Equal(s.T(), []byte("new"), data)
+	assert.NoError(s.T(), err)
+
+	exists, _, err := s.conn.Exists("/other")
+
+	assert.False(s.T(), exists)
+	assert.NoError(s.T(), err)
+
+	path, err := s.conn.Sync("/node")
+
+	assert.Equal(s.T(), "/node", path)
+	assert.NoError(s.T(), err)
+}
+
+func (s *FakeZookeeperTestSuite) TestFramework() {
+	builder := &CuratorFrameworkBuilder{
+		ZookeeperDialer:   s.zookeeper,
+		SessionTimeout:    DEFAULT_SESSION_TIMEOUT,
+		ConnectionTimeout: DEFAULT_CONNECTION_TIMEOUT,
+		RetryPolicy:       NewRetryOneTime(0),
+	}
+
+	client := builder.ConnectString(s.zookeeper.ConnectString()).Build()
+
+	s.Require().NoError(client.Start())
+
+	defer client.Close()
+
+	assert.NoError(s.T(), client.BlockUntilConnected())
+
+	path, err := client.Create().CreatingParentsIfNeeded().ForPathWithData("/parent/child", []byte("data"))
+
+	assert.Equal(s.T(), "/parent/child", path)
+	assert.NoError(s.T(), err)
+
+	data, err := client.GetData().ForPath("/parent/child")
+
+	assert.Equal(s.T(), []byte("data"), data)
+	assert.NoError(s.T(), err)
+
+	assert.NoError(s.T(), client.Delete().DeletingChildrenIfNeeded().ForPath("/parent"))
+
+	stat, err := client.CheckExists().ForPath("/parent")
+
+	assert.Nil(s.T(), stat)
+	assert.NoError(s.T(), err)
+}
@@ -0,0 +1,43 @@
+package curator
+
+import (
+	"time"
+
+	"github.com/flier/curator.go/internal/fakezk"
+	"github.com/samuel/go-zookeeper/zk"
+)
+
+// The in-memory ZooKeeper of the integration tests, used as the ZookeeperDialer of the framework
+//
+//	zookeeper := NewFakeZookeeper()
+//	zookeeper.Start()
+//	defer zookeeper.Close()
+//
+//	client := (&CuratorFrameworkBuilder{ZookeeperDialer: zookeeper, RetryPolicy: NewRetryOneTime(0)}).
+//		ConnectString(zookeeper.ConnectString()).Build()
+type FakeZookeeper struct {
+	*fakezk.Server
+}
+
+func NewFakeZookeeper() *FakeZookeeper {
+	return &FakeZookeeper{fakezk.NewServer()}
+}
+
+// Open a new session on the fake server
+func (z *FakeZookeeper) Dial(connString string, sessionTimeout time.Duration, canBeReadOnly bool) (ZookeeperConnection, <-chan zk.Event, error) {
+	conn, events, err := z.Server.Dial(connString, sessionTimeout, canBeReadOnly)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &fakeConn{conn}, events, nil
+}
+
+type fakeConn struct {
+	*fakezk.Conn
+}
+
+func (c *fakeConn) RemoveWatch(path string, watcherType WatcherType) error {
+	return c.Conn.RemoveWatch(path, int32(watcherType))
+}
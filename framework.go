@@ -1,8 +1,14 @@
 package curator
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/samuel/go-zookeeper/zk"
@@ -26,6 +32,12 @@ type CuratorFramework interface {
 	// Returns the state of this instance
 	State() State
 
+	// Returns the lifecycle state of this instance
+	GetState() CuratorFrameworkState
+
+	// Block until the instance reaches the state or the context is done
+	WaitForState(ctx context.Context, state CuratorFrameworkState) error
+
 	// Return true if the client is started, not closed, etc.
 	Started() bool
 
@@ -41,6 +53,9 @@ type CuratorFramework interface {
 	// Start a get data builder
 	GetData() GetDataBuilder
 
+	// Start a builder which gets the data of multiple nodes concurrently
+	BatchGetData() BatchGetDataBuilder
+
 	// Start a set data builder
 	SetData() SetDataBuilder
 
@@ -53,6 +68,9 @@ type CuratorFramework interface {
 	// Start a set ACL builder
 	SetACL() SetACLBuilder
 
+	// Start a builder which creates multiple nodes atomically
+	BatchCreate() BatchCreateBuilder
+
 	// Start a transaction builder
 	InTransaction() Transaction
 
@@ -65,9 +83,18 @@ type CuratorFramework interface {
 	// Returns the listenable interface for the Connect State
 	ConnectionStateListenable() ConnectionStateListenable
 
+	// Add a listener that will be notified, on a dedicated goroutine, when the connection state changed
+	AddConnectionStateListener(listener ConnectionStateListener)
+
 	// Returns the listenable interface for events
 	CuratorListenable() CuratorListenable
 
+	// Add a listener that will receive the watched events and the results of background operations without callback
+	AddCuratorListener(listener CuratorListener)
+
+	// Remove a previously added listener
+	RemoveCuratorListener(listener CuratorListener)
+
 	// Returns the listenable interface for unhandled errors
 	UnhandledErrorListenable() UnhandledErrorListenable
 
@@ -76,7 +103,7 @@ type CuratorFramework interface {
 
 	// Returns a facade of the current instance that uses the specified namespace
 	// or no namespace if newNamespace is empty.
-	UsingNamespace(newNamespace string) CuratorFramework
+	UsingNamespace(newNamespace string) NamespaceFacade
 
 	// Return the current namespace or "" if none
 	Namespace() string
@@ -84,14 +111,61 @@ type CuratorFramework interface {
 	// Return the managed zookeeper client
 	ZookeeperClient() CuratorZookeeperClient
 
+	// Same as ZookeeperClient, but return ErrClientNotStarted if the client is not started
+	GetZookeeperClient() (CuratorZookeeperClient, error)
+
+	// Return the address of the ZooKeeper server currently connected to, or "" if not connected
+	GetCurrentConnectionString() string
+
+	// Block until a connection to ZooKeeper is available, and return the underlying connection. For testing purposes.
+	InternalGetZookeeperConnection() (ZookeeperConnection, error)
+
 	// Allocates an ensure path instance that is namespace aware
 	NewNamespaceAwareEnsurePath(path string) EnsurePath
 
+	// Create all the missing nodes of the path as the container nodes, which are deleted by the server once empty.
+	//
+	// The container nodes require a ContainerConnection, go-zookeeper doesn't support them,
+	// the persistent nodes are created instead and a warning is reported to the UnhandledErrorListener.
+	CreateContainers(path string) error
+
+	// Walk the subtree breadth-first, the visitor is called with the data and stat of each node
+	WalkTree(path string, visitor TreeVisitor, options ...WalkOption) error
+
+	// Copy the data and ACLs of the subtree to the destination, return zk.ErrNodeExists if it exists unless overwritten.
+	// The nodes are created by several transactions, the copied nodes are deleted if any of them failed.
+	CopyTree(src, dst string, options ...CopyOption) error
+
+	// Compare the data and ACLs of the nodes in the subtrees
+	Diff(path1, path2 string, options ...DiffOption) (*TreeDiff, error)
+
+	// Write the subtree to the writer as the newline delimited JSON, one ExportedNode per line
+	Export(path string, w io.Writer) error
+
+	// Re-create the subtree from the reader written by Export, stop at the first error unless ignored
+	Import(path string, r io.Reader, options ...ImportOption) error
+
 	// Block until a connection to ZooKeeper is available.
 	BlockUntilConnected() error
 
 	// Block until a connection to ZooKeeper is available or the maxWaitTime has been exceeded
 	BlockUntilConnectedTimeout(maxWaitTime time.Duration) error
+
+	// Block until a connection to ZooKeeper is available or the context is done
+	BlockUntilConnectedWithContext(ctx context.Context) error
+
+	// Add the authorization to the connection, it will be re-applied after reconnection
+	AddAuth(scheme string, auth []byte) error
+
+	// Reconfigure the ensemble (ZooKeeper 3.5+) with the joining and leaving servers, or replace all the members with the given ones.
+	// The config is the expected config version or -1 to skip the check, return the new config and its stat.
+	Reconfig(joining, leaving []string, members []string, config int64) ([]byte, *zk.Stat, error)
+
+	// Return a new buffered channel which receives all the raw watched events, it will be closed with the framework
+	WatchedEvents() <-chan zk.Event
+
+	// Register a channel which receives all the raw watched events, the events are dropped if it's full
+	AddWatchedEventChannel(ch chan<- zk.Event)
 }
 
 // Create a new client with default session timeout and default connection timeout
@@ -111,24 +185,145 @@ func NewClientTimeout(connString string, sessionTimeout, connectionTimeout time.
 }
 
 type CuratorFrameworkBuilder struct {
-	AuthInfos           []AuthInfo          // the connection authorization
-	ZookeeperDialer     ZookeeperDialer     // the zookeeper dialer to use
-	EnsembleProvider    EnsembleProvider    // the list ensemble provider.
-	DefaultData         []byte              // the data to use when PathAndBytesable.ForPath(String) is used.
-	Namespace           string              // as ZooKeeper is a shared space, users of a given cluster should stay within a pre-defined namespace
-	SessionTimeout      time.Duration       // the session timeout
-	ConnectionTimeout   time.Duration       // the connection timeout
-	MaxCloseWait        time.Duration       // the time to wait during close to wait background tasks
-	RetryPolicy         RetryPolicy         // the retry policy to use
-	CompressionProvider CompressionProvider // the compression provider
-	AclProvider         ACLProvider         // the provider for ACLs
-	CanBeReadOnly       bool                // allow ZooKeeper client to enter read only mode in case of a network partition.
-}
-
-// Apply the current values and build a new CuratorFramework
-func (b *CuratorFrameworkBuilder) Build() CuratorFramework {
+	AuthInfos              []AuthInfo             // the connection authorization
+	ZookeeperDialer        ZookeeperDialer        // the zookeeper dialer to use
+	EnsembleProvider       EnsembleProvider       // the list ensemble provider.
+	DefaultData            []byte                 // the data to use when PathAndBytesable.ForPath(String) is used.
+	Namespace              string                 // as ZooKeeper is a shared space, users of a given cluster should stay within a pre-defined namespace
+	SessionTimeout         time.Duration          // the session timeout
+	ConnectionTimeout      time.Duration          // the connection timeout
+	MaxCloseWait           time.Duration          // the time to wait during close to wait background tasks
+	RetryPolicy            RetryPolicy            // the retry policy to use
+	CompressionProvider    CompressionProvider    // the compression provider
+	AclProvider            ACLProvider            // the provider for ACLs
+	CanBeReadOnly          bool                   // allow ZooKeeper client to enter read only mode in case of a network partition.
+	EventBus               *EventBus              // the bus which receives all the watched events, it won't be closed with the framework
+	UnhandledErrorListener UnhandledErrorListener // the listener of the errors and panics in the background goroutines
+	TLSConfig              *tls.Config            // the TLS config to encrypt the connections if no dialer is given
+	SchemaSet              *SchemaSet             // the schemas to validate the operations before executing them
+	NodeMutationHooks      []NodeMutationHook     // the hooks to modify the data before writing, applied in registration order
+	NodeReadHooks          []NodeReadHook         // the hooks to modify the data after reading, applied in registration order
+}
+
+// The lifecycle state of the framework: LATENT, STARTED or STOPPED
+type CuratorFrameworkState = State
+
+var ErrClientNotStarted = errors.New("the framework is not started")
+
+// The state of the framework, the transitions are protected by a mutex and could be waited for
+type frameworkState struct {
+	lock    sync.Mutex
+	value   State
+	changed chan struct{} // closed when the state changed
+}
+
+func newFrameworkState() *frameworkState {
+	return &frameworkState{changed: make(chan struct{})}
+}
+
+func (s *frameworkState) Change(oldState, newState State) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.value != oldState {
+		return false
+	}
+
+	s.value = newState
+
+	close(s.changed)
+
+	s.changed = make(chan struct{})
+
+	return true
+}
+
+func (s *frameworkState) Value() State {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.value
+}
+
+func (s *frameworkState) Check(state State, msg string) {
+	s.Value().Check(state, msg)
+}
+
+func (s *frameworkState) WaitFor(ctx context.Context, state State) error {
+	for {
+		s.lock.Lock()
+		value, changed := s.value, s.changed
+		s.lock.Unlock()
+
+		if value == state {
+			return nil
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// The dialer of the builder, or a TLS dialer if only the TLS config is given
+func (b *CuratorFrameworkBuilder) zookeeperDialer() ZookeeperDialer {
+	if b.ZookeeperDialer == nil && b.TLSConfig != nil {
+		return NewTLSZookeeperDialer(b.TLSConfig)
+	}
+
+	return b.ZookeeperDialer
+}
+
+// The missing or invalid fields of a CuratorFrameworkBuilder
+type BuilderError struct {
+	Errors []string
+}
+
+func (e *BuilderError) Error() string {
+	return "invalid builder, " + strings.Join(e.Errors, ", ")
+}
+
+// Check the required fields, return a BuilderError listing every missing or invalid field.
+//
+// Only the fixed connection string is checked, the other ensemble providers are not started yet.
+func (b *CuratorFrameworkBuilder) Validate() error {
+	var errs []string
+
 	if b.EnsembleProvider == nil {
-		panic("missed ensemble provider")
+		errs = append(errs, "missed ensemble provider")
+	} else if p, ok := b.EnsembleProvider.(*FixedEnsembleProvider); ok && len(p.ConnectionString()) == 0 {
+		errs = append(errs, "empty connection string")
+	}
+
+	if b.RetryPolicy == nil {
+		errs = append(errs, "missed retry policy")
+	}
+
+	if b.SessionTimeout < 0 {
+		errs = append(errs, fmt.Sprintf("negative session timeout %s", b.SessionTimeout))
+	}
+
+	if b.ConnectionTimeout < 0 {
+		errs = append(errs, fmt.Sprintf("negative connection timeout %s", b.ConnectionTimeout))
+	}
+
+	if b.MaxCloseWait < 0 {
+		errs = append(errs, fmt.Sprintf("negative max close wait %s", b.MaxCloseWait))
+	}
+
+	if len(errs) > 0 {
+		return &BuilderError{errs}
+	}
+
+	return nil
+}
+
+// Apply the current values and build a new CuratorFramework, it panics with the BuilderError if the builder is invalid
+func (b *CuratorFrameworkBuilder) Build() CuratorFramework {
+	if err := b.Validate(); err != nil {
+		panic(err)
 	}
 
 	builder := *b
@@ -175,10 +370,46 @@ func (b *CuratorFrameworkBuilder) Compression(name string) *CuratorFrameworkBuil
 	return b
 }
 
+// Apply the options to the builder
+func (b *CuratorFrameworkBuilder) WithOptions(opts ...BuildOption) *CuratorFrameworkBuilder {
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	return b
+}
+
+// An option of CuratorFrameworkBuilder, which could be composed with WithOptions
+type BuildOption func(builder *CuratorFrameworkBuilder)
+
+func WithNamespace(namespace string) BuildOption {
+	return func(b *CuratorFrameworkBuilder) { b.Namespace = namespace }
+}
+
+func WithRetryPolicy(retryPolicy RetryPolicy) BuildOption {
+	return func(b *CuratorFrameworkBuilder) { b.RetryPolicy = retryPolicy }
+}
+
+func WithSessionTimeout(sessionTimeout time.Duration) BuildOption {
+	return func(b *CuratorFrameworkBuilder) { b.SessionTimeout = sessionTimeout }
+}
+
+func WithDefaultData(data []byte) BuildOption {
+	return func(b *CuratorFrameworkBuilder) { b.DefaultData = data }
+}
+
+func WithACLProvider(aclProvider ACLProvider) BuildOption {
+	return func(b *CuratorFrameworkBuilder) { b.AclProvider = aclProvider }
+}
+
+func WithCompressionProvider(compressionProvider CompressionProvider) BuildOption {
+	return func(b *CuratorFrameworkBuilder) { b.CompressionProvider = compressionProvider }
+}
+
 type curatorFramework struct {
 	client                  *curatorZookeeperClient
 	stateManager            *connectionStateManager
-	state                   State
+	state                   *frameworkState // shared with the namespace facades
 	listeners               CuratorListenable
 	unhandledErrorListeners UnhandledErrorListenable
 	defaultData             []byte
@@ -189,19 +420,42 @@ type curatorFramework struct {
 	retryPolicy             RetryPolicy
 	compressionProvider     CompressionProvider
 	aclProvider             ACLProvider
+	eventBus                *EventBus
+	schemaSet               *SchemaSet
+	nodeMutationHooks       []NodeMutationHook
+	nodeReadHooks           []NodeReadHook
+	watchedEvents           *watchedEventChannels
 }
 
 func newCuratorFramework(b *CuratorFrameworkBuilder) *curatorFramework {
 	c := &curatorFramework{
+		state:                   newFrameworkState(),
 		listeners:               &curatorListenerContainer{},
 		unhandledErrorListeners: &unhandledErrorListenerContainer{},
 		defaultData:             b.DefaultData,
 		retryPolicy:             b.RetryPolicy,
 		compressionProvider:     b.CompressionProvider,
 		aclProvider:             b.AclProvider,
+		eventBus:                b.EventBus,
+		schemaSet:               b.SchemaSet,
+		nodeMutationHooks:       b.NodeMutationHooks,
+		nodeReadHooks:           b.NodeReadHooks,
+		watchedEvents:           &watchedEventChannels{},
+	}
+
+	if b.UnhandledErrorListener != nil {
+		c.unhandledErrorListeners.AddListener(b.UnhandledErrorListener)
 	}
 
 	watcher := NewWatcher(func(event *zk.Event) {
+		defer c.recoverPanic("watcher")
+
+		if c.eventBus != nil {
+			c.eventBus.Publish(*event)
+		}
+
+		c.watchedEvents.Publish(*event)
+
 		c.processEvent(&curatorEvent{
 			eventType:    WATCHED,
 			err:          event.Err,
@@ -210,8 +464,10 @@ func newCuratorFramework(b *CuratorFrameworkBuilder) *curatorFramework {
 		})
 	})
 
-	c.client = NewCuratorZookeeperClient(b.ZookeeperDialer, b.EnsembleProvider, b.SessionTimeout, b.ConnectionTimeout, watcher, b.RetryPolicy, b.CanBeReadOnly, b.AuthInfos)
+	c.client = NewCuratorZookeeperClient(b.zookeeperDialer(), b.EnsembleProvider, b.SessionTimeout, b.ConnectionTimeout, watcher, b.RetryPolicy, b.CanBeReadOnly, b.AuthInfos)
+	c.client.aclProvider = b.AclProvider
 	c.stateManager = newConnectionStateManager(c)
+	c.stateManager.errorHandler = c.logError
 	c.namespace = newNamespace(c, b.Namespace)
 	c.namespaceFacadeCache = newNamespaceFacadeCache(c)
 	c.fixForNamespace = c.namespace.fixForNamespace
@@ -247,7 +503,19 @@ func (c *curatorFramework) Close() error {
 	c.unhandledErrorListeners.Clear()
 	c.stateManager.Close()
 
-	return c.client.Close()
+	err := c.client.Close()
+
+	c.watchedEvents.Close()
+
+	return err
+}
+
+func (c *curatorFramework) WatchedEvents() <-chan zk.Event {
+	return c.watchedEvents.New(DEFAULT_WATCHED_EVENTS_BUFFER_SIZE)
+}
+
+func (c *curatorFramework) AddWatchedEventChannel(ch chan<- zk.Event) {
+	c.watchedEvents.Add(ch)
 }
 
 func (c *curatorFramework) State() State {
@@ -258,57 +526,63 @@ func (c *curatorFramework) Started() bool {
 	return c.State() == STARTED
 }
 
-func (c *curatorFramework) Create() CreateBuilder {
-	c.state.Check(STARTED, "instance must be started before calling this method")
+func (c *curatorFramework) GetState() CuratorFrameworkState {
+	return c.state.Value()
+}
+
+func (c *curatorFramework) WaitForState(ctx context.Context, state CuratorFrameworkState) error {
+	return c.state.WaitFor(ctx, state)
+}
+
+func (c *curatorFramework) checkStarted() error {
+	if c.state.Value() != STARTED {
+		return ErrClientNotStarted
+	}
 
+	return nil
+}
+
+func (c *curatorFramework) Create() CreateBuilder {
 	return &createBuilder{client: c, acling: acling{aclProvider: c.aclProvider}}
 }
 
 func (c *curatorFramework) Delete() DeleteBuilder {
-	c.state.Check(STARTED, "instance must be started before calling this method")
-
 	return &deleteBuilder{client: c, version: AnyVersion}
 }
 
 func (c *curatorFramework) CheckExists() CheckExistsBuilder {
-	c.state.Check(STARTED, "instance must be started before calling this method")
-
 	return &checkExistsBuilder{client: c}
 }
 
 func (c *curatorFramework) GetData() GetDataBuilder {
-	c.state.Check(STARTED, "instance must be started before calling this method")
-
 	return &getDataBuilder{client: c}
 }
 
-func (c *curatorFramework) SetData() SetDataBuilder {
-	c.state.Check(STARTED, "instance must be started before calling this method")
+func (c *curatorFramework) BatchGetData() BatchGetDataBuilder {
+	return &batchGetDataBuilder{client: c, concurrency: DEFAULT_BATCH_CONCURRENCY, ctx: context.Background()}
+}
+
+func (c *curatorFramework) BatchCreate() BatchCreateBuilder {
+	return &batchCreateBuilder{client: c, maxRequestSize: DEFAULT_MAX_REQUEST_SIZE}
+}
 
+func (c *curatorFramework) SetData() SetDataBuilder {
 	return &setDataBuilder{client: c, version: AnyVersion}
 }
 
 func (c *curatorFramework) GetChildren() GetChildrenBuilder {
-	c.state.Check(STARTED, "instance must be started before calling this method")
-
-	return &getChildrenBuilder{client: c}
+	return &getChildrenBuilder{client: c, version: AnyVersion}
 }
 
 func (c *curatorFramework) GetACL() GetACLBuilder {
-	c.state.Check(STARTED, "instance must be started before calling this method")
-
 	return &getACLBuilder{client: c}
 }
 
 func (c *curatorFramework) SetACL() SetACLBuilder {
-	c.state.Check(STARTED, "instance must be started before calling this method")
-
 	return &setACLBuilder{client: c, version: AnyVersion, acling: acling{aclProvider: c.aclProvider}}
 }
 
 func (c *curatorFramework) InTransaction() Transaction {
-	c.state.Check(STARTED, "instance must be started before calling this method")
-
 	return &curatorTransaction{client: c}
 }
 
@@ -317,8 +591,6 @@ func (c *curatorFramework) DoSync(path string, context interface{}) {
 }
 
 func (c *curatorFramework) Sync() SyncBuilder {
-	c.state.Check(STARTED, "instance must be started before calling this method")
-
 	return &syncBuilder{client: c}
 }
 
@@ -326,10 +598,26 @@ func (c *curatorFramework) ConnectionStateListenable() ConnectionStateListenable
 	return c.stateManager.Listenable()
 }
 
+func (c *curatorFramework) AddConnectionStateListener(listener ConnectionStateListener) {
+	c.stateManager.Listenable().AddListener(listener)
+}
+
+func (c *curatorFramework) GetConnectionState() ConnectionState {
+	return c.stateManager.CurrentConnectionState()
+}
+
 func (c *curatorFramework) CuratorListenable() CuratorListenable {
 	return c.listeners
 }
 
+func (c *curatorFramework) AddCuratorListener(listener CuratorListener) {
+	c.listeners.AddListener(listener)
+}
+
+func (c *curatorFramework) RemoveCuratorListener(listener CuratorListener) {
+	c.listeners.RemoveListener(listener)
+}
+
 func (c *curatorFramework) UnhandledErrorListenable() UnhandledErrorListenable {
 	return c.unhandledErrorListeners
 }
@@ -350,6 +638,24 @@ func (c *curatorFramework) processEvent(event CuratorEvent) {
 	})
 }
 
+// Create a retry loop with the retry policy of the operation if any, or the default one of the framework
+func (c *curatorFramework) newRetryLoop(retryPolicy RetryPolicy) RetryLoop {
+	if retryPolicy == nil {
+		return c.client.NewRetryLoop()
+	}
+
+	return c.client.NewRetryLoopWithPolicy(retryPolicy)
+}
+
+// Deliver the result of a background operation to its callback, or to the listeners if no callback was given
+func (c *curatorFramework) processBackgroundEvent(callback BackgroundCallback, event CuratorEvent) {
+	if callback == nil {
+		c.processEvent(event)
+	} else if err := callback(c, event); err != nil {
+		c.logError(fmt.Errorf("Background callback threw exception, %s", err))
+	}
+}
+
 func (c *curatorFramework) validateConnection(state zk.State) {
 	switch state {
 	case zk.StateDisconnected:
@@ -358,7 +664,7 @@ func (c *curatorFramework) validateConnection(state zk.State) {
 	case zk.StateExpired:
 		c.stateManager.AddStateChange(LOST)
 
-	case zk.StateConnected:
+	case zk.StateConnected, zk.StateHasSession:
 		c.stateManager.AddStateChange(RECONNECTED)
 
 	case zk.StateConnectedReadOnly:
@@ -371,7 +677,9 @@ func (c *curatorFramework) suspendConnection() {
 		return
 	}
 
-	go c.doSyncForSuspendedConnection(c.client.InstanceIndex())
+	instanceIndex := c.client.InstanceIndex()
+
+	c.goSafely("doSyncForSuspendedConnection", func() { c.doSyncForSuspendedConnection(instanceIndex) })
 }
 
 func (c *curatorFramework) doSyncForSuspendedConnection(instanceIndex int64) {
@@ -394,23 +702,43 @@ func (c *curatorFramework) doSyncForSuspendedConnection(instanceIndex int64) {
 	if instanceIndex < 0 || instanceIndex == c.client.InstanceIndex() {
 		c.stateManager.AddStateChange(LOST)
 	} else {
-		go c.doSyncForSuspendedConnection(-1)
+		c.goSafely("doSyncForSuspendedConnection", func() { c.doSyncForSuspendedConnection(-1) })
 	}
 }
 
+// Report the error to the unhandled error listeners, or log it if there is no listener
 func (c *curatorFramework) logError(err error) {
-	log.Printf("error: %s", err)
+	if c.unhandledErrorListeners.Len() == 0 {
+		slog.Error("unhandled error", "err", err)
+
+		return
+	}
 
 	c.unhandledErrorListeners.ForEach(func(listener interface{}) {
 		listener.(UnhandledErrorListener).UnhandledError(err)
 	})
 }
 
+// Run the function in a new goroutine, and report the panic as an unhandled error
+func (c *curatorFramework) goSafely(name string, fn func()) {
+	go func() {
+		defer c.recoverPanic(name)
+
+		fn()
+	}()
+}
+
+func (c *curatorFramework) recoverPanic(name string) {
+	if v := recover(); v != nil {
+		c.logError(fmt.Errorf("panic in %s, %v", name, v))
+	}
+}
+
 func (c *curatorFramework) NonNamespaceView() CuratorFramework {
 	return c.UsingNamespace("")
 }
 
-func (c *curatorFramework) UsingNamespace(newNamespace string) CuratorFramework {
+func (c *curatorFramework) UsingNamespace(newNamespace string) NamespaceFacade {
 	c.state.Check(STARTED, "instance must be started before calling this method")
 
 	return c.namespaceFacadeCache.Get(newNamespace)
@@ -420,18 +748,140 @@ func (c *curatorFramework) Namespace() string {
 	return c.namespace.namespace
 }
 
+// Deliver the events of a watch to the watcher, the event bus if any and the watched event channels, until the watch is removed
+func (c *curatorFramework) watchEvents(events <-chan zk.Event, watcher Watcher, removed <-chan struct{}) {
+	if events == nil {
+		return
+	}
+
+	if watcher != nil {
+		userWatcher := watcher
+
+		watcher = NewWatcher(func(event *zk.Event) {
+			defer c.recoverPanic("watcher")
+
+			userWatcher.process(event)
+		})
+	}
+
+	go func() {
+		watchers := NewWatchers(watcher)
+
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+
+				// the removal of the watch may trigger an event
+				select {
+				case <-removed:
+					return
+				default:
+				}
+
+				if c.eventBus != nil {
+					c.eventBus.Publish(event)
+				}
+
+				c.watchedEvents.Publish(event)
+
+				watchers.Fire(&event)
+			case <-removed:
+				return
+			}
+		}
+	}()
+}
+
+// Wrap the watcher to remove the namespace from the paths of the watched events
 func (c *curatorFramework) getNamespaceWatcher(watcher Watcher) Watcher {
-	return watcher
+	if watcher == nil || c.namespace == nil || len(c.namespace.namespace) == 0 {
+		return watcher
+	}
+
+	return NewWatcher(func(event *zk.Event) {
+		unfixed := *event
+
+		unfixed.Path = c.unfixForNamespace(event.Path)
+
+		watcher.process(&unfixed)
+	})
 }
 
 func (c *curatorFramework) ZookeeperClient() CuratorZookeeperClient {
 	return c.client
 }
 
+func (c *curatorFramework) GetZookeeperClient() (CuratorZookeeperClient, error) {
+	if err := c.checkStarted(); err != nil {
+		return nil, err
+	}
+
+	return c.client, nil
+}
+
+func (c *curatorFramework) GetCurrentConnectionString() string {
+	if c.checkStarted() != nil || !c.client.Connected() {
+		return ""
+	}
+
+	conn, err := c.client.Conn()
+
+	if err != nil {
+		return ""
+	}
+
+	if conn, ok := conn.(ServerConnection); ok {
+		return conn.Server()
+	}
+
+	return ""
+}
+
+func (c *curatorFramework) InternalGetZookeeperConnection() (ZookeeperConnection, error) {
+	if err := c.checkStarted(); err != nil {
+		return nil, err
+	}
+
+	if err := c.BlockUntilConnected(); err != nil {
+		return nil, err
+	}
+
+	return c.client.Conn()
+}
+
 func (c *curatorFramework) NewNamespaceAwareEnsurePath(path string) EnsurePath {
 	return NewEnsurePathWithAcl(c.fixForNamespace(path, false), c.aclProvider)
 }
 
+func (c *curatorFramework) CreateContainers(path string) error {
+	if err := c.checkStarted(); err != nil {
+		return err
+	}
+
+	adjustedPath := c.fixForNamespace(path, false)
+
+	_, err := c.newRetryLoop(nil).CallWithRetry(func() (interface{}, error) {
+		conn, err := c.client.Conn()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if err = makeDirs(conn, adjustedPath, true, c.aclProvider, CONTAINER); err == ErrContainerNotSupported {
+			c.logError(fmt.Errorf("container nodes are not supported by the connection, fall back to persistent nodes for `%s`", path))
+
+			err = makeDirs(conn, adjustedPath, true, c.aclProvider, PERSISTENT)
+		}
+
+		return nil, err
+	})
+
+	return err
+}
+
 func (c *curatorFramework) BlockUntilConnected() error {
 	return c.BlockUntilConnectedTimeout(0)
 }
@@ -439,3 +889,59 @@ func (c *curatorFramework) BlockUntilConnected() error {
 func (c *curatorFramework) BlockUntilConnectedTimeout(maxWaitTime time.Duration) error {
 	return c.stateManager.BlockUntilConnected(maxWaitTime)
 }
+
+func (c *curatorFramework) BlockUntilConnectedWithContext(ctx context.Context) error {
+	return c.stateManager.BlockUntilConnectedWithContext(ctx)
+}
+
+func (c *curatorFramework) AddAuth(scheme string, auth []byte) error {
+	return c.client.AddAuth(scheme, auth)
+}
+
+func (c *curatorFramework) Reconfig(joining, leaving []string, members []string, config int64) ([]byte, *zk.Stat, error) {
+	if err := c.checkStarted(); err != nil {
+		return nil, nil, err
+	}
+
+	if len(members) > 0 && (len(joining) > 0 || len(leaving) > 0) {
+		return nil, nil, ErrConflictingReconfig
+	}
+
+	var data []byte
+	var stat *zk.Stat
+
+	_, err := c.newRetryLoop(nil).CallWithRetry(func() (interface{}, error) {
+		conn, err := c.client.Conn()
+
+		if err != nil {
+			return nil, err
+		}
+
+		reconfigConn, ok := conn.(ReconfigConnection)
+
+		if !ok {
+			return nil, ErrReconfigNotSupported
+		}
+
+		if len(members) > 0 {
+			stat, err = reconfigConn.Reconfig(members, config)
+		} else {
+			stat, err = reconfigConn.IncrementalReconfig(joining, leaving, config)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		// the new config is not returned by the connection, read it from the config node instead
+		data, _, err = conn.Get(ZOOKEEPER_CONFIG_NODE)
+
+		return nil, err
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return data, stat, nil
+}
@@ -0,0 +1,410 @@
+package curator
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/samuel/go-zookeeper/zk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilderValidate(t *testing.T) {
+	builder := &CuratorFrameworkBuilder{
+		SessionTimeout: -time.Second,
+	}
+
+	err := builder.Validate()
+
+	assert.EqualError(t, err, "invalid builder, missed ensemble provider, missed retry policy, negative session timeout -1s")
+
+	if builderErr, ok := err.(*BuilderError); assert.True(t, ok) {
+		assert.Len(t, builderErr.Errors, 3)
+	}
+
+	builder = &CuratorFrameworkBuilder{
+		RetryPolicy:       NewRetryOneTime(time.Second),
+		ConnectionTimeout: -time.Second,
+	}
+
+	assert.EqualError(t, builder.ConnectString("").Validate(), "invalid builder, empty connection string, negative connection timeout -1s")
+
+	builder.ConnectionTimeout = 0
+
+	assert.NoError(t, builder.ConnectString("localhost:2181").Validate())
+
+	// the other ensemble providers are not started yet
+	builder.EnsembleProvider = NewDynamicEnsembleProvider("localhost:2181", ZOOKEEPER_CONFIG_NODE)
+
+	assert.NoError(t, builder.Validate())
+
+	// the invalid builder can't be built
+	assert.PanicsWithError(t, "invalid builder, missed retry policy", func() {
+		(&CuratorFrameworkBuilder{}).ConnectString("localhost:2181").Build()
+	})
+}
+
+func TestBuilderOptions(t *testing.T) {
+	retryPolicy := NewRetryOneTime(time.Second)
+	aclProvider := NewDefaultACLProvider()
+	compressionProvider := NewGzipCompressionProvider()
+
+	builder := (&CuratorFrameworkBuilder{Namespace: "default"}).WithOptions(
+		WithNamespace("app"),
+		WithRetryPolicy(retryPolicy),
+		WithSessionTimeout(time.Minute),
+		WithDefaultData([]byte("data")),
+		WithACLProvider(aclProvider),
+		WithCompressionProvider(compressionProvider),
+	)
+
+	assert.Equal(t, "app", builder.Namespace)
+	assert.Equal(t, retryPolicy, builder.RetryPolicy)
+	assert.Equal(t, time.Minute, builder.SessionTimeout)
+	assert.Equal(t, []byte("data"), builder.DefaultData)
+	assert.Equal(t, aclProvider, builder.AclProvider)
+	assert.Equal(t, compressionProvider, builder.CompressionProvider)
+
+	// the options are applied in order, the later ones win
+	assert.Equal(t, "other", builder.WithOptions(WithNamespace("app"), WithNamespace("other")).Namespace)
+}
+
+func TestAddAuth(t *testing.T) {
+	newMockContainer().Test(t, func(client CuratorFramework, conn *mockConn, ensembleProvider *mockEnsembleProvider, events chan zk.Event) {
+		auth := []byte("user:password")
+
+		conn.On("AddAuth", "digest", auth).Return(nil).Once()
+
+		assert.NoError(t, client.AddAuth("digest", auth))
+
+		// the connection re-submits the authorization after the session has been re-established
+		ensembleProvider.On("ConnectionString").Return("connStr").Once()
+
+		events <- zk.Event{Type: zk.EventSession, State: zk.StateHasSession}
+
+		for !client.ZookeeperClient().Connected() {
+			time.Sleep(time.Millisecond)
+		}
+	})
+}
+
+func TestReconfig(t *testing.T) {
+	newMockContainer().Test(t, func(client CuratorFramework, conn *mockConn, stat *zk.Stat) {
+		config := []byte("server.1=host1:2888:3888:participant;2181\nversion=100000002")
+
+		conn.On("IncrementalReconfig", []string{"server.2=host2:2888:3888;2181"}, []string{"3"}, int64(-1)).Return(stat, nil).Once()
+		conn.On("Get", ZOOKEEPER_CONFIG_NODE).Return(config, stat, nil).Twice()
+
+		data, newStat, err := client.Reconfig([]string{"server.2=host2:2888:3888;2181"}, []string{"3"}, nil, -1)
+
+		assert.Equal(t, config, data)
+		assert.Equal(t, stat, newStat)
+		assert.NoError(t, err)
+
+		conn.On("Reconfig", []string{"server.1=host1:2888:3888;2181"}, int64(100000001)).Return(stat, nil).Once()
+
+		data, newStat, err = client.Reconfig(nil, nil, []string{"server.1=host1:2888:3888;2181"}, 100000001)
+
+		assert.Equal(t, config, data)
+		assert.Equal(t, stat, newStat)
+		assert.NoError(t, err)
+
+		// the members can't be mixed with the incremental changes
+		_, _, err = client.Reconfig([]string{"server.2=host2:2888:3888;2181"}, nil, []string{"server.1=host1:2888:3888;2181"}, -1)
+
+		assert.Equal(t, ErrConflictingReconfig, err)
+	})
+}
+
+func TestSimulateSessionExpiry(t *testing.T) {
+	newMockContainer().Test(t, func(client CuratorFramework, conn *mockConn, zookeeperClient *mockZookeeperClient, data []byte, stat *zk.Stat) {
+		framework := client.(*curatorFramework)
+
+		instanceIndex := framework.client.InstanceIndex()
+
+		assert.NoError(t, zookeeperClient.SimulateSessionExpiry())
+
+		assert.Equal(t, instanceIndex+1, framework.client.InstanceIndex())
+		assert.True(t, client.ZookeeperClient().Connected())
+		assert.True(t, framework.GetConnectionState().Connected())
+
+		// the framework works with the new session
+		conn.On("Get", "/node").Return(data, stat, nil).Once()
+
+		data2, err := client.GetData().ForPath("/node")
+
+		assert.Equal(t, data, data2)
+		assert.NoError(t, err)
+
+		assert.NoError(t, zookeeperClient.SimulateSessionExpiry())
+	})
+}
+
+func TestMockConnLatency(t *testing.T) {
+	newMockContainer().Test(t, func(client CuratorFramework, conn *mockConn, data []byte, stat *zk.Stat) {
+		sleeper := &mockRetrySleeper{}
+
+		conn.sleeper = sleeper
+		conn.SetLatencyFunc(func(op string) time.Duration {
+			if op == "Get" {
+				return time.Minute
+			}
+
+			return 0
+		})
+
+		// only the slow operation sleeps, the sleeper fast-forwards it
+		sleeper.On("SleepFor", time.Minute).Return(nil).Once()
+		conn.On("Get", "/node").Return(data, stat, nil).Once()
+		conn.On("Exists", "/node").Return(true, stat, nil).Once()
+
+		_, err := client.GetData().ForPath("/node")
+
+		assert.NoError(t, err)
+
+		_, err = client.CheckExists().ForPath("/node")
+
+		assert.NoError(t, err)
+
+		sleeper.AssertExpectations(t)
+
+		// sleep for real with the default sleeper
+		conn.sleeper = nil
+		conn.SetLatency(10 * time.Millisecond)
+
+		conn.On("Exists", "/node").Return(true, stat, nil).Once()
+
+		start := time.Now()
+
+		_, err = client.CheckExists().ForPath("/node")
+
+		assert.NoError(t, err)
+		assert.True(t, time.Since(start) >= 10*time.Millisecond)
+
+		conn.SetLatencyFunc(nil)
+	})
+}
+
+func TestConnectionStateListener(t *testing.T) {
+	newMockContainer().Test(t, func(client CuratorFramework) {
+		var wg sync.WaitGroup
+		var states []ConnectionState
+
+		wg.Add(3)
+
+		client.AddConnectionStateListener(NewConnectionStateListener(func(c CuratorFramework, newState ConnectionState) {
+			assert.True(t, client == c)
+
+			states = append(states, newState)
+
+			wg.Done()
+		}))
+
+		framework := client.(*curatorFramework)
+
+		framework.validateConnection(zk.StateHasSession)
+		framework.validateConnection(zk.StateConnectedReadOnly)
+		framework.validateConnection(zk.StateHasSession)
+
+		wg.Wait()
+
+		assert.Equal(t, []ConnectionState{CONNECTED, READ_ONLY, RECONNECTED}, states)
+	})
+}
+
+func TestUnhandledErrorListener(t *testing.T) {
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+
+	newMockContainer().Prepare(func(builder *CuratorFrameworkBuilder) {
+		builder.UnhandledErrorListener = NewUnhandledErrorListener(func(err error) {
+			defer wg.Done()
+
+			assert.EqualError(t, err, "panic in getDataBuilder.pathInBackground, boom")
+		})
+	}).Test(t, func(client CuratorFramework, conn *mockConn, data []byte, stat *zk.Stat) {
+		conn.On("Get", "/node").Return(data, stat, nil).Once()
+
+		_, err := client.GetData().InBackgroundWithCallback(func(client CuratorFramework, event CuratorEvent) error {
+			panic("boom")
+		}).ForPath("/node")
+
+		assert.NoError(t, err)
+
+		wg.Wait()
+	})
+}
+
+func TestCreateContainers(t *testing.T) {
+	zookeeper := NewFakeZookeeper()
+
+	assert.NoError(t, zookeeper.Start())
+
+	defer zookeeper.Close()
+
+	builder := &CuratorFrameworkBuilder{
+		ZookeeperDialer:   zookeeper,
+		Namespace:         "app",
+		SessionTimeout:    DEFAULT_SESSION_TIMEOUT,
+		ConnectionTimeout: DEFAULT_CONNECTION_TIMEOUT,
+		RetryPolicy:       NewRetryOneTime(0),
+	}
+
+	client := builder.ConnectString(zookeeper.ConnectString()).Build()
+
+	assert.NoError(t, client.Start())
+
+	defer client.Close()
+
+	assert.NoError(t, client.CreateContainers("/a/b"))
+	assert.NoError(t, client.CreateContainers("/a/b"))
+
+	_, err := client.Create().ForPath("/a/b/c")
+
+	assert.NoError(t, err)
+
+	// the containers are deleted once the last child was deleted
+	assert.NoError(t, client.Delete().ForPath("/a/b/c"))
+
+	stat, err := client.CheckExists().ForPath("/a")
+
+	assert.Nil(t, stat)
+	assert.NoError(t, err)
+}
+
+func TestCreateContainersFallback(t *testing.T) {
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+
+	newMockContainer().Prepare(func(builder *CuratorFrameworkBuilder) {
+		builder.UnhandledErrorListener = NewUnhandledErrorListener(func(err error) {
+			defer wg.Done()
+
+			assert.EqualError(t, err, "container nodes are not supported by the connection, fall back to persistent nodes for `/a/b`")
+		})
+	}).Test(t, func(client CuratorFramework, conn *mockConn, aclProvider *mockACLProvider) {
+		aclProvider.On("GetAclForPath", "/a").Return(OPEN_ACL_UNSAFE).Twice()
+		aclProvider.On("GetAclForPath", "/a/b").Return(OPEN_ACL_UNSAFE).Once()
+		conn.On("Exists", "/a").Return(false, nil, nil).Twice()
+		conn.On("CreateContainer", "/a", []byte{}, OPEN_ACL_UNSAFE).Return("", ErrContainerNotSupported).Once()
+		conn.On("Create", "/a", []byte{}, int32(PERSISTENT), OPEN_ACL_UNSAFE).Return("/a", nil).Once()
+		conn.On("Exists", "/a/b").Return(false, nil, nil).Once()
+		conn.On("Create", "/a/b", []byte{}, int32(PERSISTENT), OPEN_ACL_UNSAFE).Return("/a/b", nil).Once()
+
+		assert.NoError(t, client.CreateContainers("/a/b"))
+
+		wg.Wait()
+	})
+}
+
+func TestGetZookeeperClient(t *testing.T) {
+	zookeeper := NewFakeZookeeper()
+
+	assert.NoError(t, zookeeper.Start())
+
+	defer zookeeper.Close()
+
+	builder := &CuratorFrameworkBuilder{
+		ZookeeperDialer:   zookeeper,
+		SessionTimeout:    DEFAULT_SESSION_TIMEOUT,
+		ConnectionTimeout: DEFAULT_CONNECTION_TIMEOUT,
+		RetryPolicy:       NewRetryOneTime(0),
+	}
+
+	client := builder.ConnectString(zookeeper.ConnectString()).Build()
+
+	zkClient, err := client.GetZookeeperClient()
+
+	assert.Nil(t, zkClient)
+	assert.Equal(t, ErrClientNotStarted, err)
+
+	_, err = client.InternalGetZookeeperConnection()
+
+	assert.Equal(t, ErrClientNotStarted, err)
+
+	assert.NoError(t, client.Start())
+
+	defer client.Close()
+
+	zkClient, err = client.GetZookeeperClient()
+
+	assert.Equal(t, client.ZookeeperClient(), zkClient)
+	assert.NoError(t, err)
+
+	conn, err := client.InternalGetZookeeperConnection()
+
+	assert.NoError(t, err)
+
+	exists, _, err := conn.Exists(PATH_SEPARATOR)
+
+	assert.True(t, exists)
+	assert.NoError(t, err)
+}
+
+func TestGetCurrentConnectionString(t *testing.T) {
+	zookeeper := NewFakeZookeeper()
+
+	assert.NoError(t, zookeeper.Start())
+
+	defer zookeeper.Close()
+
+	builder := &CuratorFrameworkBuilder{
+		ZookeeperDialer:   zookeeper,
+		SessionTimeout:    DEFAULT_SESSION_TIMEOUT,
+		ConnectionTimeout: DEFAULT_CONNECTION_TIMEOUT,
+		RetryPolicy:       NewRetryOneTime(0),
+	}
+
+	client := builder.ConnectString(zookeeper.ConnectString()).Build()
+
+	assert.Equal(t, "", client.GetCurrentConnectionString())
+	assert.NoError(t, client.Start())
+
+	defer client.Close()
+
+	assert.NoError(t, client.BlockUntilConnectedTimeout(time.Second))
+	assert.Equal(t, zookeeper.ConnectString(), client.GetCurrentConnectionString())
+
+	// not connected after the server is gone
+	assert.NoError(t, zookeeper.Close())
+	assert.Eventually(t, func() bool { return client.GetCurrentConnectionString() == "" }, time.Second, time.Millisecond)
+}
+
+func TestFrameworkState(t *testing.T) {
+	client := NewClient("localhost:2181", NewRetryOneTime(time.Second))
+
+	assert.Equal(t, LATENT, client.GetState())
+
+	_, err := client.GetData().ForPath("/node")
+
+	assert.Equal(t, ErrClientNotStarted, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+
+	defer cancel()
+
+	assert.Equal(t, context.DeadlineExceeded, client.WaitForState(ctx, STARTED))
+
+	state := newFrameworkState()
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		assert.NoError(t, state.WaitFor(context.Background(), STOPPED))
+	}()
+
+	assert.True(t, state.Change(LATENT, STARTED))
+	assert.False(t, state.Change(LATENT, STARTED))
+	assert.True(t, state.Change(STARTED, STOPPED))
+
+	wg.Wait()
+
+	assert.Equal(t, STOPPED, state.Value())
+}
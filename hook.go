@@ -0,0 +1,52 @@
+package curator
+
+// Intercept and modify the data before it's written, e.g. encrypting the data or tagging it for auditing.
+type NodeMutationHook interface {
+	// Called with the data of the node to create, before it's compressed
+	MutateCreate(path string, data []byte) ([]byte, error)
+
+	// Called with the data to set to the node, before it's compressed
+	MutateSet(path string, data []byte) ([]byte, error)
+}
+
+// Modify the data after it's read, the reciprocal of NodeMutationHook
+type NodeReadHook interface {
+	// Called with the data of the node, after it's decompressed
+	MutateGet(path string, data []byte) ([]byte, error)
+}
+
+func (c *curatorFramework) mutateCreate(path string, data []byte) ([]byte, error) {
+	var err error
+
+	for _, hook := range c.nodeMutationHooks {
+		if data, err = hook.MutateCreate(path, data); err != nil {
+			return nil, err
+		}
+	}
+
+	return data, nil
+}
+
+func (c *curatorFramework) mutateSet(path string, data []byte) ([]byte, error) {
+	var err error
+
+	for _, hook := range c.nodeMutationHooks {
+		if data, err = hook.MutateSet(path, data); err != nil {
+			return nil, err
+		}
+	}
+
+	return data, nil
+}
+
+func (c *curatorFramework) mutateGet(path string, data []byte) ([]byte, error) {
+	var err error
+
+	for _, hook := range c.nodeReadHooks {
+		if data, err = hook.MutateGet(path, data); err != nil {
+			return nil, err
+		}
+	}
+
+	return data, nil
+}
@@ -0,0 +1,100 @@
+package curator
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type tagHook struct {
+	tag string
+}
+
+func (h *tagHook) MutateCreate(path string, data []byte) ([]byte, error) {
+	return append([]byte(h.tag+"+"), data...), nil
+}
+
+func (h *tagHook) MutateSet(path string, data []byte) ([]byte, error) {
+	if path == "/readonly" {
+		return nil, errors.New("readonly")
+	}
+
+	return append([]byte(h.tag+"*"), data...), nil
+}
+
+func (h *tagHook) MutateGet(path string, data []byte) ([]byte, error) {
+	return bytes.TrimPrefix(bytes.TrimPrefix(data, []byte(h.tag+"+")), []byte(h.tag+"*")), nil
+}
+
+func TestNodeMutationHooks(t *testing.T) {
+	zookeeper := NewFakeZookeeper()
+
+	assert.NoError(t, zookeeper.Start())
+
+	defer zookeeper.Close()
+
+	builder := &CuratorFrameworkBuilder{
+		ZookeeperDialer:   zookeeper,
+		Namespace:         "app",
+		SessionTimeout:    DEFAULT_SESSION_TIMEOUT,
+		ConnectionTimeout: DEFAULT_CONNECTION_TIMEOUT,
+		RetryPolicy:       NewRetryOneTime(0),
+		NodeMutationHooks: []NodeMutationHook{&tagHook{"a"}, &tagHook{"b"}},
+		NodeReadHooks:     []NodeReadHook{&tagHook{"b"}, &tagHook{"a"}},
+	}
+
+	client := builder.ConnectString(zookeeper.ConnectString()).Build()
+
+	assert.NoError(t, client.Start())
+
+	defer client.Close()
+
+	conn, err := client.ZookeeperClient().Conn()
+
+	assert.NoError(t, err)
+
+	// the hooks are applied in registration order
+	_, err = client.Create().Compressed().ForPathWithData("/node", []byte("data"))
+
+	assert.NoError(t, err)
+
+	data, err := client.GetData().Decompressed().ForPath("/node")
+
+	assert.Equal(t, []byte("data"), data)
+	assert.NoError(t, err)
+
+	_, err = client.SetData().ForPathWithData("/node", []byte("new"))
+
+	assert.NoError(t, err)
+
+	raw, _, err := conn.Get("/app/node")
+
+	assert.Equal(t, []byte("b*a*new"), raw)
+	assert.NoError(t, err)
+
+	data, err = client.GetData().ForPath("/node")
+
+	assert.Equal(t, []byte("new"), data)
+	assert.NoError(t, err)
+
+	// the transactions are also intercepted
+	_, err = client.InTransaction().Create().ForPathWithData("/other", []byte("data")).Commit()
+
+	assert.NoError(t, err)
+
+	raw, _, err = conn.Get("/app/other")
+
+	assert.Equal(t, []byte("b+a+data"), raw)
+	assert.NoError(t, err)
+
+	// the errors of the hooks abort the operations
+	_, err = client.SetData().ForPathWithData("/readonly", []byte("data"))
+
+	assert.EqualError(t, err, "readonly")
+
+	_, err = client.InTransaction().SetData().ForPathWithData("/readonly", []byte("data")).Commit()
+
+	assert.EqualError(t, err, "readonly")
+}
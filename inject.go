@@ -0,0 +1,295 @@
+package curator
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/samuel/go-zookeeper/zk"
+)
+
+// The policy to decide the delay and the error injected before a ZooKeeper operation
+type InjectionPolicy interface {
+	// Return the delay and the error for the n-th (starts from 1) call of the operation,
+	// the operation is named after the method of ZookeeperConnection, e.g. "Create" or "GetW".
+	Inject(operation string, call int) (time.Duration, error)
+}
+
+// The InjectionPolicy implemented by a function
+type InjectionPolicyFunc func(operation string, call int) (time.Duration, error)
+
+func (f InjectionPolicyFunc) Inject(operation string, call int) (time.Duration, error) {
+	return f(operation, call)
+}
+
+// The rule to inject the delay or error to an operation
+type InjectionRule struct {
+	Operation string        // the operation to match, or all the operations if empty
+	Calls     []int         // the calls to match, e.g. 3 for the 3rd call, or all the calls if empty
+	Rate      float64       // the probability to inject the error in a matched call, 0 means always
+	Delay     time.Duration // the delay before the matched call
+	Err       error         // the error returned instead of delegating the matched call
+}
+
+func (r *InjectionRule) match(operation string, call int) bool {
+	if r.Operation != "" && r.Operation != operation {
+		return false
+	}
+
+	if len(r.Calls) == 0 {
+		return true
+	}
+
+	for _, n := range r.Calls {
+		if n == call {
+			return true
+		}
+	}
+
+	return false
+}
+
+// The InjectionPolicy which applies the first matched rule
+type InjectionRules struct {
+	Rules  []InjectionRule
+	Random *rand.Rand // the random source of the rates, use the global source if nil
+
+	lock sync.Mutex
+}
+
+func (p *InjectionRules) Inject(operation string, call int) (time.Duration, error) {
+	for i := range p.Rules {
+		rule := &p.Rules[i]
+
+		if !rule.match(operation, call) {
+			continue
+		}
+
+		if rule.Rate > 0 && p.random() >= rule.Rate {
+			return rule.Delay, nil
+		}
+
+		return rule.Delay, rule.Err
+	}
+
+	return 0, nil
+}
+
+func (p *InjectionRules) random() float64 {
+	if p.Random == nil {
+		return rand.Float64()
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.Random.Float64()
+}
+
+// The ZooKeeper connection which injects the delays and errors before delegating the operations
+type errorInjector struct {
+	conn   ZookeeperConnection
+	policy InjectionPolicy
+	lock   sync.Mutex
+	calls  map[string]int
+}
+
+// Wrap the connection to inject the delays and errors decided by the policy, it is used to test the retry logic.
+func NewErrorInjector(conn ZookeeperConnection, policy InjectionPolicy) ZookeeperConnection {
+	return &errorInjector{conn: conn, policy: policy, calls: make(map[string]int)}
+}
+
+func (c *errorInjector) inject(operation string) error {
+	c.lock.Lock()
+	c.calls[operation]++
+	call := c.calls[operation]
+	c.lock.Unlock()
+
+	delay, err := c.policy.Inject(operation, call)
+
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+
+	return err
+}
+
+func (c *errorInjector) AddAuth(scheme string, auth []byte) error {
+	if err := c.inject("AddAuth"); err != nil {
+		return err
+	}
+
+	return c.conn.AddAuth(scheme, auth)
+}
+
+func (c *errorInjector) Close() {
+	c.conn.Close()
+}
+
+func (c *errorInjector) Create(path string, data []byte, flags int32, acl []zk.ACL) (string, error) {
+	if err := c.inject("Create"); err != nil {
+		return "", err
+	}
+
+	return c.conn.Create(path, data, flags, acl)
+}
+
+func (c *errorInjector) CreateTTL(path string, data []byte, flags int32, acl []zk.ACL, ttl int64) (string, error) {
+	ttlConn, ok := c.conn.(TTLConnection)
+
+	if !ok {
+		return "", ErrTTLNotSupported
+	}
+
+	if err := c.inject("CreateTTL"); err != nil {
+		return "", err
+	}
+
+	return ttlConn.CreateTTL(path, data, flags, acl, ttl)
+}
+
+func (c *errorInjector) CreateContainer(path string, data []byte, acl []zk.ACL) (string, error) {
+	containerConn, ok := c.conn.(ContainerConnection)
+
+	if !ok {
+		return "", ErrContainerNotSupported
+	}
+
+	if err := c.inject("CreateContainer"); err != nil {
+		return "", err
+	}
+
+	return containerConn.CreateContainer(path, data, acl)
+}
+
+func (c *errorInjector) Exists(path string) (bool, *zk.Stat, error) {
+	if err := c.inject("Exists"); err != nil {
+		return false, nil, err
+	}
+
+	return c.conn.Exists(path)
+}
+
+func (c *errorInjector) ExistsW(path string) (bool, *zk.Stat, <-chan zk.Event, error) {
+	if err := c.inject("ExistsW"); err != nil {
+		return false, nil, nil, err
+	}
+
+	return c.conn.ExistsW(path)
+}
+
+func (c *errorInjector) Delete(path string, version int32) error {
+	if err := c.inject("Delete"); err != nil {
+		return err
+	}
+
+	return c.conn.Delete(path, version)
+}
+
+func (c *errorInjector) Get(path string) ([]byte, *zk.Stat, error) {
+	if err := c.inject("Get"); err != nil {
+		return nil, nil, err
+	}
+
+	return c.conn.Get(path)
+}
+
+func (c *errorInjector) GetW(path string) ([]byte, *zk.Stat, <-chan zk.Event, error) {
+	if err := c.inject("GetW"); err != nil {
+		return nil, nil, nil, err
+	}
+
+	return c.conn.GetW(path)
+}
+
+func (c *errorInjector) Set(path string, data []byte, version int32) (*zk.Stat, error) {
+	if err := c.inject("Set"); err != nil {
+		return nil, err
+	}
+
+	return c.conn.Set(path, data, version)
+}
+
+func (c *errorInjector) Children(path string) ([]string, *zk.Stat, error) {
+	if err := c.inject("Children"); err != nil {
+		return nil, nil, err
+	}
+
+	return c.conn.Children(path)
+}
+
+func (c *errorInjector) ChildrenW(path string) ([]string, *zk.Stat, <-chan zk.Event, error) {
+	if err := c.inject("ChildrenW"); err != nil {
+		return nil, nil, nil, err
+	}
+
+	return c.conn.ChildrenW(path)
+}
+
+func (c *errorInjector) GetACL(path string) ([]zk.ACL, *zk.Stat, error) {
+	if err := c.inject("GetACL"); err != nil {
+		return nil, nil, err
+	}
+
+	return c.conn.GetACL(path)
+}
+
+func (c *errorInjector) SetACL(path string, acl []zk.ACL, version int32) (*zk.Stat, error) {
+	if err := c.inject("SetACL"); err != nil {
+		return nil, err
+	}
+
+	return c.conn.SetACL(path, acl, version)
+}
+
+func (c *errorInjector) Multi(ops ...interface{}) ([]zk.MultiResponse, error) {
+	if err := c.inject("Multi"); err != nil {
+		return nil, err
+	}
+
+	return c.conn.Multi(ops...)
+}
+
+func (c *errorInjector) Reconfig(members []string, version int64) (*zk.Stat, error) {
+	reconfigConn, ok := c.conn.(ReconfigConnection)
+
+	if !ok {
+		return nil, ErrReconfigNotSupported
+	}
+
+	if err := c.inject("Reconfig"); err != nil {
+		return nil, err
+	}
+
+	return reconfigConn.Reconfig(members, version)
+}
+
+func (c *errorInjector) IncrementalReconfig(joining, leaving []string, version int64) (*zk.Stat, error) {
+	reconfigConn, ok := c.conn.(ReconfigConnection)
+
+	if !ok {
+		return nil, ErrReconfigNotSupported
+	}
+
+	if err := c.inject("IncrementalReconfig"); err != nil {
+		return nil, err
+	}
+
+	return reconfigConn.IncrementalReconfig(joining, leaving, version)
+}
+
+func (c *errorInjector) Server() string {
+	if conn, ok := c.conn.(ServerConnection); ok {
+		return conn.Server()
+	}
+
+	return ""
+}
+
+func (c *errorInjector) Sync(path string) (string, error) {
+	if err := c.inject("Sync"); err != nil {
+		return "", err
+	}
+
+	return c.conn.Sync(path)
+}
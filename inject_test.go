@@ -0,0 +1,113 @@
+package curator
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/samuel/go-zookeeper/zk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInjectionRules(t *testing.T) {
+	policy := &InjectionRules{
+		Rules: []InjectionRule{
+			{Operation: "Create", Calls: []int{3}, Err: zk.ErrConnectionClosed},
+			{Operation: "Get", Delay: time.Millisecond},
+			{Operation: "Set", Rate: 0.5, Err: zk.ErrSessionMoved},
+		},
+		Random: rand.New(rand.NewSource(0)),
+	}
+
+	delay, err := policy.Inject("Create", 1)
+
+	assert.Zero(t, delay)
+	assert.NoError(t, err)
+
+	delay, err = policy.Inject("Create", 3)
+
+	assert.Zero(t, delay)
+	assert.Equal(t, zk.ErrConnectionClosed, err)
+
+	delay, err = policy.Inject("Get", 5)
+
+	assert.Equal(t, time.Millisecond, delay)
+	assert.NoError(t, err)
+
+	var injected int
+
+	for i := 1; i <= 1000; i++ {
+		if _, err := policy.Inject("Set", i); err != nil {
+			injected++
+		}
+	}
+
+	assert.InDelta(t, 500, injected, 100)
+}
+
+func TestErrorInjector(t *testing.T) {
+	conn := &mockConn{}
+	acls := zk.WorldACL(zk.PermAll)
+
+	conn.On("Create", "/node", []byte("data"), int32(PERSISTENT), acls).Return("/node", nil).Twice()
+	conn.On("Get", "/node").Return([]byte("data"), &zk.Stat{}, nil).Once()
+
+	injector := NewErrorInjector(conn, &InjectionRules{
+		Rules: []InjectionRule{
+			{Operation: "Create", Calls: []int{2}, Err: zk.ErrConnectionClosed},
+			{Operation: "Get", Calls: []int{1}, Delay: 10 * time.Millisecond},
+		},
+	})
+
+	for i, expected := range []error{nil, zk.ErrConnectionClosed, nil} {
+		_, err := injector.Create("/node", []byte("data"), int32(PERSISTENT), acls)
+
+		assert.Equal(t, expected, err, "call #%d", i+1)
+	}
+
+	start := time.Now()
+
+	data, _, err := injector.Get("/node")
+
+	assert.Equal(t, []byte("data"), data)
+	assert.NoError(t, err)
+	assert.True(t, time.Since(start) >= 10*time.Millisecond)
+
+	conn.AssertExpectations(t)
+}
+
+func TestErrorInjectorWithRetry(t *testing.T) {
+	zookeeper := NewFakeZookeeper()
+
+	assert.NoError(t, zookeeper.Start())
+
+	defer zookeeper.Close()
+
+	policy := &InjectionRules{Rules: []InjectionRule{{Operation: "Create", Calls: []int{1, 2}, Err: zk.ErrSessionMoved}}}
+
+	builder := &CuratorFrameworkBuilder{
+		ZookeeperDialer: NewZookeeperDialer(func(connString string, sessionTimeout time.Duration, canBeReadOnly bool) (ZookeeperConnection, <-chan zk.Event, error) {
+			conn, events, err := zookeeper.Dial(connString, sessionTimeout, canBeReadOnly)
+
+			if err != nil {
+				return nil, nil, err
+			}
+
+			return NewErrorInjector(conn, policy), events, nil
+		}),
+		SessionTimeout:    DEFAULT_SESSION_TIMEOUT,
+		ConnectionTimeout: DEFAULT_CONNECTION_TIMEOUT,
+		RetryPolicy:       NewRetryNTimes(2, 0, 0),
+	}
+
+	client := builder.ConnectString(zookeeper.ConnectString()).Build()
+
+	assert.NoError(t, client.Start())
+
+	defer client.Close()
+
+	path, err := client.Create().ForPath("/node")
+
+	assert.Equal(t, "/node", path)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,726 @@
+// Package fakezk is an in-process, in-memory ZooKeeper server for the integration tests.
+package fakezk
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/samuel/go-zookeeper/zk"
+)
+
+var ErrNotStarted = errors.New("the fake zookeeper is not started")
+
+var serverIds int64
+
+const (
+	pathSeparator = "/"
+	anyVersion    = -1
+
+	// the create flag of the PERSISTENT_SEQUENTIAL_WITH_TTL mode
+	flagSequentialTTL = 6
+
+	// the watcher types of the RemoveWatch request
+	watcherTypeChildren = 1
+	watcherTypeData     = 2
+	watcherTypeAny      = 3
+)
+
+type fakeNode struct {
+	data      []byte
+	acl       []zk.ACL
+	stat      zk.Stat
+	children  map[string]struct{}
+	container bool
+}
+
+func (n *fakeNode) clone() *fakeNode {
+	c := *n
+
+	c.children = make(map[string]struct{}, len(n.children))
+
+	for child := range n.children {
+		c.children[child] = struct{}{}
+	}
+
+	return &c
+}
+
+type fakeWatch struct {
+	conn   *Conn
+	events chan zk.Event
+}
+
+type fakeWatchKind int
+
+const (
+	fakeDataWatch fakeWatchKind = iota
+	fakeChildWatch
+)
+
+type fakeWatchKey struct {
+	path string
+	kind fakeWatchKind
+}
+
+type fakeEvent struct {
+	key   fakeWatchKey
+	event zk.Event
+}
+
+// An in-process, in-memory ZooKeeper for the integration tests.
+//
+// Each dial opens a new session on the fake server, the tests adapt it to the ZookeeperDialer of the framework.
+type Server struct {
+	id        int64
+	lock      sync.Mutex
+	started   bool
+	zxid      int64
+	sessionId int64
+	nodes     map[string]*fakeNode
+	watches   map[fakeWatchKey][]*fakeWatch
+	sessions  map[int64]*Conn
+	pending   []fakeEvent
+}
+
+func NewServer() *Server {
+	return &Server{
+		id: atomic.AddInt64(&serverIds, 1),
+		nodes: map[string]*fakeNode{
+			pathSeparator: {acl: zk.WorldACL(zk.PermAll), children: make(map[string]struct{})},
+		},
+		watches:  make(map[fakeWatchKey][]*fakeWatch),
+		sessions: make(map[int64]*Conn),
+	}
+}
+
+// Start to accept the connections
+func (z *Server) Start() error {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+
+	if z.started {
+		return errors.New("Already started")
+	}
+
+	z.started = true
+
+	return nil
+}
+
+// Close all the sessions and stop to accept the connections, the ephemeral nodes will be deleted.
+func (z *Server) Close() error {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+
+	for _, conn := range z.sessions {
+		z.closeSession(conn)
+	}
+
+	z.started = false
+
+	return nil
+}
+
+// The connection string which should be used to dial the fake server
+func (z *Server) ConnectString() string {
+	return fmt.Sprintf("fake-zookeeper-%d:2181", z.id)
+}
+
+// Open a new session on the fake server
+func (z *Server) Dial(connString string, sessionTimeout time.Duration, canBeReadOnly bool) (*Conn, <-chan zk.Event, error) {
+	if connString != z.ConnectString() {
+		return nil, nil, fmt.Errorf("unknown fake zookeeper `%s`, expected `%s`", connString, z.ConnectString())
+	}
+
+	z.lock.Lock()
+	defer z.lock.Unlock()
+
+	if !z.started {
+		return nil, nil, ErrNotStarted
+	}
+
+	z.sessionId++
+
+	conn := &Conn{
+		zookeeper:      z,
+		sessionId:      z.sessionId,
+		sessionTimeout: sessionTimeout,
+		events:         make(chan zk.Event, 16),
+	}
+
+	z.sessions[conn.sessionId] = conn
+
+	conn.sendEvent(zk.Event{Type: zk.EventSession, State: zk.StateConnected})
+	conn.sendEvent(zk.Event{Type: zk.EventSession, State: zk.StateHasSession})
+
+	return conn, conn.events, nil
+}
+
+func (z *Server) closeSession(conn *Conn) {
+	if conn.closed {
+		return
+	}
+
+	conn.closed = true
+
+	delete(z.sessions, conn.sessionId)
+
+	var ephemerals []string
+
+	for nodePath, node := range z.nodes {
+		if node.stat.EphemeralOwner == conn.sessionId {
+			ephemerals = append(ephemerals, nodePath)
+		}
+	}
+
+	sort.Strings(ephemerals)
+
+	for _, nodePath := range ephemerals {
+		z.delete(nodePath, anyVersion)
+	}
+
+	z.flush()
+
+	for key := range z.watches {
+		z.removeWatches(conn, key, zk.ErrClosing)
+	}
+
+	conn.sendEvent(zk.Event{Type: zk.EventSession, State: zk.StateDisconnected})
+
+	close(conn.events)
+}
+
+// Remove the watches of the session, the removed watches receive the EventNotWatching event
+func (z *Server) removeWatches(conn *Conn, key fakeWatchKey, err error) {
+	var remains []*fakeWatch
+
+	for _, watch := range z.watches[key] {
+		if watch.conn == conn {
+			watch.events <- zk.Event{Type: zk.EventNotWatching, State: zk.StateDisconnected, Path: key.path, Err: err}
+			close(watch.events)
+		} else {
+			remains = append(remains, watch)
+		}
+	}
+
+	if len(remains) == 0 {
+		delete(z.watches, key)
+	} else {
+		z.watches[key] = remains
+	}
+}
+
+func (z *Server) watch(conn *Conn, nodePath string, kind fakeWatchKind) <-chan zk.Event {
+	key := fakeWatchKey{nodePath, kind}
+	watch := &fakeWatch{conn, make(chan zk.Event, 1)}
+
+	z.watches[key] = append(z.watches[key], watch)
+
+	return watch.events
+}
+
+func (z *Server) trigger(nodePath string, kind fakeWatchKind, eventType zk.EventType) {
+	z.pending = append(z.pending, fakeEvent{
+		key:   fakeWatchKey{nodePath, kind},
+		event: zk.Event{Type: eventType, State: zk.StateHasSession, Path: nodePath},
+	})
+}
+
+// Fire the pending events, each watch is triggered only once
+func (z *Server) flush() {
+	for _, pending := range z.pending {
+		for _, watch := range z.watches[pending.key] {
+			watch.events <- pending.event
+			close(watch.events)
+		}
+
+		delete(z.watches, pending.key)
+	}
+
+	z.pending = nil
+}
+
+// The number of the watches set on the server
+func (z *Server) WatchCount() int {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+
+	return len(z.watches)
+}
+
+func (z *Server) now() int64 {
+	return time.Now().UnixNano() / int64(time.Millisecond)
+}
+
+func isSequential(flags int32) bool {
+	return flags == zk.FlagSequence || flags == zk.FlagEphemeral|zk.FlagSequence || flags == flagSequentialTTL
+}
+
+func isEphemeral(flags int32) bool {
+	return flags == zk.FlagEphemeral || flags == zk.FlagEphemeral|zk.FlagSequence
+}
+
+func validateFakePath(nodePath string) error {
+	if !strings.HasPrefix(nodePath, pathSeparator) || (nodePath != pathSeparator && strings.HasSuffix(nodePath, pathSeparator)) {
+		return zk.ErrInvalidPath
+	}
+
+	return nil
+}
+
+func (z *Server) create(conn *Conn, nodePath string, data []byte, flags int32, acl []zk.ACL, container bool) (string, error) {
+	// the sequential node could be created with a path ends with the separator
+	if err := validateFakePath(strings.TrimSuffix(nodePath, pathSeparator) + "x"); err != nil || (!isSequential(flags) && validateFakePath(nodePath) != nil) {
+		return "", zk.ErrInvalidPath
+	} else if len(acl) == 0 {
+		return "", zk.ErrInvalidACL
+	}
+
+	parentPath := path.Dir(nodePath)
+	parent, exists := z.nodes[parentPath]
+
+	if !exists {
+		return "", zk.ErrNoNode
+	} else if parent.stat.EphemeralOwner != 0 {
+		return "", zk.ErrNoChildrenForEphemerals
+	}
+
+	if isSequential(flags) {
+		nodePath = fmt.Sprintf("%s%010d", nodePath, parent.stat.Cversion)
+	}
+
+	if _, exists := z.nodes[nodePath]; exists {
+		return "", zk.ErrNodeExists
+	}
+
+	z.zxid++
+
+	node := &fakeNode{
+		data:     data,
+		acl:      acl,
+		children: make(map[string]struct{}),
+		stat: zk.Stat{
+			Czxid:      z.zxid,
+			Mzxid:      z.zxid,
+			Pzxid:      z.zxid,
+			Ctime:      z.now(),
+			Mtime:      z.now(),
+			DataLength: int32(len(data)),
+		},
+	}
+
+	if isEphemeral(flags) {
+		node.stat.EphemeralOwner = conn.sessionId
+	}
+
+	// the server creates a persistent node for the CONTAINER mode of the create request
+	node.container = container
+
+	z.nodes[nodePath] = node
+
+	parent.children[path.Base(nodePath)] = struct{}{}
+	parent.stat.Cversion++
+	parent.stat.Pzxid = z.zxid
+	parent.stat.NumChildren = int32(len(parent.children))
+
+	z.trigger(nodePath, fakeDataWatch, zk.EventNodeCreated)
+	z.trigger(parentPath, fakeChildWatch, zk.EventNodeChildrenChanged)
+
+	return nodePath, nil
+}
+
+func (z *Server) lookup(nodePath string, version int32) (*fakeNode, error) {
+	if err := validateFakePath(nodePath); err != nil {
+		return nil, err
+	}
+
+	node, exists := z.nodes[nodePath]
+
+	if !exists {
+		return nil, zk.ErrNoNode
+	} else if version != anyVersion && version != node.stat.Version {
+		return nil, zk.ErrBadVersion
+	}
+
+	return node, nil
+}
+
+func (z *Server) delete(nodePath string, version int32) error {
+	if nodePath == pathSeparator {
+		return zk.ErrBadArguments
+	}
+
+	node, err := z.lookup(nodePath, version)
+
+	if err != nil {
+		return err
+	} else if len(node.children) > 0 {
+		return zk.ErrNotEmpty
+	}
+
+	z.zxid++
+
+	delete(z.nodes, nodePath)
+
+	parentPath := path.Dir(nodePath)
+	parent := z.nodes[parentPath]
+
+	delete(parent.children, path.Base(nodePath))
+	parent.stat.Cversion++
+	parent.stat.Pzxid = z.zxid
+	parent.stat.NumChildren = int32(len(parent.children))
+
+	z.trigger(nodePath, fakeDataWatch, zk.EventNodeDeleted)
+	z.trigger(nodePath, fakeChildWatch, zk.EventNodeDeleted)
+	z.trigger(parentPath, fakeChildWatch, zk.EventNodeChildrenChanged)
+
+	// the server deletes the container once its last child was deleted
+	if parent.container && len(parent.children) == 0 {
+		return z.delete(parentPath, anyVersion)
+	}
+
+	return nil
+}
+
+func (z *Server) set(nodePath string, data []byte, version int32) (*zk.Stat, error) {
+	node, err := z.lookup(nodePath, version)
+
+	if err != nil {
+		return nil, err
+	}
+
+	z.zxid++
+
+	node.data = data
+	node.stat.Version++
+	node.stat.Mzxid = z.zxid
+	node.stat.Mtime = z.now()
+	node.stat.DataLength = int32(len(data))
+
+	z.trigger(nodePath, fakeDataWatch, zk.EventNodeDataChanged)
+
+	stat := node.stat
+
+	return &stat, nil
+}
+
+func (z *Server) multi(conn *Conn, ops ...interface{}) ([]zk.MultiResponse, error) {
+	nodes := make(map[string]*fakeNode, len(z.nodes))
+
+	for nodePath, node := range z.nodes {
+		nodes[nodePath] = node.clone()
+	}
+
+	zxid := z.zxid
+
+	responses := make([]zk.MultiResponse, len(ops))
+
+	for i, op := range ops {
+		var err error
+
+		switch req := op.(type) {
+		case *zk.CreateRequest:
+			responses[i].String, err = z.create(conn, req.Path, req.Data, req.Flags, req.Acl, false)
+		case *zk.DeleteRequest:
+			err = z.delete(req.Path, req.Version)
+		case *zk.SetDataRequest:
+			responses[i].Stat, err = z.set(req.Path, req.Data, req.Version)
+		case *zk.CheckVersionRequest:
+			_, err = z.lookup(req.Path, req.Version)
+		default:
+			return nil, fmt.Errorf("unknown operation type %T", op)
+		}
+
+		// rollback all the operations if any of them failed
+		if err != nil {
+			z.nodes = nodes
+			z.zxid = zxid
+			z.pending = nil
+
+			responses[i].Error = err
+
+			return responses, err
+		}
+	}
+
+	return responses, nil
+}
+
+// A session on the fake server
+type Conn struct {
+	zookeeper      *Server
+	sessionId      int64
+	sessionTimeout time.Duration
+	events         chan zk.Event
+	closed         bool // protected by the zookeeper lock
+}
+
+func (c *Conn) sendEvent(event zk.Event) {
+	select {
+	case c.events <- event:
+	default:
+	}
+}
+
+// Run the operation with the server locked, and fire the triggered watches once it finished
+func (c *Conn) do(op func(z *Server) error) error {
+	z := c.zookeeper
+
+	z.lock.Lock()
+	defer z.lock.Unlock()
+
+	if c.closed {
+		return zk.ErrConnectionClosed
+	}
+
+	err := op(z)
+
+	z.flush()
+
+	return err
+}
+
+// The ID of the session
+func (c *Conn) SessionID() int64 { return c.sessionId }
+
+// The password of the session, derived from its ID
+func (c *Conn) SessionPassword() []byte {
+	return []byte(fmt.Sprintf("fake-password-%d", c.sessionId))
+}
+
+func (c *Conn) SessionTimeout() time.Duration { return c.sessionTimeout }
+
+func (c *Conn) Server() string { return c.zookeeper.ConnectString() }
+
+func (c *Conn) AddAuth(scheme string, auth []byte) error {
+	return c.do(func(z *Server) error { return nil })
+}
+
+func (c *Conn) Close() {
+	z := c.zookeeper
+
+	z.lock.Lock()
+	defer z.lock.Unlock()
+
+	z.closeSession(c)
+}
+
+func (c *Conn) Create(path string, data []byte, flags int32, acl []zk.ACL) (createdPath string, err error) {
+	err = c.do(func(z *Server) (err error) {
+		createdPath, err = z.create(c, path, data, flags, acl, false)
+
+		return
+	})
+
+	return
+}
+
+func (c *Conn) CreateContainer(path string, data []byte, acl []zk.ACL) (createdPath string, err error) {
+	err = c.do(func(z *Server) (err error) {
+		createdPath, err = z.create(c, path, data, 0, acl, true)
+
+		return
+	})
+
+	return
+}
+
+// The TTL is accepted but the node never expires
+func (c *Conn) CreateTTL(path string, data []byte, flags int32, acl []zk.ACL, ttl int64) (string, error) {
+	return c.Create(path, data, flags, acl)
+}
+
+func (c *Conn) exists(path string, watch bool) (exists bool, stat *zk.Stat, events <-chan zk.Event, err error) {
+	err = c.do(func(z *Server) error {
+		node, err := z.lookup(path, anyVersion)
+
+		if err == zk.ErrNoNode {
+			err = nil
+		} else if err == nil {
+			exists = true
+			stat = &zk.Stat{}
+			*stat = node.stat
+		}
+
+		if err == nil && watch {
+			events = z.watch(c, path, fakeDataWatch)
+		}
+
+		return err
+	})
+
+	return
+}
+
+func (c *Conn) Exists(path string) (bool, *zk.Stat, error) {
+	exists, stat, _, err := c.exists(path, false)
+
+	return exists, stat, err
+}
+
+func (c *Conn) ExistsW(path string) (bool, *zk.Stat, <-chan zk.Event, error) {
+	return c.exists(path, true)
+}
+
+func (c *Conn) Delete(path string, version int32) error {
+	return c.do(func(z *Server) error { return z.delete(path, version) })
+}
+
+func (c *Conn) get(path string, watch bool) (data []byte, stat *zk.Stat, events <-chan zk.Event, err error) {
+	err = c.do(func(z *Server) error {
+		node, err := z.lookup(path, anyVersion)
+
+		if err != nil {
+			return err
+		}
+
+		data = append([]byte(nil), node.data...)
+		stat = &zk.Stat{}
+		*stat = node.stat
+
+		if watch {
+			events = z.watch(c, path, fakeDataWatch)
+		}
+
+		return nil
+	})
+
+	return
+}
+
+func (c *Conn) Get(path string) ([]byte, *zk.Stat, error) {
+	data, stat, _, err := c.get(path, false)
+
+	return data, stat, err
+}
+
+func (c *Conn) GetW(path string) ([]byte, *zk.Stat, <-chan zk.Event, error) {
+	return c.get(path, true)
+}
+
+func (c *Conn) Set(path string, data []byte, version int32) (stat *zk.Stat, err error) {
+	err = c.do(func(z *Server) (err error) {
+		stat, err = z.set(path, data, version)
+
+		return
+	})
+
+	return
+}
+
+func (c *Conn) children(path string, watch bool) (children []string, stat *zk.Stat, events <-chan zk.Event, err error) {
+	err = c.do(func(z *Server) error {
+		node, err := z.lookup(path, anyVersion)
+
+		if err != nil {
+			return err
+		}
+
+		children = make([]string, 0, len(node.children))
+
+		for child := range node.children {
+			children = append(children, child)
+		}
+
+		sort.Strings(children)
+
+		stat = &zk.Stat{}
+		*stat = node.stat
+
+		if watch {
+			events = z.watch(c, path, fakeChildWatch)
+		}
+
+		return nil
+	})
+
+	return
+}
+
+func (c *Conn) Children(path string) ([]string, *zk.Stat, error) {
+	children, stat, _, err := c.children(path, false)
+
+	return children, stat, err
+}
+
+func (c *Conn) ChildrenW(path string) ([]string, *zk.Stat, <-chan zk.Event, error) {
+	return c.children(path, true)
+}
+
+func (c *Conn) GetACL(path string) (acl []zk.ACL, stat *zk.Stat, err error) {
+	err = c.do(func(z *Server) error {
+		node, err := z.lookup(path, anyVersion)
+
+		if err != nil {
+			return err
+		}
+
+		acl = append([]zk.ACL(nil), node.acl...)
+		stat = &zk.Stat{}
+		*stat = node.stat
+
+		return nil
+	})
+
+	return
+}
+
+func (c *Conn) SetACL(path string, acl []zk.ACL, version int32) (stat *zk.Stat, err error) {
+	err = c.do(func(z *Server) error {
+		if len(acl) == 0 {
+			return zk.ErrInvalidACL
+		}
+
+		node, err := z.lookup(path, anyVersion)
+
+		if err != nil {
+			return err
+		} else if version != anyVersion && version != node.stat.Aversion {
+			return zk.ErrBadVersion
+		}
+
+		node.acl = acl
+		node.stat.Aversion++
+
+		stat = &zk.Stat{}
+		*stat = node.stat
+
+		return nil
+	})
+
+	return
+}
+
+func (c *Conn) Multi(ops ...interface{}) (responses []zk.MultiResponse, err error) {
+	err = c.do(func(z *Server) (err error) {
+		responses, err = z.multi(c, ops...)
+
+		return
+	})
+
+	return
+}
+
+func (c *Conn) Sync(path string) (string, error) {
+	return path, c.do(func(z *Server) error { return validateFakePath(path) })
+}
+
+func (c *Conn) RemoveWatch(path string, watcherType int32) error {
+	return c.do(func(z *Server) error {
+		if watcherType == watcherTypeData || watcherType == watcherTypeAny {
+			z.removeWatches(c, fakeWatchKey{path, fakeDataWatch}, nil)
+		}
+
+		if watcherType == watcherTypeChildren || watcherType == watcherTypeAny {
+			z.removeWatches(c, fakeWatchKey{path, fakeChildWatch}, nil)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,137 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/flier/curator.go"
+)
+
+const SPAN_NAME_PREFIX = "curator."
+
+// The trace contexts of the goroutines, keyed by the goroutine ID
+var traceContexts sync.Map
+
+// The trace context bound to the current goroutine, it should be closed once the operations finished
+type CloseableTrace interface {
+	Close()
+}
+
+type goroutineTrace struct {
+	id       uint64
+	previous interface{}
+	closed   sync.Once
+}
+
+// Bind the context to the current goroutine, the spans of the ZooKeeper operations
+// performed by this goroutine will be the children of the span in the context.
+//
+// The operations performed in background run in another goroutine, they will start a new trace,
+// while the operations WithContext keep the context of the calling goroutine.
+func WithTraceContext(ctx context.Context) CloseableTrace {
+	id := goroutineID()
+
+	previous, _ := traceContexts.Load(id)
+
+	traceContexts.Store(id, ctx)
+
+	return &goroutineTrace{id: id, previous: previous}
+}
+
+func (t *goroutineTrace) Close() {
+	t.closed.Do(func() {
+		if t.previous != nil {
+			traceContexts.Store(t.id, t.previous)
+		} else {
+			traceContexts.Delete(t.id)
+		}
+	})
+}
+
+func traceContext() context.Context {
+	if ctx, ok := traceContexts.Load(goroutineID()); ok {
+		return ctx.(context.Context)
+	}
+
+	return context.Background()
+}
+
+// Parse the goroutine ID from the header of the stack trace, e.g. "goroutine 18 [running]:"
+func goroutineID() uint64 {
+	var buf [64]byte
+
+	b := bytes.TrimPrefix(buf[:runtime.Stack(buf[:], false)], []byte("goroutine "))
+
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		b = b[:i]
+	}
+
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+
+	return id
+}
+
+type otelTracerDriver struct {
+	tracer trace.Tracer
+}
+
+// Create a TracerDriver which starts an OpenTelemetry span for each trace.
+//
+// The span is named "curator.<name>", the spans of the ZooKeeper operations carry
+// the "zk.path", "zk.version" and "zk.error" attributes.
+// The parent span is taken from the context bound with WithTraceContext.
+func NewOTelTracerDriver(tracer trace.Tracer) curator.TracerDriver {
+	return &otelTracerDriver{tracer}
+}
+
+func (d *otelTracerDriver) AddTime(name string, elapsed time.Duration) {
+	d.span(name, elapsed)
+}
+
+func (d *otelTracerDriver) AddCount(name string, increment int) {}
+
+func (d *otelTracerDriver) AddOperation(op *curator.OperationTrace) {
+	d.span(op.Name, op.Elapsed, func(span trace.Span) {
+		span.SetAttributes(attribute.String("zk.path", op.Path), attribute.Int("zk.version", int(op.Version)))
+
+		if op.Err != nil {
+			span.SetAttributes(attribute.String("zk.error", op.Err.Error()))
+			span.RecordError(op.Err)
+			span.SetStatus(codes.Error, op.Err.Error())
+		}
+	})
+}
+
+// Capture the trace context bound to the current goroutine,
+// so the operations performed by the builders WithContext in another goroutine keep the parent span.
+func (d *otelTracerDriver) CaptureGoroutine() func() func() {
+	ctx, ok := traceContexts.Load(goroutineID())
+
+	return func() func() {
+		if !ok {
+			return func() {}
+		}
+
+		return WithTraceContext(ctx.(context.Context)).Close
+	}
+}
+
+func (d *otelTracerDriver) span(name string, elapsed time.Duration, annotates ...func(span trace.Span)) {
+	endTime := time.Now()
+
+	_, span := d.tracer.Start(traceContext(), SPAN_NAME_PREFIX+name, trace.WithTimestamp(endTime.Add(-elapsed)))
+
+	for _, annotate := range annotates {
+		annotate(span)
+	}
+
+	span.End(trace.WithTimestamp(endTime))
+}
@@ -0,0 +1,135 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/samuel/go-zookeeper/zk"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/flier/curator.go"
+)
+
+func TestOTelTracerDriver(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	tracer := provider.Tracer("curator")
+
+	ctx, parent := tracer.Start(context.Background(), "parent")
+
+	trace := WithTraceContext(ctx)
+
+	d := NewOTelTracerDriver(tracer).(*otelTracerDriver)
+
+	d.AddOperation(&curator.OperationTrace{
+		Name:    curator.TRACE_DELETE,
+		Path:    "/node",
+		Version: 3,
+		Elapsed: 5 * time.Millisecond,
+		Err:     zk.ErrBadVersion,
+	})
+
+	trace.Close()
+
+	d.AddTime("create", time.Millisecond)
+
+	parent.End()
+
+	spans := recorder.Ended()
+
+	if assert.Len(t, spans, 3) {
+		assert.Equal(t, "curator.curator_delete", spans[0].Name())
+		assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+		assert.Equal(t, 5*time.Millisecond, spans[0].EndTime().Sub(spans[0].StartTime()))
+		assert.Equal(t, []attribute.KeyValue{
+			attribute.String("zk.path", "/node"),
+			attribute.Int("zk.version", 3),
+			attribute.String("zk.error", zk.ErrBadVersion.Error()),
+		}, spans[0].Attributes())
+		assert.Equal(t, codes.Error, spans[0].Status().Code)
+
+		// the context has been unbound from the goroutine
+		assert.Equal(t, "curator.create", spans[1].Name())
+		assert.False(t, spans[1].Parent().IsValid())
+	}
+}
+
+func TestGoroutineTraceContext(t *testing.T) {
+	type key struct{}
+
+	outer := WithTraceContext(context.WithValue(context.Background(), key{}, "outer"))
+	inner := WithTraceContext(context.WithValue(context.Background(), key{}, "inner"))
+
+	assert.Equal(t, "inner", traceContext().Value(key{}))
+
+	done := make(chan interface{})
+
+	go func() { done <- traceContext().Value(key{}) }()
+
+	assert.Nil(t, <-done)
+
+	inner.Close()
+
+	assert.Equal(t, "outer", traceContext().Value(key{}))
+
+	outer.Close()
+
+	assert.Nil(t, traceContext().Value(key{}))
+}
+
+func TestCaptureGoroutineTraceContext(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	tracer := provider.Tracer("curator")
+
+	ctx, parent := tracer.Start(context.Background(), "parent")
+
+	trace := WithTraceContext(ctx)
+
+	d := NewOTelTracerDriver(tracer).(*otelTracerDriver)
+
+	// the builders WithContext perform the operation in another goroutine
+	bind := d.CaptureGoroutine()
+
+	trace.Close()
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		unbind := bind()
+
+		d.AddOperation(&curator.OperationTrace{Name: curator.TRACE_GET, Path: "/node"})
+
+		unbind()
+
+		d.AddTime("create", time.Millisecond)
+	}()
+
+	<-done
+
+	parent.End()
+
+	spans := recorder.Ended()
+
+	if assert.Len(t, spans, 3) {
+		assert.Equal(t, "curator.curator_get", spans[0].Name())
+		assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+
+		assert.Equal(t, "curator.create", spans[1].Name())
+		assert.False(t, spans[1].Parent().IsValid())
+	}
+
+	// nothing is bound if the caller has no trace context
+	unbind := d.CaptureGoroutine()()
+
+	assert.Equal(t, context.Background(), traceContext())
+
+	unbind()
+}
@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/flier/curator.go"
+)
+
+// The bucket boundaries (in seconds) of the ZooKeeper operation latencies, from 0.5 ms to 10 s
+var DEFAULT_LATENCY_BUCKETS = []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// The operations which are traced for each ZooKeeper call
+var TRACED_OPERATIONS = []string{
+	curator.TRACE_ADD_AUTH,
+	curator.TRACE_CREATE,
+	curator.TRACE_EXISTS,
+	curator.TRACE_DELETE,
+	curator.TRACE_GET,
+	curator.TRACE_SET,
+	curator.TRACE_CHILDREN,
+	curator.TRACE_GET_ACL,
+	curator.TRACE_SET_ACL,
+	curator.TRACE_MULTI,
+	curator.TRACE_SYNC,
+	curator.TRACE_REMOVE_WATCH,
+}
+
+type prometheusTracerDriver struct {
+	durations *prometheus.HistogramVec
+	counters  *prometheus.CounterVec
+}
+
+// Create a TracerDriver which exports the traces as the Prometheus metrics.
+//
+// The times are observed by a histogram and the counts are added to a counter, both labelled by the trace name.
+// The metrics are registered with the given registerer, which could be a non-default registry in the tests.
+func NewPrometheusTracerDriver(registerer prometheus.Registerer) curator.TracerDriver {
+	d := &prometheusTracerDriver{
+		durations: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "curator",
+			Name:      "operation_duration_seconds",
+			Help:      "The round-trip time of the ZooKeeper operations.",
+			Buckets:   DEFAULT_LATENCY_BUCKETS,
+		}, []string{"operation"}),
+		counters: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "curator",
+			Name:      "operations_total",
+			Help:      "The count of the ZooKeeper operations and errors.",
+		}, []string{"operation"}),
+	}
+
+	registerer.MustRegister(d.durations, d.counters)
+
+	for _, name := range TRACED_OPERATIONS {
+		d.durations.WithLabelValues(name)
+	}
+
+	return d
+}
+
+func (d *prometheusTracerDriver) AddTime(name string, elapsed time.Duration) {
+	d.durations.WithLabelValues(name).Observe(elapsed.Seconds())
+}
+
+func (d *prometheusTracerDriver) AddCount(name string, increment int) {
+	if increment > 0 {
+		d.counters.WithLabelValues(name).Add(float64(increment))
+	}
+}
@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/flier/curator.go"
+)
+
+func TestPrometheusTracerDriver(t *testing.T) {
+	registry := prometheus.NewRegistry()
+
+	d := NewPrometheusTracerDriver(registry).(*prometheusTracerDriver)
+
+	assert.Equal(t, len(TRACED_OPERATIONS), testutil.CollectAndCount(d.durations))
+
+	d.AddTime(curator.TRACE_CREATE, 5*time.Millisecond)
+	d.AddCount(curator.TRACE_CREATE, 1)
+	d.AddCount(curator.TRACE_CREATE, 1)
+	d.AddCount(curator.TRACE_CREATE+curator.TRACE_ERROR_SUFFIX, 1)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(d.counters.WithLabelValues(curator.TRACE_CREATE)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(d.counters.WithLabelValues(curator.TRACE_CREATE+curator.TRACE_ERROR_SUFFIX)))
+
+	families, err := registry.Gather()
+
+	assert.NoError(t, err)
+	assert.Len(t, families, 2)
+
+	// the registry rejects the duplicated metrics
+	assert.Panics(t, func() { NewPrometheusTracerDriver(registry) })
+}
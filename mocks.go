@@ -1,7 +1,9 @@
 package curator
 
 import (
+	"context"
 	"errors"
+	"io"
 	"math/rand"
 	"reflect"
 	"sync"
@@ -119,11 +121,47 @@ func (p *mockEnsembleProvider) ConnectionString() string {
 type mockConn struct {
 	mock.Mock
 
-	log        infof
-	operations []interface{}
+	log         infof
+	operations  []interface{}
+	latencyLock sync.Mutex
+	latency     func(op string) time.Duration
+	sleeper     RetrySleeper // sleep for the latency, replace it with a mockRetrySleeper to fast-forward
+}
+
+// Sleep for the given latency before returning from any operation
+func (c *mockConn) SetLatency(d time.Duration) {
+	c.SetLatencyFunc(func(op string) time.Duration { return d })
+}
+
+// Sleep for the latency of the operation (e.g. "Get" or "Exists") before returning from it
+func (c *mockConn) SetLatencyFunc(latency func(op string) time.Duration) {
+	c.latencyLock.Lock()
+	defer c.latencyLock.Unlock()
+
+	c.latency = latency
+}
+
+func (c *mockConn) delay(op string) {
+	c.latencyLock.Lock()
+	latency, sleeper := c.latency, c.sleeper
+	c.latencyLock.Unlock()
+
+	if latency == nil {
+		return
+	}
+
+	if sleeper == nil {
+		sleeper = DefaultRetrySleeper
+	}
+
+	if d := latency(op); d > 0 {
+		sleeper.SleepFor(d)
+	}
 }
 
 func (c *mockConn) AddAuth(scheme string, auth []byte) error {
+	c.delay("AddAuth")
+
 	args := c.Called(scheme, auth)
 	err := args.Error(0)
 
@@ -143,6 +181,8 @@ func (c *mockConn) Close() {
 }
 
 func (c *mockConn) Create(path string, data []byte, flags int32, acls []zk.ACL) (string, error) {
+	c.delay("Create")
+
 	args := c.Called(path, data, flags, acls)
 
 	createPath := args.String(0)
@@ -155,7 +195,39 @@ func (c *mockConn) Create(path string, data []byte, flags int32, acls []zk.ACL)
 	return createPath, err
 }
 
+func (c *mockConn) CreateTTL(path string, data []byte, flags int32, acls []zk.ACL, ttl int64) (string, error) {
+	c.delay("CreateTTL")
+
+	args := c.Called(path, data, flags, acls, ttl)
+
+	createPath := args.String(0)
+	err := args.Error(1)
+
+	if c.log != nil {
+		c.log("ZookeeperConnection.CreateTTL(path=\"%s\", data=[]byte(\"%s\"), flags=%d, alcs=%v, ttl=%d) (createdPath=\"%s\", error=%v)", path, data, flags, acls, ttl, createPath, err)
+	}
+
+	return createPath, err
+}
+
+func (c *mockConn) CreateContainer(path string, data []byte, acls []zk.ACL) (string, error) {
+	c.delay("CreateContainer")
+
+	args := c.Called(path, data, acls)
+
+	createPath := args.String(0)
+	err := args.Error(1)
+
+	if c.log != nil {
+		c.log("ZookeeperConnection.CreateContainer(path=\"%s\", data=[]byte(\"%s\"), alcs=%v) (createdPath=\"%s\", error=%v)", path, data, acls, createPath, err)
+	}
+
+	return createPath, err
+}
+
 func (c *mockConn) Exists(path string) (bool, *zk.Stat, error) {
+	c.delay("Exists")
+
 	args := c.Called(path)
 
 	exists := args.Bool(0)
@@ -170,6 +242,8 @@ func (c *mockConn) Exists(path string) (bool, *zk.Stat, error) {
 }
 
 func (c *mockConn) ExistsW(path string) (bool, *zk.Stat, <-chan zk.Event, error) {
+	c.delay("ExistsW")
+
 	args := c.Called(path)
 
 	exists := args.Bool(0)
@@ -185,6 +259,8 @@ func (c *mockConn) ExistsW(path string) (bool, *zk.Stat, <-chan zk.Event, error)
 }
 
 func (c *mockConn) Delete(path string, version int32) error {
+	c.delay("Delete")
+
 	args := c.Called(path, version)
 
 	err := args.Error(0)
@@ -197,6 +273,8 @@ func (c *mockConn) Delete(path string, version int32) error {
 }
 
 func (c *mockConn) Get(path string) ([]byte, *zk.Stat, error) {
+	c.delay("Get")
+
 	args := c.Called(path)
 
 	data, _ := args.Get(0).([]byte)
@@ -211,6 +289,8 @@ func (c *mockConn) Get(path string) ([]byte, *zk.Stat, error) {
 }
 
 func (c *mockConn) GetW(path string) ([]byte, *zk.Stat, <-chan zk.Event, error) {
+	c.delay("GetW")
+
 	args := c.Called(path)
 
 	data, _ := args.Get(0).([]byte)
@@ -226,6 +306,8 @@ func (c *mockConn) GetW(path string) ([]byte, *zk.Stat, <-chan zk.Event, error)
 }
 
 func (c *mockConn) Set(path string, data []byte, version int32) (*zk.Stat, error) {
+	c.delay("Set")
+
 	args := c.Called(path, data, version)
 
 	stat, _ := args.Get(0).(*zk.Stat)
@@ -239,6 +321,8 @@ func (c *mockConn) Set(path string, data []byte, version int32) (*zk.Stat, error
 }
 
 func (c *mockConn) Children(path string) ([]string, *zk.Stat, error) {
+	c.delay("Children")
+
 	args := c.Called(path)
 
 	children, _ := args.Get(0).([]string)
@@ -253,6 +337,8 @@ func (c *mockConn) Children(path string) ([]string, *zk.Stat, error) {
 }
 
 func (c *mockConn) ChildrenW(path string) ([]string, *zk.Stat, <-chan zk.Event, error) {
+	c.delay("ChildrenW")
+
 	args := c.Called(path)
 
 	children, _ := args.Get(0).([]string)
@@ -268,6 +354,8 @@ func (c *mockConn) ChildrenW(path string) ([]string, *zk.Stat, <-chan zk.Event,
 }
 
 func (c *mockConn) GetACL(path string) ([]zk.ACL, *zk.Stat, error) {
+	c.delay("GetACL")
+
 	args := c.Called(path)
 
 	acls, _ := args.Get(0).([]zk.ACL)
@@ -282,6 +370,8 @@ func (c *mockConn) GetACL(path string) ([]zk.ACL, *zk.Stat, error) {
 }
 
 func (c *mockConn) SetACL(path string, acls []zk.ACL, version int32) (*zk.Stat, error) {
+	c.delay("SetACL")
+
 	args := c.Called(path, acls, version)
 
 	stat, _ := args.Get(0).(*zk.Stat)
@@ -295,6 +385,8 @@ func (c *mockConn) SetACL(path string, acls []zk.ACL, version int32) (*zk.Stat,
 }
 
 func (c *mockConn) Multi(ops ...interface{}) ([]zk.MultiResponse, error) {
+	c.delay("Multi")
+
 	c.operations = append(c.operations, ops...)
 
 	args := c.Called(ops)
@@ -310,6 +402,8 @@ func (c *mockConn) Multi(ops ...interface{}) ([]zk.MultiResponse, error) {
 }
 
 func (c *mockConn) Sync(path string) (string, error) {
+	c.delay("Sync")
+
 	args := c.Called(path)
 	p := args.String(0)
 	err := args.Error(1)
@@ -318,7 +412,49 @@ func (c *mockConn) Sync(path string) (string, error) {
 		c.log("ZookeeperConnection.Sync(path=\"%s\")(path=\"%s\", error=%v)", path, p, err)
 	}
 
-	return path, err
+	return p, err
+}
+
+func (c *mockConn) Reconfig(members []string, version int64) (*zk.Stat, error) {
+	c.delay("Reconfig")
+
+	args := c.Called(members, version)
+
+	stat, _ := args.Get(0).(*zk.Stat)
+	err := args.Error(1)
+
+	if c.log != nil {
+		c.log("ZookeeperConnection.Reconfig(members=%v, version=%d) (stat=%v, error=%v)", members, version, stat, err)
+	}
+
+	return stat, err
+}
+
+func (c *mockConn) IncrementalReconfig(joining, leaving []string, version int64) (*zk.Stat, error) {
+	c.delay("IncrementalReconfig")
+
+	args := c.Called(joining, leaving, version)
+
+	stat, _ := args.Get(0).(*zk.Stat)
+	err := args.Error(1)
+
+	if c.log != nil {
+		c.log("ZookeeperConnection.IncrementalReconfig(joining=%v, leaving=%v, version=%d) (stat=%v, error=%v)", joining, leaving, version, stat, err)
+	}
+
+	return stat, err
+}
+
+func (c *mockConn) RemoveWatch(path string, watcherType WatcherType) error {
+	c.delay("RemoveWatch")
+
+	err := c.Called(path, watcherType).Error(0)
+
+	if c.log != nil {
+		c.log("ZookeeperConnection.RemoveWatch(path=\"%s\", watcherType=%d) error=%v", path, watcherType, err)
+	}
+
+	return err
 }
 
 type mockZookeeperDialer struct {
@@ -341,6 +477,78 @@ func (d *mockZookeeperDialer) Dial(connString string, sessionTimeout time.Durati
 	return conn, events, err
 }
 
+// Drive the session of the framework built by the mock container
+type mockZookeeperClient struct {
+	client           CuratorFramework
+	conn             *mockConn
+	dialer           *mockZookeeperDialer
+	ensembleProvider *mockEnsembleProvider // nil if the ensemble provider is not mocked
+	events           chan zk.Event
+	sessionTimeout   time.Duration
+	canBeReadOnly    bool
+}
+
+// Expire the session and reconnect, blocking until the framework has been re-initialized.
+//
+// The connection is closed and dialed again, the new connection reuses the mock connection and the events channel.
+func (c *mockZookeeperClient) SimulateSessionExpiry() error {
+	if c.client == nil {
+		return errors.New("the framework was not built")
+	}
+
+	states := make(chan ConnectionState, STATE_QUEUE_SIZE)
+
+	listener := NewConnectionStateListener(func(client CuratorFramework, newState ConnectionState) {
+		states <- newState
+	})
+
+	c.client.ConnectionStateListenable().AddListener(listener)
+
+	defer c.client.ConnectionStateListenable().RemoveListener(listener)
+
+	redialed := make(chan struct{})
+
+	if c.ensembleProvider != nil {
+		c.ensembleProvider.On("ConnectionString").Return("connStr").Maybe()
+	}
+
+	c.conn.On("Close").Return().Once()
+	c.dialer.On("Dial", mock.AnythingOfType("string"), c.sessionTimeout, c.canBeReadOnly).Return(c.conn, nil, nil).Once().Run(func(args mock.Arguments) {
+		close(redialed)
+	})
+
+	timeout := time.After(DEFAULT_CONNECTION_TIMEOUT)
+
+	waitFor := func(ready func(state ConnectionState) bool) error {
+		for {
+			select {
+			case state := <-states:
+				if ready(state) {
+					return nil
+				}
+			case <-timeout:
+				return ErrTimeoutWaitingForConnection
+			}
+		}
+	}
+
+	c.events <- zk.Event{Type: zk.EventSession, State: zk.StateExpired}
+
+	if err := waitFor(func(state ConnectionState) bool { return state == LOST }); err != nil {
+		return err
+	}
+
+	select {
+	case <-redialed:
+	case <-timeout:
+		return ErrTimeoutWaitingForConnection
+	}
+
+	c.events <- zk.Event{Type: zk.EventSession, State: zk.StateHasSession}
+
+	return waitFor(func(state ConnectionState) bool { return state.Connected() })
+}
+
 type mockCompressionProvider struct {
 	mock.Mock
 
@@ -433,6 +641,18 @@ func (e *mockEnsurePath) ExcludingLast() EnsurePath {
 	return ret
 }
 
+func (e *mockEnsurePath) WithACL(acls []zk.ACL) EnsurePath {
+	args := e.Mock.Called(acls)
+
+	ret, _ := args.Get(0).(EnsurePath)
+
+	if e.log != nil {
+		e.log("EnsurePath.WithACL(acls=%v) EnsurePath=%p", acls, ret)
+	}
+
+	return ret
+}
+
 type mockEnsurePathHelper struct {
 	mock.Mock
 
@@ -490,6 +710,16 @@ func (c *mockCuratorZookeeperClient) NewRetryLoop() RetryLoop {
 	return retryLoop
 }
 
+func (c *mockCuratorZookeeperClient) NewRetryLoopWithPolicy(retryPolicy RetryPolicy) RetryLoop {
+	retryLoop, _ := c.Called(retryPolicy).Get(0).(RetryLoop)
+
+	if c.log != nil {
+		c.log("CuratorZookeeperClient.NewRetryLoopWithPolicy(retryPolicy=%v) retryLoop=%v", retryPolicy, retryLoop)
+	}
+
+	return retryLoop
+}
+
 func (c *mockCuratorZookeeperClient) Connected() bool {
 	connected := c.Called().Bool(0)
 
@@ -540,6 +770,46 @@ func (c *mockCuratorZookeeperClient) StartTracer(name string) Tracer {
 	return tracer
 }
 
+func (c *mockCuratorZookeeperClient) GetLastNegotiatedSessionTimeout() time.Duration {
+	timeout, _ := c.Called().Get(0).(time.Duration)
+
+	if c.log != nil {
+		c.log("CuratorZookeeperClient.GetLastNegotiatedSessionTimeout() timeout=%v", timeout)
+	}
+
+	return timeout
+}
+
+func (c *mockCuratorZookeeperClient) GetSessionId() int64 {
+	sessionId, _ := c.Called().Get(0).(int64)
+
+	if c.log != nil {
+		c.log("CuratorZookeeperClient.GetSessionId() sessionId=%d", sessionId)
+	}
+
+	return sessionId
+}
+
+func (c *mockCuratorZookeeperClient) GetSessionPassword() []byte {
+	password, _ := c.Called().Get(0).([]byte)
+
+	if c.log != nil {
+		c.log("CuratorZookeeperClient.GetSessionPassword() password=%v", password)
+	}
+
+	return password
+}
+
+func (c *mockCuratorZookeeperClient) AddAuth(scheme string, auth []byte) error {
+	err := c.Called(scheme, auth).Error(0)
+
+	if c.log != nil {
+		c.log("CuratorZookeeperClient.AddAuth(scheme=\"%s\", auth=[]byte(\"%s\")) error=%v", scheme, auth, err)
+	}
+
+	return err
+}
+
 type mockCuratorFramework struct {
 	mock.Mock
 
@@ -576,6 +846,26 @@ func (c *mockCuratorFramework) State() State {
 	return state
 }
 
+func (c *mockCuratorFramework) GetState() CuratorFrameworkState {
+	state, _ := c.Called().Get(0).(CuratorFrameworkState)
+
+	if c.log != nil {
+		c.log("CuratorFramework.GetState() state=%v", state)
+	}
+
+	return state
+}
+
+func (c *mockCuratorFramework) WaitForState(ctx context.Context, state CuratorFrameworkState) error {
+	err := c.Called(ctx, state).Error(0)
+
+	if c.log != nil {
+		c.log("CuratorFramework.WaitForState(ctx=%v, state=%v) error=%v", ctx, state, err)
+	}
+
+	return err
+}
+
 func (c *mockCuratorFramework) Started() bool {
 	started := c.Called().Bool(0)
 
@@ -626,6 +916,26 @@ func (c *mockCuratorFramework) GetData() GetDataBuilder {
 	return builder
 }
 
+func (c *mockCuratorFramework) BatchGetData() BatchGetDataBuilder {
+	builder, _ := c.Called().Get(0).(BatchGetDataBuilder)
+
+	if c.log != nil {
+		c.log("CuratorFramework.BatchGetData() BatchGetDataBuilder=%v", builder)
+	}
+
+	return builder
+}
+
+func (c *mockCuratorFramework) BatchCreate() BatchCreateBuilder {
+	builder, _ := c.Called().Get(0).(BatchCreateBuilder)
+
+	if c.log != nil {
+		c.log("CuratorFramework.BatchCreate() BatchCreateBuilder=%v", builder)
+	}
+
+	return builder
+}
+
 func (c *mockCuratorFramework) SetData() SetDataBuilder {
 	builder, _ := c.Called().Get(0).(SetDataBuilder)
 
@@ -704,6 +1014,14 @@ func (c *mockCuratorFramework) ConnectionStateListenable() ConnectionStateListen
 	return listenable
 }
 
+func (c *mockCuratorFramework) AddConnectionStateListener(listener ConnectionStateListener) {
+	c.Called(listener)
+
+	if c.log != nil {
+		c.log("CuratorFramework.AddConnectionStateListener(listener=%v)", listener)
+	}
+}
+
 func (c *mockCuratorFramework) CuratorListenable() CuratorListenable {
 	listenable, _ := c.Called().Get(0).(CuratorListenable)
 
@@ -714,6 +1032,22 @@ func (c *mockCuratorFramework) CuratorListenable() CuratorListenable {
 	return listenable
 }
 
+func (c *mockCuratorFramework) AddCuratorListener(listener CuratorListener) {
+	c.Called(listener)
+
+	if c.log != nil {
+		c.log("CuratorFramework.AddCuratorListener(listener=%v)", listener)
+	}
+}
+
+func (c *mockCuratorFramework) RemoveCuratorListener(listener CuratorListener) {
+	c.Called(listener)
+
+	if c.log != nil {
+		c.log("CuratorFramework.RemoveCuratorListener(listener=%v)", listener)
+	}
+}
+
 func (c *mockCuratorFramework) UnhandledErrorListenable() UnhandledErrorListenable {
 	listenable, _ := c.Called().Get(0).(UnhandledErrorListenable)
 
@@ -734,8 +1068,8 @@ func (c *mockCuratorFramework) NonNamespaceView() CuratorFramework {
 	return framework
 }
 
-func (c *mockCuratorFramework) UsingNamespace(newNamespace string) CuratorFramework {
-	framework, _ := c.Called(newNamespace).Get(0).(CuratorFramework)
+func (c *mockCuratorFramework) UsingNamespace(newNamespace string) NamespaceFacade {
+	framework, _ := c.Called(newNamespace).Get(0).(NamespaceFacade)
 
 	if c.log != nil {
 		c.log("CuratorFramework.NonNamespaceView(newNamespace=\"%s\") Framework=%v", newNamespace, framework)
@@ -764,6 +1098,32 @@ func (c *mockCuratorFramework) ZookeeperClient() CuratorZookeeperClient {
 	return client
 }
 
+func (c *mockCuratorFramework) GetZookeeperClient() (CuratorZookeeperClient, error) {
+	args := c.Called()
+
+	client, _ := args.Get(0).(CuratorZookeeperClient)
+	err := args.Error(1)
+
+	if c.log != nil {
+		c.log("CuratorFramework.GetZookeeperClient() (client=%v, error=%v)", client, err)
+	}
+
+	return client, err
+}
+
+func (c *mockCuratorFramework) InternalGetZookeeperConnection() (ZookeeperConnection, error) {
+	args := c.Called()
+
+	conn, _ := args.Get(0).(ZookeeperConnection)
+	err := args.Error(1)
+
+	if c.log != nil {
+		c.log("CuratorFramework.InternalGetZookeeperConnection() (conn=%v, error=%v)", conn, err)
+	}
+
+	return conn, err
+}
+
 func (c *mockCuratorFramework) NewNamespaceAwareEnsurePath(path string) EnsurePath {
 	ensure, _ := c.Called(path).Get(0).(EnsurePath)
 
@@ -794,12 +1154,137 @@ func (c *mockCuratorFramework) BlockUntilConnectedTimeout(maxWaitTime time.Durat
 	return err
 }
 
+func (c *mockCuratorFramework) BlockUntilConnectedWithContext(ctx context.Context) error {
+	err := c.Called(ctx).Error(0)
+
+	if c.log != nil {
+		c.log("CuratorFramework.BlockUntilConnectedWithContext(ctx=%v) error=%v", ctx, err)
+	}
+
+	return err
+}
+
+func (c *mockCuratorFramework) AddAuth(scheme string, auth []byte) error {
+	err := c.Called(scheme, auth).Error(0)
+
+	if c.log != nil {
+		c.log("CuratorFramework.AddAuth(scheme=\"%s\", auth=[]byte(\"%s\")) error=%v", scheme, auth, err)
+	}
+
+	return err
+}
+
+func (c *mockCuratorFramework) GetCurrentConnectionString() string {
+	server := c.Called().String(0)
+
+	if c.log != nil {
+		c.log("CuratorFramework.GetCurrentConnectionString() server=\"%s\"", server)
+	}
+
+	return server
+}
+
+func (c *mockCuratorFramework) CreateContainers(path string) error {
+	err := c.Called(path).Error(0)
+
+	if c.log != nil {
+		c.log("CuratorFramework.CreateContainers(path=\"%s\") error=%v", path, err)
+	}
+
+	return err
+}
+
+func (c *mockCuratorFramework) WalkTree(path string, visitor TreeVisitor, options ...WalkOption) error {
+	err := c.Called(path, visitor, options).Error(0)
+
+	if c.log != nil {
+		c.log("CuratorFramework.WalkTree(path=\"%s\", visitor=%v, options=%v) error=%v", path, visitor, options, err)
+	}
+
+	return err
+}
+
+func (c *mockCuratorFramework) CopyTree(src, dst string, options ...CopyOption) error {
+	err := c.Called(src, dst, options).Error(0)
+
+	if c.log != nil {
+		c.log("CuratorFramework.CopyTree(src=\"%s\", dst=\"%s\", options=%v) error=%v", src, dst, options, err)
+	}
+
+	return err
+}
+
+func (c *mockCuratorFramework) Diff(path1, path2 string, options ...DiffOption) (*TreeDiff, error) {
+	args := c.Called(path1, path2, options)
+
+	diff, _ := args.Get(0).(*TreeDiff)
+	err := args.Error(1)
+
+	if c.log != nil {
+		c.log("CuratorFramework.Diff(path1=\"%s\", path2=\"%s\", options=%v) (diff=%v, error=%v)", path1, path2, options, diff, err)
+	}
+
+	return diff, err
+}
+
+func (c *mockCuratorFramework) Export(path string, w io.Writer) error {
+	err := c.Called(path, w).Error(0)
+
+	if c.log != nil {
+		c.log("CuratorFramework.Export(path=\"%s\", w=%v) error=%v", path, w, err)
+	}
+
+	return err
+}
+
+func (c *mockCuratorFramework) Import(path string, r io.Reader, options ...ImportOption) error {
+	err := c.Called(path, r, options).Error(0)
+
+	if c.log != nil {
+		c.log("CuratorFramework.Import(path=\"%s\", r=%v, options=%v) error=%v", path, r, options, err)
+	}
+
+	return err
+}
+
+func (c *mockCuratorFramework) Reconfig(joining, leaving []string, members []string, config int64) ([]byte, *zk.Stat, error) {
+	args := c.Called(joining, leaving, members, config)
+
+	data, _ := args.Get(0).([]byte)
+	stat, _ := args.Get(1).(*zk.Stat)
+	err := args.Error(2)
+
+	if c.log != nil {
+		c.log("CuratorFramework.Reconfig(joining=%v, leaving=%v, members=%v, config=%d) (data=%v, stat=%v, error=%v)", joining, leaving, members, config, data, stat, err)
+	}
+
+	return data, stat, err
+}
+
+func (c *mockCuratorFramework) WatchedEvents() <-chan zk.Event {
+	events, _ := c.Called().Get(0).(<-chan zk.Event)
+
+	if c.log != nil {
+		c.log("CuratorFramework.WatchedEvents() events=%v", events)
+	}
+
+	return events
+}
+
+func (c *mockCuratorFramework) AddWatchedEventChannel(ch chan<- zk.Event) {
+	c.Called(ch)
+
+	if c.log != nil {
+		c.log("CuratorFramework.AddWatchedEventChannel(ch=%v)", ch)
+	}
+}
+
 type mockContainer struct {
 	builder *CuratorFrameworkBuilder
 }
 
-func newMockContainer() *mockContainer {
-	return &mockContainer{
+func newMockContainer(options ...BuildOption) *mockContainer {
+	c := &mockContainer{
 		builder: &CuratorFrameworkBuilder{
 			SessionTimeout:    DEFAULT_SESSION_TIMEOUT,
 			ConnectionTimeout: DEFAULT_CONNECTION_TIMEOUT,
@@ -807,6 +1292,10 @@ func newMockContainer() *mockContainer {
 			DefaultData:       []byte("default"),
 		},
 	}
+
+	c.builder.WithOptions(options...)
+
+	return c
 }
 
 func (c *mockContainer) Prepare(callback func(builder *CuratorFrameworkBuilder)) *mockContainer {
@@ -816,15 +1305,14 @@ func (c *mockContainer) Prepare(callback func(builder *CuratorFrameworkBuilder))
 }
 
 func (c *mockContainer) WithNamespace(namespace string) *mockContainer {
-	c.builder.Namespace = namespace
-
-	return c
+	return c.Prepare(WithNamespace(namespace))
 }
 
 func (c *mockContainer) Test(t *testing.T, callback interface{}) {
 	var client CuratorFramework
 	var events chan zk.Event
 	var wg *sync.WaitGroup
+	var zookeeperClient *mockZookeeperClient
 
 	zookeeperConnection := &mockConn{log: t.Logf}
 	zookeeperDialer := &mockZookeeperDialer{log: t.Logf}
@@ -899,6 +1387,15 @@ func (c *mockContainer) Test(t *testing.T, callback interface{}) {
 			wg = new(sync.WaitGroup)
 			args[i] = reflect.ValueOf(wg)
 
+		case reflect.TypeOf(zookeeperClient):
+			zookeeperClient = &mockZookeeperClient{
+				conn:           zookeeperConnection,
+				dialer:         zookeeperDialer,
+				sessionTimeout: c.builder.SessionTimeout,
+				canBeReadOnly:  c.builder.CanBeReadOnly,
+			}
+			args[i] = reflect.ValueOf(zookeeperClient)
+
 		case reflect.TypeOf(data):
 			args[i] = reflect.ValueOf(data)
 
@@ -916,6 +1413,19 @@ func (c *mockContainer) Test(t *testing.T, callback interface{}) {
 		}
 	}
 
+	if zookeeperClient != nil {
+		if events == nil {
+			events = make(chan zk.Event)
+		}
+
+		zookeeperClient.client = client
+		zookeeperClient.events = events
+
+		if c.builder.EnsembleProvider == ensembleProvider {
+			zookeeperClient.ensembleProvider = ensembleProvider
+		}
+	}
+
 	if client != nil {
 		if c.builder.EnsembleProvider == ensembleProvider {
 			ensembleProvider.On("ConnectionString").Return("connStr").Once()
@@ -927,6 +1437,7 @@ func (c *mockContainer) Test(t *testing.T, callback interface{}) {
 			zookeeperDialer.On("Dial", mock.AnythingOfType("string"), c.builder.SessionTimeout, c.builder.CanBeReadOnly).Return(zookeeperConnection, events, nil).Once()
 		}
 
+		assert.NoError(t, c.builder.Validate())
 		assert.NoError(t, client.Start())
 	}
 
@@ -969,7 +1480,15 @@ func (s *mockContainerTestSuite) With(callback interface{}) {
 }
 
 func (s *mockContainerTestSuite) WithNamespace(namespace string, callback interface{}) {
-	newMockContainer().WithNamespace(namespace).Test(s.T(), callback)
+	newMockContainer(WithNamespace(namespace)).Test(s.T(), callback)
+}
+
+func (s *mockContainerTestSuite) WithRetryPolicy(retryPolicy RetryPolicy, callback interface{}) {
+	newMockContainer(WithRetryPolicy(retryPolicy)).Test(s.T(), callback)
+}
+
+func (s *mockContainerTestSuite) WithOptions(options []BuildOption, callback interface{}) {
+	newMockContainer(options...).Test(s.T(), callback)
 }
 
 func (s *mockContainerTestSuite) WithPrepare(prepare func(*CuratorFrameworkBuilder), callback interface{}) {
@@ -26,7 +26,7 @@ func newNamespace(client *curatorFramework, namespace string) *namespaceImpl {
 			return newNamespace(client, "")
 		}
 
-		n.ensurePath = NewEnsurePath(JoinPath("/", namespace))
+		n.ensurePath = NewEnsurePathWithAcl(JoinPath("/", namespace), client.aclProvider)
 	}
 
 	return n
@@ -51,24 +51,42 @@ func (n *namespaceImpl) fixForNamespace(path string, isSequential bool) string {
 	return s
 }
 
+// Apply the namespace to the given path without creating the namespace node
+func (n *namespaceImpl) fixForNamespaceWithoutEnsure(path string, isSequential bool) string {
+	s, _ := FixForNamespace(n.namespace, path, isSequential)
+
+	return s
+}
+
 func (n *namespaceImpl) unfixForNamespace(path string) string {
 	if len(n.namespace) > 0 && len(path) > 0 {
 		prefix := JoinPath(n.namespace)
 
-		if strings.HasPrefix(path, prefix) {
-			if len(prefix) < len(path) {
-				return path[len(prefix):]
-			} else {
-				return PATH_SEPARATOR
-			}
+		if path == prefix {
+			return PATH_SEPARATOR
+		} else if strings.HasPrefix(path, prefix+PATH_SEPARATOR) {
+			return path[len(prefix):]
 		}
 	}
 
 	return path
 }
 
+// A facade of the framework which uses a namespace and shares the session of the framework.
+//
+// The connection state listeners added through the facade are notified with the facade as the client,
+// so they know the namespace which was active when they were registered.
+type NamespaceFacade interface {
+	CuratorFramework
+
+	// Return the current connection state of the shared session
+	GetConnectionState() ConnectionState
+}
+
 type namespaceFacade struct {
 	curatorFramework
+
+	connectionStateListeners *namespaceConnectionStateListenable
 }
 
 func newNamespaceFacade(client *curatorFramework, namespace string) *namespaceFacade {
@@ -79,6 +97,11 @@ func newNamespaceFacade(client *curatorFramework, namespace string) *namespaceFa
 	facade.namespace = newNamespace(client, namespace)
 	facade.fixForNamespace = facade.namespace.fixForNamespace
 	facade.unfixForNamespace = facade.namespace.unfixForNamespace
+	facade.connectionStateListeners = &namespaceConnectionStateListenable{
+		facade:    facade,
+		listeners: client.stateManager.Listenable(),
+		wrappers:  make(map[ConnectionStateListener]*namespaceConnectionStateListener),
+	}
 
 	return facade
 }
@@ -87,8 +110,9 @@ func (f *namespaceFacade) Start() error {
 	return errors.New("the requested operation is not supported")
 }
 
+// Only the root client can close the shared session
 func (f *namespaceFacade) Close() error {
-	return errors.New("the requested operation is not supported")
+	return nil
 }
 
 func (f *namespaceFacade) CuratorListenable() CuratorListenable {
@@ -101,6 +125,84 @@ func (f *namespaceFacade) Namespace() string {
 	return f.namespace.namespace
 }
 
+func (f *namespaceFacade) ConnectionStateListenable() ConnectionStateListenable {
+	return f.connectionStateListeners
+}
+
+func (f *namespaceFacade) AddConnectionStateListener(listener ConnectionStateListener) {
+	f.connectionStateListeners.AddListener(listener)
+}
+
+// Notify the listener with the facade where it was registered
+type namespaceConnectionStateListener struct {
+	facade   *namespaceFacade
+	listener ConnectionStateListener
+}
+
+func (l *namespaceConnectionStateListener) StateChanged(client CuratorFramework, newState ConnectionState) {
+	l.listener.StateChanged(l.facade, newState)
+}
+
+// The listeners registered through a facade, they are added to the shared listenable of the framework
+type namespaceConnectionStateListenable struct {
+	facade    *namespaceFacade
+	listeners ConnectionStateListenable
+	lock      sync.Mutex
+	wrappers  map[ConnectionStateListener]*namespaceConnectionStateListener
+}
+
+func (l *namespaceConnectionStateListenable) AddListener(listener ConnectionStateListener) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if _, exists := l.wrappers[listener]; exists {
+		return
+	}
+
+	wrapper := &namespaceConnectionStateListener{l.facade, listener}
+
+	l.wrappers[listener] = wrapper
+	l.listeners.AddListener(wrapper)
+}
+
+func (l *namespaceConnectionStateListenable) RemoveListener(listener ConnectionStateListener) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if wrapper, exists := l.wrappers[listener]; exists {
+		delete(l.wrappers, listener)
+
+		l.listeners.RemoveListener(wrapper)
+	}
+}
+
+func (l *namespaceConnectionStateListenable) Len() int {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return len(l.wrappers)
+}
+
+func (l *namespaceConnectionStateListenable) Clear() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	for listener, wrapper := range l.wrappers {
+		delete(l.wrappers, listener)
+
+		l.listeners.RemoveListener(wrapper)
+	}
+}
+
+func (l *namespaceConnectionStateListenable) ForEach(callback func(interface{})) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	for listener := range l.wrappers {
+		callback(listener)
+	}
+}
+
 type namespaceFacadeCache struct {
 	client *curatorFramework
 	cache  map[string]*namespaceFacade
@@ -0,0 +1,121 @@
+package curator
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/samuel/go-zookeeper/zk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFixForNamespace(t *testing.T) {
+	tests := []struct {
+		namespace string
+		path      string
+		expected  string
+	}{
+		{"", "/child", "/child"},
+		{"parent", "/child", "/parent/child"},
+		{"parent", "child", "/parent/child"},
+		{"parent/sub", "/child", "/parent/sub/child"},
+		{"parent", "/", "/parent"},
+	}
+
+	for _, test := range tests {
+		path, err := FixForNamespace(test.namespace, test.path, false)
+
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, path, "namespace=%s path=%s", test.namespace, test.path)
+	}
+}
+
+func TestUnfixForNamespace(t *testing.T) {
+	tests := []struct {
+		namespace string
+		path      string
+		expected  string
+	}{
+		{"", "/child", "/child"},
+		{"parent", "", ""},
+		{"parent", "/parent", "/"},
+		{"parent", "/parent/child", "/child"},
+		{"parent", "/parent/child-0000000001", "/child-0000000001"},
+		{"parent/sub", "/parent/sub", "/"},
+		{"parent/sub", "/parent/sub/child", "/child"},
+		{"parent", "/parentx/child", "/parentx/child"},
+		{"parent", "/other", "/other"},
+	}
+
+	for _, test := range tests {
+		n := &namespaceImpl{namespace: test.namespace}
+
+		assert.Equal(t, test.expected, n.unfixForNamespace(test.path), "namespace=%s path=%s", test.namespace, test.path)
+	}
+}
+
+func TestUsingNamespace(t *testing.T) {
+	newMockContainer().Test(t, func(client CuratorFramework, conn *mockConn, data []byte, acls []zk.ACL) {
+		facade := client.UsingNamespace("parent")
+
+		assert.Equal(t, "parent", facade.Namespace())
+		assert.Equal(t, STARTED, facade.State())
+		assert.Equal(t, facade, client.UsingNamespace("parent"))
+
+		// share the same connection
+		conn.On("Exists", "/parent").Return(true, nil, nil).Once()
+		conn.On("Create", "/parent/child", data, int32(PERSISTENT), acls).Return("/parent/child", nil).Once()
+
+		path, err := facade.Create().WithACL(acls...).ForPathWithData("/child", data)
+
+		assert.Equal(t, "/child", path)
+		assert.NoError(t, err)
+
+		// closing the facade won't close the session
+		assert.NoError(t, facade.Close())
+		assert.Equal(t, STARTED, client.State())
+		assert.Equal(t, STARTED, facade.State())
+
+		assert.Equal(t, "", client.NonNamespaceView().Namespace())
+	})
+}
+
+func TestNamespaceFacadeConnectionState(t *testing.T) {
+	newMockContainer().Test(t, func(client CuratorFramework) {
+		var wg sync.WaitGroup
+		var namespaces []string
+
+		facade := client.UsingNamespace("plugin")
+		registered := client.ConnectionStateListenable().Len()
+
+		assert.Equal(t, UNKNOWN, facade.GetConnectionState())
+
+		listener := NewConnectionStateListener(func(c CuratorFramework, newState ConnectionState) {
+			namespaces = append(namespaces, c.Namespace())
+
+			wg.Done()
+		})
+
+		wg.Add(1)
+
+		facade.AddConnectionStateListener(listener)
+		facade.AddConnectionStateListener(listener)
+
+		assert.Equal(t, 1, facade.ConnectionStateListenable().Len())
+		assert.Equal(t, registered+1, client.ConnectionStateListenable().Len())
+
+		framework := client.(*curatorFramework)
+
+		framework.validateConnection(zk.StateHasSession)
+
+		wg.Wait()
+
+		assert.Equal(t, []string{"plugin"}, namespaces)
+		assert.True(t, facade.GetConnectionState().Connected())
+		assert.NoError(t, facade.BlockUntilConnected())
+
+		facade.ConnectionStateListenable().RemoveListener(listener)
+
+		assert.Equal(t, 0, facade.ConnectionStateListenable().Len())
+		assert.Equal(t, registered, client.ConnectionStateListenable().Len())
+	})
+}
@@ -0,0 +1,181 @@
+package curator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/samuel/go-zookeeper/zk"
+)
+
+const (
+	DEFAULT_OPA_CACHE_TTL       = 1 * time.Minute
+	DEFAULT_OPA_REQUEST_TIMEOUT = 5 * time.Second
+)
+
+// The HTTP client used to query the policy engine, *http.Client or a stub for testing
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// An ACLProvider which evaluates the ACL list with an Open Policy Agent policy.
+//
+// The policy is queried with the Data API, the input is {"path": "..."} and the path is empty for the default ACL list,
+// the result should be a list of ACLs like [{"perms": 31, "scheme": "world", "id": "anyone"}].
+// The results are cached for the TTL, and the emergency ACL list is used if the policy engine is unavailable.
+type OPAACLProvider struct {
+	url                     string
+	client                  HTTPClient
+	cacheTTL                time.Duration
+	emergencyAcls           []zk.ACL
+	unhandledErrorListeners unhandledErrorListenerContainer
+	lock                    sync.Mutex
+	cache                   map[string]*opaCacheEntry
+	now                     func() time.Time
+}
+
+type opaCacheEntry struct {
+	acls    []zk.ACL
+	expires time.Time
+}
+
+type opaRequest struct {
+	Input opaInput `json:"input"`
+}
+
+type opaInput struct {
+	Path string `json:"path"`
+}
+
+type opaResponse struct {
+	Result []zk.ACL `json:"result"`
+}
+
+func NewOPAACLProvider(opaURL string, policyPath string) *OPAACLProvider {
+	return &OPAACLProvider{
+		url:           strings.TrimSuffix(opaURL, "/") + "/v1/data/" + strings.Trim(policyPath, "/"),
+		client:        &http.Client{Timeout: DEFAULT_OPA_REQUEST_TIMEOUT},
+		cacheTTL:      DEFAULT_OPA_CACHE_TTL,
+		emergencyAcls: CREATOR_ALL_ACL,
+		cache:         make(map[string]*opaCacheEntry),
+		now:           time.Now,
+	}
+}
+
+// Use the HTTP client to query the policy engine
+func (p *OPAACLProvider) WithHTTPClient(client HTTPClient) *OPAACLProvider {
+	p.client = client
+
+	return p
+}
+
+// Cache the results for the TTL, or disable the cache if it's zero
+func (p *OPAACLProvider) WithCacheTTL(ttl time.Duration) *OPAACLProvider {
+	p.cacheTTL = ttl
+
+	return p
+}
+
+// Use the ACL list when the policy engine is unavailable, the default is CREATOR_ALL_ACL
+func (p *OPAACLProvider) WithEmergencyAcls(acls ...zk.ACL) *OPAACLProvider {
+	p.emergencyAcls = acls
+
+	return p
+}
+
+// Returns the listenable interface for the errors of the policy engine
+func (p *OPAACLProvider) UnhandledErrorListenable() UnhandledErrorListenable {
+	return &p.unhandledErrorListeners
+}
+
+func (p *OPAACLProvider) GetDefaultAcl() []zk.ACL {
+	if acls := p.evaluate(""); acls != nil {
+		return acls
+	}
+
+	return p.emergencyAcls
+}
+
+func (p *OPAACLProvider) GetAclForPath(path string) []zk.ACL {
+	return p.evaluate(path)
+}
+
+// Return the cached or evaluated ACL list, nil if the policy is undefined for the path
+func (p *OPAACLProvider) evaluate(path string) []zk.ACL {
+	p.lock.Lock()
+	entry, cached := p.cache[path]
+	p.lock.Unlock()
+
+	if cached && p.now().Before(entry.expires) {
+		return entry.acls
+	}
+
+	acls, err := p.query(path)
+
+	if err != nil {
+		p.logError(fmt.Errorf("fail to evaluate the ACL policy for `%s`, fall back to the emergency ACLs, %w", path, err))
+
+		return p.emergencyAcls
+	}
+
+	if p.cacheTTL > 0 {
+		p.lock.Lock()
+		p.cache[path] = &opaCacheEntry{acls, p.now().Add(p.cacheTTL)}
+		p.lock.Unlock()
+	}
+
+	return acls
+}
+
+func (p *OPAACLProvider) query(path string) ([]zk.ACL, error) {
+	body, err := json.Marshal(&opaRequest{opaInput{path}})
+
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.url, bytes.NewReader(body))
+
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := p.client.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status `%s`", res.Status)
+	}
+
+	var result opaResponse
+
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return result.Result, nil
+}
+
+func (p *OPAACLProvider) logError(err error) {
+	if p.unhandledErrorListeners.Len() == 0 {
+		slog.Error("unhandled error", "err", err)
+
+		return
+	}
+
+	p.unhandledErrorListeners.ForEach(func(listener interface{}) {
+		listener.(UnhandledErrorListener).UnhandledError(err)
+	})
+}
@@ -0,0 +1,87 @@
+package curator
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/samuel/go-zookeeper/zk"
+	"github.com/stretchr/testify/assert"
+)
+
+type httpClientFunc func(req *http.Request) (*http.Response, error)
+
+func (f httpClientFunc) Do(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestOPAACLProvider(t *testing.T) {
+	var inputs []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req opaRequest
+
+		assert.Equal(t, "/v1/data/zookeeper/acl", r.URL.Path)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		inputs = append(inputs, req.Input.Path)
+
+		switch req.Input.Path {
+		case "":
+			w.Write([]byte(`{"result": [{"perms": 1, "scheme": "world", "id": "anyone"}]}`))
+		case "/secret":
+			w.Write([]byte(`{"result": [{"perms": 31, "scheme": "digest", "id": "admin:password"}]}`))
+		default:
+			w.Write([]byte(`{}`)) // undefined
+		}
+	}))
+
+	defer server.Close()
+
+	now := time.Now()
+
+	provider := NewOPAACLProvider(server.URL+"/", "/zookeeper/acl").WithCacheTTL(time.Minute)
+	provider.now = func() time.Time { return now }
+
+	assert.Equal(t, READ_ACL_UNSAFE, provider.GetDefaultAcl())
+	assert.Equal(t, []zk.ACL{{Perms: zk.PermAll, Scheme: "digest", ID: "admin:password"}}, provider.GetAclForPath("/secret"))
+	assert.Nil(t, provider.GetAclForPath("/public"))
+
+	// cached until expired
+	assert.Equal(t, READ_ACL_UNSAFE, provider.GetDefaultAcl())
+	assert.Nil(t, provider.GetAclForPath("/public"))
+	assert.Equal(t, []string{"", "/secret", "/public"}, inputs)
+
+	now = now.Add(time.Minute)
+
+	assert.Equal(t, READ_ACL_UNSAFE, provider.GetDefaultAcl())
+	assert.Equal(t, []string{"", "/secret", "/public", ""}, inputs)
+
+	// fall back to the emergency ACLs when the policy engine is unavailable
+	var errs []error
+
+	provider.UnhandledErrorListenable().AddListener(NewUnhandledErrorListener(func(err error) {
+		errs = append(errs, err)
+	}))
+
+	server.Close()
+
+	now = now.Add(time.Minute)
+
+	assert.Equal(t, CREATOR_ALL_ACL, provider.GetDefaultAcl())
+	assert.Len(t, errs, 1)
+
+	provider.WithEmergencyAcls(OPEN_ACL_UNSAFE...)
+
+	assert.Equal(t, OPEN_ACL_UNSAFE, provider.GetAclForPath("/secret"))
+	assert.Len(t, errs, 2)
+
+	// the injected HTTP client
+	provider.WithHTTPClient(httpClientFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New("unreachable")
+	}))
+
+	assert.Equal(t, OPEN_ACL_UNSAFE, provider.GetAclForPath("/public"))
+	assert.ErrorContains(t, errs[2], "unreachable")
+}
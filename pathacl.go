@@ -0,0 +1,129 @@
+package curator
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/samuel/go-zookeeper/zk"
+)
+
+// The key of the rules used when no prefix matches the path
+const DEFAULT_ACL_RULE = "default"
+
+// An ACLProvider which maps the path prefixes to the ACL lists, the longest prefix wins.
+//
+// The prefixes are matched on the whole path segments, e.g. "/app/prod" matches "/app/prod/secrets" but not "/app/production".
+type PerPathACLProvider struct {
+	lock        sync.RWMutex
+	root        *aclTrieNode
+	defaultAcls []zk.ACL
+}
+
+// A node of the radix tree keyed by the path segments
+type aclTrieNode struct {
+	children map[string]*aclTrieNode
+	acls     []zk.ACL
+}
+
+func NewPerPathACLProvider(rules map[string][]zk.ACL) *PerPathACLProvider {
+	p := &PerPathACLProvider{}
+
+	p.UpdateRules(rules)
+
+	return p
+}
+
+// Load the rules from a JSON file, e.g. {"default": [...], "/app": [{"perms": 31, "scheme": "world", "id": "anyone"}]}
+func LoadPerPathACLProviderFromFile(filename string) (*PerPathACLProvider, error) {
+	data, err := os.ReadFile(filename)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var rules map[string][]zk.ACL
+
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, err
+	}
+
+	return NewPerPathACLProvider(rules), nil
+}
+
+// Replace all the rules, it's safe to be called while the provider is used
+func (p *PerPathACLProvider) UpdateRules(rules map[string][]zk.ACL) {
+	root := &aclTrieNode{}
+	defaultAcls := OPEN_ACL_UNSAFE
+
+	for prefix, acls := range rules {
+		if prefix == DEFAULT_ACL_RULE {
+			defaultAcls = acls
+
+			continue
+		}
+
+		node := root
+
+		for _, segment := range splitPathSegments(prefix) {
+			if node.children == nil {
+				node.children = make(map[string]*aclTrieNode)
+			}
+
+			child, exists := node.children[segment]
+
+			if !exists {
+				child = &aclTrieNode{}
+				node.children[segment] = child
+			}
+
+			node = child
+		}
+
+		node.acls = acls
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.root = root
+	p.defaultAcls = defaultAcls
+}
+
+func (p *PerPathACLProvider) GetDefaultAcl() []zk.ACL {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.defaultAcls
+}
+
+func (p *PerPathACLProvider) GetAclForPath(path string) []zk.ACL {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	node := p.root
+	acls := node.acls
+
+	for _, segment := range splitPathSegments(path) {
+		if node = node.children[segment]; node == nil {
+			break
+		} else if node.acls != nil {
+			acls = node.acls
+		}
+	}
+
+	if acls == nil {
+		return p.defaultAcls
+	}
+
+	return acls
+}
+
+func splitPathSegments(path string) []string {
+	if path = strings.Trim(path, PATH_SEPARATOR); len(path) == 0 {
+		return nil
+	}
+
+	return strings.Split(path, PATH_SEPARATOR)
+}
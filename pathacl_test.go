@@ -0,0 +1,69 @@
+package curator
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/samuel/go-zookeeper/zk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPerPathACLProvider(t *testing.T) {
+	secret := zk.DigestACL(zk.PermAll, "admin", "password")
+	prod := zk.WorldACL(zk.PermRead | zk.PermWrite)
+
+	provider := NewPerPathACLProvider(map[string][]zk.ACL{
+		"default":           CREATOR_ALL_ACL,
+		"/":                 READ_ACL_UNSAFE,
+		"/app":              OPEN_ACL_UNSAFE,
+		"/app/prod":         prod,
+		"/app/prod/secrets": secret,
+	})
+
+	assert.Equal(t, CREATOR_ALL_ACL, provider.GetDefaultAcl())
+	assert.Equal(t, secret, provider.GetAclForPath("/app/prod/secrets"))
+	assert.Equal(t, secret, provider.GetAclForPath("/app/prod/secrets/key"))
+	assert.Equal(t, prod, provider.GetAclForPath("/app/prod"))
+	assert.Equal(t, prod, provider.GetAclForPath("/app/prod/config"))
+	assert.Equal(t, OPEN_ACL_UNSAFE, provider.GetAclForPath("/app/production"))
+	assert.Equal(t, READ_ACL_UNSAFE, provider.GetAclForPath("/other"))
+
+	// update the rules at runtime
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		provider.UpdateRules(map[string][]zk.ACL{"/app": prod})
+	}()
+
+	provider.GetAclForPath("/app")
+
+	wg.Wait()
+
+	assert.Equal(t, OPEN_ACL_UNSAFE, provider.GetDefaultAcl())
+	assert.Equal(t, prod, provider.GetAclForPath("/app/prod/secrets"))
+	assert.Equal(t, OPEN_ACL_UNSAFE, provider.GetAclForPath("/other"))
+
+	// load the rules from the file
+	filename := filepath.Join(t.TempDir(), "acls.json")
+
+	assert.NoError(t, os.WriteFile(filename, []byte(`{
+		"default": [{"perms": 1, "scheme": "world", "id": "anyone"}],
+		"/app": [{"perms": 31, "scheme": "digest", "id": "admin:password"}]
+	}`), 0644))
+
+	provider, err := LoadPerPathACLProviderFromFile(filename)
+
+	assert.NoError(t, err)
+	assert.Equal(t, READ_ACL_UNSAFE, provider.GetAclForPath("/other"))
+	assert.Equal(t, []zk.ACL{{Perms: zk.PermAll, Scheme: "digest", ID: "admin:password"}}, provider.GetAclForPath("/app/node"))
+
+	_, err = LoadPerPathACLProviderFromFile(filepath.Join(t.TempDir(), "missing.json"))
+
+	assert.Error(t, err)
+}
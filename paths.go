@@ -138,6 +138,11 @@ func ValidatePath(path string) error {
 
 // Make sure all the nodes in the path are created
 func MakeDirs(conn ZookeeperConnection, path string, makeLastNode bool, aclProvider ACLProvider) error {
+	return makeDirs(conn, path, makeLastNode, aclProvider, PERSISTENT)
+}
+
+// Make sure all the nodes in the path are created with the given mode
+func makeDirs(conn ZookeeperConnection, path string, makeLastNode bool, aclProvider ACLProvider, mode CreateMode) error {
 	if err := ValidatePath(path); err != nil {
 		return err
 	}
@@ -172,7 +177,15 @@ func MakeDirs(conn ZookeeperConnection, path string, makeLastNode bool, aclProvi
 				acls = OPEN_ACL_UNSAFE
 			}
 
-			if _, err := conn.Create(subPath, []byte{}, int32(PERSISTENT), acls); err != nil && err != zk.ErrNodeExists {
+			var err error
+
+			if mode.IsContainer() {
+				_, err = createContainer(conn, subPath, []byte{}, acls)
+			} else {
+				_, err = conn.Create(subPath, []byte{}, int32(mode), acls)
+			}
+
+			if err != nil && err != zk.ErrNodeExists {
 				return err
 			}
 		}
@@ -181,6 +194,15 @@ func MakeDirs(conn ZookeeperConnection, path string, makeLastNode bool, aclProvi
 	return nil
 }
 
+// Create a container node, the connection must implement ContainerConnection
+func createContainer(conn ZookeeperConnection, path string, data []byte, acl []zk.ACL) (string, error) {
+	if containerConn, ok := conn.(ContainerConnection); ok {
+		return containerConn.CreateContainer(path, data, acl)
+	}
+
+	return "", ErrContainerNotSupported
+}
+
 // Recursively deletes children of a node.
 func DeleteChildren(conn ZookeeperConnection, path string, deleteSelf bool) error {
 	if err := ValidatePath(path); err != nil {
@@ -213,6 +235,36 @@ func DeleteChildren(conn ZookeeperConnection, path string, deleteSelf bool) erro
 	return nil
 }
 
+// Make sure all the nodes in the path are created with the retry policy of the client,
+// each created node asks the ACLProvider of the client for its own ACL list.
+//
+// The nodes are created with OPEN_ACL_UNSAFE if the client doesn't implement ACLProviderClient.
+func EnsurePathWithACL(client CuratorZookeeperClient, path string, makeLastNode bool) error {
+	var aclProvider ACLProvider
+
+	if client, ok := client.(ACLProviderClient); ok {
+		aclProvider = client.AclProvider()
+	}
+
+	return EnsurePathWithAclProvider(client, path, makeLastNode, aclProvider)
+}
+
+// Make sure all the nodes in the path are created with the retry policy of the client,
+// each created node asks the given ACLProvider for its own ACL list.
+func EnsurePathWithAclProvider(client CuratorZookeeperClient, path string, makeLastNode bool, aclProvider ACLProvider) error {
+	_, err := client.NewRetryLoop().CallWithRetry(func() (interface{}, error) {
+		if conn, err := client.Conn(); err != nil {
+			return nil, err
+		} else if err := MakeDirs(conn, path, makeLastNode, aclProvider); err != nil {
+			return nil, err
+		} else {
+			return nil, nil
+		}
+	})
+
+	return err
+}
+
 type EnsurePath interface {
 	// First time, synchronizes and makes sure all nodes in the path are created.
 	// Subsequent calls with this instance are NOPs.
@@ -220,6 +272,10 @@ type EnsurePath interface {
 
 	// Returns a view of this EnsurePath instance that does not make the last node.
 	ExcludingLast() EnsurePath
+
+	// Returns a view of this EnsurePath instance that creates the nodes with the given ACL list
+	// instead of the one from the ACLProvider.
+	WithACL(acls []zk.ACL) EnsurePath
 }
 
 type EnsurePathHelper interface {
@@ -233,19 +289,15 @@ type ensurePathHelper struct {
 }
 
 func (h *ensurePathHelper) Ensure(client CuratorZookeeperClient, path string, makeLastNode bool) error {
+	return h.ensure(client, path, makeLastNode, h.owner.ensureAclProvider())
+}
+
+func (h *ensurePathHelper) ensure(client CuratorZookeeperClient, path string, makeLastNode bool, aclProvider ACLProvider) error {
 	h.lock.Lock()
 	defer h.lock.Unlock()
 
 	if !h.started {
-		_, err := client.NewRetryLoop().CallWithRetry(func() (interface{}, error) {
-			if conn, err := client.Conn(); err != nil {
-				return nil, err
-			} else if err := MakeDirs(conn, path, makeLastNode, h.owner.aclProvider); err != nil {
-				return nil, err
-			} else {
-				return nil, nil
-			}
-		})
+		err := EnsurePathWithAclProvider(client, path, makeLastNode, aclProvider)
 
 		h.started = true
 
@@ -261,6 +313,7 @@ func (h *ensurePathHelper) Ensure(client CuratorZookeeperClient, path string, ma
 type ensurePath struct {
 	path         string
 	aclProvider  ACLProvider
+	acls         []zk.ACL
 	makeLastNode bool
 	helper       EnsurePathHelper
 }
@@ -293,13 +346,35 @@ func (p *ensurePath) ExcludingLast() EnsurePath {
 	return &ensurePath{
 		path:         p.path,
 		aclProvider:  p.aclProvider,
+		acls:         p.acls,
 		makeLastNode: false,
 		helper:       p.helper,
 	}
 }
 
+func (p *ensurePath) WithACL(acls []zk.ACL) EnsurePath {
+	return &ensurePath{
+		path:         p.path,
+		aclProvider:  p.aclProvider,
+		acls:         acls,
+		makeLastNode: p.makeLastNode,
+		helper:       p.helper,
+	}
+}
+
+// The ACL list given by WithACL takes precedence over the ACLProvider
+func (p *ensurePath) ensureAclProvider() ACLProvider {
+	if len(p.acls) > 0 {
+		return &defaultACLProvider{p.acls}
+	}
+
+	return p.aclProvider
+}
+
 func (p *ensurePath) Ensure(client CuratorZookeeperClient) error {
-	if p.helper != nil {
+	if helper, ok := p.helper.(*ensurePathHelper); ok {
+		return helper.ensure(client, p.path, p.makeLastNode, p.ensureAclProvider())
+	} else if p.helper != nil {
 		return p.helper.Ensure(client, p.path, p.makeLastNode)
 	}
 
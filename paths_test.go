@@ -169,3 +169,88 @@ func TestEnsurePath(t *testing.T) {
 	helper.AssertExpectations(t)
 	client.AssertExpectations(t)
 }
+
+func TestEnsurePathWithAclProvider(t *testing.T) {
+	client := &mockCuratorZookeeperClient{log: t.Logf}
+	conn := &mockConn{log: t.Logf}
+	acls := &mockACLProvider{log: t.Logf}
+
+	client.On("NewRetryLoop").Return(newRetryLoop(NewRetryOneTime(0), nil)).Once()
+	client.On("Conn").Return(conn, nil).Once()
+
+	conn.On("Exists", "/app").Return(false, nil, nil).Once()
+	acls.On("GetAclForPath", "/app").Return(READ_ACL_UNSAFE).Once()
+	conn.On("Create", "/app", []byte{}, int32(PERSISTENT), READ_ACL_UNSAFE).Return("/app", nil).Once()
+
+	conn.On("Exists", "/app/secrets").Return(false, nil, nil).Once()
+	acls.On("GetAclForPath", "/app/secrets").Return(CREATOR_ALL_ACL).Once()
+	conn.On("Create", "/app/secrets", []byte{}, int32(PERSISTENT), CREATOR_ALL_ACL).Return("/app/secrets", nil).Once()
+
+	assert.NoError(t, EnsurePathWithAclProvider(client, "/app/secrets", true, acls))
+
+	client.AssertExpectations(t)
+	conn.AssertExpectations(t)
+	acls.AssertExpectations(t)
+}
+
+func TestEnsurePathWithACL(t *testing.T) {
+	client := &mockCuratorZookeeperClient{log: t.Logf}
+	conn := &mockConn{log: t.Logf}
+	aclProvider := &mockACLProvider{log: t.Logf}
+
+	client.On("NewRetryLoop").Return(newRetryLoop(NewRetryOneTime(0), nil)).Once()
+	client.On("Conn").Return(conn, nil).Once()
+
+	// the ACL provider is never asked since the ACL list takes precedence
+	conn.On("Exists", "/app").Return(false, nil, nil).Once()
+	conn.On("Create", "/app", []byte{}, int32(PERSISTENT), CREATOR_ALL_ACL).Return("/app", nil).Once()
+
+	ensure := NewEnsurePathWithAcl("/app/secrets", aclProvider).WithACL(CREATOR_ALL_ACL).ExcludingLast()
+
+	assert.Equal(t, CREATOR_ALL_ACL, ensure.(*ensurePath).acls)
+	assert.NoError(t, ensure.Ensure(client))
+
+	// the following calls are NOPs
+	assert.NoError(t, ensure.Ensure(client))
+
+	client.AssertExpectations(t)
+	conn.AssertExpectations(t)
+	aclProvider.AssertExpectations(t)
+}
+
+func TestEnsurePathWithACLOfClient(t *testing.T) {
+	zookeeper := NewFakeZookeeper()
+
+	assert.NoError(t, zookeeper.Start())
+
+	defer zookeeper.Close()
+
+	builder := &CuratorFrameworkBuilder{
+		ZookeeperDialer:   zookeeper,
+		SessionTimeout:    DEFAULT_SESSION_TIMEOUT,
+		ConnectionTimeout: DEFAULT_CONNECTION_TIMEOUT,
+		RetryPolicy:       NewRetryOneTime(0),
+		AclProvider: NewPerPathACLProvider(map[string][]zk.ACL{
+			"/app":         zk.WorldACL(zk.PermAll),
+			"/app/secrets": READ_ACL_UNSAFE,
+		}),
+	}
+
+	client := builder.ConnectString(zookeeper.ConnectString()).Build()
+
+	assert.NoError(t, client.Start())
+
+	defer client.Close()
+
+	assert.NoError(t, EnsurePathWithACL(client.ZookeeperClient(), "/app/secrets", true))
+
+	acls, err := client.GetACL().ForPath("/app")
+
+	assert.Equal(t, zk.WorldACL(zk.PermAll), acls)
+	assert.NoError(t, err)
+
+	acls, err = client.GetACL().ForPath("/app/secrets")
+
+	assert.Equal(t, READ_ACL_UNSAFE, acls)
+	assert.NoError(t, err)
+}
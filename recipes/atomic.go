@@ -2,12 +2,24 @@ package recipes
 
 import (
 	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
 	"time"
 
 	"github.com/flier/curator.go"
 	"github.com/samuel/go-zookeeper/zk"
 )
 
+// The maximum size of the data of a ZooKeeper node (jute.maxbuffer)
+const MAX_NODE_DATA_SIZE = 1024 * 1024
+
+var (
+	ErrValueTooLarge = errors.New("value exceeds the maximum size of the node data")
+	ErrOverflow      = errors.New("the operation overflows the value")
+	ErrCorruptedLong = errors.New("the node data is not a valid long value")
+)
+
 // Debugging stats about operations
 type AtomicStats struct {
 	//  the number of optimistic locks used to perform the operation
@@ -24,34 +36,34 @@ type AtomicStats struct {
 }
 
 // Abstracts a value returned from one of the Atomics
-type AtomicValue interface {
+type AtomicValue[T any] struct {
 	// MUST be checked.
-	// Returns true if the operation succeeded. If false is returned,
+	// True if the operation succeeded. If false,
 	// the operation failed and the atomic was not updated.
-	Succeeded() bool
+	Succeeded bool
 
-	// Returns the value of the counter prior to the operation
-	PreValue() []byte
+	// The value of the counter prior to the operation
+	PreValue T
 
-	// Returns the value of the counter after to the operation
-	PostValue() []byte
+	// The value of the counter after to the operation
+	PostValue T
 
-	// Returns debugging stats about the operation
-	Stats() *AtomicStats
+	// Debugging stats about the operation
+	Stats AtomicStats
 }
 
 type DistributedAtomicValue interface {
 	// Returns the current value of the counter.
-	Get() (AtomicValue, error)
+	Get() (AtomicValue[[]byte], error)
 
 	// Atomically sets the value to the given updated value
 	// if the current value == the expected value.
 	// Remember to always check AtomicValue.Succeeded().
-	CompareAndSet(expectedValue, newValue []byte) (AtomicValue, error)
+	CompareAndSet(expectedValue, newValue []byte) (AtomicValue[[]byte], error)
 
 	// Attempt to atomically set the value to the given value.
 	// Remember to always check AtomicValue.Succeeded().
-	TrySet(newValue []byte) (AtomicValue, error)
+	TrySet(newValue []byte) (AtomicValue[[]byte], error)
 
 	// Forcibly sets the value of the counter without any guarantees of atomicity.
 	ForceSet(newValue []byte) error
@@ -65,35 +77,21 @@ type DistributedAtomicValue interface {
 type DistributedAtomicNumber interface {
 	// Add 1 to the current value and return the new value information.
 	// Remember to always check AtomicValue.Succeeded().
-	Increment() (AtomicValue, error)
+	Increment() (AtomicValue[[]byte], error)
 
 	// Subtract 1 from the current value and return the new value information.
 	// Remember to always check AtomicValue.Succeeded().
-	Decrement() (AtomicValue, error)
+	Decrement() (AtomicValue[[]byte], error)
 
 	// Add delta to the current value and return the new value information.
 	// Remember to always check AtomicValue.Succeeded().
-	Add(delta []byte) (AtomicValue, error)
+	Add(delta []byte) (AtomicValue[[]byte], error)
 
 	// Subtract delta from the current value and return the new value information.
 	// Remember to always check AtomicValue.Succeeded().
-	Subtract(delta []byte) (AtomicValue, error)
+	Subtract(delta []byte) (AtomicValue[[]byte], error)
 }
 
-type mutableAtomicValue struct {
-	preValue, postValue []byte
-	succeeded           bool
-	stats               AtomicStats
-}
-
-func (v *mutableAtomicValue) Succeeded() bool { return v.succeeded }
-
-func (v *mutableAtomicValue) PreValue() []byte { return v.preValue }
-
-func (v *mutableAtomicValue) PostValue() []byte { return v.postValue }
-
-func (v *mutableAtomicValue) Stats() *AtomicStats { return &v.stats }
-
 type PromotedToLock struct {
 	lockPath    string
 	maxLockTime time.Duration
@@ -135,22 +133,26 @@ func NewDistributedAtomicValueWithLock(client curator.CuratorFramework, path str
 	return v, nil
 }
 
-func (v *distributedAtomicValue) Get() (AtomicValue, error) {
-	var result mutableAtomicValue
+func (v *distributedAtomicValue) Get() (AtomicValue[[]byte], error) {
+	var result AtomicValue[[]byte]
 
 	if _, err := v.currentValue(&result, nil); err != nil {
-		return nil, err
+		return result, err
 	}
 
-	result.postValue = result.preValue
-	result.succeeded = true
+	result.PostValue = result.PreValue
+	result.Succeeded = true
 
-	return &result, nil
+	return result, nil
 }
 
 func (v *distributedAtomicValue) ForceSet(newValue []byte) (err error) {
+	if len(newValue) > MAX_NODE_DATA_SIZE {
+		return ErrValueTooLarge
+	}
+
 	if _, err = v.client.SetData().ForPathWithData(v.path, newValue); err == zk.ErrNoNode {
-		if _, err = v.client.Create().ForPathWithData(v.path, newValue); err == zk.ErrNodeExists {
+		if _, err = v.client.Create().CreatingParentsIfNeeded().ForPathWithData(v.path, newValue); err == zk.ErrNodeExists {
 			_, err = v.client.SetData().ForPathWithData(v.path, newValue)
 		}
 	}
@@ -158,44 +160,57 @@ func (v *distributedAtomicValue) ForceSet(newValue []byte) (err error) {
 	return
 }
 
-func (v *distributedAtomicValue) CompareAndSet(expectedValue, newValue []byte) (AtomicValue, error) {
-	var result mutableAtomicValue
+func (v *distributedAtomicValue) CompareAndSet(expectedValue, newValue []byte) (AtomicValue[[]byte], error) {
+	var result AtomicValue[[]byte]
 	var stat zk.Stat
 
+	if len(newValue) > MAX_NODE_DATA_SIZE {
+		return result, ErrValueTooLarge
+	}
+
 	if createIt, err := v.currentValue(&result, &stat); err != nil {
-		return nil, err
-	} else if !createIt && bytes.Equal(expectedValue, result.preValue) {
+		return result, err
+	} else if !createIt && bytes.Equal(expectedValue, result.PreValue) {
 		if _, err := v.client.SetData().WithVersion(stat.Version).ForPathWithData(v.path, newValue); err == nil {
-			result.succeeded = true
-			result.postValue = newValue
+			result.Succeeded = true
+			result.PostValue = newValue
 		} else if err == zk.ErrBadVersion || err == zk.ErrNoNode {
-			result.succeeded = false
+			result.Succeeded = false
 		} else {
-			return nil, err
+			return result, err
 		}
 	} else {
-		result.succeeded = false
+		result.Succeeded = false
 	}
 
-	return &result, nil
+	return result, nil
 }
 
-func (v *distributedAtomicValue) TrySet(newValue []byte) (AtomicValue, error) {
-	var result mutableAtomicValue
+func (v *distributedAtomicValue) TrySet(newValue []byte) (AtomicValue[[]byte], error) {
+	return v.trySetWith(func(preValue []byte) ([]byte, error) { return newValue, nil })
+}
 
-	if err := v.tryOptimistic(&result, newValue); err != nil {
-		return nil, err
-	} else if !result.succeeded && v.mutex != nil {
-		if err := v.tryWithMutex(&result, newValue); err != nil {
-			return nil, err
+// Attempt to atomically set the value to the one made from the current value
+func (v *distributedAtomicValue) trySetWith(makeValue func(preValue []byte) ([]byte, error)) (AtomicValue[[]byte], error) {
+	var result AtomicValue[[]byte]
+
+	if err := v.tryOptimistic(&result, makeValue); err != nil {
+		return result, err
+	} else if !result.Succeeded && v.mutex != nil {
+		if err := v.tryWithMutex(&result, makeValue); err != nil {
+			return result, err
 		}
 	}
 
-	return &result, nil
+	return result, nil
 }
 
 func (v *distributedAtomicValue) Initialize(value []byte) (bool, error) {
-	if _, err := v.client.Create().ForPath(v.path); err == nil {
+	if len(value) > MAX_NODE_DATA_SIZE {
+		return false, ErrValueTooLarge
+	}
+
+	if _, err := v.client.Create().CreatingParentsIfNeeded().ForPathWithData(v.path, value); err == nil {
 		return true, nil
 	} else if err == zk.ErrNodeExists {
 		return false, nil
@@ -204,13 +219,13 @@ func (v *distributedAtomicValue) Initialize(value []byte) (bool, error) {
 	}
 }
 
-func (v *distributedAtomicValue) currentValue(result *mutableAtomicValue, stat *zk.Stat) (bool, error) {
+func (v *distributedAtomicValue) currentValue(result *AtomicValue[[]byte], stat *zk.Stat) (bool, error) {
 	if data, err := v.client.GetData().StoringStatIn(stat).ForPath(v.path); err == nil {
-		result.preValue = data
+		result.PreValue = data
 
 		return false, nil
 	} else if err == zk.ErrNoNode {
-		result.preValue = nil
+		result.PreValue = nil
 
 		return true, nil
 	} else {
@@ -218,23 +233,23 @@ func (v *distributedAtomicValue) currentValue(result *mutableAtomicValue, stat *
 	}
 }
 
-func (v *distributedAtomicValue) tryOptimistic(result *mutableAtomicValue, newValue []byte) error {
+func (v *distributedAtomicValue) tryOptimistic(result *AtomicValue[[]byte], makeValue func(preValue []byte) ([]byte, error)) error {
 	startTime := time.Now()
 
 	defer func() {
-		result.stats.OptimisticTime = time.Now().Sub(startTime)
+		result.Stats.OptimisticTime = time.Now().Sub(startTime)
 	}()
 
 	for {
-		result.stats.OptimisticTries++
+		result.Stats.OptimisticTries++
 
-		if success, err := v.tryOnce(result, newValue); err != nil {
+		if success, err := v.tryOnce(result, makeValue); err != nil {
 			return err
 		} else if success {
-			result.succeeded = true
+			result.Succeeded = true
 
 			break
-		} else if !v.retryPolicy.AllowRetry(result.stats.OptimisticTries, time.Now().Sub(startTime), curator.DefaultRetrySleeper) {
+		} else if !v.retryPolicy.AllowRetry(result.Stats.OptimisticTries, time.Now().Sub(startTime), curator.DefaultRetrySleeper) {
 			break
 		}
 	}
@@ -242,22 +257,26 @@ func (v *distributedAtomicValue) tryOptimistic(result *mutableAtomicValue, newVa
 	return nil
 }
 
-func (v *distributedAtomicValue) tryOnce(result *mutableAtomicValue, newValue []byte) (bool, error) {
+func (v *distributedAtomicValue) tryOnce(result *AtomicValue[[]byte], makeValue func(preValue []byte) ([]byte, error)) (bool, error) {
 	var stat zk.Stat
 
 	if createIt, err := v.currentValue(result, &stat); err != nil {
 		return false, err
+	} else if newValue, err := makeValue(result.PreValue); err != nil {
+		return false, err
+	} else if len(newValue) > MAX_NODE_DATA_SIZE {
+		return false, ErrValueTooLarge
 	} else {
 		var err error
 
 		if createIt {
-			_, err = v.client.Create().ForPathWithData(v.path, newValue)
+			_, err = v.client.Create().CreatingParentsIfNeeded().ForPathWithData(v.path, newValue)
 		} else {
 			_, err = v.client.SetData().WithVersion(stat.Version).ForPathWithData(v.path, newValue)
 		}
 
 		if err == nil {
-			result.postValue = newValue
+			result.PostValue = newValue
 
 			return true, nil
 		} else if err == zk.ErrNodeExists || err == zk.ErrBadVersion || err == zk.ErrNoNode {
@@ -268,11 +287,11 @@ func (v *distributedAtomicValue) tryOnce(result *mutableAtomicValue, newValue []
 	}
 }
 
-func (v *distributedAtomicValue) tryWithMutex(result *mutableAtomicValue, newValue []byte) error {
+func (v *distributedAtomicValue) tryWithMutex(result *AtomicValue[[]byte], makeValue func(preValue []byte) ([]byte, error)) error {
 	startTime := time.Now()
 
 	defer func() {
-		result.stats.PromotedTime = time.Now().Sub(startTime)
+		result.Stats.PromotedTime = time.Now().Sub(startTime)
 	}()
 
 	if locked, err := v.mutex.AcquireTimeout(v.promotedToLock.maxLockTime); err != nil {
@@ -281,15 +300,15 @@ func (v *distributedAtomicValue) tryWithMutex(result *mutableAtomicValue, newVal
 		defer v.mutex.Release()
 
 		for {
-			result.stats.PromotedTries++
+			result.Stats.PromotedTries++
 
-			if success, err := v.tryOnce(result, newValue); err != nil {
+			if success, err := v.tryOnce(result, makeValue); err != nil {
 				return err
 			} else if success {
-				result.succeeded = true
+				result.Succeeded = true
 
 				break
-			} else if !v.promotedToLock.retryPolicy.AllowRetry(result.stats.PromotedTries, time.Now().Sub(startTime), curator.DefaultRetrySleeper) {
+			} else if !v.promotedToLock.retryPolicy.AllowRetry(result.Stats.PromotedTries, time.Now().Sub(startTime), curator.DefaultRetrySleeper) {
 				break
 			}
 		}
@@ -297,3 +316,149 @@ func (v *distributedAtomicValue) tryWithMutex(result *mutableAtomicValue, newVal
 
 	return nil
 }
+
+// A counter that attempts atomic increments.
+// It first tries using optimistic locking. If that fails, an optional InterProcessMutex is taken.
+// For both optimistic and mutex, a retry policy is used to retry the increment.
+type DistributedAtomicLong struct {
+	value *distributedAtomicValue
+	err   error // the path is invalid or the promoted lock couldn't be created
+}
+
+func NewDistributedAtomicLong(client curator.CuratorFramework, path string, retryPolicy curator.RetryPolicy) *DistributedAtomicLong {
+	return NewDistributedAtomicLongWithLock(client, path, retryPolicy, nil)
+}
+
+func NewDistributedAtomicLongWithLock(client curator.CuratorFramework, path string, retryPolicy curator.RetryPolicy, promotedToLock *PromotedToLock) *DistributedAtomicLong {
+	if value, err := NewDistributedAtomicValueWithLock(client, path, retryPolicy, promotedToLock); err != nil {
+		return &DistributedAtomicLong{err: err}
+	} else {
+		return &DistributedAtomicLong{value: value.(*distributedAtomicValue)}
+	}
+}
+
+// Returns the current value of the counter, a nonexistent counter is 0.
+func (l *DistributedAtomicLong) Get() (AtomicValue[int64], error) {
+	if l.err != nil {
+		return AtomicValue[int64]{}, l.err
+	}
+
+	return l.wrap(l.value.Get())
+}
+
+// Atomically sets the value to the given updated value if the current value == the expected value.
+// Remember to always check AtomicValue.Succeeded.
+func (l *DistributedAtomicLong) CompareAndSet(expectedValue, newValue int64) (AtomicValue[int64], error) {
+	if l.err != nil {
+		return AtomicValue[int64]{}, l.err
+	}
+
+	return l.wrap(l.value.CompareAndSet(longToBytes(expectedValue), longToBytes(newValue)))
+}
+
+// Attempt to atomically set the value to the given value.
+// Remember to always check AtomicValue.Succeeded.
+func (l *DistributedAtomicLong) TrySet(newValue int64) (AtomicValue[int64], error) {
+	if l.err != nil {
+		return AtomicValue[int64]{}, l.err
+	}
+
+	return l.wrap(l.value.TrySet(longToBytes(newValue)))
+}
+
+// Forcibly sets the value of the counter without any guarantees of atomicity.
+func (l *DistributedAtomicLong) Set(newValue int64) error {
+	if l.err != nil {
+		return l.err
+	}
+
+	return l.value.ForceSet(longToBytes(newValue))
+}
+
+// The value will be set if and only iff the node does not exist.
+func (l *DistributedAtomicLong) Initialize(value int64) (bool, error) {
+	if l.err != nil {
+		return false, l.err
+	}
+
+	return l.value.Initialize(longToBytes(value))
+}
+
+// Add 1 to the current value and return the new value information.
+// Remember to always check AtomicValue.Succeeded.
+func (l *DistributedAtomicLong) Increment() (AtomicValue[int64], error) {
+	return l.Add(1)
+}
+
+// Subtract 1 from the current value and return the new value information.
+// Remember to always check AtomicValue.Succeeded.
+func (l *DistributedAtomicLong) Decrement() (AtomicValue[int64], error) {
+	return l.Add(-1)
+}
+
+// Add delta to the current value and return the new value information.
+// Return ErrOverflow if the result doesn't fit in an int64.
+// Remember to always check AtomicValue.Succeeded.
+func (l *DistributedAtomicLong) Add(delta int64) (AtomicValue[int64], error) {
+	if l.err != nil {
+		return AtomicValue[int64]{}, l.err
+	}
+
+	return l.wrap(l.value.trySetWith(func(preValue []byte) ([]byte, error) {
+		n, err := bytesToLong(preValue)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if (delta > 0 && n > math.MaxInt64-delta) || (delta < 0 && n < math.MinInt64-delta) {
+			return nil, ErrOverflow
+		}
+
+		return longToBytes(n + delta), nil
+	}))
+}
+
+// Subtract delta from the current value and return the new value information.
+// Remember to always check AtomicValue.Succeeded.
+func (l *DistributedAtomicLong) Subtract(delta int64) (AtomicValue[int64], error) {
+	if delta == math.MinInt64 {
+		return AtomicValue[int64]{}, ErrOverflow
+	}
+
+	return l.Add(-delta)
+}
+
+func (l *DistributedAtomicLong) wrap(value AtomicValue[[]byte], err error) (AtomicValue[int64], error) {
+	result := AtomicValue[int64]{Stats: value.Stats}
+
+	if err != nil {
+		return result, err
+	} else if result.PreValue, err = bytesToLong(value.PreValue); err != nil {
+		return result, err
+	}
+
+	result.PostValue, _ = bytesToLong(value.PostValue)
+	result.Succeeded = value.Succeeded
+
+	return result, nil
+}
+
+func longToBytes(n int64) []byte {
+	buf := make([]byte, 8)
+
+	binary.BigEndian.PutUint64(buf, uint64(n))
+
+	return buf
+}
+
+func bytesToLong(buf []byte) (int64, error) {
+	switch len(buf) {
+	case 0:
+		return 0, nil // the counter has not been initialized
+	case 8:
+		return int64(binary.BigEndian.Uint64(buf)), nil
+	default:
+		return 0, ErrCorruptedLong
+	}
+}
@@ -1,8 +1,13 @@
 package recipes
 
 import (
+	"math"
 	"testing"
 
+	"github.com/flier/curator.go"
+	"github.com/samuel/go-zookeeper/zk"
+	"github.com/stretchr/testify/mock"
+
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -11,3 +16,104 @@ func TestDistributedAtomicValue(t *testing.T) {
 
 	})
 }
+
+func TestDistributedAtomicLong(t *testing.T) {
+	Convey("Given a DistributedAtomicLong base on path", t, func() {
+		mocks := newMockBuilder(t)
+
+		client := mocks.Build()
+
+		So(client.Start(), ShouldBeNil)
+
+		counter := NewDistributedAtomicLong(client, "/counter", mocks.retryPolicy)
+
+		So(counter, ShouldNotBeNil)
+
+		Convey("When increment a nonexistent counter", func() {
+			mocks.conn.On("Get", "/counter").Return(nil, nil, zk.ErrNoNode).Once()
+			mocks.conn.On("Create", "/counter", longToBytes(1), int32(curator.PERSISTENT), curator.OPEN_ACL_UNSAFE).Return("/counter", nil).Once()
+
+			value, err := counter.Increment()
+
+			Convey("Should create it from 0", func() {
+				So(err, ShouldBeNil)
+				So(value.Succeeded, ShouldBeTrue)
+				So(value.PreValue, ShouldEqual, 0)
+				So(value.PostValue, ShouldEqual, 1)
+
+				mocks.Check(t)
+			})
+		})
+
+		Convey("When the counter was changed concurrently", func() {
+			mocks.conn.On("Get", "/counter").Return(longToBytes(5), &zk.Stat{Version: 1}, nil).Once()
+			mocks.conn.On("Set", "/counter", longToBytes(15), int32(1)).Return(nil, zk.ErrBadVersion).Once()
+			mocks.retryPolicy.On("AllowRetry", 1, mock.Anything, mock.Anything).Return(true).Once()
+			mocks.conn.On("Get", "/counter").Return(longToBytes(6), &zk.Stat{Version: 2}, nil).Once()
+			mocks.conn.On("Set", "/counter", longToBytes(16), int32(2)).Return(&zk.Stat{Version: 3}, nil).Once()
+
+			value, err := counter.Add(10)
+
+			Convey("Should retry with the new value", func() {
+				So(err, ShouldBeNil)
+				So(value.Succeeded, ShouldBeTrue)
+				So(value.PreValue, ShouldEqual, 6)
+				So(value.PostValue, ShouldEqual, 16)
+				So(value.Stats.OptimisticTries, ShouldEqual, 2)
+
+				mocks.Check(t)
+			})
+		})
+
+		Convey("When the counter would overflow", func() {
+			mocks.conn.On("Get", "/counter").Return(longToBytes(math.MaxInt64), &zk.Stat{}, nil).Once()
+
+			value, err := counter.Increment()
+
+			Convey("Should return an error", func() {
+				So(value.Succeeded, ShouldBeFalse)
+				So(err, ShouldEqual, ErrOverflow)
+
+				mocks.Check(t)
+			})
+		})
+
+		Convey("When set the counter", func() {
+			mocks.conn.On("Set", "/counter", longToBytes(42), int32(-1)).Return(&zk.Stat{}, nil).Once()
+
+			err := counter.Set(42)
+
+			Convey("Should write the value whatever the current one", func() {
+				So(err, ShouldBeNil)
+
+				mocks.Check(t)
+			})
+		})
+
+		Convey("When the node data is not a long", func() {
+			mocks.conn.On("Get", "/counter").Return([]byte("abc"), &zk.Stat{}, nil).Once()
+
+			value, err := counter.Get()
+
+			Convey("Should return an error", func() {
+				So(value.Succeeded, ShouldBeFalse)
+				So(err, ShouldEqual, ErrCorruptedLong)
+
+				mocks.Check(t)
+			})
+		})
+
+		Convey("When the path is invalid", func() {
+			counter := NewDistributedAtomicLong(client, "counter", mocks.retryPolicy)
+
+			value, err := counter.Get()
+
+			Convey("Should return the error from the operations", func() {
+				So(counter, ShouldNotBeNil)
+				So(value.Succeeded, ShouldBeFalse)
+				So(err, ShouldNotBeNil)
+				So(counter.Set(1), ShouldEqual, err)
+			})
+		})
+	})
+}
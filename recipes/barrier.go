@@ -0,0 +1,321 @@
+package recipes
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/flier/curator.go"
+	"github.com/samuel/go-zookeeper/zk"
+)
+
+// A barrier as described in the ZK recipes. Quoting the recipe:
+//
+// Distributed systems use barriers to block processing of a set of nodes until a condition is met
+// at which time all the nodes are allowed to proceed.
+type Barrier struct {
+	client      curator.CuratorFramework
+	barrierPath string
+}
+
+func NewBarrier(client curator.CuratorFramework, barrierPath string) *Barrier {
+	return &Barrier{client: client, barrierPath: barrierPath}
+}
+
+// Utility to set the barrier node
+func (b *Barrier) SetBarrier() error {
+	if _, err := b.client.Create().CreatingParentsIfNeeded().ForPath(b.barrierPath); err != nil && err != zk.ErrNodeExists {
+		return err
+	}
+
+	return nil
+}
+
+// Utility to remove the barrier node
+func (b *Barrier) RemoveBarrier() error {
+	if err := b.client.Delete().ForPath(b.barrierPath); err != nil && err != zk.ErrNoNode {
+		return err
+	}
+
+	return nil
+}
+
+// Blocks until the barrier node comes into non-existence or the context is done,
+// it returns immediately if the barrier is not set.
+func (b *Barrier) WaitOnBarrier(ctx context.Context) error {
+	events := make(chan struct{}, 1)
+
+	notify := func() {
+		select {
+		case events <- struct{}{}:
+		default:
+		}
+	}
+
+	// the watch may be lost with the session, check again after reconnection
+	listener := curator.NewConnectionStateListener(func(client curator.CuratorFramework, newState curator.ConnectionState) {
+		if newState == curator.RECONNECTED {
+			notify()
+		}
+	})
+
+	b.client.ConnectionStateListenable().AddListener(listener)
+
+	defer b.client.ConnectionStateListenable().RemoveListener(listener)
+
+	watcher := curator.NewWatcher(func(event *zk.Event) { notify() })
+
+	for {
+		if stat, err := b.client.CheckExists().UsingWatcher(watcher).ForPath(b.barrierPath); err != nil {
+			return err
+		} else if stat == nil {
+			return nil
+		}
+
+		select {
+		case <-events:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+var ErrDoubleBarrierBroken = errors.New("a participant has left the double barrier before it was ready")
+
+const (
+	DOUBLE_BARRIER_READY_NODE  = "ready"
+	DOUBLE_BARRIER_NODE_PREFIX = "member-"
+)
+
+// A double barrier as described in the ZK recipes. Quoting the recipe:
+//
+// Double barriers enable clients to synchronize the beginning and the end of a computation.
+// When enough processes have joined the barrier, processes start their computation
+// and leave the barrier once they have finished.
+//
+// If a participant leaves (e.g. its session expired) while the others are entering,
+// the remaining participants get ErrDoubleBarrierBroken instead of waiting forever.
+type DoubleBarrier struct {
+	client      curator.CuratorFramework
+	barrierPath string
+	memberQty   int
+	readyPath   string
+	lock        sync.Mutex
+	ourPath     string
+}
+
+func NewDoubleBarrier(client curator.CuratorFramework, barrierPath string, memberQty int) *DoubleBarrier {
+	return &DoubleBarrier{
+		client:      client,
+		barrierPath: barrierPath,
+		memberQty:   memberQty,
+		readyPath:   curator.JoinPath(barrierPath, DOUBLE_BARRIER_READY_NODE),
+	}
+}
+
+// Enter the barrier and block until all members have entered or the context is done
+func (b *DoubleBarrier) Enter(ctx context.Context) error {
+	ourPath, err := b.client.Create().CreatingParentsIfNeeded().WithMode(curator.EPHEMERAL_SEQUENTIAL).ForPath(curator.JoinPath(b.barrierPath, DOUBLE_BARRIER_NODE_PREFIX))
+
+	if err != nil {
+		return err
+	}
+
+	b.lock.Lock()
+	b.ourPath = ourPath
+	b.lock.Unlock()
+
+	if err := b.internalEnter(ctx); err != nil {
+		b.deleteOurPath()
+
+		return err
+	}
+
+	return nil
+}
+
+// Leave the barrier and block until all members have left or the context is done
+func (b *DoubleBarrier) Leave(ctx context.Context) error {
+	b.lock.Lock()
+	ourNode := curator.GetNodeFromPath(b.ourPath)
+	b.lock.Unlock()
+
+	events, cancel := b.watchEvents()
+
+	defer cancel()
+
+	watcher := curator.NewWatcher(func(event *zk.Event) { events.notify() })
+
+	for {
+		children, err := b.getChildren(nil)
+
+		if err != nil {
+			return err
+		}
+
+		var nodeToWatch string
+
+		switch {
+		case len(children) == 0:
+			// all the members have left
+		case len(children) == 1 && children[0] == ourNode:
+			// we are the last member
+			if err := b.deleteOurPath(); err != nil {
+				return err
+			}
+		case children[0] == ourNode:
+			// the lowest member leaves last, wait for the highest one
+			nodeToWatch = children[len(children)-1]
+		default:
+			if err := b.deleteOurPath(); err != nil {
+				return err
+			}
+
+			nodeToWatch = children[0]
+		}
+
+		if nodeToWatch == "" {
+			break
+		}
+
+		if stat, err := b.client.CheckExists().UsingWatcher(watcher).ForPath(curator.JoinPath(b.barrierPath, nodeToWatch)); err != nil {
+			return err
+		} else if stat == nil {
+			continue
+		}
+
+		if err := events.wait(ctx); err != nil {
+			return err
+		}
+	}
+
+	if err := b.client.Delete().ForPath(b.readyPath); err != nil && err != zk.ErrNoNode {
+		return err
+	}
+
+	return nil
+}
+
+func (b *DoubleBarrier) internalEnter(ctx context.Context) error {
+	events, cancel := b.watchEvents()
+
+	defer cancel()
+
+	watcher := curator.NewWatcher(func(event *zk.Event) { events.notify() })
+
+	maxSeen := 0
+
+	for {
+		if stat, err := b.client.CheckExists().UsingWatcher(watcher).ForPath(b.readyPath); err != nil {
+			return err
+		} else if stat != nil {
+			return nil
+		}
+
+		children, err := b.getChildren(watcher)
+
+		if err != nil {
+			return err
+		}
+
+		if len(children) < maxSeen {
+			return ErrDoubleBarrierBroken
+		}
+
+		maxSeen = len(children)
+
+		if len(children) >= b.memberQty {
+			if _, err := b.client.Create().ForPath(b.readyPath); err != nil && err != zk.ErrNodeExists {
+				return err
+			}
+
+			return nil
+		}
+
+		if err := events.wait(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// Return the sorted member nodes, excluding the ready node
+func (b *DoubleBarrier) getChildren(watcher curator.Watcher) ([]string, error) {
+	builder := b.client.GetChildren()
+
+	if watcher != nil {
+		builder = builder.UsingWatcher(watcher)
+	}
+
+	children, err := builder.ForPath(b.barrierPath)
+
+	if err == zk.ErrNoNode {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	members := children[:0]
+
+	for _, child := range children {
+		if child != DOUBLE_BARRIER_READY_NODE {
+			members = append(members, child)
+		}
+	}
+
+	sort.Strings(members)
+
+	return members, nil
+}
+
+func (b *DoubleBarrier) deleteOurPath() error {
+	b.lock.Lock()
+	ourPath := b.ourPath
+	b.ourPath = ""
+	b.lock.Unlock()
+
+	if ourPath == "" {
+		return nil
+	}
+
+	if err := b.client.Delete().ForPath(ourPath); err != nil && err != zk.ErrNoNode {
+		return err
+	}
+
+	return nil
+}
+
+// Wake up the waiting loop on the watched events and after reconnection
+func (b *DoubleBarrier) watchEvents() (*barrierEvents, func()) {
+	events := &barrierEvents{make(chan struct{}, 1)}
+
+	listener := curator.NewConnectionStateListener(func(client curator.CuratorFramework, newState curator.ConnectionState) {
+		if newState == curator.RECONNECTED {
+			events.notify()
+		}
+	})
+
+	b.client.ConnectionStateListenable().AddListener(listener)
+
+	return events, func() { b.client.ConnectionStateListenable().RemoveListener(listener) }
+}
+
+type barrierEvents struct {
+	c chan struct{}
+}
+
+func (e *barrierEvents) notify() {
+	select {
+	case e.c <- struct{}{}:
+	default:
+	}
+}
+
+func (e *barrierEvents) wait(ctx context.Context) error {
+	select {
+	case <-e.c:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,172 @@
+package recipes
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/flier/curator.go"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestBarrier(t *testing.T) {
+	Convey("Given a Barrier", t, func() {
+		zookeeper := newFakeZookeeper()
+
+		So(zookeeper.Start(), ShouldBeNil)
+
+		defer zookeeper.Close()
+
+		client := newFakeClient(zookeeper)
+		other := newFakeClient(zookeeper)
+
+		So(client.Start(), ShouldBeNil)
+		So(other.Start(), ShouldBeNil)
+
+		defer client.Close()
+		defer other.Close()
+
+		barrier := NewBarrier(client, "/barriers/test")
+
+		Convey("Should not wait if the barrier is not set", func() {
+			So(barrier.WaitOnBarrier(context.Background()), ShouldBeNil)
+		})
+
+		Convey("When the barrier was set", func() {
+			So(barrier.SetBarrier(), ShouldBeNil)
+			So(barrier.SetBarrier(), ShouldBeNil)
+
+			Convey("Should time out while waiting", func() {
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+
+				defer cancel()
+
+				So(barrier.WaitOnBarrier(ctx), ShouldEqual, context.DeadlineExceeded)
+			})
+
+			Convey("Should unblock all the waiters once removed", func() {
+				var wg sync.WaitGroup
+
+				errs := make(chan error, 4)
+
+				for _, c := range []curator.CuratorFramework{client, client, other, other} {
+					wg.Add(1)
+
+					go func(c curator.CuratorFramework) {
+						defer wg.Done()
+
+						errs <- NewBarrier(c, "/barriers/test").WaitOnBarrier(context.Background())
+					}(c)
+				}
+
+				time.Sleep(10 * time.Millisecond)
+
+				So(NewBarrier(other, "/barriers/test").RemoveBarrier(), ShouldBeNil)
+
+				wg.Wait()
+
+				close(errs)
+
+				for err := range errs {
+					So(err, ShouldBeNil)
+				}
+
+				So(barrier.RemoveBarrier(), ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestDoubleBarrier(t *testing.T) {
+	Convey("Given a DoubleBarrier of three members", t, func() {
+		zookeeper := newFakeZookeeper()
+
+		So(zookeeper.Start(), ShouldBeNil)
+
+		defer zookeeper.Close()
+
+		var clients []curator.CuratorFramework
+
+		for i := 0; i < 3; i++ {
+			client := newFakeClient(zookeeper)
+
+			So(client.Start(), ShouldBeNil)
+
+			defer client.Close()
+
+			clients = append(clients, client)
+		}
+
+		Convey("Should enter and leave together", func() {
+			var wg sync.WaitGroup
+			var lock sync.Mutex
+			var steps []string
+
+			errs := make(chan error, 6)
+
+			for _, client := range clients {
+				wg.Add(1)
+
+				go func(client curator.CuratorFramework) {
+					defer wg.Done()
+
+					barrier := NewDoubleBarrier(client, "/barriers/double", 3)
+
+					errs <- barrier.Enter(context.Background())
+
+					lock.Lock()
+					steps = append(steps, "entered")
+					lock.Unlock()
+
+					errs <- barrier.Leave(context.Background())
+
+					lock.Lock()
+					steps = append(steps, "left")
+					lock.Unlock()
+				}(client)
+			}
+
+			wg.Wait()
+
+			close(errs)
+
+			for err := range errs {
+				So(err, ShouldBeNil)
+			}
+
+			So(steps, ShouldResemble, []string{"entered", "entered", "entered", "left", "left", "left"})
+
+			children, err := clients[0].GetChildren().ForPath("/barriers/double")
+
+			So(children, ShouldBeEmpty)
+			So(err, ShouldBeNil)
+		})
+
+		Convey("When a member failed before the barrier was ready", func() {
+			_, err := clients[1].Create().CreatingParentsIfNeeded().WithMode(curator.EPHEMERAL_SEQUENTIAL).ForPath("/barriers/double/member-")
+
+			So(err, ShouldBeNil)
+
+			entered := make(chan error)
+
+			go func() {
+				entered <- NewDoubleBarrier(clients[0], "/barriers/double", 3).Enter(context.Background())
+			}()
+
+			time.Sleep(10 * time.Millisecond)
+
+			So(clients[1].Close(), ShouldBeNil)
+
+			Convey("Should notify the remaining members", func() {
+				So(<-entered, ShouldEqual, ErrDoubleBarrierBroken)
+
+				children, err := clients[0].GetChildren().ForPath("/barriers/double")
+
+				So(children, ShouldBeEmpty)
+				So(err, ShouldBeNil)
+			})
+		})
+	})
+}
@@ -1,8 +1,13 @@
 package recipes
 
 import (
+	"context"
 	"fmt"
+	"log"
+	"math"
 	"reflect"
+	"sort"
+	"sync"
 	"sync/atomic"
 	"unsafe"
 
@@ -22,6 +27,14 @@ const (
 	INITIALIZED                                  // Posted when PathChildrenCache.Start(StartMode) is called with POST_INITIALIZED_EVENT
 )
 
+// The event types of TreeCache, share the values with the PathChildrenCache ones
+const (
+	NODE_ADDED             = CHILD_ADDED   // A node was added
+	NODE_UPDATED           = CHILD_UPDATED // A node's data was changed
+	NODE_REMOVED           = CHILD_REMOVED // A node was removed from the tree
+	TREE_CACHE_INITIALIZED = INITIALIZED   // Posted after the initial cache has been fully populated
+)
+
 type ChildData struct {
 	Path string
 	Stat *zk.Stat
@@ -42,6 +55,20 @@ type NodeCacheListener interface {
 	NodeChanged() error
 }
 
+type nodeCacheListenerCallback func() error
+
+type nodeCacheListenerStub struct {
+	callback nodeCacheListenerCallback
+}
+
+func NewNodeCacheListener(callback nodeCacheListenerCallback) NodeCacheListener {
+	return &nodeCacheListenerStub{callback}
+}
+
+func (l *nodeCacheListenerStub) NodeChanged() error {
+	return l.callback()
+}
+
 type NodeCacheListenable interface {
 	curator.Listenable /* [T] */
 
@@ -97,14 +124,16 @@ func NewNodeCache(client curator.CuratorFramework, path string, dataIsCompressed
 		path:             path,
 		dataIsCompressed: dataIsCompressed,
 		ensurePath:       client.NewNamespaceAwareEnsurePath(path).ExcludingLast(),
-		listeners:        &NodeCacheListenerContainer{},
+		listeners:        &NodeCacheListenerContainer{&curator.ListenerContainer{}},
 	}
 
+	c.isConnected.Set(true)
+
 	c.connectionStateListener = curator.NewConnectionStateListener(func(client curator.CuratorFramework, newState curator.ConnectionState) {
 		if newState.Connected() {
 			if c.isConnected.CompareAndSwap(false, true) {
 				if err := c.reset(); err != nil {
-					panic(fmt.Errorf("Trying to reset after reconnection, %s", err))
+					log.Printf("fail to reset node cache of %s after reconnection, %s", c.path, err)
 				}
 			}
 		} else {
@@ -113,7 +142,9 @@ func NewNodeCache(client curator.CuratorFramework, path string, dataIsCompressed
 	})
 
 	c.watcher = curator.NewWatcher(func(event *zk.Event) {
-		c.reset()
+		if err := c.reset(); err != nil {
+			log.Printf("fail to reset node cache of %s, %s", c.path, err)
+		}
 	})
 
 	c.backgroundCallback = func(client curator.CuratorFramework, event curator.CuratorEvent) error {
@@ -161,6 +192,25 @@ func (c *NodeCache) NodeCacheListenable() NodeCacheListenable {
 	return c.listeners
 }
 
+// Return the current data. There are no guarantees of accuracy.
+// This is merely the most recent view of the data. If the node does not exist, this returns nil
+func (c *NodeCache) GetCurrentData() *ChildData {
+	return (*ChildData)(atomic.LoadPointer((*unsafe.Pointer)(unsafe.Pointer(&c.data))))
+}
+
+// Return the cached data of the node, only if the path is the cached one and the cache is connected
+func (c *NodeCache) GetCachedData(path string) ([]byte, *zk.Stat, bool) {
+	if path != c.path || c.state.Value() != curator.STARTED || !c.isConnected.Load() {
+		return nil, nil, false
+	}
+
+	if data := c.GetCurrentData(); data != nil {
+		return data.Data, data.Stat, true
+	}
+
+	return nil, nil, false
+}
+
 func (c *NodeCache) internalRebuild() error {
 	var stat zk.Stat
 
@@ -196,9 +246,14 @@ func (c *NodeCache) processBackgroundResult(event curator.CuratorEvent) error {
 	case curator.GET_DATA:
 		if event.Err() == nil {
 			c.setNewData(&ChildData{c.path, event.Stat(), event.Data()})
+		} else if event.Err() == zk.ErrNoNode {
+			// the node was deleted before we could get the data, watch for it again
+			c.setNewData(nil)
+
+			return c.reset()
 		}
 	case curator.EXISTS:
-		if event.Err() == zk.ErrNoNode {
+		if event.Err() == zk.ErrNoNode || (event.Err() == nil && event.Stat() == nil) {
 			c.setNewData(nil)
 		} else if event.Err() == nil {
 			builder := c.client.GetData()
@@ -232,6 +287,112 @@ const (
 	POST_INITIALIZED
 )
 
+// A queue of operations, the operations are executed in order by a background goroutine
+type operationQueue struct {
+	lock       sync.Mutex
+	operations []func() error
+	wakeup     chan struct{}
+}
+
+func newOperationQueue() *operationQueue {
+	return &operationQueue{wakeup: make(chan struct{}, 1)}
+}
+
+func (q *operationQueue) offer(operation func() error) {
+	q.lock.Lock()
+	q.operations = append(q.operations, operation)
+	q.lock.Unlock()
+
+	select {
+	case q.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// An unbounded queue of events, the events are forwarded in order to a channel by a background goroutine,
+// so the operations are never blocked by a slow reader of the channel.
+type eventQueue[T any] struct {
+	lock   sync.Mutex
+	events []T
+	wakeup chan struct{}
+}
+
+func newEventQueue[T any]() *eventQueue[T] {
+	return &eventQueue[T]{wakeup: make(chan struct{}, 1)}
+}
+
+func (q *eventQueue[T]) offer(event T) {
+	q.lock.Lock()
+	q.events = append(q.events, event)
+	q.lock.Unlock()
+
+	select {
+	case q.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// Forward the queued events to the channel until done
+func (q *eventQueue[T]) forward(out chan<- T, done <-chan struct{}) {
+	for {
+		q.lock.Lock()
+		events := q.events
+		q.events = nil
+		q.lock.Unlock()
+
+		for _, event := range events {
+			select {
+			case out <- event:
+			case <-done:
+				return
+			}
+		}
+
+		select {
+		case <-q.wakeup:
+		case <-done:
+			return
+		}
+	}
+}
+
+// Execute the queued operations until done, or the state is not STARTED anymore
+func (q *operationQueue) process(state *curator.State, done <-chan struct{}, onError func(err error)) {
+	for {
+		q.lock.Lock()
+		operations := q.operations
+		q.operations = nil
+		q.lock.Unlock()
+
+		for _, operation := range operations {
+			if state.Value() != curator.STARTED {
+				return
+			}
+
+			if err := operation(); err != nil {
+				onError(err)
+			}
+		}
+
+		select {
+		case <-q.wakeup:
+		case <-done:
+			return
+		}
+	}
+}
+
+// Method of priming cache on PathChildrenCache.Start(StartMode)
+type StartMode int
+
+const (
+	NORMAL                 StartMode = iota // Cache will be primed (in the background) with initial values
+	BUILD_INITIAL_CACHE                     // Start() will block until initial values have been retrieved
+	POST_INITIALIZED_EVENT                  // After cache is primed with initial values (in the background) a INITIALIZED event will be posted
+)
+
+const PATH_CHILDREN_CACHE_QUEUE_SIZE = 64
+
 // A utility that attempts to keep all data from all children of a ZK path locally cached.
 // This class will watch the ZK path, respond to update/create/delete events, pull down the data, etc.
 // You can register a listener that will get notified when changes occur.
@@ -244,6 +405,15 @@ type PathChildrenCache struct {
 	state                   curator.State
 	connectionStateListener curator.ConnectionStateListener
 	isConnected             curator.AtomicBool
+	childrenWatcher         curator.Watcher
+	lock                    sync.RWMutex
+	currentData             map[string]*ChildData
+	watched                 map[string]bool // the children whose data watch is set
+	operations              *operationQueue
+	pendingEvents           *eventQueue[PathChildrenCacheEvent]
+	events                  chan PathChildrenCacheEvent
+	done                    chan struct{}
+	wg                      sync.WaitGroup
 }
 
 func NewPathChildrenCache(client curator.CuratorFramework, path string, cacheData, dataIsCompressed bool) *PathChildrenCache {
@@ -253,32 +423,839 @@ func NewPathChildrenCache(client curator.CuratorFramework, path string, cacheDat
 		cacheData:        cacheData,
 		dataIsCompressed: dataIsCompressed,
 		ensurePath:       client.NewNamespaceAwareEnsurePath(path),
+		currentData:      make(map[string]*ChildData),
+		watched:          make(map[string]bool),
+		operations:       newOperationQueue(),
+		pendingEvents:    newEventQueue[PathChildrenCacheEvent](),
+		events:           make(chan PathChildrenCacheEvent, PATH_CHILDREN_CACHE_QUEUE_SIZE),
+		done:             make(chan struct{}),
 	}
 
+	c.isConnected.Set(true)
+
 	c.connectionStateListener = curator.NewConnectionStateListener(func(client curator.CuratorFramework, newState curator.ConnectionState) {
-		if newState.Connected() {
-			if c.isConnected.CompareAndSwap(false, true) {
-				/*
-					if err := c.reset(); err != nil {
-						panic(fmt.Errorf("Trying to reset after reconnection, %s", err))
-					}
-				*/
+		c.handleStateChange(newState)
+	})
+
+	c.childrenWatcher = curator.NewWatcher(func(event *zk.Event) {
+		c.offerOperation(func() error { return c.refresh(STANDARD) })
+	})
+
+	return c
+}
+
+// Start the cache. The cache is not started automatically. You must call this method.
+func (c *PathChildrenCache) Start(mode StartMode) error {
+	if !c.state.Change(curator.LATENT, curator.STARTED) {
+		return fmt.Errorf("Cannot be started more than once")
+	}
+
+	c.client.ConnectionStateListenable().AddListener(c.connectionStateListener)
+
+	switch mode {
+	case BUILD_INITIAL_CACHE:
+		if err := c.rebuild(); err != nil {
+			c.client.ConnectionStateListenable().RemoveListener(c.connectionStateListener)
+
+			c.state.Change(curator.STARTED, curator.LATENT)
+
+			return err
+		}
+	case POST_INITIALIZED_EVENT:
+		c.offerOperation(func() error { return c.refresh(POST_INITIALIZED) })
+	default:
+		c.offerOperation(func() error { return c.refresh(STANDARD) })
+	}
+
+	c.wg.Add(2)
+
+	go c.processOperations()
+	go c.forwardEvents()
+
+	return nil
+}
+
+// Close/end the cache, the events channel will be closed
+func (c *PathChildrenCache) Close() error {
+	if c.state.Change(curator.STARTED, curator.STOPPED) {
+		c.client.ConnectionStateListenable().RemoveListener(c.connectionStateListener)
+
+		close(c.done)
+
+		c.wg.Wait()
+
+		close(c.events)
+	}
+
+	return nil
+}
+
+// Return the channel that receives the cache events.
+// The events are queued without limit until received, so the channel should be drained as long as the cache is started.
+func (c *PathChildrenCache) Events() <-chan PathChildrenCacheEvent {
+	return c.events
+}
+
+// Return the current data, sorted by the full path.
+// There are no guarantees of accuracy. This is merely the most recent view of the data.
+func (c *PathChildrenCache) GetCurrentData() []ChildData {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	paths := make([]string, 0, len(c.currentData))
+
+	for path := range c.currentData {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+
+	data := make([]ChildData, len(paths))
+
+	for i, path := range paths {
+		data[i] = *c.currentData[path]
+	}
+
+	return data
+}
+
+// Return the current data for the given full path, or nil if the node is not in the cache.
+func (c *PathChildrenCache) GetCurrentDataForPath(fullPath string) *ChildData {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.currentData[fullPath]
+}
+
+// Queue a refresh of the children in the given mode, e.g. FORCE_GET_DATA_AND_STAT to fetch the data of all the children
+func (c *PathChildrenCache) RefreshMode(mode RefreshMode) {
+	c.offerOperation(func() error { return c.refresh(mode) })
+}
+
+// Clear out current data and begin a new query on the path
+func (c *PathChildrenCache) Rebuild() error {
+	if c.state.Value() != curator.STARTED {
+		return fmt.Errorf("cache has been closed")
+	}
+
+	return c.rebuild()
+}
+
+func (c *PathChildrenCache) rebuild() error {
+	if err := c.ensurePath.Ensure(c.client.ZookeeperClient()); err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	c.currentData = make(map[string]*ChildData)
+	c.lock.Unlock()
+
+	return c.processChildren(FORCE_GET_DATA_AND_STAT, false)
+}
+
+func (c *PathChildrenCache) handleStateChange(newState curator.ConnectionState) {
+	switch newState {
+	case curator.SUSPENDED:
+		c.offerEvent(PathChildrenCacheEvent{Type: CONNECTION_SUSPENDED})
+	case curator.LOST:
+		c.isConnected.Set(false)
+
+		// the watches are gone with the session
+		c.lock.Lock()
+		c.watched = make(map[string]bool)
+		c.lock.Unlock()
+
+		c.offerEvent(PathChildrenCacheEvent{Type: CONNECTION_LOST})
+	case curator.CONNECTED, curator.RECONNECTED:
+		c.isConnected.Set(true)
+
+		// the watches may have been lost, refresh all the children
+		c.offerOperation(func() error { return c.refresh(FORCE_GET_DATA_AND_STAT) })
+
+		c.offerEvent(PathChildrenCacheEvent{Type: CONNECTION_RECONNECTED})
+	}
+}
+
+func (c *PathChildrenCache) offerOperation(operation func() error) {
+	if c.state.Value() != curator.STOPPED {
+		c.operations.offer(operation)
+	}
+}
+
+// Queue an event behind the pending operations, to keep the events in order
+func (c *PathChildrenCache) offerEvent(event PathChildrenCacheEvent) {
+	c.offerOperation(func() error {
+		c.postEvent(event)
+
+		return nil
+	})
+}
+
+func (c *PathChildrenCache) postEvent(event PathChildrenCacheEvent) {
+	c.pendingEvents.offer(event)
+}
+
+func (c *PathChildrenCache) forwardEvents() {
+	defer c.wg.Done()
+
+	c.pendingEvents.forward(c.events, c.done)
+}
+
+func (c *PathChildrenCache) processOperations() {
+	defer c.wg.Done()
+
+	c.operations.process(&c.state, c.done, func(err error) {
+		log.Printf("fail to refresh the children cache of %s, %s", c.path, err)
+	})
+}
+
+func (c *PathChildrenCache) refresh(mode RefreshMode) error {
+	if !c.isConnected.Load() {
+		return nil
+	}
+
+	if err := c.ensurePath.Ensure(c.client.ZookeeperClient()); err != nil {
+		return err
+	}
+
+	return c.processChildren(mode, true)
+}
+
+func (c *PathChildrenCache) processChildren(mode RefreshMode, notify bool) error {
+	children, err := c.client.GetChildren().UsingWatcher(c.childrenWatcher).ForPath(c.path)
+
+	if err != nil {
+		return err
+	}
+
+	fullPaths := make(map[string]bool)
+
+	for _, child := range children {
+		fullPaths[curator.JoinPath(c.path, child)] = true
+	}
+
+	c.lock.RLock()
+
+	var removedPaths []string
+
+	for fullPath := range c.currentData {
+		if !fullPaths[fullPath] {
+			removedPaths = append(removedPaths, fullPath)
+		}
+	}
+
+	c.lock.RUnlock()
+
+	sort.Strings(removedPaths)
+
+	for _, fullPath := range removedPaths {
+		c.remove(fullPath, notify)
+	}
+
+	sort.Strings(children)
+
+	for _, child := range children {
+		fullPath := curator.JoinPath(c.path, child)
+
+		if mode == FORCE_GET_DATA_AND_STAT || c.GetCurrentDataForPath(fullPath) == nil {
+			if err := c.getDataAndStat(fullPath, notify); err != nil {
+				return err
 			}
-		} else {
-			c.isConnected.Set(false)
 		}
+	}
+
+	if mode == POST_INITIALIZED && notify {
+		c.postEvent(PathChildrenCacheEvent{Type: INITIALIZED})
+	}
+
+	return nil
+}
+
+// Fetch the data of the child, the data watch is only set if the child is not watched yet
+func (c *PathChildrenCache) getDataAndStat(fullPath string, notify bool) error {
+	c.lock.Lock()
+	watch := !c.watched[fullPath]
+	c.watched[fullPath] = true
+	c.lock.Unlock()
+
+	var watcher curator.Watcher
+
+	if watch {
+		watcher = c.newDataWatcher(fullPath)
+	}
+
+	var stat zk.Stat
+	var data []byte
+	var err error
+
+	if c.cacheData {
+		builder := c.client.GetData()
+
+		if c.dataIsCompressed {
+			builder.Decompressed()
+		}
+
+		if watcher != nil {
+			builder.UsingWatcher(watcher)
+		}
+
+		data, err = builder.StoringStatIn(&stat).ForPath(fullPath)
+	} else {
+		var exists *zk.Stat
+
+		builder := c.client.CheckExists()
+
+		if watcher != nil {
+			builder.UsingWatcher(watcher)
+		}
+
+		if exists, err = builder.ForPath(fullPath); err == nil {
+			if exists == nil {
+				err = zk.ErrNoNode
+			} else {
+				stat = *exists
+			}
+		}
+	}
+
+	if err != nil && watch {
+		// the data watch is not set if the child couldn't be read
+		c.lock.Lock()
+		delete(c.watched, fullPath)
+		c.lock.Unlock()
+	}
+
+	if err == zk.ErrNoNode {
+		c.remove(fullPath, notify)
+
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	newData := &ChildData{fullPath, &stat, data}
+
+	c.lock.Lock()
+	previousData := c.currentData[fullPath]
+	c.currentData[fullPath] = newData
+	c.lock.Unlock()
+
+	if notify {
+		if previousData == nil {
+			c.postEvent(PathChildrenCacheEvent{Type: CHILD_ADDED, Data: *newData})
+		} else if previousData.Stat.Mzxid != stat.Mzxid {
+			c.postEvent(PathChildrenCacheEvent{Type: CHILD_UPDATED, Data: *newData})
+		}
+	}
+
+	return nil
+}
+
+// The data watch of a child is consumed by its first event
+func (c *PathChildrenCache) newDataWatcher(fullPath string) curator.Watcher {
+	return curator.NewWatcher(func(event *zk.Event) {
+		c.lock.Lock()
+		delete(c.watched, fullPath)
+		c.lock.Unlock()
+
+		switch event.Type {
+		case zk.EventNodeDataChanged:
+			c.offerOperation(func() error { return c.getDataAndStat(fullPath, true) })
+		case zk.EventNodeDeleted:
+			c.offerOperation(func() error {
+				c.remove(fullPath, true)
+
+				return nil
+			})
+		}
+	})
+}
+
+func (c *PathChildrenCache) remove(fullPath string, notify bool) {
+	c.lock.Lock()
+	previousData, exists := c.currentData[fullPath]
+	delete(c.currentData, fullPath)
+	c.lock.Unlock()
+
+	if exists && notify {
+		c.postEvent(PathChildrenCacheEvent{Type: CHILD_REMOVED, Data: *previousData})
+	}
+}
+
+const DEFAULT_TREE_CACHE_MAX_DEPTH = math.MaxInt32
+
+type treeNode struct {
+	data     *ChildData
+	children map[string]bool
+}
+
+// A utility that attempts to keep all data from all children of a ZK path locally cached.
+// This class will watch the ZK path, respond to update/create/delete events, pull down the data, etc.
+// Each node of the subtree is watched with GetW and each level with ChildrenW.
+type TreeCache struct {
+	client                  curator.CuratorFramework
+	root                    string
+	cacheData               bool
+	dataIsCompressed        bool
+	maxDepth                int
+	state                   curator.State
+	connectionStateListener curator.ConnectionStateListener
+	isConnected             curator.AtomicBool
+	lock                    sync.RWMutex
+	nodes                   map[string]*treeNode
+	operations              *operationQueue
+	pendingEvents           *eventQueue[TreeCacheEvent]
+	events                  chan TreeCacheEvent
+	initialized             chan struct{}
+	initFailed              chan struct{}
+	initErr                 error
+	done                    chan struct{}
+	wg                      sync.WaitGroup
+}
+
+func NewTreeCache(client curator.CuratorFramework, path string, cacheData, dataIsCompressed bool) *TreeCache {
+	c := &TreeCache{
+		client:           client,
+		root:             path,
+		cacheData:        cacheData,
+		dataIsCompressed: dataIsCompressed,
+		maxDepth:         DEFAULT_TREE_CACHE_MAX_DEPTH,
+		nodes:            make(map[string]*treeNode),
+		operations:       newOperationQueue(),
+		pendingEvents:    newEventQueue[TreeCacheEvent](),
+		events:           make(chan TreeCacheEvent, PATH_CHILDREN_CACHE_QUEUE_SIZE),
+		initialized:      make(chan struct{}),
+		initFailed:       make(chan struct{}),
+		done:             make(chan struct{}),
+	}
+
+	c.isConnected.Set(true)
+
+	c.connectionStateListener = curator.NewConnectionStateListener(func(client curator.CuratorFramework, newState curator.ConnectionState) {
+		c.handleStateChange(newState)
 	})
 
 	return c
 }
 
-func (c *PathChildrenCache) RefreshMode(mode RefreshMode) {
-	c.ensurePath.Ensure(c.client.ZookeeperClient())
-	/*
-		c.client.GetChildren().UsingWatcher(c.childrenWatcher).InBackground(func(client CuratorFramework, event CuratorEvent) error {
-			if c.state.Value() == STOPPED {
+// Set the maximum depth to explore/watch, 0 means only the root node. Must be called before Start()
+func (c *TreeCache) MaxDepth(depth int) *TreeCache {
+	c.maxDepth = depth
+
+	return c
+}
+
+// Start the cache. The cache is not started automatically. You must call this method.
+func (c *TreeCache) Start() error {
+	if err := curator.ValidatePath(c.root); err != nil {
+		return err
+	}
+
+	if !c.state.Change(curator.LATENT, curator.STARTED) {
+		return fmt.Errorf("Cannot be started more than once")
+	}
+
+	c.client.ConnectionStateListenable().AddListener(c.connectionStateListener)
+
+	c.offerOperation(func() error {
+		if err := c.refreshNode(c.root, 0); err != nil {
+			c.initErr = err
+
+			close(c.initFailed)
+
+			return err
+		}
+
+		close(c.initialized)
+
+		c.postEvent(TreeCacheEvent{Type: TREE_CACHE_INITIALIZED})
+
+		return nil
+	})
+
+	c.wg.Add(2)
+
+	go func() {
+		defer c.wg.Done()
+
+		c.operations.process(&c.state, c.done, func(err error) {
+			log.Printf("fail to refresh the tree cache of %s, %s", c.root, err)
+		})
+	}()
+
+	go func() {
+		defer c.wg.Done()
+
+		c.pendingEvents.forward(c.events, c.done)
+	}()
+
+	return nil
+}
+
+// Close/end the cache, the events channel will be closed
+func (c *TreeCache) Close() error {
+	if c.state.Change(curator.STARTED, curator.STOPPED) {
+		c.client.ConnectionStateListenable().RemoveListener(c.connectionStateListener)
+
+		close(c.done)
+
+		c.wg.Wait()
+
+		close(c.events)
+	}
+
+	return nil
+}
+
+// Return the channel that receives the cache events.
+// The events are queued without limit until received, so the channel should be drained as long as the cache is started.
+func (c *TreeCache) Events() <-chan TreeCacheEvent {
+	return c.events
+}
+
+// Block until the whole tree has been loaded once, the context is done or the cache is closed.
+// Return the error if the initial load failed.
+func (c *TreeCache) WaitForInitialCache(ctx context.Context) error {
+	select {
+	case <-c.initialized:
+		return nil
+	case <-c.initFailed:
+		return c.initErr
+	case <-c.done:
+		return fmt.Errorf("cache has been closed")
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Return the current data for the given full path, or nil if the node is not in the cache.
+func (c *TreeCache) GetCurrentData(fullPath string) *ChildData {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if node, exists := c.nodes[fullPath]; exists {
+		return node.data
+	}
+
+	return nil
+}
+
+// Return the current set of children at the given full path, mapped by child name.
+// Return nil if the node is not in the cache.
+func (c *TreeCache) GetCurrentChildren(fullPath string) map[string]*ChildData {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	node, exists := c.nodes[fullPath]
+
+	if !exists {
+		return nil
+	}
+
+	children := make(map[string]*ChildData)
+
+	for child := range node.children {
+		if childNode, exists := c.nodes[curator.JoinPath(fullPath, child)]; exists {
+			children[child] = childNode.data
+		}
+	}
+
+	return children
+}
+
+func (c *TreeCache) handleStateChange(newState curator.ConnectionState) {
+	switch newState {
+	case curator.SUSPENDED:
+		c.offerEvent(TreeCacheEvent{Type: CONNECTION_SUSPENDED})
+	case curator.LOST:
+		c.isConnected.Set(false)
+
+		c.offerEvent(TreeCacheEvent{Type: CONNECTION_LOST})
+	case curator.CONNECTED, curator.RECONNECTED:
+		c.isConnected.Set(true)
+
+		// the watches may have been lost, refresh the whole tree
+		c.offerOperation(func() error { return c.wasReconnected(c.root, 0) })
+
+		c.offerEvent(TreeCacheEvent{Type: CONNECTION_RECONNECTED})
+	}
+}
+
+func (c *TreeCache) offerOperation(operation func() error) {
+	if c.state.Value() != curator.STOPPED {
+		c.operations.offer(operation)
+	}
+}
+
+func (c *TreeCache) offerEvent(event TreeCacheEvent) {
+	c.offerOperation(func() error {
+		c.postEvent(event)
+
+		return nil
+	})
+}
+
+func (c *TreeCache) postEvent(event TreeCacheEvent) {
+	c.pendingEvents.offer(event)
+}
+
+func (c *TreeCache) refreshNode(fullPath string, depth int) error {
+	if !c.isConnected.Load() {
+		return nil
+	}
+
+	if exists, err := c.refreshData(fullPath, depth); err != nil || !exists {
+		return err
+	}
+
+	return c.refreshChildren(fullPath, depth)
+}
+
+// Refresh the node and all the nodes already in the tree below it, the new children are loaded by refreshNode
+func (c *TreeCache) wasReconnected(fullPath string, depth int) error {
+	c.lock.RLock()
+
+	var children []string
 
+	if node, exists := c.nodes[fullPath]; exists {
+		for child := range node.children {
+			children = append(children, child)
+		}
+	}
+
+	c.lock.RUnlock()
+
+	if err := c.refreshNode(fullPath, depth); err != nil {
+		return err
+	}
+
+	sort.Strings(children)
+
+	for _, child := range children {
+		c.lock.RLock()
+		node, exists := c.nodes[fullPath]
+		exists = exists && node.children[child]
+		c.lock.RUnlock()
+
+		if exists {
+			if err := c.wasReconnected(curator.JoinPath(fullPath, child), depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Watch the children of a node, the deletion of the node is handled by the data watcher
+func (c *TreeCache) newChildrenWatcher(fullPath string, depth int) curator.Watcher {
+	return curator.NewWatcher(func(event *zk.Event) {
+		if event.Type == zk.EventNodeChildrenChanged {
+			c.offerOperation(func() error { return c.refreshChildren(fullPath, depth) })
+		}
+	})
+}
+
+func (c *TreeCache) newWatcher(fullPath string, depth int) curator.Watcher {
+	return curator.NewWatcher(func(event *zk.Event) {
+		switch event.Type {
+		case zk.EventNodeCreated:
+			c.offerOperation(func() error { return c.refreshNode(fullPath, depth) })
+		case zk.EventNodeDataChanged:
+			c.offerOperation(func() error {
+				_, err := c.refreshData(fullPath, depth)
+
+				return err
+			})
+		case zk.EventNodeDeleted:
+			c.offerOperation(func() error {
+				c.removeNode(fullPath)
+
+				if fullPath == c.root {
+					_, err := c.refreshData(fullPath, depth) // watch for the root to be re-created
+
+					return err
+				}
+
+				return nil
+			})
+		}
+	})
+}
+
+// Fetch and watch the data of a node, return false if the node doesn't exist
+func (c *TreeCache) refreshData(fullPath string, depth int) (bool, error) {
+	watcher := c.newWatcher(fullPath, depth)
+
+	var stat zk.Stat
+	var data []byte
+	var err error
+
+	if c.cacheData {
+		builder := c.client.GetData()
+
+		if c.dataIsCompressed {
+			builder.Decompressed()
+		}
+
+		data, err = builder.StoringStatIn(&stat).UsingWatcher(watcher).ForPath(fullPath)
+	} else {
+		var exists *zk.Stat
+
+		if exists, err = c.client.CheckExists().UsingWatcher(watcher).ForPath(fullPath); err == nil {
+			if exists == nil {
+				err = zk.ErrNoNode
+			} else {
+				stat = *exists
+			}
+		}
+	}
+
+	if err == zk.ErrNoNode {
+		c.removeNode(fullPath)
+
+		if fullPath == c.root && c.cacheData {
+			// watch for the root to be created
+			if stat, err := c.client.CheckExists().UsingWatcher(watcher).ForPath(fullPath); err != nil || stat == nil {
+				return false, err
 			}
-		}).ForPath(c.path)
-	*/
+
+			return c.refreshData(fullPath, depth)
+		}
+
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	newData := &ChildData{fullPath, &stat, data}
+
+	c.lock.Lock()
+
+	node, exists := c.nodes[fullPath]
+
+	if !exists {
+		node = &treeNode{children: make(map[string]bool)}
+
+		c.nodes[fullPath] = node
+	}
+
+	previousData := node.data
+	node.data = newData
+
+	c.lock.Unlock()
+
+	if previousData == nil {
+		c.postEvent(TreeCacheEvent{Type: NODE_ADDED, Data: *newData})
+	} else if previousData.Stat.Mzxid != stat.Mzxid {
+		c.postEvent(TreeCacheEvent{Type: NODE_UPDATED, Data: *newData})
+	}
+
+	return true, nil
+}
+
+// Fetch and watch the children of a node, load the new children and remove the deleted ones
+func (c *TreeCache) refreshChildren(fullPath string, depth int) error {
+	if depth >= c.maxDepth {
+		return nil
+	}
+
+	children, err := c.client.GetChildren().UsingWatcher(c.newChildrenWatcher(fullPath, depth)).ForPath(fullPath)
+
+	if err == zk.ErrNoNode {
+		c.removeNode(fullPath)
+
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	sort.Strings(children)
+
+	current := make(map[string]bool)
+
+	for _, child := range children {
+		current[child] = true
+	}
+
+	c.lock.Lock()
+
+	node, exists := c.nodes[fullPath]
+
+	if !exists {
+		c.lock.Unlock()
+
+		return nil
+	}
+
+	var removed, added []string
+
+	for child := range node.children {
+		if !current[child] {
+			removed = append(removed, child)
+		}
+	}
+
+	for _, child := range children {
+		if !node.children[child] {
+			added = append(added, child)
+		}
+	}
+
+	node.children = current
+
+	c.lock.Unlock()
+
+	sort.Strings(removed)
+
+	for _, child := range removed {
+		c.removeNode(curator.JoinPath(fullPath, child))
+	}
+
+	for _, child := range added {
+		if err := c.refreshNode(curator.JoinPath(fullPath, child), depth+1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Remove the node and all its descendants from the cache, the deepest nodes are removed first
+func (c *TreeCache) removeNode(fullPath string) {
+	c.lock.Lock()
+
+	node, exists := c.nodes[fullPath]
+
+	if !exists {
+		c.lock.Unlock()
+
+		return
+	}
+
+	delete(c.nodes, fullPath)
+
+	children := make([]string, 0, len(node.children))
+
+	for child := range node.children {
+		children = append(children, child)
+	}
+
+	if parentAndNode, err := curator.SplitPath(fullPath); err == nil {
+		if parent, exists := c.nodes[parentAndNode.Path]; exists {
+			delete(parent.children, parentAndNode.Node)
+		}
+	}
+
+	c.lock.Unlock()
+
+	sort.Strings(children)
+
+	for _, child := range children {
+		c.removeNode(curator.JoinPath(fullPath, child))
+	}
+
+	if node.data != nil {
+		c.postEvent(TreeCacheEvent{Type: NODE_REMOVED, Data: *node.data})
+	}
 }
@@ -0,0 +1,393 @@
+package recipes
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/flier/curator.go"
+	"github.com/samuel/go-zookeeper/zk"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestNodeCache(t *testing.T) {
+	Convey("Given a NodeCache base on a nonexistent node", t, func() {
+		mocks := newMockBuilder(t)
+
+		client := mocks.Build()
+
+		So(client.Start(), ShouldBeNil)
+
+		existsEvents := make(chan zk.Event)
+		dataEvents := make(chan zk.Event)
+		changed := make(chan struct{})
+
+		mocks.conn.On("ExistsW", "/node").Return(false, nil, existsEvents, nil).Once()
+
+		cache := NewNodeCache(client, "/node", false)
+
+		cache.NodeCacheListenable().AddListener(NewNodeCacheListener(func() error {
+			changed <- struct{}{}
+
+			return nil
+		}))
+
+		So(cache.Start(), ShouldBeNil)
+		So(cache.GetCurrentData(), ShouldBeNil)
+
+		_, _, ok := cache.GetCachedData("/node")
+
+		So(ok, ShouldBeFalse)
+
+		Convey("When the node was created", func() {
+			stat := &zk.Stat{Version: 1}
+
+			mocks.conn.On("ExistsW", "/node").Return(true, stat, existsEvents, nil).Once()
+			mocks.conn.On("GetW", "/node").Return([]byte("data"), stat, dataEvents, nil).Once()
+
+			existsEvents <- zk.Event{Type: zk.EventNodeCreated, Path: "/node"}
+
+			<-changed
+
+			Convey("Should cache the node data", func() {
+				data := cache.GetCurrentData()
+
+				So(data, ShouldNotBeNil)
+				So(data.Path, ShouldEqual, "/node")
+				So(data.Data, ShouldResemble, []byte("data"))
+				So(data.Stat, ShouldEqual, stat)
+
+				// read from the cache without a network call
+				var cachedStat zk.Stat
+
+				cached, err := client.GetData().EventuallyConsistent().WithCache(cache).StoringStatIn(&cachedStat).ForPath("/node")
+
+				So(cached, ShouldResemble, []byte("data"))
+				So(cachedStat, ShouldResemble, *stat)
+				So(err, ShouldBeNil)
+
+				_, _, ok := cache.GetCachedData("/other")
+
+				So(ok, ShouldBeFalse)
+
+				Convey("When the node was deleted", func() {
+					mocks.conn.On("ExistsW", "/node").Return(false, nil, existsEvents, nil).Once()
+
+					dataEvents <- zk.Event{Type: zk.EventNodeDeleted, Path: "/node"}
+
+					<-changed
+
+					Convey("Should clean the cached data", func() {
+						So(cache.GetCurrentData(), ShouldBeNil)
+						So(cache.Close(), ShouldBeNil)
+
+						mocks.Check(t)
+					})
+				})
+			})
+		})
+	})
+}
+
+func TestPathChildrenCache(t *testing.T) {
+	Convey("Given a PathChildrenCache base on a path", t, func() {
+		mocks := newMockBuilder(t)
+
+		client := mocks.Build()
+
+		So(client.Start(), ShouldBeNil)
+
+		childrenEvents := make(chan zk.Event)
+		dataEvents := make(chan zk.Event)
+		stat := &zk.Stat{Mzxid: 1}
+
+		mocks.conn.On("Exists", "/parent").Return(true, nil, nil).Once()
+		mocks.conn.On("ChildrenW", "/parent").Return([]string{"child"}, &zk.Stat{}, childrenEvents, nil).Once()
+		mocks.conn.On("GetW", "/parent/child").Return([]byte("data"), stat, dataEvents, nil).Once()
+
+		cache := NewPathChildrenCache(client, "/parent", true, false)
+
+		Convey("When start with the initial build", func() {
+			So(cache.Start(BUILD_INITIAL_CACHE), ShouldBeNil)
+
+			Convey("Should cache the children without events", func() {
+				So(cache.GetCurrentData(), ShouldResemble, []ChildData{{"/parent/child", stat, []byte("data")}})
+				So(cache.GetCurrentDataForPath("/parent/child"), ShouldNotBeNil)
+				So(cache.Close(), ShouldBeNil)
+
+				_, ok := <-cache.Events()
+
+				So(ok, ShouldBeFalse)
+
+				mocks.Check(t)
+			})
+
+			Convey("When the connection was reconnected", func() {
+				mocks.conn.On("ChildrenW", "/parent").Return([]string{"child"}, &zk.Stat{}, childrenEvents, nil).Once()
+				mocks.conn.On("Get", "/parent/child").Return([]byte("data"), stat, nil).Once()
+
+				cache.connectionStateListener.StateChanged(client, curator.RECONNECTED)
+
+				Convey("Should refresh the data without watching the child again", func() {
+					So((<-cache.Events()).Type, ShouldEqual, CONNECTION_RECONNECTED)
+					So(cache.Close(), ShouldBeNil)
+
+					mocks.Check(t)
+				})
+			})
+
+			Convey("When refreshed with a mode", func() {
+				mocks.conn.On("ChildrenW", "/parent").Return([]string{"child"}, &zk.Stat{}, childrenEvents, nil).Once()
+				mocks.conn.On("Get", "/parent/child").Return([]byte("new"), &zk.Stat{Mzxid: 2}, nil).Once()
+
+				cache.RefreshMode(FORCE_GET_DATA_AND_STAT)
+
+				Convey("Should fetch the data of all the children", func() {
+					event := <-cache.Events()
+
+					So(event.Type, ShouldEqual, CHILD_UPDATED)
+					So(event.Data.Data, ShouldResemble, []byte("new"))
+					So(cache.Close(), ShouldBeNil)
+
+					mocks.Check(t)
+				})
+			})
+		})
+
+		Convey("When start with the initialized event", func() {
+			So(cache.Start(POST_INITIALIZED_EVENT), ShouldBeNil)
+
+			Convey("Should receive the added children and the initialized event", func() {
+				event := <-cache.Events()
+
+				So(event.Type, ShouldEqual, CHILD_ADDED)
+				So(event.Data.Path, ShouldEqual, "/parent/child")
+				So(event.Data.Data, ShouldResemble, []byte("data"))
+				So((<-cache.Events()).Type, ShouldEqual, INITIALIZED)
+
+				Convey("When the child data was changed", func() {
+					mocks.conn.On("GetW", "/parent/child").Return([]byte("new"), &zk.Stat{Mzxid: 2}, dataEvents, nil).Once()
+
+					dataEvents <- zk.Event{Type: zk.EventNodeDataChanged, Path: "/parent/child"}
+
+					event := <-cache.Events()
+
+					So(event.Type, ShouldEqual, CHILD_UPDATED)
+					So(event.Data.Data, ShouldResemble, []byte("new"))
+
+					Convey("When the child was removed", func() {
+						mocks.conn.On("ChildrenW", "/parent").Return([]string{}, &zk.Stat{}, childrenEvents, nil).Once()
+
+						childrenEvents <- zk.Event{Type: zk.EventNodeChildrenChanged, Path: "/parent"}
+
+						event := <-cache.Events()
+
+						So(event.Type, ShouldEqual, CHILD_REMOVED)
+						So(event.Data.Path, ShouldEqual, "/parent/child")
+						So(cache.GetCurrentData(), ShouldBeEmpty)
+						So(cache.Close(), ShouldBeNil)
+
+						mocks.Check(t)
+					})
+				})
+			})
+		})
+	})
+
+	Convey("Given a PathChildrenCache fails to build the initial cache", t, func() {
+		mocks := newMockBuilder(t)
+
+		client := mocks.Build()
+
+		So(client.Start(), ShouldBeNil)
+
+		mocks.conn.On("Exists", "/parent").Return(true, nil, nil)
+		mocks.conn.On("ChildrenW", "/parent").Return(nil, nil, nil, zk.ErrNoAuth).Once()
+
+		cache := NewPathChildrenCache(client, "/parent", true, false)
+
+		So(cache.Start(BUILD_INITIAL_CACHE), ShouldEqual, zk.ErrNoAuth)
+
+		Convey("When start it again", func() {
+			mocks.conn.On("ChildrenW", "/parent").Return([]string{}, &zk.Stat{}, make(chan zk.Event), nil).Once()
+
+			err := cache.Start(BUILD_INITIAL_CACHE)
+
+			Convey("Should build the cache", func() {
+				So(err, ShouldBeNil)
+				So(cache.GetCurrentData(), ShouldBeEmpty)
+				So(cache.Close(), ShouldBeNil)
+
+				mocks.Check(t)
+			})
+		})
+	})
+}
+
+func TestTreeCache(t *testing.T) {
+	Convey("Given a TreeCache base on a subtree", t, func() {
+		mocks := newMockBuilder(t)
+
+		client := mocks.Build()
+
+		So(client.Start(), ShouldBeNil)
+
+		rootEvents := make(chan zk.Event)
+		childEvents := make(chan zk.Event)
+		grandchildEvents := make(chan zk.Event)
+		rootStat := &zk.Stat{Mzxid: 1}
+		childStat := &zk.Stat{Mzxid: 2}
+
+		mocks.conn.On("GetW", "/root").Return([]byte("root"), rootStat, rootEvents, nil).Once()
+
+		cache := NewTreeCache(client, "/root", true, false)
+
+		Convey("When the depth is limited to the root", func() {
+			So(cache.MaxDepth(0).Start(), ShouldBeNil)
+
+			Convey("Should only cache the root", func() {
+				So(cache.WaitForInitialCache(context.Background()), ShouldBeNil)
+
+				event := <-cache.Events()
+
+				So(event.Type, ShouldEqual, NODE_ADDED)
+				So(event.Data.Path, ShouldEqual, "/root")
+				So((<-cache.Events()).Type, ShouldEqual, TREE_CACHE_INITIALIZED)
+				So(cache.GetCurrentChildren("/root"), ShouldBeEmpty)
+				So(cache.Close(), ShouldBeNil)
+
+				mocks.Check(t)
+			})
+		})
+
+		Convey("When the whole tree is cached", func() {
+			mocks.conn.On("ChildrenW", "/root").Return([]string{"child"}, rootStat, rootEvents, nil).Once()
+			mocks.conn.On("GetW", "/root/child").Return([]byte("child"), childStat, childEvents, nil).Once()
+			mocks.conn.On("ChildrenW", "/root/child").Return([]string{}, childStat, grandchildEvents, nil).Once()
+
+			So(cache.Start(), ShouldBeNil)
+
+			Convey("Should cache all the nodes", func() {
+				So(cache.WaitForInitialCache(context.Background()), ShouldBeNil)
+
+				So((<-cache.Events()).Data.Path, ShouldEqual, "/root")
+				So((<-cache.Events()).Data.Path, ShouldEqual, "/root/child")
+				So((<-cache.Events()).Type, ShouldEqual, TREE_CACHE_INITIALIZED)
+
+				So(cache.GetCurrentData("/root/child"), ShouldResemble, &ChildData{"/root/child", childStat, []byte("child")})
+				So(cache.GetCurrentChildren("/root"), ShouldResemble, map[string]*ChildData{
+					"child": {"/root/child", childStat, []byte("child")},
+				})
+
+				Convey("When a node was deleted", func() {
+					childEvents <- zk.Event{Type: zk.EventNodeDeleted, Path: "/root/child"}
+
+					Convey("Should remove it from the cache", func() {
+						event := <-cache.Events()
+
+						So(event.Type, ShouldEqual, NODE_REMOVED)
+						So(event.Data.Path, ShouldEqual, "/root/child")
+						So(cache.GetCurrentData("/root/child"), ShouldBeNil)
+						So(cache.GetCurrentChildren("/root"), ShouldBeEmpty)
+						So(cache.Close(), ShouldBeNil)
+
+						_, ok := <-cache.Events()
+
+						So(ok, ShouldBeFalse)
+
+						mocks.Check(t)
+					})
+				})
+
+				Convey("When the connection was reconnected", func() {
+					mocks.conn.On("GetW", "/root").Return([]byte("root"), rootStat, rootEvents, nil).Once()
+					mocks.conn.On("ChildrenW", "/root").Return([]string{"child"}, rootStat, rootEvents, nil).Once()
+					mocks.conn.On("GetW", "/root/child").Return([]byte("child"), childStat, childEvents, nil).Once()
+					mocks.conn.On("ChildrenW", "/root/child").Return([]string{}, childStat, grandchildEvents, nil).Once()
+
+					cache.connectionStateListener.StateChanged(client, curator.RECONNECTED)
+
+					Convey("Should refresh every node of the tree", func() {
+						So((<-cache.Events()).Type, ShouldEqual, CONNECTION_RECONNECTED)
+						So(cache.Close(), ShouldBeNil)
+
+						mocks.Check(t)
+					})
+				})
+			})
+		})
+	})
+
+	Convey("Given a TreeCache fails to load the tree", t, func() {
+		mocks := newMockBuilder(t)
+
+		client := mocks.Build()
+
+		So(client.Start(), ShouldBeNil)
+
+		mocks.conn.On("GetW", "/root").Return(nil, nil, nil, zk.ErrNoAuth).Once()
+
+		cache := NewTreeCache(client, "/root", true, false)
+
+		So(cache.Start(), ShouldBeNil)
+
+		Convey("Should return the error instead of being initialized", func() {
+			So(cache.WaitForInitialCache(context.Background()), ShouldEqual, zk.ErrNoAuth)
+			So(cache.Close(), ShouldBeNil)
+
+			_, ok := <-cache.Events()
+
+			So(ok, ShouldBeFalse)
+
+			mocks.Check(t)
+		})
+	})
+}
+
+func TestPathChildrenCacheUndrainedEvents(t *testing.T) {
+	Convey("Given a PathChildrenCache whose events are not drained", t, func() {
+		zookeeper := newFakeZookeeper()
+
+		So(zookeeper.Start(), ShouldBeNil)
+
+		defer zookeeper.Close()
+
+		client := newFakeClient(zookeeper)
+
+		So(client.Start(), ShouldBeNil)
+
+		defer client.Close()
+
+		children := PATH_CHILDREN_CACHE_QUEUE_SIZE * 2
+
+		for i := 0; i < children; i++ {
+			_, err := client.Create().CreatingParentsIfNeeded().ForPath(fmt.Sprintf("/parent/child-%d", i))
+
+			So(err, ShouldBeNil)
+		}
+
+		cache := NewPathChildrenCache(client, "/parent", true, false)
+
+		So(cache.Start(NORMAL), ShouldBeNil)
+
+		defer cache.Close()
+
+		Convey("Should keep refreshing the cache and queue the events", func() {
+			_, err := client.Create().ForPath("/parent/last")
+
+			So(err, ShouldBeNil)
+
+			for i := 0; i < 100 && cache.GetCurrentDataForPath("/parent/last") == nil; i++ {
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			So(cache.GetCurrentDataForPath("/parent/last"), ShouldNotBeNil)
+
+			for i := 0; i <= children; i++ {
+				So((<-cache.Events()).Type, ShouldEqual, CHILD_ADDED)
+			}
+		})
+	})
+}
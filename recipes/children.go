@@ -0,0 +1,150 @@
+package recipes
+
+import (
+	"context"
+	"sort"
+
+	"github.com/flier/curator.go"
+)
+
+const DEFAULT_CHILDREN_PAGE_SIZE = 1000
+
+// Read the children of a node page by page in the sorted order.
+//
+// ZooKeeper doesn't support paginated listing, so the children are read once at the first page,
+// and the pages bound the amount of the children processed at a time.
+type SequentialChildrenReader struct {
+	client   curator.CuratorFramework
+	path     string
+	pageSize int
+	children []string
+	loaded   bool
+	offset   int
+}
+
+func NewSequentialChildrenReader(client curator.CuratorFramework, path string, pageSize int) *SequentialChildrenReader {
+	if pageSize <= 0 {
+		pageSize = DEFAULT_CHILDREN_PAGE_SIZE
+	}
+
+	return &SequentialChildrenReader{
+		client:   client,
+		path:     path,
+		pageSize: pageSize,
+	}
+}
+
+// Returns true if there are more children to read, the children are not read yet before the first page
+func (r *SequentialChildrenReader) HasNext() bool {
+	return !r.loaded || r.offset < len(r.children)
+}
+
+// Return the next page of the children, or nil if all the children have been read
+func (r *SequentialChildrenReader) Next() ([]string, error) {
+	return r.next(nil)
+}
+
+func (r *SequentialChildrenReader) next(ctx context.Context) ([]string, error) {
+	if !r.loaded {
+		builder := r.client.GetChildren()
+
+		if ctx != nil {
+			builder = builder.WithContext(ctx)
+		}
+
+		children, err := builder.ForPath(r.path)
+
+		if err != nil {
+			return nil, err
+		}
+
+		sort.Strings(children)
+
+		r.children = children
+		r.loaded = true
+	}
+
+	if r.offset >= len(r.children) {
+		return nil, nil
+	}
+
+	end := r.offset + r.pageSize
+
+	if end > len(r.children) {
+		end = len(r.children)
+	}
+
+	page := r.children[r.offset:end]
+
+	r.offset = end
+
+	return page, nil
+}
+
+// Call the handler with each of the remaining children page by page,
+// stop at the first error of the handler or once the context is done.
+//
+// The pages are read in another goroutine while the handler processes the previous page,
+// the page read ahead is dropped once the handler stops.
+func (r *SequentialChildrenReader) AsyncChildren(ctx context.Context, handler func(child string) error) error {
+	type result struct {
+		page []string
+		err  error
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	pages := make(chan result)
+
+	go func() {
+		defer close(pages)
+
+		for r.HasNext() {
+			page, err := r.next(ctx)
+
+			select {
+			case pages <- result{page, err}:
+			case <-ctx.Done():
+				return
+			}
+
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	defer func() {
+		cancel()
+
+		// wait for the reading goroutine, the reader is not shared with it anymore
+		for range pages {
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case res, ok := <-pages:
+			if !ok {
+				return ctx.Err()
+			}
+
+			if res.err != nil {
+				return res.err
+			}
+
+			for _, child := range res.page {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+
+				if err := handler(child); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package recipes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/samuel/go-zookeeper/zk"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSequentialChildrenReader(t *testing.T) {
+	Convey("Given a node with many children", t, func() {
+		zookeeper := newFakeZookeeper()
+
+		So(zookeeper.Start(), ShouldBeNil)
+
+		defer zookeeper.Close()
+
+		client := newFakeClient(zookeeper)
+
+		So(client.Start(), ShouldBeNil)
+
+		defer client.Close()
+
+		var children []string
+
+		for i := 0; i < 25; i++ {
+			child := fmt.Sprintf("child-%02d", 24-i)
+
+			_, err := client.Create().CreatingParentsIfNeeded().ForPath("/parent/" + child)
+
+			So(err, ShouldBeNil)
+
+			children = append([]string{child}, children...)
+		}
+
+		reader := NewSequentialChildrenReader(client, "/parent", 10)
+
+		Convey("Should read the sorted children page by page", func() {
+			var pages [][]string
+
+			for reader.HasNext() {
+				page, err := reader.Next()
+
+				So(err, ShouldBeNil)
+
+				pages = append(pages, page)
+			}
+
+			So(pages, ShouldResemble, [][]string{children[:10], children[10:20], children[20:]})
+
+			page, err := reader.Next()
+
+			So(page, ShouldBeNil)
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Should process each child with the handler", func() {
+			var processed []string
+
+			So(reader.AsyncChildren(context.Background(), func(child string) error {
+				processed = append(processed, child)
+
+				return nil
+			}), ShouldBeNil)
+
+			So(processed, ShouldResemble, children)
+		})
+
+		Convey("Should stop at the first error or once the context is done", func() {
+			errStop := errors.New("stop")
+
+			So(reader.AsyncChildren(context.Background(), func(child string) error {
+				if child == "child-12" {
+					return errStop
+				}
+
+				return nil
+			}), ShouldEqual, errStop)
+
+			ctx, cancel := context.WithCancel(context.Background())
+
+			cancel()
+
+			So(reader.AsyncChildren(ctx, func(child string) error { return nil }), ShouldEqual, context.Canceled)
+
+			// the children are not read with the done context
+			reader := NewSequentialChildrenReader(client, "/parent", 10)
+
+			So(reader.AsyncChildren(ctx, func(child string) error { return nil }), ShouldEqual, context.Canceled)
+			So(reader.loaded, ShouldBeFalse)
+		})
+
+		Convey("Should return the error of the missing node", func() {
+			reader := NewSequentialChildrenReader(client, "/missing", 0)
+
+			So(reader.HasNext(), ShouldBeTrue)
+
+			_, err := reader.Next()
+
+			So(err, ShouldEqual, zk.ErrNoNode)
+		})
+	})
+}
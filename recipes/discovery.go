@@ -0,0 +1,376 @@
+package recipes
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/flier/curator.go"
+	"github.com/samuel/go-zookeeper/zk"
+)
+
+// A service instance, registered as an ephemeral node under basePath/<name>/<id>
+type ServiceInstance[T any] struct {
+	Name             string    // The name of the service
+	Id               string    // The id of this instance, unique in the service
+	Address          string    // The address of this instance
+	Port             int       // The port of this instance, if any
+	SslPort          int       // The SSL port of this instance, if any
+	Payload          T         // The custom payload, encoded by the PayloadSerializer
+	RegistrationTime time.Time // The time the instance has been registered
+}
+
+// Encode and decode the custom payload of the service instances
+type PayloadSerializer[T any] interface {
+	Serialize(payload T) ([]byte, error)
+
+	Deserialize(data []byte) (T, error)
+}
+
+// Serialize the payload as JSON
+type JsonPayloadSerializer[T any] struct{}
+
+func NewJsonPayloadSerializer[T any]() *JsonPayloadSerializer[T] {
+	return &JsonPayloadSerializer[T]{}
+}
+
+func (s *JsonPayloadSerializer[T]) Serialize(payload T) ([]byte, error) {
+	return json.Marshal(payload)
+}
+
+func (s *JsonPayloadSerializer[T]) Deserialize(data []byte) (T, error) {
+	var payload T
+
+	err := json.Unmarshal(data, &payload)
+
+	return payload, err
+}
+
+type serviceInstanceNode struct {
+	Name                string          `json:"name"`
+	Id                  string          `json:"id"`
+	Address             string          `json:"address,omitempty"`
+	Port                int             `json:"port,omitempty"`
+	SslPort             int             `json:"sslPort,omitempty"`
+	Payload             json.RawMessage `json:"payload,omitempty"`
+	RegistrationTimeUTC int64           `json:"registrationTimeUTC"`
+}
+
+// A mechanism to register and query service instances using ZooKeeper, the payload of the instances is a T
+type ServiceDiscovery[T any] struct {
+	client                  curator.CuratorFramework
+	basePath                string
+	serializer              PayloadSerializer[T]
+	state                   curator.State
+	lock                    sync.Mutex
+	services                map[string]*ServiceInstance[T]
+	caches                  []*ServiceCache[T]
+	connectionStateListener curator.ConnectionStateListener
+}
+
+// Create a discovery, the payload is ignored if the serializer is nil
+func NewServiceDiscovery[T any](client curator.CuratorFramework, basePath string, serializer PayloadSerializer[T]) *ServiceDiscovery[T] {
+	d := &ServiceDiscovery[T]{
+		client:     client,
+		basePath:   basePath,
+		serializer: serializer,
+		services:   make(map[string]*ServiceInstance[T]),
+	}
+
+	d.connectionStateListener = curator.NewConnectionStateListener(func(client curator.CuratorFramework, newState curator.ConnectionState) {
+		if newState == curator.RECONNECTED {
+			if err := d.reRegisterServices(); err != nil {
+				log.Printf("fail to re-register services after reconnection, %s", err)
+			}
+		}
+	})
+
+	return d
+}
+
+// The discovery must be started before use
+func (d *ServiceDiscovery[T]) Start() error {
+	if err := curator.ValidatePath(d.basePath); err != nil {
+		return err
+	}
+
+	if !d.state.Change(curator.LATENT, curator.STARTED) {
+		return fmt.Errorf("Cannot be started more than once")
+	}
+
+	d.client.ConnectionStateListenable().AddListener(d.connectionStateListener)
+
+	if err := d.reRegisterServices(); err != nil {
+		d.client.ConnectionStateListenable().RemoveListener(d.connectionStateListener)
+
+		d.state.Change(curator.STARTED, curator.LATENT)
+
+		return err
+	}
+
+	return nil
+}
+
+// Unregister all the services and close the caches
+func (d *ServiceDiscovery[T]) Close() error {
+	if !d.state.Change(curator.STARTED, curator.STOPPED) {
+		return nil
+	}
+
+	d.client.ConnectionStateListenable().RemoveListener(d.connectionStateListener)
+
+	d.lock.Lock()
+
+	caches := d.caches
+	services := d.services
+
+	d.caches = nil
+	d.services = make(map[string]*ServiceInstance[T])
+
+	d.lock.Unlock()
+
+	for _, cache := range caches {
+		cache.Close()
+	}
+
+	for _, service := range services {
+		if err := d.internalUnregisterService(service); err != nil {
+			log.Printf("fail to unregister service %s/%s, %s", service.Name, service.Id, err)
+		}
+	}
+
+	return nil
+}
+
+// Register/re-register a service instance
+func (d *ServiceDiscovery[T]) RegisterService(service *ServiceInstance[T]) error {
+	d.lock.Lock()
+	d.services[service.Id] = service
+	d.lock.Unlock()
+
+	if d.state.Value() != curator.STARTED {
+		return nil // will be registered by Start()
+	}
+
+	return d.internalRegisterService(service)
+}
+
+// Unregister (remove) a service instance
+func (d *ServiceDiscovery[T]) UnregisterService(service *ServiceInstance[T]) error {
+	d.lock.Lock()
+	delete(d.services, service.Id)
+	d.lock.Unlock()
+
+	return d.internalUnregisterService(service)
+}
+
+// Return the names of all known services
+func (d *ServiceDiscovery[T]) QueryForNames() ([]string, error) {
+	names, err := d.client.GetChildren().ForPath(d.basePath)
+
+	if err == zk.ErrNoNode {
+		return []string{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// Return all known instances for the given service
+func (d *ServiceDiscovery[T]) QueryForInstances(name string) ([]*ServiceInstance[T], error) {
+	servicePath := curator.JoinPath(d.basePath, name)
+
+	ids, err := d.client.GetChildren().ForPath(servicePath)
+
+	if err == zk.ErrNoNode {
+		return []*ServiceInstance[T]{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(ids)
+
+	instances := make([]*ServiceInstance[T], 0, len(ids))
+
+	for _, id := range ids {
+		if instance, err := d.QueryForInstance(name, id); err != nil {
+			return nil, err
+		} else if instance != nil {
+			instances = append(instances, instance)
+		}
+	}
+
+	return instances, nil
+}
+
+// Return a service instance or nil if not found
+func (d *ServiceDiscovery[T]) QueryForInstance(name, id string) (*ServiceInstance[T], error) {
+	data, err := d.client.GetData().ForPath(d.pathForInstance(name, id))
+
+	if err == zk.ErrNoNode {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return d.deserialize(data)
+}
+
+// Create a cache of the instances of the given service, the cache will be closed with the discovery
+func (d *ServiceDiscovery[T]) NewServiceCache(name string) *ServiceCache[T] {
+	cache := &ServiceCache[T]{
+		discovery: d,
+		cache:     NewPathChildrenCache(d.client, curator.JoinPath(d.basePath, name), true, false),
+	}
+
+	d.lock.Lock()
+	d.caches = append(d.caches, cache)
+	d.lock.Unlock()
+
+	return cache
+}
+
+func (d *ServiceDiscovery[T]) pathForInstance(name, id string) string {
+	return curator.JoinPath(d.basePath, name, id)
+}
+
+func (d *ServiceDiscovery[T]) reRegisterServices() error {
+	d.lock.Lock()
+
+	services := make([]*ServiceInstance[T], 0, len(d.services))
+
+	for _, service := range d.services {
+		services = append(services, service)
+	}
+
+	d.lock.Unlock()
+
+	for _, service := range services {
+		if err := d.internalRegisterService(service); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *ServiceDiscovery[T]) internalRegisterService(service *ServiceInstance[T]) error {
+	data, err := d.serialize(service)
+
+	if err != nil {
+		return err
+	}
+
+	path := d.pathForInstance(service.Name, service.Id)
+
+	for {
+		_, err := d.client.Create().CreatingParentsIfNeeded().WithMode(curator.EPHEMERAL).ForPathWithData(path, data)
+
+		if err != zk.ErrNodeExists {
+			return err
+		}
+
+		// the node may be left by a previous session, replace it
+		if err := d.client.Delete().ForPath(path); err != nil && err != zk.ErrNoNode {
+			return err
+		}
+	}
+}
+
+func (d *ServiceDiscovery[T]) internalUnregisterService(service *ServiceInstance[T]) error {
+	if err := d.client.Delete().ForPath(d.pathForInstance(service.Name, service.Id)); err != nil && err != zk.ErrNoNode {
+		return err
+	}
+
+	return nil
+}
+
+func (d *ServiceDiscovery[T]) serialize(service *ServiceInstance[T]) ([]byte, error) {
+	node := &serviceInstanceNode{
+		Name:                service.Name,
+		Id:                  service.Id,
+		Address:             service.Address,
+		Port:                service.Port,
+		SslPort:             service.SslPort,
+		RegistrationTimeUTC: service.RegistrationTime.UnixNano() / int64(time.Millisecond),
+	}
+
+	if d.serializer != nil {
+		if payload, err := d.serializer.Serialize(service.Payload); err != nil {
+			return nil, err
+		} else {
+			node.Payload = payload
+		}
+	}
+
+	return json.Marshal(node)
+}
+
+func (d *ServiceDiscovery[T]) deserialize(data []byte) (*ServiceInstance[T], error) {
+	var node serviceInstanceNode
+
+	if err := json.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+
+	service := &ServiceInstance[T]{
+		Name:             node.Name,
+		Id:               node.Id,
+		Address:          node.Address,
+		Port:             node.Port,
+		SslPort:          node.SslPort,
+		RegistrationTime: time.Unix(0, node.RegistrationTimeUTC*int64(time.Millisecond)),
+	}
+
+	if len(node.Payload) > 0 && d.serializer != nil {
+		if payload, err := d.serializer.Deserialize(node.Payload); err != nil {
+			return nil, err
+		} else {
+			service.Payload = payload
+		}
+	}
+
+	return service, nil
+}
+
+// A cache of the instances of a service, backed by a PathChildrenCache
+type ServiceCache[T any] struct {
+	discovery *ServiceDiscovery[T]
+	cache     *PathChildrenCache
+}
+
+// Start the cache, the initial instances are loaded before it returns
+func (c *ServiceCache[T]) Start() error {
+	return c.cache.Start(BUILD_INITIAL_CACHE)
+}
+
+func (c *ServiceCache[T]) Close() error {
+	return c.cache.Close()
+}
+
+// Return the current list of instances, sorted by id
+func (c *ServiceCache[T]) GetInstances() []*ServiceInstance[T] {
+	var instances []*ServiceInstance[T]
+
+	for _, data := range c.cache.GetCurrentData() {
+		if instance, err := c.discovery.deserialize(data.Data); err != nil {
+			log.Printf("fail to decode service instance %s, %s", data.Path, err)
+		} else {
+			instances = append(instances, instance)
+		}
+	}
+
+	return instances
+}
+
+// Return the channel that receives the topology changes of the service,
+// the events are queued until received like PathChildrenCache.Events().
+func (c *ServiceCache[T]) Events() <-chan PathChildrenCacheEvent {
+	return c.cache.Events()
+}
@@ -0,0 +1,139 @@
+package recipes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flier/curator.go"
+	"github.com/samuel/go-zookeeper/zk"
+	"github.com/stretchr/testify/mock"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type testPayload struct {
+	Zone string
+}
+
+func TestServiceDiscovery(t *testing.T) {
+	Convey("Given a ServiceDiscovery base on a path", t, func() {
+		mocks := newMockBuilder(t)
+
+		client := mocks.Build()
+
+		So(client.Start(), ShouldBeNil)
+
+		discovery := NewServiceDiscovery[testPayload](client, "/services", NewJsonPayloadSerializer[testPayload]())
+
+		So(discovery.Start(), ShouldBeNil)
+
+		service := &ServiceInstance[testPayload]{
+			Name:             "api",
+			Id:               "1",
+			Address:          "127.0.0.1",
+			Port:             8080,
+			Payload:          testPayload{"east"},
+			RegistrationTime: time.Unix(1000, 0),
+		}
+
+		Convey("When register a service", func() {
+			var data []byte
+
+			mocks.conn.On("Create", "/services/api/1", mock.Anything, int32(curator.EPHEMERAL), curator.OPEN_ACL_UNSAFE).Return("/services/api/1", nil).Once().Run(func(args mock.Arguments) {
+				data = args.Get(1).([]byte)
+			})
+
+			So(discovery.RegisterService(service), ShouldBeNil)
+
+			Convey("Should query the service names and instances", func() {
+				mocks.conn.On("Children", "/services").Return([]string{"api"}, &zk.Stat{}, nil).Once()
+				mocks.conn.On("Children", "/services/api").Return([]string{"1"}, &zk.Stat{}, nil).Once()
+				mocks.conn.On("Get", "/services/api/1").Return(data, &zk.Stat{}, nil).Once()
+
+				names, err := discovery.QueryForNames()
+
+				So(err, ShouldBeNil)
+				So(names, ShouldResemble, []string{"api"})
+
+				instances, err := discovery.QueryForInstances("api")
+
+				So(err, ShouldBeNil)
+				So(instances, ShouldHaveLength, 1)
+				So(instances[0].Name, ShouldEqual, "api")
+				So(instances[0].Id, ShouldEqual, "1")
+				So(instances[0].Address, ShouldEqual, "127.0.0.1")
+				So(instances[0].Port, ShouldEqual, 8080)
+				So(instances[0].Payload, ShouldResemble, testPayload{"east"})
+				So(instances[0].RegistrationTime.Equal(service.RegistrationTime), ShouldBeTrue)
+
+				mocks.Check(t)
+			})
+
+			Convey("Should cache the service instances", func() {
+				mocks.conn.On("Exists", "/services").Return(true, nil, nil).Once()
+				mocks.conn.On("Exists", "/services/api").Return(true, nil, nil).Once()
+				mocks.conn.On("ChildrenW", "/services/api").Return([]string{"1"}, &zk.Stat{}, make(chan zk.Event), nil).Once()
+				mocks.conn.On("GetW", "/services/api/1").Return(data, &zk.Stat{}, make(chan zk.Event), nil).Once()
+
+				cache := discovery.NewServiceCache("api")
+
+				So(cache.Start(), ShouldBeNil)
+
+				instances := cache.GetInstances()
+
+				So(instances, ShouldHaveLength, 1)
+				So(instances[0].Id, ShouldEqual, "1")
+				So(cache.Close(), ShouldBeNil)
+
+				mocks.Check(t)
+			})
+
+			Convey("Should unregister the service when closed", func() {
+				mocks.conn.On("Delete", "/services/api/1", int32(curator.AnyVersion)).Return(nil).Once()
+
+				So(discovery.Close(), ShouldBeNil)
+
+				mocks.Check(t)
+			})
+		})
+
+		Convey("When query a nonexistent service", func() {
+			mocks.conn.On("Children", "/services/api").Return(nil, nil, zk.ErrNoNode).Once()
+
+			instances, err := discovery.QueryForInstances("api")
+
+			So(err, ShouldBeNil)
+			So(instances, ShouldBeEmpty)
+
+			mocks.Check(t)
+		})
+	})
+
+	Convey("Given a ServiceDiscovery fails to register the services", t, func() {
+		mocks := newMockBuilder(t)
+
+		client := mocks.Build()
+
+		So(client.Start(), ShouldBeNil)
+
+		discovery := NewServiceDiscovery[testPayload](client, "/services", NewJsonPayloadSerializer[testPayload]())
+
+		So(discovery.RegisterService(&ServiceInstance[testPayload]{Name: "api", Id: "1"}), ShouldBeNil)
+
+		mocks.conn.On("Create", "/services/api/1", mock.Anything, int32(curator.EPHEMERAL), curator.OPEN_ACL_UNSAFE).Return("", zk.ErrNoAuth).Once()
+
+		So(discovery.Start(), ShouldEqual, zk.ErrNoAuth)
+
+		Convey("When start it again", func() {
+			mocks.conn.On("Create", "/services/api/1", mock.Anything, int32(curator.EPHEMERAL), curator.OPEN_ACL_UNSAFE).Return("/services/api/1", nil).Once()
+
+			err := discovery.Start()
+
+			Convey("Should register the services", func() {
+				So(err, ShouldBeNil)
+
+				mocks.Check(t)
+			})
+		})
+	})
+}
@@ -0,0 +1,37 @@
+package recipes
+
+import (
+	"time"
+
+	"github.com/flier/curator.go"
+	"github.com/flier/curator.go/internal/fakezk"
+	"github.com/samuel/go-zookeeper/zk"
+)
+
+// The in-memory ZooKeeper of the integration tests, used as the ZookeeperDialer of the framework
+type fakeZookeeper struct {
+	*fakezk.Server
+}
+
+func newFakeZookeeper() *fakeZookeeper {
+	return &fakeZookeeper{fakezk.NewServer()}
+}
+
+// Open a new session on the fake server
+func (z *fakeZookeeper) Dial(connString string, sessionTimeout time.Duration, canBeReadOnly bool) (curator.ZookeeperConnection, <-chan zk.Event, error) {
+	conn, events, err := z.Server.Dial(connString, sessionTimeout, canBeReadOnly)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &fakeConn{conn}, events, nil
+}
+
+type fakeConn struct {
+	*fakezk.Conn
+}
+
+func (c *fakeConn) RemoveWatch(path string, watcherType curator.WatcherType) error {
+	return c.Conn.RemoveWatch(path, int32(watcherType))
+}
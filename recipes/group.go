@@ -0,0 +1,147 @@
+package recipes
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"sync"
+
+	"github.com/flier/curator.go"
+	"github.com/samuel/go-zookeeper/zk"
+)
+
+// Group membership management. Adds this instance into a group and keeps a cache of members in the group.
+//
+// The member is registered as an ephemeral node at <membershipPath>/<thisId>,
+// the id is percent-encoded so that it could contain a slash.
+type GroupMember struct {
+	client         curator.CuratorFramework
+	membershipPath string
+	thisId         string
+	payload        []byte
+	state          curator.State
+	cache          *PathChildrenCache
+	wg             sync.WaitGroup
+}
+
+func NewGroupMember(client curator.CuratorFramework, membershipPath string, thisId string, payload []byte) *GroupMember {
+	return &GroupMember{
+		client:         client,
+		membershipPath: membershipPath,
+		thisId:         thisId,
+		payload:        payload,
+		cache:          NewPathChildrenCache(client, membershipPath, true, false),
+	}
+}
+
+// Register this instance and start the cache, the initial members are loaded before it returns
+func (m *GroupMember) Start() error {
+	if err := curator.ValidatePath(m.membershipPath); err != nil {
+		return err
+	}
+
+	if !m.state.Change(curator.LATENT, curator.STARTED) {
+		return fmt.Errorf("Cannot be started more than once")
+	}
+
+	if err := m.register(); err != nil {
+		m.state.Change(curator.STARTED, curator.LATENT)
+
+		return err
+	}
+
+	if err := m.cache.Start(BUILD_INITIAL_CACHE); err != nil {
+		m.client.Delete().ForPath(m.memberPath())
+
+		m.state.Change(curator.STARTED, curator.LATENT)
+
+		return err
+	}
+
+	m.wg.Add(1)
+
+	go m.processEvents()
+
+	return nil
+}
+
+// Stop the cache and remove this instance from the group
+func (m *GroupMember) Close() error {
+	if !m.state.Change(curator.STARTED, curator.STOPPED) {
+		return nil
+	}
+
+	m.cache.Close()
+
+	m.wg.Wait()
+
+	if err := m.client.Delete().ForPath(m.memberPath()); err != nil && err != zk.ErrNoNode {
+		return err
+	}
+
+	return nil
+}
+
+// Return the current view of the members, keyed by the decoded ids, this instance is always included.
+func (m *GroupMember) GetCurrentMembers() map[string][]byte {
+	members := map[string][]byte{m.thisId: m.payload}
+
+	for _, data := range m.cache.GetCurrentData() {
+		id := m.IdFromPath(data.Path)
+
+		if id != m.thisId {
+			members[id] = data.Data
+		}
+	}
+
+	return members
+}
+
+// Return the decoded id of the member from its full path
+func (m *GroupMember) IdFromPath(path string) string {
+	node := curator.GetNodeFromPath(path)
+
+	if id, err := url.PathUnescape(node); err == nil {
+		return id
+	}
+
+	return node
+}
+
+func (m *GroupMember) memberPath() string {
+	return curator.JoinPath(m.membershipPath, url.PathEscape(m.thisId))
+}
+
+func (m *GroupMember) register() error {
+	path := m.memberPath()
+
+	for {
+		_, err := m.client.Create().CreatingParentsIfNeeded().WithMode(curator.EPHEMERAL).ForPathWithData(path, m.payload)
+
+		if err != zk.ErrNodeExists {
+			return err
+		}
+
+		// the node may be left by a previous session, replace it
+		if err := m.client.Delete().ForPath(path); err != nil && err != zk.ErrNoNode {
+			return err
+		}
+	}
+}
+
+// Drain the cache events, and re-register this instance after the session may have been re-established
+func (m *GroupMember) processEvents() {
+	defer m.wg.Done()
+
+	for event := range m.cache.Events() {
+		if event.Type == CONNECTION_RECONNECTED && m.state.Value() == curator.STARTED {
+			if stat, err := m.client.CheckExists().ForPath(m.memberPath()); err != nil {
+				log.Printf("fail to check the member %s, %s", m.thisId, err)
+			} else if stat == nil {
+				if err := m.register(); err != nil {
+					log.Printf("fail to re-register the member %s, %s", m.thisId, err)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package recipes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flier/curator.go"
+	"github.com/samuel/go-zookeeper/zk"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func newFakeClient(zookeeper *fakeZookeeper) curator.CuratorFramework {
+	builder := &curator.CuratorFrameworkBuilder{
+		ZookeeperDialer:   zookeeper,
+		SessionTimeout:    curator.DEFAULT_SESSION_TIMEOUT,
+		ConnectionTimeout: curator.DEFAULT_CONNECTION_TIMEOUT,
+		RetryPolicy:       curator.NewRetryOneTime(0),
+	}
+
+	return builder.ConnectString(zookeeper.ConnectString()).Build()
+}
+
+func waitForMembers(member *GroupMember, n int) map[string][]byte {
+	for i := 0; i < 100; i++ {
+		if members := member.GetCurrentMembers(); len(members) == n {
+			return members
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return member.GetCurrentMembers()
+}
+
+func TestGroupMember(t *testing.T) {
+	Convey("Given two members of a group", t, func() {
+		zookeeper := newFakeZookeeper()
+
+		So(zookeeper.Start(), ShouldBeNil)
+
+		defer zookeeper.Close()
+
+		client := newFakeClient(zookeeper)
+		other := newFakeClient(zookeeper)
+
+		So(client.Start(), ShouldBeNil)
+		So(other.Start(), ShouldBeNil)
+
+		defer client.Close()
+		defer other.Close()
+
+		member := NewGroupMember(client, "/group", "host/1", []byte("one"))
+		otherMember := NewGroupMember(other, "/group", "host/2", []byte("two"))
+
+		So(member.Start(), ShouldBeNil)
+		So(member.Start(), ShouldNotBeNil)
+		So(otherMember.Start(), ShouldBeNil)
+
+		defer member.Close()
+
+		Convey("Should track all the live members", func() {
+			So(waitForMembers(member, 2), ShouldResemble, map[string][]byte{"host/1": []byte("one"), "host/2": []byte("two")})
+
+			stat, err := client.CheckExists().ForPath("/group/host%2F1")
+
+			So(stat, ShouldNotBeNil)
+			So(err, ShouldBeNil)
+
+			Convey("When the other member was closed", func() {
+				So(otherMember.Close(), ShouldBeNil)
+
+				Convey("Should remove its node", func() {
+					So(waitForMembers(member, 1), ShouldResemble, map[string][]byte{"host/1": []byte("one")})
+
+					stat, err := client.CheckExists().ForPath("/group/host%2F2")
+
+					So(stat, ShouldBeNil)
+					So(err, ShouldBeNil)
+				})
+			})
+		})
+	})
+
+	Convey("Given a GroupMember fails to load the members", t, func() {
+		mocks := newMockBuilder(t)
+
+		client := mocks.Build()
+
+		So(client.Start(), ShouldBeNil)
+
+		member := NewGroupMember(client, "/group", "1", []byte("one"))
+
+		mocks.conn.On("Create", "/group/1", []byte("one"), int32(curator.EPHEMERAL), curator.OPEN_ACL_UNSAFE).Return("/group/1", nil).Twice()
+		mocks.conn.On("Exists", "/group").Return(true, nil, nil)
+		mocks.conn.On("ChildrenW", "/group").Return(nil, nil, nil, zk.ErrNoAuth).Once()
+		mocks.conn.On("Delete", "/group/1", int32(-1)).Return(nil).Once()
+
+		So(member.Start(), ShouldEqual, zk.ErrNoAuth)
+
+		Convey("When start it again", func() {
+			mocks.conn.On("ChildrenW", "/group").Return([]string{"1"}, &zk.Stat{}, make(chan zk.Event), nil).Once()
+			mocks.conn.On("GetW", "/group/1").Return([]byte("one"), &zk.Stat{}, make(chan zk.Event), nil).Once()
+
+			err := member.Start()
+
+			Convey("Should register this instance again", func() {
+				So(err, ShouldBeNil)
+				So(member.GetCurrentMembers(), ShouldResemble, map[string][]byte{"1": []byte("one")})
+
+				mocks.Check(t)
+			})
+		})
+	})
+}
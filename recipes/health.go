@@ -0,0 +1,162 @@
+package recipes
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/flier/curator.go"
+)
+
+const DEFAULT_HEALTH_CHECK_INTERVAL = 10 * time.Second
+
+// Notification for the changes of the health status
+type HealthChangeListener interface {
+	// Called when the health status changed, err is the error of the failed probe if unhealthy
+	HealthChanged(healthy bool, err error)
+}
+
+type healthChangeListenerCallback func(healthy bool, err error)
+
+type healthChangeListenerStub struct {
+	callback healthChangeListenerCallback
+}
+
+func NewHealthChangeListener(callback healthChangeListenerCallback) HealthChangeListener {
+	return &healthChangeListenerStub{callback}
+}
+
+func (l *healthChangeListenerStub) HealthChanged(healthy bool, err error) {
+	l.callback(healthy, err)
+}
+
+// Periodically probe the ZooKeeper ensemble with CheckExists on the root node.
+//
+// The probes are retried with the RetryPolicy of the framework,
+// the check is unhealthy until the first probe succeeded.
+type ZookeeperHealthCheck struct {
+	client    curator.CuratorFramework
+	interval  time.Duration
+	state     curator.State
+	healthy   curator.AtomicBool
+	lock      sync.Mutex
+	lastError error
+	listeners curator.ListenerContainer
+	done      chan struct{}
+	wg        sync.WaitGroup
+}
+
+func NewZookeeperHealthCheck(client curator.CuratorFramework, interval time.Duration) *ZookeeperHealthCheck {
+	if interval <= 0 {
+		interval = DEFAULT_HEALTH_CHECK_INTERVAL
+	}
+
+	return &ZookeeperHealthCheck{
+		client:   client,
+		interval: interval,
+		done:     make(chan struct{}),
+	}
+}
+
+// Start probing in the background, the first probe is sent immediately
+func (c *ZookeeperHealthCheck) Start() error {
+	if !c.state.Change(curator.LATENT, curator.STARTED) {
+		return fmt.Errorf("Cannot be started more than once")
+	}
+
+	c.wg.Add(1)
+
+	go c.run()
+
+	return nil
+}
+
+// Stop probing, the listeners will never be called after Close returned
+func (c *ZookeeperHealthCheck) Close() error {
+	if !c.state.Change(curator.STARTED, curator.STOPPED) {
+		return nil
+	}
+
+	close(c.done)
+
+	c.wg.Wait()
+
+	return nil
+}
+
+// Return true if the last probe succeeded
+func (c *ZookeeperHealthCheck) IsHealthy() bool {
+	return c.healthy.Load()
+}
+
+// Return the error of the last probe, or nil if it succeeded
+func (c *ZookeeperHealthCheck) LastError() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.lastError
+}
+
+// Add a listener that will be notified when the health status changed
+func (c *ZookeeperHealthCheck) AddListener(listener HealthChangeListener) {
+	c.listeners.Add(listener)
+}
+
+// Remove a previously added listener
+func (c *ZookeeperHealthCheck) RemoveListener(listener HealthChangeListener) {
+	c.listeners.Remove(listener)
+}
+
+// Return a HTTP handler which responds 200 if healthy or 503 with the last error
+func (c *ZookeeperHealthCheck) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c.IsHealthy() {
+			w.WriteHeader(http.StatusOK)
+
+			fmt.Fprintln(w, "OK")
+		} else {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			if err := c.LastError(); err != nil {
+				fmt.Fprintln(w, err)
+			} else {
+				fmt.Fprintln(w, "not checked yet")
+			}
+		}
+	})
+}
+
+func (c *ZookeeperHealthCheck) run() {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(c.interval)
+
+	defer ticker.Stop()
+
+	for {
+		c.probe()
+
+		select {
+		case <-ticker.C:
+		case <-c.done:
+			return
+		}
+	}
+}
+
+func (c *ZookeeperHealthCheck) probe() {
+	_, err := c.client.CheckExists().ForPath("/")
+
+	c.lock.Lock()
+	c.lastError = err
+	c.lock.Unlock()
+
+	healthy := err == nil
+
+	if c.healthy.Swap(healthy) != healthy {
+		c.listeners.ForEach(func(listener interface{}) {
+			listener.(HealthChangeListener).HealthChanged(healthy, err)
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package recipes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/samuel/go-zookeeper/zk"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestZookeeperHealthCheck(t *testing.T) {
+	Convey("Given a ZookeeperHealthCheck probing a flapping ensemble", t, func() {
+		mocks := newMockBuilder(t)
+
+		client := mocks.Build()
+
+		So(client.Start(), ShouldBeNil)
+
+		changes := make(chan bool, 2)
+
+		mocks.conn.On("Exists", "/").Return(true, &zk.Stat{}, nil).Once()
+		mocks.conn.On("Exists", "/").Return(false, nil, zk.ErrNoAuth)
+
+		check := NewZookeeperHealthCheck(client, time.Millisecond)
+
+		check.AddListener(NewHealthChangeListener(func(healthy bool, err error) {
+			changes <- healthy
+		}))
+
+		So(check.IsHealthy(), ShouldBeFalse)
+		So(check.Start(), ShouldBeNil)
+		So(check.Start(), ShouldNotBeNil)
+
+		Convey("When the probes succeeded then failed", func() {
+			So(<-changes, ShouldBeTrue)
+			So(<-changes, ShouldBeFalse)
+
+			So(check.Close(), ShouldBeNil)
+
+			Convey("Should report the last error", func() {
+				So(check.IsHealthy(), ShouldBeFalse)
+				So(check.LastError(), ShouldEqual, zk.ErrNoAuth)
+
+				w := httptest.NewRecorder()
+
+				check.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/health", nil))
+
+				So(w.Code, ShouldEqual, http.StatusServiceUnavailable)
+				So(w.Body.String(), ShouldEqual, zk.ErrNoAuth.Error()+"\n")
+			})
+		})
+	})
+}
@@ -0,0 +1,233 @@
+package recipes
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/flier/curator.go"
+)
+
+const LEADER_RETRY_INTERVAL = 1 * time.Second
+
+// Notification for leadership
+type LeaderSelectorListener interface {
+	// Called when your instance has been granted leadership.
+	// This method should not return until you wish to release leadership
+	TakeLeadership(client curator.CuratorFramework) error
+}
+
+type leaderSelectorListenerCallback func(client curator.CuratorFramework) error
+
+type leaderSelectorListenerStub struct {
+	callback leaderSelectorListenerCallback
+}
+
+func NewLeaderSelectorListener(callback leaderSelectorListenerCallback) LeaderSelectorListener {
+	return &leaderSelectorListenerStub{callback}
+}
+
+func (l *leaderSelectorListenerStub) TakeLeadership(client curator.CuratorFramework) error {
+	return l.callback(client)
+}
+
+// Notification for leadership, which is told to give up the leadership through the context
+type LeaderSelectorContextListener interface {
+	LeaderSelectorListener
+
+	// Called when your instance has been granted leadership, instead of TakeLeadership.
+	// The context is done when the selector was closed or the connection was lost, the method should return as soon as possible.
+	TakeLeadershipWithContext(ctx context.Context, client curator.CuratorFramework) error
+}
+
+type leaderSelectorContextListenerCallback func(ctx context.Context, client curator.CuratorFramework) error
+
+type leaderSelectorContextListenerStub struct {
+	callback leaderSelectorContextListenerCallback
+}
+
+func NewLeaderSelectorContextListener(callback leaderSelectorContextListenerCallback) LeaderSelectorContextListener {
+	return &leaderSelectorContextListenerStub{callback}
+}
+
+func (l *leaderSelectorContextListenerStub) TakeLeadership(client curator.CuratorFramework) error {
+	return l.callback(context.Background(), client)
+}
+
+func (l *leaderSelectorContextListenerStub) TakeLeadershipWithContext(ctx context.Context, client curator.CuratorFramework) error {
+	return l.callback(ctx, client)
+}
+
+// Abstraction to select a "leader" amongst multiple contenders in a group of processes connected to a Zookeeper cluster.
+// If a group of N thread/processes contends for leadership, one will be assigned leader until it releases leadership
+// at which time another one from the group will be chosen.
+//
+// The contenders acquire an InterProcessMutex on the path, the contender holding it takes the leadership,
+// and re-queues itself once it released. The leadership is given up when the connection was lost,
+// a LeaderSelectorContextListener is told to return through its context.
+type LeaderSelector struct {
+	client                  curator.CuratorFramework
+	basePath                string
+	listener                LeaderSelectorListener
+	mutex                   *InterProcessMutex
+	state                   curator.State
+	connectionStateListener curator.ConnectionStateListener
+	lock                    sync.Mutex
+	ourPath                 string
+	cancelLeadership        context.CancelFunc
+	hasLeadership           curator.AtomicBool
+	ctx                     context.Context
+	cancel                  context.CancelFunc
+	wg                      sync.WaitGroup
+}
+
+func NewLeaderSelector(client curator.CuratorFramework, path string, listener LeaderSelectorListener) *LeaderSelector {
+	s := &LeaderSelector{
+		client:   client,
+		basePath: path,
+		listener: listener,
+	}
+
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+
+	s.connectionStateListener = curator.NewConnectionStateListener(func(client curator.CuratorFramework, newState curator.ConnectionState) {
+		if newState == curator.LOST {
+			s.lock.Lock()
+			defer s.lock.Unlock()
+
+			if s.cancelLeadership != nil {
+				s.cancelLeadership()
+			}
+		}
+	})
+
+	return s
+}
+
+// Attempt leadership. This attempt is done in the background
+func (s *LeaderSelector) Start() error {
+	mutex, err := NewInterProcessMutex(s.client, s.basePath)
+
+	if err != nil {
+		return err
+	}
+
+	if !s.state.Change(curator.LATENT, curator.STARTED) {
+		return fmt.Errorf("Cannot be started more than once")
+	}
+
+	s.mutex = mutex
+
+	s.client.ConnectionStateListenable().AddListener(s.connectionStateListener)
+
+	s.wg.Add(1)
+
+	go s.run()
+
+	return nil
+}
+
+// Shutdown this selector and remove yourself from the leadership group.
+//
+// Close cancels the context of a LeaderSelectorContextListener and blocks until the running TakeLeadership returns,
+// the listener will never be called after Close returned.
+func (s *LeaderSelector) Close() error {
+	if !s.state.Change(curator.STARTED, curator.STOPPED) {
+		return nil
+	}
+
+	s.client.ConnectionStateListenable().RemoveListener(s.connectionStateListener)
+
+	s.cancel()
+
+	s.wg.Wait()
+
+	return nil
+}
+
+// Return true if leadership is currently held by this instance
+func (s *LeaderSelector) HasLeadership() bool {
+	return s.hasLeadership.Load()
+}
+
+// Return the path of the lock node held by this instance, or empty if it's not the leader
+func (s *LeaderSelector) Id() string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.ourPath
+}
+
+func (s *LeaderSelector) run() {
+	defer s.wg.Done()
+
+	for s.ctx.Err() == nil {
+		if err := s.doWork(); err != nil && s.ctx.Err() == nil {
+			log.Printf("fail to take the leadership of %s, %s", s.basePath, err)
+
+			select {
+			case <-time.After(LEADER_RETRY_INTERVAL):
+			case <-s.ctx.Done():
+			}
+		}
+	}
+}
+
+// Acquire the mutex, run the listener once and release the mutex
+func (s *LeaderSelector) doWork() error {
+	if s.client.State() != curator.STARTED {
+		return fmt.Errorf("client is not started")
+	}
+
+	if locked, err := s.mutex.internalLock(s.ctx, -1); err != nil {
+		return err
+	} else if !locked {
+		return fmt.Errorf("Lost connection while trying to acquire lock: %s", s.basePath)
+	}
+
+	ctx, cancel := context.WithCancel(s.ctx)
+
+	s.lock.Lock()
+	s.ourPath = s.mutex.lockPath
+	s.cancelLeadership = cancel
+	s.lock.Unlock()
+
+	defer s.release(cancel)
+
+	if s.ctx.Err() != nil {
+		return nil
+	}
+
+	s.hasLeadership.Set(true)
+
+	defer s.hasLeadership.Set(false)
+
+	var err error
+
+	if listener, ok := s.listener.(LeaderSelectorContextListener); ok {
+		err = listener.TakeLeadershipWithContext(ctx, s.client)
+	} else {
+		err = s.listener.TakeLeadership(s.client)
+	}
+
+	if err != nil {
+		log.Printf("leader of %s failed, %s", s.basePath, err)
+	}
+
+	return nil
+}
+
+func (s *LeaderSelector) release(cancel context.CancelFunc) {
+	cancel()
+
+	s.lock.Lock()
+	s.ourPath = ""
+	s.cancelLeadership = nil
+	s.lock.Unlock()
+
+	if err := s.mutex.Release(); err != nil && s.client.State() == curator.STARTED {
+		log.Printf("fail to release the leadership of %s, %s", s.basePath, err)
+	}
+}
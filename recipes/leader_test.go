@@ -0,0 +1,133 @@
+package recipes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/flier/curator.go"
+	"github.com/samuel/go-zookeeper/zk"
+	"github.com/stretchr/testify/mock"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLeaderSelector(t *testing.T) {
+	Convey("Given a LeaderSelector queued behind another contender", t, func() {
+		mocks := newMockBuilder(t)
+
+		client := mocks.Build()
+
+		So(client.Start(), ShouldBeNil)
+
+		prevEvents := make(chan zk.Event)
+		leading := make(chan int)
+		release := make(chan struct{})
+		times := 0
+
+		mocks.conn.On("Create", "/leader/lock-", mock.Anything, int32(curator.EPHEMERAL_SEQUENTIAL), mock.Anything).Return("/leader/lock-0000000001", nil).Once()
+		mocks.conn.On("Children", "/leader").Return([]string{"lock-0000000001", "lock-0000000000"}, &zk.Stat{}, nil).Once()
+		mocks.conn.On("GetW", "/leader/lock-0000000000").Return([]byte{}, &zk.Stat{}, prevEvents, nil).Once()
+		mocks.conn.On("Children", "/leader").Return([]string{"lock-0000000001"}, &zk.Stat{}, nil).Once()
+		mocks.conn.On("Delete", "/leader/lock-0000000001", int32(curator.AnyVersion)).Return(nil).Once()
+
+		mocks.conn.On("Create", "/leader/lock-", mock.Anything, int32(curator.EPHEMERAL_SEQUENTIAL), mock.Anything).Return("/leader/lock-0000000002", nil).Once()
+		mocks.conn.On("Children", "/leader").Return([]string{"lock-0000000002"}, &zk.Stat{}, nil).Once()
+		mocks.conn.On("Delete", "/leader/lock-0000000002", int32(curator.AnyVersion)).Return(nil).Once()
+
+		mocks.conn.On("Create", "/leader/lock-", mock.Anything, int32(curator.EPHEMERAL_SEQUENTIAL), mock.Anything).Return("", zk.ErrAPIError).Maybe()
+
+		selector := NewLeaderSelector(client, "/leader", NewLeaderSelectorListener(func(client curator.CuratorFramework) error {
+			times++
+
+			leading <- times
+
+			if times > 1 {
+				<-release
+			}
+
+			return nil
+		}))
+
+		So(selector.Start(), ShouldBeNil)
+		So(selector.Start(), ShouldNotBeNil)
+
+		Convey("When the previous node was deleted", func() {
+			prevEvents <- zk.Event{Type: zk.EventNodeDeleted, Path: "/leader/lock-0000000000"}
+
+			Convey("Should take the leadership and re-queue itself once released", func() {
+				So(<-leading, ShouldEqual, 1)
+				So(<-leading, ShouldEqual, 2)
+				So(selector.HasLeadership(), ShouldBeTrue)
+
+				closed := make(chan error)
+
+				go func() { closed <- selector.Close() }()
+
+				close(release)
+
+				So(<-closed, ShouldBeNil)
+				So(selector.HasLeadership(), ShouldBeFalse)
+				So(selector.Id(), ShouldBeEmpty)
+
+				mocks.Check(t)
+			})
+		})
+	})
+}
+
+func TestLeaderSelectorContext(t *testing.T) {
+	Convey("Given a LeaderSelector with a context listener", t, func() {
+		zookeeper := newFakeZookeeper()
+
+		So(zookeeper.Start(), ShouldBeNil)
+
+		defer zookeeper.Close()
+
+		client := newFakeClient(zookeeper)
+
+		So(client.Start(), ShouldBeNil)
+
+		defer client.Close()
+
+		leading := make(chan context.Context)
+
+		selector := NewLeaderSelector(client, "/leader", NewLeaderSelectorContextListener(func(ctx context.Context, client curator.CuratorFramework) error {
+			leading <- ctx
+
+			<-ctx.Done()
+
+			return nil
+		}))
+
+		So(selector.Start(), ShouldBeNil)
+
+		ctx := <-leading
+
+		So(selector.HasLeadership(), ShouldBeTrue)
+		So(selector.Id(), ShouldStartWith, "/leader/lock-")
+
+		Convey("Should give up the leadership and re-queue itself when the connection was lost", func() {
+			selector.connectionStateListener.StateChanged(client, curator.LOST)
+
+			<-ctx.Done()
+
+			next := <-leading
+
+			So(next.Err(), ShouldBeNil)
+			So(selector.Close(), ShouldBeNil)
+			So(next.Err(), ShouldNotBeNil)
+		})
+
+		Convey("Should cancel the leadership when closed", func() {
+			So(selector.Close(), ShouldBeNil)
+			So(ctx.Err(), ShouldNotBeNil)
+			So(selector.HasLeadership(), ShouldBeFalse)
+			So(selector.Id(), ShouldBeEmpty)
+
+			children, err := client.GetChildren().ForPath("/leader")
+
+			So(children, ShouldBeEmpty)
+			So(err, ShouldBeNil)
+		})
+	})
+}
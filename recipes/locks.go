@@ -1,330 +1,596 @@
-package recipes
-
-import (
-	"fmt"
-	"sort"
-	"strings"
-	"sync/atomic"
-	"time"
-
-	"github.com/flier/curator.go"
-	"github.com/samuel/go-zookeeper/zk"
-)
-
-const LockPrefix = "lock-"
-
-type InterProcessLock interface {
-	// Acquire the mutex - blocking until it's available.
-	// Each call to acquire must be balanced by a call to Release()
-	Acquire() (bool, error)
-
-	// Acquire the mutex - blocks until it's available or the given time expires.
-	AcquireTimeout(expires time.Duration) (bool, error)
-
-	// Perform one release of the mutex.
-	Release() error
-
-	// Returns true if the mutex is acquired by a go-routine in this process
-	IsAcquiredInThisProcess() bool
-}
-
-type RevocationListener interface {
-	// Called when a revocation request has been received.
-	// You should release the lock as soon as possible. Revocation is cooperative.
-	RevocationRequested(forLock InterProcessMutex)
-}
-
-// Specifies locks that can be revoked
-type Revocable interface {
-	// Make the lock revocable.
-	// Your listener will get called when another process/thread wants you to release the lock. Revocation is cooperative.
-	MakeRevocable(listener RevocationListener)
-}
-
-type LockInternalsSorter interface {
-	FixForSorting(str, lockName string) string
-}
-
-type PredicateResults struct {
-	GetsTheLock bool
-	PathToWatch string
-}
-
-type LockInternalsDriver interface {
-	LockInternalsSorter
-
-	GetsTheLock(client curator.CuratorFramework, children []string, sequenceNodeName string, maxLeases int) (*PredicateResults, error)
-
-	CreatesTheLock(client curator.CuratorFramework, path string, lockNodeBytes []byte) (string, error)
-}
-
-type StandardLockInternalsDriver struct{}
-
-func NewStandardLockInternalsDriver() *StandardLockInternalsDriver {
-	return &StandardLockInternalsDriver{}
-}
-
-func (d *StandardLockInternalsDriver) FixForSorting(str, lockName string) string {
-	if idx := strings.LastIndex(str, lockName); idx >= 0 {
-		idx += len(lockName)
-
-		if idx <= len(str) {
-			return str[idx:]
-		} else {
-			return ""
-		}
-	}
-
-	return str
-}
-
-func (d *StandardLockInternalsDriver) GetsTheLock(client curator.CuratorFramework, children []string, sequenceNodeName string, maxLeases int) (*PredicateResults, error) {
-	for i, child := range children {
-		if child == sequenceNodeName {
-			var pathToWatch string
-
-			getsTheLock := i < maxLeases
-
-			if !getsTheLock {
-				pathToWatch = children[i-maxLeases]
-			}
-
-			return &PredicateResults{GetsTheLock: getsTheLock, PathToWatch: pathToWatch}, nil
-		}
-	}
-
-	return nil, zk.ErrNoNode
-}
-
-func (d *StandardLockInternalsDriver) CreatesTheLock(client curator.CuratorFramework, path string, lockNodeBytes []byte) (string, error) {
-	if lockNodeBytes == nil {
-		return client.Create().CreatingParentsIfNeeded().WithMode(curator.EPHEMERAL_SEQUENTIAL).ForPath(path)
-	} else {
-		return client.Create().CreatingParentsIfNeeded().WithMode(curator.EPHEMERAL_SEQUENTIAL).ForPathWithData(path, lockNodeBytes)
-	}
-}
-
-// A re-entrant mutex that works across processes. Uses Zookeeper to hold the lock.
-// All processes that use the same lock path will achieve an inter-process critical section.
-// Further, this mutex is "fair" - each user will get the mutex in the order requested (from ZK's point of view)
-type InterProcessMutex struct {
-	basePath      string
-	internals     *lockInternals
-	lockPath      string
-	lockCount     int32
-	LockNodeBytes []byte
-}
-
-func NewInterProcessMutex(client curator.CuratorFramework, path string) (*InterProcessMutex, error) {
-	return NewInterProcessMutexWithDriver(client, path, NewStandardLockInternalsDriver())
-}
-
-func NewInterProcessMutexWithDriver(client curator.CuratorFramework, path string, driver LockInternalsDriver) (*InterProcessMutex, error) {
-	if err := curator.ValidatePath(path); err != nil {
-		return nil, err
-	}
-
-	if internals, err := newLockInternals(client, driver, path, LockPrefix, 1); err != nil {
-		return nil, err
-	} else {
-		return &InterProcessMutex{
-			basePath:  path,
-			internals: internals,
-		}, nil
-	}
-}
-
-func (m *InterProcessMutex) Acquire() (bool, error) {
-	if locked, err := m.internalLock(-1); err != nil {
-		return false, err
-	} else if !locked {
-		return false, fmt.Errorf("Lost connection while trying to acquire lock: %s", m.basePath)
-	} else {
-		return true, err
-	}
-}
-
-func (m *InterProcessMutex) AcquireTimeout(expires time.Duration) (bool, error) {
-	return m.internalLock(expires)
-}
-
-func (m *InterProcessMutex) Release() error {
-	if !m.IsAcquiredInThisProcess() {
-		return fmt.Errorf("You do not own the lock: %s", m.basePath)
-	}
-
-	count := atomic.AddInt32(&m.lockCount, -1)
-
-	switch {
-	case count > 0:
-		return nil
-	case count < 0:
-		return fmt.Errorf("Lock count has gone negative for lock: %s", m.basePath)
-	default:
-		return m.internals.releaseLock(m.lockPath)
-	}
-}
-
-func (m *InterProcessMutex) IsAcquiredInThisProcess() bool {
-	return atomic.LoadInt32(&m.lockCount) > 0
-}
-
-func (m *InterProcessMutex) internalLock(expires time.Duration) (bool, error) {
-	if m.IsAcquiredInThisProcess() {
-		// re-entering
-		atomic.AddInt32(&m.lockCount, 1)
-
-		return true, nil
-	}
-
-	if lockPath, err := m.internals.attemptLock(expires, m.LockNodeBytes); err != nil {
-		return false, err
-	} else if len(lockPath) > 0 {
-		m.lockPath = lockPath
-
-		atomic.StoreInt32(&m.lockCount, 1)
-
-		return true, nil
-	}
-
-	return false, nil
-}
-
-type lockInternals struct {
-	client    curator.CuratorFramework
-	driver    LockInternalsDriver
-	basePath  string
-	lockName  string
-	lockPath  string
-	maxLeases int
-}
-
-func newLockInternals(client curator.CuratorFramework, driver LockInternalsDriver, basePath, lockName string, maxLeases int) (*lockInternals, error) {
-	if err := curator.ValidatePath(basePath); err != nil {
-		return nil, err
-	}
-
-	return &lockInternals{
-		client:    client,
-		driver:    driver,
-		basePath:  basePath,
-		lockName:  lockName,
-		lockPath:  curator.JoinPath(basePath, lockName),
-		maxLeases: maxLeases,
-	}, nil
-}
-
-func (l *lockInternals) attemptLock(waitTime time.Duration, lockNodeBytes []byte) (string, error) {
-	startTime := time.Now()
-	retryCount := 0
-
-	for {
-		var ourPath string
-		var err error
-
-		if ourPath, err = l.driver.CreatesTheLock(l.client, l.lockPath, lockNodeBytes); err == nil {
-			if hasTheLock, err := l.internalLockLoop(startTime, waitTime, ourPath); err == nil {
-				if hasTheLock {
-					return ourPath, nil
-				} else {
-					return "", nil
-				}
-			}
-		}
-
-		if err == zk.ErrNoNode {
-			retryCount++
-
-			if l.client.ZookeeperClient().RetryPolicy().AllowRetry(retryCount, time.Now().Sub(startTime), curator.DefaultRetrySleeper) {
-				continue
-			}
-		}
-
-		if err != nil {
-			return "", err
-		}
-	}
-}
-
-func (l *lockInternals) releaseLock(path string) error {
-	return l.deleteOurPath(path)
-}
-
-func (l *lockInternals) deleteOurPath(path string) error {
-	if err := l.client.Delete().ForPath(path); err == zk.ErrNoNode {
-		return nil // ignore - already deleted (possibly expired session, etc.)
-	} else {
-		return err
-	}
-}
-
-func (l *lockInternals) internalLockLoop(startTime time.Time, waitTime time.Duration, path string) (haveTheLock bool, err error) {
-	var doDelete bool
-
-	for l.client.State() == curator.STARTED && !haveTheLock {
-		if children, err := l.getSortedChildren(); err != nil {
-			break
-		} else {
-			sequenceNodeName := path[len(l.basePath)+1:]
-
-			if results, err := l.driver.GetsTheLock(l.client, children, sequenceNodeName, l.maxLeases); err != nil {
-				break
-			} else if results.GetsTheLock {
-				haveTheLock = true
-
-				break
-			} else {
-				previousSequencePath := curator.JoinPath(l.basePath, results.PathToWatch)
-
-				c := make(chan error)
-
-				t := time.NewTimer(waitTime - time.Now().Sub(startTime))
-
-				l.client.GetData().UsingWatcher(curator.NewWatcher(func(event *zk.Event) {
-					c <- event.Err
-				})).ForPath(previousSequencePath)
-
-				select {
-				case err := <-c:
-					if err != nil && err != zk.ErrNoNode {
-						break
-					}
-				case <-t.C:
-				}
-			}
-		}
-	}
-
-	if err != nil || doDelete {
-		l.deleteOurPath(path)
-	}
-
-	return haveTheLock, err
-}
-
-type ChildrenSorter struct {
-	children []string
-	less     func(lhs, rhs string) bool
-}
-
-func (s ChildrenSorter) Len() int {
-	return len(s.children)
-}
-
-func (s ChildrenSorter) Less(i, j int) bool {
-	return s.less(s.children[i], s.children[j])
-}
-
-func (s ChildrenSorter) Swap(i, j int) { s.children[i], s.children[j] = s.children[j], s.children[i] }
-
-func (l *lockInternals) getSortedChildren() ([]string, error) {
-	if children, err := l.client.GetChildren().ForPath(l.basePath); err != nil {
-		return nil, err
-	} else {
-		sort.Sort(ChildrenSorter{children, func(lhs, rhs string) bool {
-			return l.driver.FixForSorting(lhs, l.lockName) < l.driver.FixForSorting(rhs, l.lockName)
-		}})
-
-		return children, nil
-	}
-}
+package recipes
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/flier/curator.go"
+	"github.com/samuel/go-zookeeper/zk"
+)
+
+const LockPrefix = "lock-"
+
+type InterProcessLock interface {
+	// Acquire the mutex - blocking until it's available.
+	// Each call to acquire must be balanced by a call to Release()
+	Acquire() (bool, error)
+
+	// Acquire the mutex - blocks until it's available or the given time expires.
+	AcquireTimeout(expires time.Duration) (bool, error)
+
+	// Perform one release of the mutex.
+	Release() error
+
+	// Returns true if the mutex is acquired by a go-routine in this process
+	IsAcquiredInThisProcess() bool
+}
+
+type RevocationListener interface {
+	// Called when a revocation request has been received.
+	// You should release the lock as soon as possible. Revocation is cooperative.
+	RevocationRequested(forLock InterProcessLock)
+}
+
+type revocationListenerCallback func(forLock InterProcessLock)
+
+type revocationListenerStub struct {
+	callback revocationListenerCallback
+}
+
+func NewRevocationListener(callback revocationListenerCallback) RevocationListener {
+	return &revocationListenerStub{callback}
+}
+
+func (l *revocationListenerStub) RevocationRequested(forLock InterProcessLock) {
+	l.callback(forLock)
+}
+
+// Specifies locks that can be revoked
+type Revocable interface {
+	// Make the lock revocable.
+	// Your listener will get called when another process/thread wants you to release the lock. Revocation is cooperative.
+	MakeRevocable(listener RevocationListener)
+}
+
+type LockInternalsSorter interface {
+	FixForSorting(str, lockName string) string
+}
+
+type PredicateResults struct {
+	GetsTheLock bool
+	PathToWatch string
+}
+
+type LockInternalsDriver interface {
+	LockInternalsSorter
+
+	GetsTheLock(client curator.CuratorFramework, children []string, sequenceNodeName string, maxLeases int) (*PredicateResults, error)
+
+	CreatesTheLock(client curator.CuratorFramework, path string, lockNodeBytes []byte) (string, error)
+}
+
+type StandardLockInternalsDriver struct{}
+
+func NewStandardLockInternalsDriver() *StandardLockInternalsDriver {
+	return &StandardLockInternalsDriver{}
+}
+
+func (d *StandardLockInternalsDriver) FixForSorting(str, lockName string) string {
+	if idx := strings.LastIndex(str, lockName); idx >= 0 {
+		idx += len(lockName)
+
+		if idx <= len(str) {
+			return str[idx:]
+		} else {
+			return ""
+		}
+	}
+
+	return str
+}
+
+func (d *StandardLockInternalsDriver) GetsTheLock(client curator.CuratorFramework, children []string, sequenceNodeName string, maxLeases int) (*PredicateResults, error) {
+	for i, child := range children {
+		if child == sequenceNodeName {
+			var pathToWatch string
+
+			getsTheLock := i < maxLeases
+
+			if !getsTheLock {
+				pathToWatch = children[i-maxLeases]
+			}
+
+			return &PredicateResults{GetsTheLock: getsTheLock, PathToWatch: pathToWatch}, nil
+		}
+	}
+
+	return nil, zk.ErrNoNode
+}
+
+func (d *StandardLockInternalsDriver) CreatesTheLock(client curator.CuratorFramework, path string, lockNodeBytes []byte) (string, error) {
+	if lockNodeBytes == nil {
+		return client.Create().CreatingParentsIfNeeded().WithMode(curator.EPHEMERAL_SEQUENTIAL).ForPath(path)
+	} else {
+		return client.Create().CreatingParentsIfNeeded().WithMode(curator.EPHEMERAL_SEQUENTIAL).ForPathWithData(path, lockNodeBytes)
+	}
+}
+
+// A re-entrant mutex that works across processes. Uses Zookeeper to hold the lock.
+// All processes that use the same lock path will achieve an inter-process critical section.
+// Further, this mutex is "fair" - each user will get the mutex in the order requested (from ZK's point of view)
+type InterProcessMutex struct {
+	basePath      string
+	internals     *lockInternals
+	lockPath      string
+	lockCount     int32
+	LockNodeBytes []byte
+}
+
+func NewInterProcessMutex(client curator.CuratorFramework, path string) (*InterProcessMutex, error) {
+	return NewInterProcessMutexWithDriver(client, path, NewStandardLockInternalsDriver())
+}
+
+func NewInterProcessMutexWithDriver(client curator.CuratorFramework, path string, driver LockInternalsDriver) (*InterProcessMutex, error) {
+	if err := curator.ValidatePath(path); err != nil {
+		return nil, err
+	}
+
+	if internals, err := newLockInternals(client, driver, path, LockPrefix, 1); err != nil {
+		return nil, err
+	} else {
+		return &InterProcessMutex{
+			basePath:  path,
+			internals: internals,
+		}, nil
+	}
+}
+
+func (m *InterProcessMutex) Acquire() (bool, error) {
+	if locked, err := m.internalLock(context.Background(), -1); err != nil {
+		return false, err
+	} else if !locked {
+		return false, fmt.Errorf("Lost connection while trying to acquire lock: %s", m.basePath)
+	} else {
+		return true, err
+	}
+}
+
+func (m *InterProcessMutex) AcquireTimeout(expires time.Duration) (bool, error) {
+	return m.internalLock(context.Background(), expires)
+}
+
+func (m *InterProcessMutex) Release() error {
+	if !m.IsAcquiredInThisProcess() {
+		return fmt.Errorf("You do not own the lock: %s", m.basePath)
+	}
+
+	count := atomic.AddInt32(&m.lockCount, -1)
+
+	switch {
+	case count > 0:
+		return nil
+	case count < 0:
+		return fmt.Errorf("Lock count has gone negative for lock: %s", m.basePath)
+	default:
+		return m.internals.releaseLock(m.lockPath)
+	}
+}
+
+func (m *InterProcessMutex) IsAcquiredInThisProcess() bool {
+	return atomic.LoadInt32(&m.lockCount) > 0
+}
+
+// Make the lock revocable, the listener will be called when the revoke message was written into the lock node.
+func (m *InterProcessMutex) MakeRevocable(listener RevocationListener) {
+	m.internals.makeRevocable(func() { listener.RevocationRequested(m) })
+}
+
+// Return the paths of the lock nodes sorted in the acquiring order, the first one holds the lock.
+func (m *InterProcessMutex) GetParticipantNodes() ([]string, error) {
+	children, err := m.internals.getSortedChildren()
+
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]string, len(children))
+
+	for i, child := range children {
+		nodes[i] = curator.JoinPath(m.basePath, child)
+	}
+
+	return nodes, nil
+}
+
+func (m *InterProcessMutex) internalLock(ctx context.Context, expires time.Duration) (bool, error) {
+	if m.IsAcquiredInThisProcess() {
+		// re-entering
+		atomic.AddInt32(&m.lockCount, 1)
+
+		return true, nil
+	}
+
+	if lockPath, err := m.internals.attemptLock(ctx, expires, m.LockNodeBytes); err != nil {
+		return false, err
+	} else if len(lockPath) > 0 {
+		m.lockPath = lockPath
+
+		atomic.StoreInt32(&m.lockCount, 1)
+
+		return true, nil
+	}
+
+	return false, nil
+}
+
+type lockInternals struct {
+	client    curator.CuratorFramework
+	driver    LockInternalsDriver
+	basePath  string
+	lockName  string
+	lockPath  string
+	maxLeases int
+	revocable atomic.Value // func()
+}
+
+func newLockInternals(client curator.CuratorFramework, driver LockInternalsDriver, basePath, lockName string, maxLeases int) (*lockInternals, error) {
+	if err := curator.ValidatePath(basePath); err != nil {
+		return nil, err
+	}
+
+	return &lockInternals{
+		client:    client,
+		driver:    driver,
+		basePath:  basePath,
+		lockName:  lockName,
+		lockPath:  curator.JoinPath(basePath, lockName),
+		maxLeases: maxLeases,
+	}, nil
+}
+
+// Create our lock node and wait until it gets the lock, the waitTime is unlimited if negative.
+// Return an empty path if the lock wasn't acquired in time, or an error if the context is done or the session has expired.
+func (l *lockInternals) attemptLock(ctx context.Context, waitTime time.Duration, lockNodeBytes []byte) (string, error) {
+	startTime := time.Now()
+	retryCount := 0
+
+	for {
+		ourPath, err := l.driver.CreatesTheLock(l.client, l.lockPath, lockNodeBytes)
+
+		if err == nil {
+			var hasTheLock bool
+
+			if hasTheLock, err = l.internalLockLoop(ctx, startTime, waitTime, ourPath); err == nil {
+				if hasTheLock {
+					return ourPath, nil
+				} else {
+					return "", nil
+				}
+			}
+		}
+
+		if err == zk.ErrNoNode {
+			retryCount++
+
+			if l.client.ZookeeperClient().RetryPolicy().AllowRetry(retryCount, time.Now().Sub(startTime), curator.DefaultRetrySleeper) {
+				continue
+			}
+		}
+
+		return "", err
+	}
+}
+
+func (l *lockInternals) makeRevocable(revocable func()) {
+	l.revocable.Store(revocable)
+}
+
+// Watch the data of our node, the revocable callback will be called if it became the revoke message
+func (l *lockInternals) checkRevocableWatcher(path string) {
+	revocable, _ := l.revocable.Load().(func())
+
+	if revocable == nil {
+		return
+	}
+
+	data, err := l.client.GetData().UsingWatcher(curator.NewWatcher(func(event *zk.Event) {
+		if event.Type == zk.EventNodeDataChanged {
+			l.checkRevocableWatcher(path)
+		}
+	})).ForPath(path)
+
+	if err == nil && bytes.Equal(data, REVOKE_MESSAGE) {
+		go revocable()
+	}
+}
+
+func (l *lockInternals) releaseLock(path string) error {
+	return l.deleteOurPath(path)
+}
+
+func (l *lockInternals) deleteOurPath(path string) error {
+	if err := l.client.Delete().ForPath(path); err == zk.ErrNoNode {
+		return nil // ignore - already deleted (possibly expired session, etc.)
+	} else {
+		return err
+	}
+}
+
+func (l *lockInternals) internalLockLoop(ctx context.Context, startTime time.Time, waitTime time.Duration, path string) (haveTheLock bool, err error) {
+	var doDelete bool
+	var lostOnce, closedOnce sync.Once
+
+	lost := make(chan struct{})
+	closed := make(chan struct{})
+
+	stateListener := curator.NewConnectionStateListener(func(client curator.CuratorFramework, newState curator.ConnectionState) {
+		if newState == curator.LOST {
+			lostOnce.Do(func() { close(lost) })
+		}
+	})
+
+	curatorListener := curator.NewCuratorListener(func(client curator.CuratorFramework, event curator.CuratorEvent) error {
+		if event.Type() == curator.CLOSING {
+			closedOnce.Do(func() { close(closed) })
+		}
+
+		return nil
+	})
+
+	l.client.ConnectionStateListenable().AddListener(stateListener)
+	l.client.CuratorListenable().AddListener(curatorListener)
+
+	defer l.client.ConnectionStateListenable().RemoveListener(stateListener)
+	defer l.client.CuratorListenable().RemoveListener(curatorListener)
+
+	l.checkRevocableWatcher(path)
+
+	for l.client.State() == curator.STARTED && !haveTheLock {
+		var children []string
+		var results *PredicateResults
+
+		if children, err = l.getSortedChildren(); err != nil {
+			break
+		}
+
+		sequenceNodeName := path[len(l.basePath)+1:]
+
+		if results, err = l.driver.GetsTheLock(l.client, children, sequenceNodeName, l.maxLeases); err != nil {
+			break
+		} else if results.GetsTheLock {
+			haveTheLock = true
+
+			break
+		}
+
+		previousSequencePath := curator.JoinPath(l.basePath, results.PathToWatch)
+
+		c := make(chan struct{}, 1)
+
+		if _, err = l.client.GetData().UsingWatcher(curator.NewWatcher(func(event *zk.Event) {
+			select {
+			case c <- struct{}{}:
+			default:
+			}
+		})).ForPath(previousSequencePath); err == zk.ErrNoNode {
+			err = nil // it has been deleted (i.e. lock released), try to acquire again
+
+			continue
+		} else if err != nil {
+			break
+		}
+
+		var timer *time.Timer
+		var timeout <-chan time.Time
+
+		if waitTime >= 0 {
+			remaining := waitTime - time.Now().Sub(startTime)
+
+			if remaining <= 0 {
+				doDelete = true // timed out - delete our node
+
+				break
+			}
+
+			timer = time.NewTimer(remaining)
+			timeout = timer.C
+		}
+
+		select {
+		case <-c:
+		case <-timeout:
+			doDelete = true
+		case <-lost:
+			err = ErrLockLost
+		case <-closed:
+			err = ErrLockClosed
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+
+		if timer != nil {
+			timer.Stop()
+		}
+
+		if doDelete || err != nil {
+			break
+		}
+	}
+
+	if err != nil || doDelete {
+		l.deleteOurPath(path)
+	}
+
+	return haveTheLock, err
+}
+
+type ChildrenSorter struct {
+	children []string
+	less     func(lhs, rhs string) bool
+}
+
+func (s ChildrenSorter) Len() int {
+	return len(s.children)
+}
+
+func (s ChildrenSorter) Less(i, j int) bool {
+	return s.less(s.children[i], s.children[j])
+}
+
+func (s ChildrenSorter) Swap(i, j int) { s.children[i], s.children[j] = s.children[j], s.children[i] }
+
+func (l *lockInternals) getSortedChildren() ([]string, error) {
+	if children, err := l.client.GetChildren().ForPath(l.basePath); err != nil {
+		return nil, err
+	} else {
+		sort.Sort(ChildrenSorter{children, func(lhs, rhs string) bool {
+			return l.driver.FixForSorting(lhs, l.lockName) < l.driver.FixForSorting(rhs, l.lockName)
+		}})
+
+		return children, nil
+	}
+}
+
+var (
+	ErrLockLost   = errors.New("the lock was lost because the session has expired")
+	ErrLockClosed = errors.New("the framework was closed while waiting for the lock")
+)
+
+// A re-entrant lock that works across processes, waiting callers can be cancelled with a context.
+// The lock node is an ephemeral sequential node, the lock is held by the owner of the lowest node.
+//
+// The lock is owned by the token given to AcquireWithToken, only the owner re-enters the lock,
+// the other callers of this process wait on the lock path like the other processes.
+type DistributedLock struct {
+	internals     *lockInternals
+	basePath      string
+	lock          sync.Mutex
+	lockPath      string
+	lockCount     int
+	owner         interface{}
+	lost          chan struct{}
+	stateListener curator.ConnectionStateListener
+}
+
+func NewDistributedLock(client curator.CuratorFramework, path string) (*DistributedLock, error) {
+	internals, err := newLockInternals(client, NewStandardLockInternalsDriver(), path, LockPrefix, 1)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &DistributedLock{
+		internals: internals,
+		basePath:  path,
+	}, nil
+}
+
+// Acquire the lock without an owner - blocking until it's available, the context is done or the session has expired.
+// The lock is not re-entrant, use AcquireWithToken instead. Each successful call to Acquire must be balanced by a call to Release()
+func (l *DistributedLock) Acquire(ctx context.Context) error {
+	return l.AcquireWithToken(ctx, nil)
+}
+
+// Acquire the lock for the token, which must be comparable - blocking until it's available,
+// the context is done or the session has expired.
+// Each successful call to AcquireWithToken must be balanced by a call to ReleaseWithToken() with the same token
+func (l *DistributedLock) AcquireWithToken(ctx context.Context, token interface{}) error {
+	l.lock.Lock()
+
+	if token != nil && l.lockCount > 0 && l.owner == token {
+		// re-entering
+		l.lockCount++
+
+		l.lock.Unlock()
+
+		return nil
+	}
+
+	l.lock.Unlock()
+
+	var lostOnce sync.Once
+
+	lost := make(chan struct{})
+
+	stateListener := curator.NewConnectionStateListener(func(client curator.CuratorFramework, newState curator.ConnectionState) {
+		if newState == curator.LOST {
+			lostOnce.Do(func() { close(lost) })
+		}
+	})
+
+	l.internals.client.ConnectionStateListenable().AddListener(stateListener)
+
+	ourPath, err := l.internals.attemptLock(ctx, -1, nil)
+
+	if err == nil && len(ourPath) == 0 {
+		err = fmt.Errorf("Lost connection while trying to acquire lock: %s", l.basePath)
+	}
+
+	if err != nil {
+		l.internals.client.ConnectionStateListenable().RemoveListener(stateListener)
+
+		return err
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.lockPath = ourPath
+	l.lockCount = 1
+	l.owner = token
+	l.lost = lost
+	l.stateListener = stateListener
+
+	return nil
+}
+
+// Perform one release of the lock acquired by Acquire, the lock node will be deleted by the last release.
+func (l *DistributedLock) Release() error {
+	return l.ReleaseWithToken(nil)
+}
+
+// Perform one release of the lock held by the token, the lock node will be deleted by the last release.
+func (l *DistributedLock) ReleaseWithToken(token interface{}) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.lockCount == 0 || l.owner != token {
+		return fmt.Errorf("You do not own the lock: %s", l.basePath)
+	}
+
+	if l.lockCount--; l.lockCount > 0 {
+		return nil
+	}
+
+	l.internals.client.ConnectionStateListenable().RemoveListener(l.stateListener)
+
+	ourPath := l.lockPath
+
+	l.lockPath = ""
+	l.owner = nil
+
+	return l.internals.releaseLock(ourPath)
+}
+
+// Return a channel that will be closed if the session expired while holding the lock
+func (l *DistributedLock) Lost() <-chan struct{} {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return l.lost
+}
+
+// Returns true if the lock is acquired by this process
+func (l *DistributedLock) IsAcquiredInThisProcess() bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return l.lockCount > 0
+}
@@ -1,9 +1,13 @@
 package recipes
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/flier/curator.go"
+	"github.com/samuel/go-zookeeper/zk"
+	"github.com/stretchr/testify/mock"
 
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -142,6 +146,207 @@ func TestLockInternals(t *testing.T) {
 
 func TestInterProcessMutex(t *testing.T) {
 	Convey("Given an InterProcessMutex base on a path", t, func() {
+		zookeeper := newFakeZookeeper()
 
+		So(zookeeper.Start(), ShouldBeNil)
+
+		defer zookeeper.Close()
+
+		client := newFakeClient(zookeeper)
+		other := newFakeClient(zookeeper)
+
+		So(client.Start(), ShouldBeNil)
+		So(other.Start(), ShouldBeNil)
+
+		defer client.Close()
+
+		mutex, err := NewInterProcessMutex(client, "/mutex")
+
+		So(err, ShouldBeNil)
+
+		ok, err := mutex.Acquire()
+
+		So(ok, ShouldBeTrue)
+		So(err, ShouldBeNil)
+
+		Convey("Should stop waiting when the framework was closed", func() {
+			otherMutex, err := NewInterProcessMutex(other, "/mutex")
+
+			So(err, ShouldBeNil)
+
+			errs := make(chan error, 1)
+
+			go func() {
+				_, err := otherMutex.Acquire()
+
+				errs <- err
+			}()
+
+			for i := 0; i < 100; i++ {
+				if nodes, _ := mutex.GetParticipantNodes(); len(nodes) == 2 {
+					break
+				}
+
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			So(other.Close(), ShouldBeNil)
+
+			select {
+			case err := <-errs:
+				So(err, ShouldEqual, ErrLockClosed)
+			case <-time.After(time.Second):
+				t.Error("the waiting goroutine was not unblocked by the closing")
+			}
+
+			So(mutex.Release(), ShouldBeNil)
+		})
+	})
+}
+
+func TestDistributedLock(t *testing.T) {
+	Convey("Given a DistributedLock base on a path", t, func() {
+		mocks := newMockBuilder(t)
+
+		client := mocks.Build()
+
+		So(client.Start(), ShouldBeNil)
+
+		lock, err := NewDistributedLock(client, "/lock")
+
+		So(lock, ShouldNotBeNil)
+		So(err, ShouldBeNil)
+
+		mocks.conn.On("Create", "/lock/lock-", mocks.builder.DefaultData, int32(curator.EPHEMERAL_SEQUENTIAL), curator.OPEN_ACL_UNSAFE).Return("/lock/lock-0000000001", nil).Once()
+
+		Convey("When the lock is free", func() {
+			mocks.conn.On("Children", "/lock").Return([]string{"lock-0000000001"}, &zk.Stat{}, nil).Once()
+
+			So(lock.AcquireWithToken(context.Background(), "owner"), ShouldBeNil)
+			So(lock.IsAcquiredInThisProcess(), ShouldBeTrue)
+
+			Convey("Should be re-entrant and delete the node with the last release", func() {
+				So(lock.AcquireWithToken(context.Background(), "owner"), ShouldBeNil)
+
+				So(lock.Release(), ShouldNotBeNil)
+				So(lock.ReleaseWithToken("owner"), ShouldBeNil)
+				So(lock.IsAcquiredInThisProcess(), ShouldBeTrue)
+
+				mocks.conn.On("Delete", "/lock/lock-0000000001", int32(curator.AnyVersion)).Return(nil).Once()
+
+				So(lock.ReleaseWithToken("owner"), ShouldBeNil)
+				So(lock.IsAcquiredInThisProcess(), ShouldBeFalse)
+				So(lock.ReleaseWithToken("owner"), ShouldNotBeNil)
+
+				mocks.Check(t)
+			})
+
+			Convey("Should be notified when the session expired", func() {
+				lock.stateListener.StateChanged(client, curator.LOST)
+
+				_, ok := <-lock.Lost()
+
+				So(ok, ShouldBeFalse)
+			})
+		})
+
+		Convey("When the lock is held by another process", func() {
+			events := make(chan zk.Event)
+
+			mocks.conn.On("Children", "/lock").Return([]string{"lock-0000000001", "lock-0000000000"}, &zk.Stat{}, nil).Once()
+			mocks.conn.On("Delete", "/lock/lock-0000000001", int32(curator.AnyVersion)).Return(nil).Once()
+
+			Convey("Should give up when the context is done", func() {
+				mocks.conn.On("GetW", "/lock/lock-0000000000").Return([]byte{}, &zk.Stat{}, events, nil).Once()
+
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+
+				defer cancel()
+
+				So(lock.Acquire(ctx), ShouldEqual, context.DeadlineExceeded)
+				So(lock.IsAcquiredInThisProcess(), ShouldBeFalse)
+
+				mocks.Check(t)
+			})
+
+			Convey("Should give up when the session expired", func() {
+				mocks.conn.On("GetW", "/lock/lock-0000000000").Return([]byte{}, &zk.Stat{}, events, nil).Once().Run(func(args mock.Arguments) {
+					client.ConnectionStateListenable().ForEach(func(listener interface{}) {
+						go listener.(curator.ConnectionStateListener).StateChanged(client, curator.LOST)
+					})
+				})
+
+				So(lock.Acquire(context.Background()), ShouldEqual, ErrLockLost)
+
+				mocks.Check(t)
+			})
+		})
+	})
+}
+
+func TestDistributedLockOwner(t *testing.T) {
+	Convey("Given a DistributedLock acquired without an owner", t, func() {
+		zookeeper := newFakeZookeeper()
+
+		So(zookeeper.Start(), ShouldBeNil)
+
+		defer zookeeper.Close()
+
+		client := newFakeClient(zookeeper)
+
+		So(client.Start(), ShouldBeNil)
+
+		defer client.Close()
+
+		lock, err := NewDistributedLock(client, "/locks/owner")
+
+		So(err, ShouldBeNil)
+		So(lock.Acquire(context.Background()), ShouldBeNil)
+
+		Convey("Should block the other goroutines until it's released", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+
+			defer cancel()
+
+			errs := make(chan error, 1)
+
+			go func() { errs <- lock.Acquire(ctx) }()
+
+			So(<-errs, ShouldEqual, context.DeadlineExceeded)
+
+			go func() {
+				if err := lock.Acquire(context.Background()); err != nil {
+					errs <- err
+				} else {
+					errs <- lock.Release()
+				}
+			}()
+
+			select {
+			case err := <-errs:
+				t.Errorf("the lock was acquired by another goroutine, %v", err)
+			case <-time.After(50 * time.Millisecond):
+			}
+
+			So(lock.Release(), ShouldBeNil)
+			So(<-errs, ShouldBeNil)
+			So(lock.IsAcquiredInThisProcess(), ShouldBeFalse)
+		})
+
+		Convey("Should be re-entrant with the same token in the other goroutines", func() {
+			So(lock.Release(), ShouldBeNil)
+			So(lock.AcquireWithToken(context.Background(), "token"), ShouldBeNil)
+
+			errs := make(chan error, 1)
+
+			go func() { errs <- lock.AcquireWithToken(context.Background(), "token") }()
+
+			So(<-errs, ShouldBeNil)
+			So(lock.Release(), ShouldNotBeNil)
+			So(lock.ReleaseWithToken("token"), ShouldBeNil)
+			So(lock.IsAcquiredInThisProcess(), ShouldBeTrue)
+			So(lock.ReleaseWithToken("token"), ShouldBeNil)
+			So(lock.IsAcquiredInThisProcess(), ShouldBeFalse)
+		})
 	})
 }
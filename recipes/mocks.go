@@ -309,7 +309,7 @@ func newMockBuilder(t *testing.T) *mockBuilder {
 	conn := &mockZookeeperConnection{log: t.Logf}
 
 	dialer := &mockZookeeperDialer{log: t.Logf}
-	builder := &curator.CuratorFrameworkBuilder{ZookeeperDialer: dialer}
+	builder := &curator.CuratorFrameworkBuilder{ZookeeperDialer: dialer, RetryPolicy: curator.NewRetryNTimes(0, 0, 0)}
 
 	return &mockBuilder{
 		conn:        conn,
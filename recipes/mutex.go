@@ -0,0 +1,82 @@
+package recipes
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/flier/curator.go"
+)
+
+// A mutex that works across processes and implements sync.Locker, so it could be used in place of sync.Mutex.
+//
+// Unlike DistributedLock, it is not re-entrant - the goroutines of this process are also excluded from each other.
+// Like sync.Mutex, a locked Mutex is not associated with a particular goroutine,
+// it is allowed for one goroutine to lock a Mutex and then arrange for another goroutine to unlock it.
+type Mutex struct {
+	lock   *DistributedLock
+	err    error
+	sem    chan struct{} // held by the goroutine which is acquiring or holding the lock
+	mu     sync.Mutex
+	locked bool
+}
+
+func NewMutex(client curator.CuratorFramework, path string) *Mutex {
+	lock, err := NewDistributedLock(client, path)
+
+	return &Mutex{lock: lock, err: err, sem: make(chan struct{}, 1)}
+}
+
+// Acquire the mutex, blocking until it's available.
+// Panics if the mutex couldn't be acquired, e.g. the framework has been closed.
+func (m *Mutex) Lock() {
+	if err := m.LockWithContext(context.Background()); err != nil {
+		panic(err)
+	}
+}
+
+// Acquire the mutex, blocking until it's available, the context is done or the session has expired.
+func (m *Mutex) LockWithContext(ctx context.Context) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	select {
+	case m.sem <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	if err := m.lock.AcquireWithToken(ctx, m); err != nil {
+		<-m.sem
+
+		return err
+	}
+
+	m.mu.Lock()
+	m.locked = true
+	m.mu.Unlock()
+
+	return nil
+}
+
+// Release the mutex, it may be called by any goroutine like sync.Mutex.Unlock.
+// Panics if the mutex is not locked.
+func (m *Mutex) Unlock() {
+	m.mu.Lock()
+
+	if !m.locked {
+		m.mu.Unlock()
+
+		panic("curator: unlock of unlocked mutex")
+	}
+
+	m.locked = false
+	m.mu.Unlock()
+
+	if err := m.lock.ReleaseWithToken(m); err != nil {
+		log.Printf("fail to release the mutex %s, %s", m.lock.basePath, err)
+	}
+
+	<-m.sem
+}
@@ -0,0 +1,103 @@
+package recipes
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMutex(t *testing.T) {
+	Convey("Given two Mutexes of the same path", t, func() {
+		zookeeper := newFakeZookeeper()
+
+		So(zookeeper.Start(), ShouldBeNil)
+
+		defer zookeeper.Close()
+
+		client := newFakeClient(zookeeper)
+		other := newFakeClient(zookeeper)
+
+		So(client.Start(), ShouldBeNil)
+		So(other.Start(), ShouldBeNil)
+
+		defer client.Close()
+		defer other.Close()
+
+		var mutex sync.Locker = NewMutex(client, "/mutexes/test")
+
+		otherMutex := NewMutex(other, "/mutexes/test")
+
+		Convey("Should exclude the goroutines of all the processes", func() {
+			var wg sync.WaitGroup
+
+			count := 0
+
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+
+				go func(locker sync.Locker) {
+					defer wg.Done()
+
+					locker.Lock()
+					defer locker.Unlock()
+
+					n := count
+					time.Sleep(time.Millisecond)
+					count = n + 1
+				}([]sync.Locker{mutex, otherMutex}[i%2])
+			}
+
+			wg.Wait()
+
+			So(count, ShouldEqual, 10)
+		})
+
+		Convey("Should give up when the context is done", func() {
+			mutex.Lock()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+
+			defer cancel()
+
+			So(otherMutex.LockWithContext(ctx), ShouldEqual, context.DeadlineExceeded)
+
+			mutex.Unlock()
+
+			So(otherMutex.LockWithContext(context.Background()), ShouldBeNil)
+
+			otherMutex.Unlock()
+		})
+
+		Convey("Should panic when unlocking an unlocked mutex", func() {
+			So(mutex.Unlock, ShouldPanic)
+		})
+
+		Convey("Should be unlocked by another goroutine", func() {
+			mutex.Lock()
+
+			panicked := make(chan interface{}, 1)
+
+			go func() {
+				defer func() { panicked <- recover() }()
+
+				mutex.Unlock()
+			}()
+
+			So(<-panicked, ShouldBeNil)
+			So(mutex.Unlock, ShouldPanic)
+		})
+
+		Convey("Should panic when locking after the framework was closed", func() {
+			So(client.Close(), ShouldBeNil)
+
+			So(mutex.Lock, ShouldPanic)
+		})
+
+		Convey("Should fail to lock an invalid path", func() {
+			So(NewMutex(client, "invalid").LockWithContext(context.Background()), ShouldNotBeNil)
+		})
+	})
+}
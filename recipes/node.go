@@ -0,0 +1,221 @@
+package recipes
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/flier/curator.go"
+	"github.com/samuel/go-zookeeper/zk"
+)
+
+// A node that attempts to stay present in ZooKeeper, even through connection and session interruptions.
+//
+// The node is re-created once it was deleted, e.g. the ephemeral node removed by the server after the session expired.
+type PersistentNode struct {
+	client                  curator.CuratorFramework
+	createMode              curator.CreateMode
+	useProtection           bool
+	basePath                string
+	state                   curator.State
+	lock                    sync.Mutex
+	data                    []byte
+	actualPath              string
+	watching                bool // the node has been watched by the watcher
+	watcher                 curator.Watcher
+	connectionStateListener curator.ConnectionStateListener
+	wg                      sync.WaitGroup
+}
+
+func NewPersistentNode(client curator.CuratorFramework, createMode curator.CreateMode, useProtection bool, path string, data []byte) *PersistentNode {
+	n := &PersistentNode{
+		client:        client,
+		createMode:    createMode,
+		useProtection: useProtection,
+		basePath:      path,
+		data:          data,
+	}
+
+	n.watcher = curator.NewWatcher(func(event *zk.Event) {
+		n.lock.Lock()
+		n.watching = false
+		n.lock.Unlock()
+
+		// the watch is also fired by the data changes, e.g. SetData, re-create the node or watch it again;
+		// the invalidated watch is re-armed after reconnected.
+		if event.Type != zk.EventNotWatching {
+			n.recreate()
+		}
+	})
+
+	n.connectionStateListener = curator.NewConnectionStateListener(func(client curator.CuratorFramework, newState curator.ConnectionState) {
+		if newState == curator.RECONNECTED {
+			n.recreate()
+		}
+	})
+
+	return n
+}
+
+// Create the node and keep it present until closed
+func (n *PersistentNode) Start() error {
+	if err := curator.ValidatePath(n.basePath); err != nil {
+		return err
+	}
+
+	if !n.state.Change(curator.LATENT, curator.STARTED) {
+		return fmt.Errorf("Cannot be started more than once")
+	}
+
+	n.client.ConnectionStateListenable().AddListener(n.connectionStateListener)
+
+	if err := n.ensure(); err != nil {
+		n.client.ConnectionStateListenable().RemoveListener(n.connectionStateListener)
+
+		n.lock.Lock()
+
+		if len(n.actualPath) > 0 {
+			n.client.Delete().ForPath(n.actualPath)
+
+			n.actualPath = ""
+		}
+
+		n.state.Change(curator.STARTED, curator.LATENT)
+
+		n.lock.Unlock()
+
+		return err
+	}
+
+	return nil
+}
+
+// Stop keeping the node present and delete it
+func (n *PersistentNode) Close() error {
+	if !n.state.Change(curator.STARTED, curator.STOPPED) {
+		return nil
+	}
+
+	n.client.ConnectionStateListenable().RemoveListener(n.connectionStateListener)
+
+	n.wg.Wait()
+
+	if actualPath := n.GetActualPath(); len(actualPath) > 0 {
+		if err := n.client.Delete().ForPath(actualPath); err != nil && err != zk.ErrNoNode {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Return the path of the created node, which differs from the given path for the sequential or protected nodes,
+// or "" if the node hasn't been created.
+func (n *PersistentNode) GetActualPath() string {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	return n.actualPath
+}
+
+// Return the data of the node
+func (n *PersistentNode) GetData() []byte {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	return n.data
+}
+
+// Set the data of the node, it's also used when the node is re-created
+func (n *PersistentNode) SetData(data []byte) error {
+	n.lock.Lock()
+	n.data = data
+	actualPath := n.actualPath
+	n.lock.Unlock()
+
+	if n.state.Value() != curator.STARTED || len(actualPath) == 0 {
+		return nil
+	}
+
+	if _, err := n.client.SetData().ForPathWithData(actualPath, data); err != nil && err != zk.ErrNoNode {
+		return err
+	}
+
+	return nil
+}
+
+func (n *PersistentNode) recreate() {
+	if n.state.Value() != curator.STARTED {
+		return
+	}
+
+	n.wg.Add(1)
+
+	go func() {
+		defer n.wg.Done()
+
+		if err := n.ensure(); err != nil {
+			log.Printf("fail to re-create the node %s, %s", n.basePath, err)
+		}
+	}()
+}
+
+// Create the node if it doesn't exist, and watch its deletion
+func (n *PersistentNode) ensure() error {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if n.state.Value() != curator.STARTED {
+		return nil
+	}
+
+	if len(n.actualPath) > 0 {
+		if stat, err := n.checkExists(n.actualPath); err != nil {
+			return err
+		} else if stat != nil {
+			return nil
+		}
+	}
+
+	builder := n.client.Create().CreatingParentsIfNeeded().WithMode(n.createMode)
+
+	if n.useProtection {
+		builder = builder.WithProtection()
+	}
+
+	actualPath, err := builder.ForPathWithData(n.basePath, n.data)
+
+	if err == zk.ErrNodeExists && !n.createMode.IsSequential() {
+		// the node may be left by a previous session, take it over
+		if _, err = n.client.SetData().ForPathWithData(n.basePath, n.data); err == nil {
+			actualPath = n.basePath
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	n.actualPath = actualPath
+
+	if stat, err := n.checkExists(actualPath); err != nil {
+		return err
+	} else if stat == nil {
+		n.recreate() // deleted in between
+	}
+
+	return nil
+}
+
+// Check the node exists, and watch it unless it has been watched, so the watcher is registered once
+func (n *PersistentNode) checkExists(path string) (*zk.Stat, error) {
+	if n.watching {
+		return n.client.CheckExists().ForPath(path)
+	}
+
+	stat, err := n.client.CheckExists().UsingWatcher(n.watcher).ForPath(path)
+
+	n.watching = err == nil
+
+	return stat, err
+}
@@ -0,0 +1,150 @@
+package recipes
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flier/curator.go"
+	"github.com/samuel/go-zookeeper/zk"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func waitForNode(client curator.CuratorFramework, node *PersistentNode, previousPath string) string {
+	for i := 0; i < 100; i++ {
+		if actualPath := node.GetActualPath(); actualPath != previousPath {
+			if stat, err := client.CheckExists().ForPath(actualPath); err == nil && stat != nil {
+				return actualPath
+			}
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return node.GetActualPath()
+}
+
+func TestPersistentNode(t *testing.T) {
+	Convey("Given a PersistentNode", t, func() {
+		zookeeper := newFakeZookeeper()
+
+		So(zookeeper.Start(), ShouldBeNil)
+
+		defer zookeeper.Close()
+
+		client := newFakeClient(zookeeper)
+		other := newFakeClient(zookeeper)
+
+		So(client.Start(), ShouldBeNil)
+		So(other.Start(), ShouldBeNil)
+
+		defer client.Close()
+		defer other.Close()
+
+		Convey("When the node is ephemeral", func() {
+			node := NewPersistentNode(client, curator.EPHEMERAL, false, "/nodes/test", []byte("data"))
+
+			So(node.GetActualPath(), ShouldBeEmpty)
+			So(node.Start(), ShouldBeNil)
+			So(node.Start(), ShouldNotBeNil)
+
+			defer node.Close()
+
+			So(node.GetActualPath(), ShouldEqual, "/nodes/test")
+
+			Convey("Should re-create it once it was deleted", func() {
+				So(other.Delete().ForPath("/nodes/test"), ShouldBeNil)
+
+				data, err := other.GetData().ForPath(waitForNode(other, node, ""))
+
+				So(string(data), ShouldEqual, "data")
+				So(err, ShouldBeNil)
+			})
+
+			Convey("Should update the data", func() {
+				So(node.SetData([]byte("new")), ShouldBeNil)
+
+				data, err := other.GetData().ForPath("/nodes/test")
+
+				So(string(data), ShouldEqual, "new")
+				So(err, ShouldBeNil)
+
+				Convey("Should still re-create it once it was deleted", func() {
+					time.Sleep(10 * time.Millisecond) // wait for the data change to be watched
+
+					So(other.Delete().ForPath("/nodes/test"), ShouldBeNil)
+
+					data, err := other.GetData().ForPath(waitForNode(other, node, ""))
+
+					So(string(data), ShouldEqual, "new")
+					So(err, ShouldBeNil)
+				})
+			})
+
+			Convey("Should delete it when closed", func() {
+				So(node.Close(), ShouldBeNil)
+
+				stat, err := other.CheckExists().ForPath("/nodes/test")
+
+				So(stat, ShouldBeNil)
+				So(err, ShouldBeNil)
+			})
+		})
+
+		Convey("When the node is protected and sequential", func() {
+			node := NewPersistentNode(client, curator.EPHEMERAL_SEQUENTIAL, true, "/nodes/seq-", []byte("data"))
+
+			So(node.Start(), ShouldBeNil)
+
+			defer node.Close()
+
+			actualPath := node.GetActualPath()
+
+			So(actualPath, ShouldNotEqual, "/nodes/seq-")
+			So(strings.HasPrefix(curator.GetNodeFromPath(actualPath), curator.PROTECTED_PREFIX), ShouldBeTrue)
+
+			Convey("Should re-create it with a new path", func() {
+				So(other.Delete().ForPath(actualPath), ShouldBeNil)
+
+				newPath := waitForNode(other, node, actualPath)
+
+				So(newPath, ShouldNotEqual, actualPath)
+
+				children, err := other.GetChildren().ForPath("/nodes")
+
+				So(children, ShouldHaveLength, 1)
+				So(err, ShouldBeNil)
+			})
+		})
+	})
+
+	Convey("Given a PersistentNode fails to create the node", t, func() {
+		mocks := newMockBuilder(t)
+
+		client := mocks.Build()
+
+		So(client.Start(), ShouldBeNil)
+
+		node := NewPersistentNode(client, curator.EPHEMERAL, false, "/node", []byte("data"))
+
+		mocks.conn.On("Create", "/node", []byte("data"), int32(curator.EPHEMERAL), curator.OPEN_ACL_UNSAFE).Return("", zk.ErrNoAuth).Once()
+
+		So(node.Start(), ShouldEqual, zk.ErrNoAuth)
+		So(node.GetActualPath(), ShouldBeEmpty)
+
+		Convey("When start it again", func() {
+			mocks.conn.On("Create", "/node", []byte("data"), int32(curator.EPHEMERAL), curator.OPEN_ACL_UNSAFE).Return("/node", nil).Once()
+			mocks.conn.On("ExistsW", "/node").Return(true, &zk.Stat{}, make(chan zk.Event), nil).Once()
+
+			err := node.Start()
+
+			Convey("Should create the node", func() {
+				So(err, ShouldBeNil)
+				So(node.GetActualPath(), ShouldEqual, "/node")
+
+				mocks.Check(t)
+			})
+		})
+	})
+}
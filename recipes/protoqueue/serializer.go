@@ -0,0 +1,26 @@
+package protoqueue
+
+import (
+	"google.golang.org/protobuf/proto"
+)
+
+// Serialize the queue items with protocol buffers, the zero value is ready to use.
+//
+// T must be a pointer to a generated message, e.g. ProtoQueueSerializer[*pb.Task]{}.
+type ProtoQueueSerializer[T proto.Message] struct{}
+
+func (s ProtoQueueSerializer[T]) Serialize(item T) ([]byte, error) {
+	return proto.Marshal(item)
+}
+
+func (s ProtoQueueSerializer[T]) Deserialize(data []byte) (T, error) {
+	var empty T
+
+	item := empty.ProtoReflect().New().Interface().(T)
+
+	if err := proto.Unmarshal(data, item); err != nil {
+		return empty, err
+	}
+
+	return item, nil
+}
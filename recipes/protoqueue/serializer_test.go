@@ -0,0 +1,26 @@
+package protoqueue
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestProtoQueueSerializer(t *testing.T) {
+	serializer := ProtoQueueSerializer[*wrapperspb.StringValue]{}
+
+	data, err := serializer.Serialize(wrapperspb.String("item"))
+
+	assert.NoError(t, err)
+
+	item, err := serializer.Deserialize(data)
+
+	assert.NoError(t, err)
+	assert.True(t, proto.Equal(wrapperspb.String("item"), item))
+
+	_, err = serializer.Deserialize([]byte{0xff})
+
+	assert.Error(t, err)
+}
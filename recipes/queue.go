@@ -0,0 +1,301 @@
+package recipes
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/flier/curator.go"
+	"github.com/samuel/go-zookeeper/zk"
+)
+
+const (
+	QUEUE_ITEM_PREFIX = "queue-"
+	QUEUE_LOCK_NODE   = "lock"
+)
+
+var ErrCorruptedQueueItem = errors.New("the node data is not a valid queue item")
+
+// Convert the items of the queues to and from the node data
+type QueueSerializer[T any] interface {
+	Serialize(item T) ([]byte, error)
+
+	Deserialize(data []byte) (T, error)
+}
+
+// An implementation of the Distributed Queue ZK recipe.
+//
+// The items are stored as ephemeral sequential nodes under the queue path,
+// so they are removed if the session of the producer has expired before they were taken.
+// The consumers take the item with the lowest sequence number under a distributed lock.
+type DistributedQueue[T any] struct {
+	client     curator.CuratorFramework
+	queuePath  string
+	serializer QueueSerializer[T]
+	sortItems  func(children []string) []string // filter and sort the item nodes in the order to take
+}
+
+func NewDistributedQueue[T any](client curator.CuratorFramework, queuePath string, serializer QueueSerializer[T]) *DistributedQueue[T] {
+	return &DistributedQueue[T]{
+		client:     client,
+		queuePath:  queuePath,
+		serializer: serializer,
+		sortItems:  sortQueueItems,
+	}
+}
+
+// Add an item into the queue
+func (q *DistributedQueue[T]) Put(item T) error {
+	return q.put(item, QUEUE_ITEM_PREFIX)
+}
+
+func (q *DistributedQueue[T]) put(item T, prefix string) error {
+	data, err := q.serializer.Serialize(item)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = q.client.Create().CreatingParentsIfNeeded().WithMode(curator.EPHEMERAL_SEQUENTIAL).ForPathWithData(curator.JoinPath(q.queuePath, prefix), data)
+
+	return err
+}
+
+// Remove and return the head of the queue, blocking until an item is available or the context is done
+func (q *DistributedQueue[T]) Take(ctx context.Context) (T, error) {
+	return q.waitForItem(ctx, true)
+}
+
+// Return the head of the queue without removing it, blocking until an item is available or the context is done
+func (q *DistributedQueue[T]) Peek(ctx context.Context) (T, error) {
+	return q.waitForItem(ctx, false)
+}
+
+func (q *DistributedQueue[T]) waitForItem(ctx context.Context, remove bool) (T, error) {
+	var empty T
+
+	events := make(chan struct{}, 1)
+
+	watcher := curator.NewWatcher(func(event *zk.Event) {
+		select {
+		case events <- struct{}{}:
+		default:
+		}
+	})
+
+	for {
+		item, found, err := q.tryGetItem(ctx, watcher, remove)
+
+		if err != nil {
+			return empty, err
+		} else if found {
+			return item, nil
+		}
+
+		select {
+		case <-events:
+		case <-ctx.Done():
+			return empty, ctx.Err()
+		}
+	}
+}
+
+// Return the head of the queue, the children of the queue are watched if it is empty
+func (q *DistributedQueue[T]) tryGetItem(ctx context.Context, watcher curator.Watcher, remove bool) (item T, found bool, err error) {
+	if remove {
+		var lock *DistributedLock
+
+		if lock, err = NewDistributedLock(q.client, curator.JoinPath(q.queuePath, QUEUE_LOCK_NODE)); err != nil {
+			return
+		} else if err = lock.Acquire(ctx); err != nil {
+			return
+		}
+
+		defer lock.Release()
+	}
+
+	children, err := q.client.GetChildren().UsingWatcher(watcher).ForPath(q.queuePath)
+
+	if err == zk.ErrNoNode {
+		return item, false, nil
+	} else if err != nil {
+		return
+	}
+
+	for _, child := range q.sortItems(children) {
+		itemPath := curator.JoinPath(q.queuePath, child)
+
+		data, err := q.client.GetData().ForPath(itemPath)
+
+		if err == zk.ErrNoNode {
+			continue // removed by the others or the producer has gone
+		} else if err != nil {
+			return item, false, err
+		}
+
+		if remove {
+			if err := q.client.Delete().ForPath(itemPath); err == zk.ErrNoNode {
+				continue
+			} else if err != nil {
+				return item, false, err
+			}
+		}
+
+		item, err = q.serializer.Deserialize(data)
+
+		return item, err == nil, err
+	}
+
+	return item, false, nil
+}
+
+// Return the item nodes sorted by the sequence number
+func sortQueueItems(children []string) []string {
+	items := make([]string, 0, len(children))
+
+	for _, child := range children {
+		if strings.HasPrefix(child, QUEUE_ITEM_PREFIX) {
+			items = append(items, child)
+		}
+	}
+
+	sort.Strings(items)
+
+	return items
+}
+
+// An item of the priority queue, the priority is serialized with the item
+type QueueItem[T any] struct {
+	Priority int
+	Item     T
+}
+
+// Serialize the priority as a big-endian int32 before the item
+type queueItemSerializer[T any] struct {
+	serializer QueueSerializer[T]
+}
+
+func (s *queueItemSerializer[T]) Serialize(item QueueItem[T]) ([]byte, error) {
+	data, err := s.serializer.Serialize(item.Item)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return append(encodeCount(item.Priority), data...), nil
+}
+
+func (s *queueItemSerializer[T]) Deserialize(data []byte) (QueueItem[T], error) {
+	if len(data) < 4 {
+		return QueueItem[T]{}, ErrCorruptedQueueItem
+	}
+
+	item, err := s.serializer.Deserialize(data[4:])
+
+	return QueueItem[T]{int(int32(binary.BigEndian.Uint32(data))), item}, err
+}
+
+// A distributed queue which takes the items with the lowest priority number first,
+// the items of the same priority are taken in the order they were put.
+//
+// The priority (in the int32 range) is embedded into the node name as queue-<priority>-<sequence>,
+// and serialized with the item in case the node name couldn't be parsed.
+type DistributedPriorityQueue[T any] struct {
+	queue *DistributedQueue[QueueItem[T]]
+}
+
+func NewDistributedPriorityQueue[T any](client curator.CuratorFramework, queuePath string, serializer QueueSerializer[T]) *DistributedPriorityQueue[T] {
+	q := &DistributedPriorityQueue[T]{
+		queue: NewDistributedQueue[QueueItem[T]](client, queuePath, &queueItemSerializer[T]{serializer}),
+	}
+
+	q.queue.sortItems = q.sortItems
+
+	return q
+}
+
+// Add an item with the priority into the queue
+func (q *DistributedPriorityQueue[T]) Put(item T, priority int) error {
+	return q.queue.put(QueueItem[T]{priority, item}, QUEUE_ITEM_PREFIX+encodePriority(priority)+"-")
+}
+
+// Remove and return the item with the highest priority, blocking until an item is available or the context is done
+func (q *DistributedPriorityQueue[T]) Take(ctx context.Context) (T, error) {
+	item, err := q.queue.Take(ctx)
+
+	return item.Item, err
+}
+
+// Return the item with the highest priority without removing it, blocking until an item is available or the context is done
+func (q *DistributedPriorityQueue[T]) Peek(ctx context.Context) (T, error) {
+	item, err := q.queue.Peek(ctx)
+
+	return item.Item, err
+}
+
+func (q *DistributedPriorityQueue[T]) sortItems(children []string) []string {
+	type priorityItem struct {
+		name     string
+		priority int
+	}
+
+	var items []priorityItem
+
+	for _, child := range sortQueueItems(children) {
+		priority, err := decodePriority(child)
+
+		if err != nil {
+			// fallback to the priority serialized with the item
+			data, err := q.queue.client.GetData().ForPath(curator.JoinPath(q.queue.queuePath, child))
+
+			if err != nil {
+				continue
+			}
+
+			item, err := q.queue.serializer.Deserialize(data)
+
+			if err != nil {
+				continue
+			}
+
+			priority = item.Priority
+		}
+
+		items = append(items, priorityItem{child, priority})
+	}
+
+	sort.SliceStable(items, func(i, j int) bool { return items[i].priority < items[j].priority })
+
+	names := make([]string, len(items))
+
+	for i, item := range items {
+		names[i] = item.name
+	}
+
+	return names
+}
+
+// Encode the priority as the sortable hex string of its offset binary
+func encodePriority(priority int) string {
+	return fmt.Sprintf("%08x", uint32(int32(priority))^0x80000000)
+}
+
+func decodePriority(name string) (int, error) {
+	s := strings.TrimPrefix(name, QUEUE_ITEM_PREFIX)
+
+	if len(s) < 9 || s[8] != '-' {
+		return 0, ErrCorruptedQueueItem
+	}
+
+	n, err := strconv.ParseUint(s[:8], 16, 32)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return int(int32(uint32(n) ^ 0x80000000)), nil
+}
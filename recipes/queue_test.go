@@ -0,0 +1,177 @@
+package recipes
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type intQueueSerializer struct{}
+
+func (s intQueueSerializer) Serialize(item int) ([]byte, error) {
+	return []byte(strconv.Itoa(item)), nil
+}
+
+func (s intQueueSerializer) Deserialize(data []byte) (int, error) {
+	return strconv.Atoi(string(data))
+}
+
+func TestDistributedQueue(t *testing.T) {
+	Convey("Given a DistributedQueue", t, func() {
+		zookeeper := newFakeZookeeper()
+
+		So(zookeeper.Start(), ShouldBeNil)
+
+		defer zookeeper.Close()
+
+		client := newFakeClient(zookeeper)
+
+		So(client.Start(), ShouldBeNil)
+
+		defer client.Close()
+
+		queue := NewDistributedQueue[int](client, "/queues/test", intQueueSerializer{})
+
+		Convey("Should time out when taking from an empty queue", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+
+			defer cancel()
+
+			_, err := queue.Take(ctx)
+
+			So(err, ShouldEqual, context.DeadlineExceeded)
+		})
+
+		Convey("When some items were put", func() {
+			for i := 1; i <= 3; i++ {
+				So(queue.Put(i), ShouldBeNil)
+			}
+
+			Convey("Should take the items in order", func() {
+				item, err := queue.Peek(context.Background())
+
+				So(item, ShouldEqual, 1)
+				So(err, ShouldBeNil)
+
+				for i := 1; i <= 3; i++ {
+					item, err := queue.Take(context.Background())
+
+					So(item, ShouldEqual, i)
+					So(err, ShouldBeNil)
+				}
+			})
+		})
+
+		Convey("When the consumers were waiting concurrently", func() {
+			var wg sync.WaitGroup
+			var lock sync.Mutex
+			var items []int
+
+			for i := 0; i < 5; i++ {
+				wg.Add(1)
+
+				go func() {
+					defer wg.Done()
+
+					if item, err := queue.Take(context.Background()); err != nil {
+						t.Error(err)
+					} else {
+						lock.Lock()
+						items = append(items, item)
+						lock.Unlock()
+					}
+				}()
+			}
+
+			time.Sleep(10 * time.Millisecond)
+
+			for i := 1; i <= 5; i++ {
+				So(queue.Put(i), ShouldBeNil)
+			}
+
+			wg.Wait()
+
+			Convey("Should take each item once", func() {
+				sort.Ints(items)
+
+				So(items, ShouldResemble, []int{1, 2, 3, 4, 5})
+			})
+		})
+	})
+}
+
+func TestDistributedPriorityQueue(t *testing.T) {
+	Convey("Given a DistributedPriorityQueue with some items", t, func() {
+		zookeeper := newFakeZookeeper()
+
+		So(zookeeper.Start(), ShouldBeNil)
+
+		defer zookeeper.Close()
+
+		client := newFakeClient(zookeeper)
+
+		So(client.Start(), ShouldBeNil)
+
+		defer client.Close()
+
+		queue := NewDistributedPriorityQueue[int](client, "/queues/priority", intQueueSerializer{})
+
+		So(queue.Put(1, 10), ShouldBeNil)
+		So(queue.Put(2, -5), ShouldBeNil)
+		So(queue.Put(3, 10), ShouldBeNil)
+		So(queue.Put(4, 0), ShouldBeNil)
+
+		// an item without the priority in its name
+		data, err := (&queueItemSerializer[int]{intQueueSerializer{}}).Serialize(QueueItem[int]{Priority: 5, Item: 5})
+
+		So(err, ShouldBeNil)
+
+		_, err = client.Create().ForPathWithData("/queues/priority/queue-truncated", data)
+
+		So(err, ShouldBeNil)
+
+		Convey("Should take the items by priority", func() {
+			item, err := queue.Peek(context.Background())
+
+			So(item, ShouldEqual, 2)
+			So(err, ShouldBeNil)
+
+			var items []int
+
+			for i := 0; i < 5; i++ {
+				item, err := queue.Take(context.Background())
+
+				So(err, ShouldBeNil)
+
+				items = append(items, item)
+			}
+
+			So(items, ShouldResemble, []int{2, 4, 5, 1, 3})
+		})
+	})
+}
+
+func TestQueuePriority(t *testing.T) {
+	Convey("Should encode the priorities in order", t, func() {
+		priorities := []int{math.MinInt32, -1, 0, 1, math.MaxInt32}
+
+		for i, priority := range priorities {
+			name := QUEUE_ITEM_PREFIX + encodePriority(priority) + "-0000000001"
+
+			if i > 0 {
+				So(encodePriority(priorities[i-1]), ShouldBeLessThan, encodePriority(priority))
+			}
+
+			decoded, err := decodePriority(name)
+
+			So(decoded, ShouldEqual, priority)
+			So(err, ShouldBeNil)
+		}
+	})
+}
@@ -0,0 +1,106 @@
+package recipes
+
+import (
+	"math"
+	"strings"
+
+	"github.com/flier/curator.go"
+	"github.com/samuel/go-zookeeper/zk"
+)
+
+// The lock names are shared with the Java Curator, so both could use the same lock path.
+const (
+	READ_LOCK_NAME  = "__READ__"
+	WRITE_LOCK_NAME = "__WRIT__"
+)
+
+// A re-entrant read/write mutex that works across processes.
+//
+// The read lock may be held simultaneously by multiple readers, so long as there are no writers.
+// The write lock is exclusive. The writer waits for all the lower sequence nodes to be gone,
+// and the reader waits only for the lower sequence write nodes.
+//
+// The write lock could be downgraded to a read lock by acquiring the read lock before releasing the write lock.
+type InterProcessReadWriteLock struct {
+	readMutex  *InterProcessMutex
+	writeMutex *InterProcessMutex
+}
+
+func NewInterProcessReadWriteLock(client curator.CuratorFramework, basePath string) *InterProcessReadWriteLock {
+	l := &InterProcessReadWriteLock{
+		writeMutex: newReadWriteMutex(client, basePath, WRITE_LOCK_NAME, 1, &sortingLockInternalsDriver{}),
+	}
+
+	l.readMutex = newReadWriteMutex(client, basePath, READ_LOCK_NAME, math.MaxInt32, &readLockInternalsDriver{writeMutex: l.writeMutex})
+
+	return l
+}
+
+// Returns the lock used for reading.
+func (l *InterProcessReadWriteLock) ReadLock() InterProcessLock { return l.readMutex }
+
+// Returns the lock used for writing.
+func (l *InterProcessReadWriteLock) WriteLock() InterProcessLock { return l.writeMutex }
+
+func newReadWriteMutex(client curator.CuratorFramework, basePath, lockName string, maxLeases int, driver LockInternalsDriver) *InterProcessMutex {
+	return &InterProcessMutex{
+		basePath: basePath,
+		internals: &lockInternals{
+			client:    client,
+			driver:    driver,
+			basePath:  basePath,
+			lockName:  lockName,
+			lockPath:  curator.JoinPath(basePath, lockName),
+			maxLeases: maxLeases,
+		},
+	}
+}
+
+// Sort the read and write nodes together by their sequence numbers
+type sortingLockInternalsDriver struct {
+	StandardLockInternalsDriver
+}
+
+func (d *sortingLockInternalsDriver) FixForSorting(str, lockName string) string {
+	str = d.StandardLockInternalsDriver.FixForSorting(str, READ_LOCK_NAME)
+	str = d.StandardLockInternalsDriver.FixForSorting(str, WRITE_LOCK_NAME)
+
+	return str
+}
+
+type readLockInternalsDriver struct {
+	sortingLockInternalsDriver
+
+	writeMutex *InterProcessMutex
+}
+
+func (d *readLockInternalsDriver) GetsTheLock(client curator.CuratorFramework, children []string, sequenceNodeName string, maxLeases int) (*PredicateResults, error) {
+	if d.writeMutex.IsAcquiredInThisProcess() {
+		return &PredicateResults{GetsTheLock: true}, nil
+	}
+
+	firstWriteIndex := math.MaxInt32
+	ourIndex := -1
+
+	for i, child := range children {
+		if strings.Contains(child, WRITE_LOCK_NAME) {
+			if i < firstWriteIndex {
+				firstWriteIndex = i
+			}
+		} else if strings.HasPrefix(child, sequenceNodeName) {
+			ourIndex = i
+
+			break
+		}
+	}
+
+	if ourIndex < 0 {
+		return nil, zk.ErrNoNode
+	}
+
+	if ourIndex < firstWriteIndex {
+		return &PredicateResults{GetsTheLock: true}, nil
+	}
+
+	return &PredicateResults{GetsTheLock: false, PathToWatch: children[firstWriteIndex]}, nil
+}
@@ -0,0 +1,160 @@
+package recipes
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flier/curator.go"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestInterProcessReadWriteLock(t *testing.T) {
+	Convey("Given two InterProcessReadWriteLocks of the same path", t, func() {
+		zookeeper := newFakeZookeeper()
+
+		So(zookeeper.Start(), ShouldBeNil)
+
+		defer zookeeper.Close()
+
+		client := newFakeClient(zookeeper)
+		other := newFakeClient(zookeeper)
+
+		So(client.Start(), ShouldBeNil)
+		So(other.Start(), ShouldBeNil)
+
+		defer client.Close()
+		defer other.Close()
+
+		lock := NewInterProcessReadWriteLock(client, "/rwlocks/test")
+		otherLock := NewInterProcessReadWriteLock(other, "/rwlocks/test")
+
+		Convey("When the read lock was held", func() {
+			ok, err := lock.ReadLock().Acquire()
+
+			So(ok, ShouldBeTrue)
+			So(err, ShouldBeNil)
+
+			children, err := client.GetChildren().ForPath("/rwlocks/test")
+
+			So(children, ShouldHaveLength, 1)
+			So(strings.HasPrefix(children[0], READ_LOCK_NAME), ShouldBeTrue)
+			So(err, ShouldBeNil)
+
+			Convey("Should share it with the other readers", func() {
+				ok, err := otherLock.ReadLock().AcquireTimeout(10 * time.Millisecond)
+
+				So(ok, ShouldBeTrue)
+				So(err, ShouldBeNil)
+				So(otherLock.ReadLock().Release(), ShouldBeNil)
+			})
+
+			Convey("Should block the writers until it was released", func() {
+				ok, err := otherLock.WriteLock().AcquireTimeout(10 * time.Millisecond)
+
+				So(ok, ShouldBeFalse)
+				So(err, ShouldBeNil)
+
+				children, err := client.GetChildren().ForPath("/rwlocks/test")
+
+				So(children, ShouldHaveLength, 1)
+				So(err, ShouldBeNil)
+
+				acquired := make(chan bool, 1)
+
+				go func() {
+					ok, err := otherLock.WriteLock().Acquire()
+
+					if err != nil {
+						t.Error(err)
+					}
+
+					acquired <- ok
+				}()
+
+				time.Sleep(10 * time.Millisecond)
+
+				So(acquired, ShouldBeEmpty)
+				So(lock.ReadLock().Release(), ShouldBeNil)
+				So(<-acquired, ShouldBeTrue)
+				So(otherLock.WriteLock().IsAcquiredInThisProcess(), ShouldBeTrue)
+				So(otherLock.WriteLock().Release(), ShouldBeNil)
+			})
+		})
+
+		Convey("When the write lock was held", func() {
+			ok, err := lock.WriteLock().Acquire()
+
+			So(ok, ShouldBeTrue)
+			So(err, ShouldBeNil)
+
+			Convey("Should block the readers and writers of the others", func() {
+				ok, err := otherLock.ReadLock().AcquireTimeout(10 * time.Millisecond)
+
+				So(ok, ShouldBeFalse)
+				So(err, ShouldBeNil)
+
+				ok, err = otherLock.WriteLock().AcquireTimeout(10 * time.Millisecond)
+
+				So(ok, ShouldBeFalse)
+				So(err, ShouldBeNil)
+			})
+
+			Convey("Should downgrade to the read lock", func() {
+				ok, err := lock.ReadLock().AcquireTimeout(10 * time.Millisecond)
+
+				So(ok, ShouldBeTrue)
+				So(err, ShouldBeNil)
+				So(lock.WriteLock().Release(), ShouldBeNil)
+
+				ok, err = otherLock.ReadLock().AcquireTimeout(10 * time.Millisecond)
+
+				So(ok, ShouldBeTrue)
+				So(err, ShouldBeNil)
+			})
+		})
+
+		Convey("When the write lock was held by a Java client", func() {
+			// the protected node created by the Java Curator
+			javaPath, err := other.Create().CreatingParentsIfNeeded().WithMode(curator.EPHEMERAL_SEQUENTIAL).ForPath("/rwlocks/test/_c_0f8f2a39-57ad-4e1b-a3a0-1e1f7a5d9b2c-__WRIT__")
+
+			So(err, ShouldBeNil)
+
+			Convey("Should sort it before our nodes and block the readers until it was deleted", func() {
+				acquired := make(chan bool, 1)
+
+				go func() {
+					ok, err := lock.ReadLock().Acquire()
+
+					if err != nil {
+						t.Error(err)
+					}
+
+					acquired <- ok
+				}()
+
+				time.Sleep(10 * time.Millisecond)
+
+				So(acquired, ShouldBeEmpty)
+
+				children, err := client.GetChildren().ForPath("/rwlocks/test")
+
+				So(children, ShouldHaveLength, 2)
+				So(err, ShouldBeNil)
+
+				sorted := lock.readMutex.internals.driver.FixForSorting(curator.GetNodeFromPath(javaPath), READ_LOCK_NAME)
+
+				for _, child := range children {
+					if strings.HasPrefix(child, READ_LOCK_NAME) {
+						So(lock.readMutex.internals.driver.FixForSorting(child, READ_LOCK_NAME), ShouldBeGreaterThan, sorted)
+					}
+				}
+
+				So(other.Delete().ForPath(javaPath), ShouldBeNil)
+				So(<-acquired, ShouldBeTrue)
+				So(lock.ReadLock().Release(), ShouldBeNil)
+			})
+		})
+	})
+}
@@ -0,0 +1,68 @@
+package recipes
+
+import (
+	"context"
+
+	"github.com/flier/curator.go"
+	"github.com/samuel/go-zookeeper/zk"
+)
+
+// The revoke message written into the lock node, it's shared with the Java Curator.
+var REVOKE_MESSAGE = []byte("__REVOKE__")
+
+// Ask the holder of the lock node to release it by writing the revoke message into the node,
+// blocking until the node was deleted or the context is done.
+//
+// Revocation is cooperative, the holder must have made its lock revocable.
+func AttemptRevoke(ctx context.Context, client curator.CuratorFramework, path string) error {
+	if _, err := client.SetData().ForPathWithData(path, REVOKE_MESSAGE); err == zk.ErrNoNode {
+		return nil // the lock has been released
+	} else if err != nil {
+		return err
+	}
+
+	events := make(chan struct{}, 1)
+
+	watcher := curator.NewWatcher(func(event *zk.Event) {
+		select {
+		case events <- struct{}{}:
+		default:
+		}
+	})
+
+	for {
+		if stat, err := client.CheckExists().UsingWatcher(watcher).ForPath(path); err != nil {
+			return err
+		} else if stat == nil {
+			return nil
+		}
+
+		select {
+		case <-events:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// A lock which will be notified when the others attempt to revoke it.
+//
+// The wrapped lock must implement Revocable (e.g. InterProcessMutex or the read/write locks), otherwise the listener is never called.
+type RevocableLock struct {
+	InterProcessLock
+}
+
+func NewRevocableLock(lock InterProcessLock, listener RevocationListener) *RevocableLock {
+	l := &RevocableLock{lock}
+
+	l.MakeRevocable(listener)
+
+	return l
+}
+
+// Replace the listener called when a revocation request has been received.
+func (l *RevocableLock) MakeRevocable(listener RevocationListener) {
+	if revocable, ok := l.InterProcessLock.(Revocable); ok {
+		revocable.MakeRevocable(listener)
+	}
+}
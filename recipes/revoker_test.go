@@ -0,0 +1,86 @@
+package recipes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRevocableLock(t *testing.T) {
+	Convey("Given a RevocableLock held by a process", t, func() {
+		zookeeper := newFakeZookeeper()
+
+		So(zookeeper.Start(), ShouldBeNil)
+
+		defer zookeeper.Close()
+
+		client := newFakeClient(zookeeper)
+		other := newFakeClient(zookeeper)
+
+		So(client.Start(), ShouldBeNil)
+		So(other.Start(), ShouldBeNil)
+
+		defer client.Close()
+		defer other.Close()
+
+		mutex, err := NewInterProcessMutex(client, "/revocable/test")
+
+		So(err, ShouldBeNil)
+
+		revoked := make(chan InterProcessLock, 1)
+
+		lock := NewRevocableLock(mutex, NewRevocationListener(func(forLock InterProcessLock) {
+			revoked <- forLock
+
+			if err := forLock.Release(); err != nil {
+				t.Error(err)
+			}
+		}))
+
+		ok, err := lock.Acquire()
+
+		So(ok, ShouldBeTrue)
+		So(err, ShouldBeNil)
+
+		otherMutex, err := NewInterProcessMutex(other, "/revocable/test")
+
+		So(err, ShouldBeNil)
+
+		nodes, err := otherMutex.GetParticipantNodes()
+
+		So(nodes, ShouldHaveLength, 1)
+		So(err, ShouldBeNil)
+
+		Convey("Should release the lock when another process attempted to revoke it", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+
+			defer cancel()
+
+			So(AttemptRevoke(ctx, other, nodes[0]), ShouldBeNil)
+			So(<-revoked, ShouldEqual, mutex)
+			So(lock.IsAcquiredInThisProcess(), ShouldBeFalse)
+
+			ok, err := otherMutex.AcquireTimeout(time.Second)
+
+			So(ok, ShouldBeTrue)
+			So(err, ShouldBeNil)
+
+			Convey("Should ignore the lock which has been released", func() {
+				So(AttemptRevoke(ctx, client, nodes[0]), ShouldBeNil)
+			})
+		})
+
+		Convey("Should ignore the other data changes", func() {
+			_, err := other.SetData().ForPathWithData(nodes[0], []byte("data"))
+
+			So(err, ShouldBeNil)
+
+			time.Sleep(10 * time.Millisecond)
+
+			So(revoked, ShouldBeEmpty)
+			So(lock.Release(), ShouldBeNil)
+		})
+	})
+}
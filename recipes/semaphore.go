@@ -0,0 +1,169 @@
+package recipes
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/flier/curator.go"
+	"github.com/samuel/go-zookeeper/zk"
+)
+
+const (
+	SEMAPHORE_LEASE_PREFIX = "lease-"
+	SEMAPHORE_LOCK_NODE    = "locks"
+)
+
+var (
+	ErrInvalidLeaseQty = errors.New("the number of leases must be between 1 and the max leases")
+	ErrLeaseLost       = errors.New("the lease node was deleted while waiting, the session may have expired")
+)
+
+// A lease of the semaphore, it must be closed to return the lease
+type Lease struct {
+	client curator.CuratorFramework
+	path   string
+	closed curator.AtomicBool
+}
+
+// Return the path of the lease node
+func (l *Lease) Path() string {
+	return l.path
+}
+
+// Return the lease to the semaphore, it is safe to close a lease more than once
+func (l *Lease) Close() error {
+	if !l.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+
+	if err := l.client.Delete().ForPath(l.path); err != nil && err != zk.ErrNoNode {
+		return err
+	}
+
+	return nil
+}
+
+// A counting semaphore that works across processes.
+// All processes that use the same lock path will share the max leases.
+//
+// Each lease is an ephemeral sequential node under the path, the owners of the lowest maxLeases nodes hold the leases,
+// so the leases are acquired in the order requested and are released automatically if the holder has crashed.
+//
+// The acquirers take a lock under the path while acquiring their leases, so two acquirers never wait
+// for the leases held by each other.
+type InterProcessSemaphore struct {
+	client    curator.CuratorFramework
+	path      string
+	maxLeases int
+}
+
+func NewInterProcessSemaphore(client curator.CuratorFramework, path string, maxLeases int) *InterProcessSemaphore {
+	return &InterProcessSemaphore{client: client, path: path, maxLeases: maxLeases}
+}
+
+// Acquire a lease, blocking until it's available or the context is done
+func (s *InterProcessSemaphore) Acquire(ctx context.Context) (*Lease, error) {
+	if leases, err := s.AcquireMany(ctx, 1); err != nil {
+		return nil, err
+	} else {
+		return leases[0], nil
+	}
+}
+
+// Acquire qty leases, blocking until they are all available or the context is done.
+// The acquired leases are returned if it failed to acquire all of them.
+func (s *InterProcessSemaphore) AcquireMany(ctx context.Context, qty int) ([]*Lease, error) {
+	if err := curator.ValidatePath(s.path); err != nil {
+		return nil, err
+	}
+
+	if qty <= 0 || qty > s.maxLeases {
+		return nil, ErrInvalidLeaseQty
+	}
+
+	lock, err := NewDistributedLock(s.client, curator.JoinPath(s.path, SEMAPHORE_LOCK_NODE))
+
+	if err != nil {
+		return nil, err
+	} else if err = lock.Acquire(ctx); err != nil {
+		return nil, err
+	}
+
+	defer lock.Release()
+
+	var leases []*Lease
+
+	for i := 0; i < qty; i++ {
+		lease, err := s.acquire(ctx)
+
+		if err != nil {
+			for _, lease := range leases {
+				lease.Close()
+			}
+
+			return nil, err
+		}
+
+		leases = append(leases, lease)
+	}
+
+	return leases, nil
+}
+
+func (s *InterProcessSemaphore) acquire(ctx context.Context) (*Lease, error) {
+	ourPath, err := s.client.Create().CreatingParentsIfNeeded().WithMode(curator.EPHEMERAL_SEQUENTIAL).ForPath(curator.JoinPath(s.path, SEMAPHORE_LEASE_PREFIX))
+
+	if err != nil {
+		return nil, err
+	}
+
+	lease := &Lease{client: s.client, path: ourPath}
+	ourNode := curator.GetNodeFromPath(ourPath)
+
+	events := make(chan struct{}, 1)
+
+	watcher := curator.NewWatcher(func(event *zk.Event) {
+		select {
+		case events <- struct{}{}:
+		default:
+		}
+	})
+
+	for {
+		children, err := s.client.GetChildren().UsingWatcher(watcher).ForPath(s.path)
+
+		if err != nil {
+			lease.Close()
+
+			return nil, err
+		}
+
+		nodes := children[:0]
+
+		for _, child := range children {
+			if strings.HasPrefix(child, SEMAPHORE_LEASE_PREFIX) {
+				nodes = append(nodes, child)
+			}
+		}
+
+		sort.Strings(nodes)
+
+		idx := sort.SearchStrings(nodes, ourNode)
+
+		if idx == len(nodes) || nodes[idx] != ourNode {
+			return nil, ErrLeaseLost
+		} else if idx < s.maxLeases {
+			return lease, nil
+		}
+
+		select {
+		case <-events:
+		case <-ctx.Done():
+			lease.Close()
+
+			return nil, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package recipes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestInterProcessSemaphore(t *testing.T) {
+	Convey("Given a InterProcessSemaphore with two leases", t, func() {
+		zookeeper := newFakeZookeeper()
+
+		So(zookeeper.Start(), ShouldBeNil)
+
+		defer zookeeper.Close()
+
+		client := newFakeClient(zookeeper)
+		other := newFakeClient(zookeeper)
+
+		So(client.Start(), ShouldBeNil)
+		So(other.Start(), ShouldBeNil)
+
+		defer client.Close()
+		defer other.Close()
+
+		semaphore := NewInterProcessSemaphore(client, "/semaphores/test", 2)
+
+		Convey("Should reject an invalid quantity", func() {
+			leases, err := semaphore.AcquireMany(context.Background(), 3)
+
+			So(leases, ShouldBeNil)
+			So(err, ShouldEqual, ErrInvalidLeaseQty)
+		})
+
+		Convey("Should not deadlock when acquiring many leases concurrently", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+
+			defer cancel()
+
+			held, err := NewInterProcessSemaphore(client, "/semaphores/test", 2).AcquireMany(ctx, 2)
+
+			So(held, ShouldHaveLength, 2)
+			So(err, ShouldBeNil)
+
+			errs := make(chan error, 2)
+
+			for _, semaphore := range []*InterProcessSemaphore{semaphore, NewInterProcessSemaphore(other, "/semaphores/test", 2)} {
+				go func(semaphore *InterProcessSemaphore) {
+					leases, err := semaphore.AcquireMany(ctx, 2)
+
+					for _, lease := range leases {
+						if err := lease.Close(); err != nil {
+							t.Error(err)
+						}
+					}
+
+					errs <- err
+				}(semaphore)
+			}
+
+			time.Sleep(10 * time.Millisecond)
+
+			for _, lease := range held {
+				So(lease.Close(), ShouldBeNil)
+			}
+
+			So(<-errs, ShouldBeNil)
+			So(<-errs, ShouldBeNil)
+		})
+
+		Convey("When all the leases were acquired", func() {
+			lease, err := semaphore.Acquire(context.Background())
+
+			So(lease, ShouldNotBeNil)
+			So(err, ShouldBeNil)
+
+			otherLease, err := NewInterProcessSemaphore(other, "/semaphores/test", 2).Acquire(context.Background())
+
+			So(otherLease, ShouldNotBeNil)
+			So(err, ShouldBeNil)
+
+			Convey("Should time out and remove the waiting node", func() {
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+
+				defer cancel()
+
+				leases, err := semaphore.AcquireMany(ctx, 1)
+
+				So(leases, ShouldBeNil)
+				So(err, ShouldEqual, context.DeadlineExceeded)
+
+				children, err := client.GetChildren().ForPath("/semaphores/test")
+
+				So(children, ShouldHaveLength, 3) // the two leases and the lock node
+				So(err, ShouldBeNil)
+			})
+
+			Convey("Should unblock a waiting acquirer when a lease was closed", func() {
+				acquired := make(chan *Lease, 1)
+
+				go func() {
+					if lease, err := semaphore.Acquire(context.Background()); err != nil {
+						t.Error(err)
+					} else {
+						acquired <- lease
+					}
+				}()
+
+				time.Sleep(10 * time.Millisecond)
+
+				So(acquired, ShouldBeEmpty)
+				So(lease.Close(), ShouldBeNil)
+				So(lease.Close(), ShouldBeNil)
+
+				next := <-acquired
+
+				stat, err := client.CheckExists().ForPath(lease.Path())
+
+				So(stat, ShouldBeNil)
+				So(err, ShouldBeNil)
+				So(next.Close(), ShouldBeNil)
+			})
+
+			Convey("Should unblock a waiting acquirer when the holder has crashed", func() {
+				acquired := make(chan *Lease, 1)
+
+				go func() {
+					if lease, err := semaphore.Acquire(context.Background()); err != nil {
+						t.Error(err)
+					} else {
+						acquired <- lease
+					}
+				}()
+
+				time.Sleep(10 * time.Millisecond)
+
+				So(acquired, ShouldBeEmpty)
+				So(other.Close(), ShouldBeNil)
+
+				next := <-acquired
+
+				So(next.Close(), ShouldBeNil)
+			})
+		})
+	})
+}
@@ -0,0 +1,43 @@
+package recipes
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+)
+
+// Serialize the queue items as JSON, the zero value is ready to use
+type JSONQueueSerializer[T any] struct{}
+
+func (s JSONQueueSerializer[T]) Serialize(item T) ([]byte, error) {
+	return json.Marshal(item)
+}
+
+func (s JSONQueueSerializer[T]) Deserialize(data []byte) (T, error) {
+	var item T
+
+	err := json.Unmarshal(data, &item)
+
+	return item, err
+}
+
+// Serialize the queue items with encoding/gob, the zero value is ready to use
+type GobQueueSerializer[T any] struct{}
+
+func (s GobQueueSerializer[T]) Serialize(item T) ([]byte, error) {
+	var buf bytes.Buffer
+
+	if err := gob.NewEncoder(&buf).Encode(item); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (s GobQueueSerializer[T]) Deserialize(data []byte) (T, error) {
+	var item T
+
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&item)
+
+	return item, err
+}
@@ -0,0 +1,51 @@
+package recipes
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type queueTask struct {
+	Name     string
+	Priority int
+	Tags     []string
+}
+
+func TestQueueSerializers(t *testing.T) {
+	Convey("Given the built-in queue serializers", t, func() {
+		task := queueTask{"build", 3, []string{"ci"}}
+
+		for _, test := range []struct {
+			name       string
+			serializer QueueSerializer[queueTask]
+		}{
+			{"json", JSONQueueSerializer[queueTask]{}},
+			{"gob", GobQueueSerializer[queueTask]{}},
+		} {
+			serializer := test.serializer
+
+			Convey("Should round trip the item with "+test.name, func() {
+				data, err := serializer.Serialize(task)
+
+				So(err, ShouldBeNil)
+
+				item, err := serializer.Deserialize(data)
+
+				So(item, ShouldResemble, task)
+				So(err, ShouldBeNil)
+
+				_, err = serializer.Deserialize([]byte("corrupted"))
+
+				So(err, ShouldNotBeNil)
+			})
+		}
+
+		Convey("Should be used by the queues", func() {
+			data, err := (&queueItemSerializer[queueTask]{JSONQueueSerializer[queueTask]{}}).Serialize(QueueItem[queueTask]{1, task})
+
+			So(err, ShouldBeNil)
+			So(string(data[4:]), ShouldEqual, `{"Name":"build","Priority":3,"Tags":["ci"]}`)
+		})
+	})
+}
@@ -0,0 +1,340 @@
+package recipes
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/flier/curator.go"
+	"github.com/samuel/go-zookeeper/zk"
+)
+
+var (
+	ErrSharedValueNotStarted = errors.New("the shared value is not started")
+	ErrCorruptedCount        = errors.New("the node data is not a valid count value")
+)
+
+// Listener for changes to a shared value
+type SharedValueListener interface {
+	// Called when the shared value has changed
+	ValueHasChanged(sharedValue *SharedValue, newValue []byte)
+}
+
+type sharedValueListenerCallback func(sharedValue *SharedValue, newValue []byte)
+
+type sharedValueListenerStub struct {
+	callback sharedValueListenerCallback
+}
+
+func NewSharedValueListener(callback sharedValueListenerCallback) SharedValueListener {
+	return &sharedValueListenerStub{callback}
+}
+
+func (l *sharedValueListenerStub) ValueHasChanged(sharedValue *SharedValue, newValue []byte) {
+	l.callback(sharedValue, newValue)
+}
+
+// Manages a shared value. All clients watching the same path will have the up-to-date value
+// (considering ZK's normal consistency guarantees).
+//
+// The writes are retried on the connection errors with the RetryPolicy of the framework,
+// TrySetValue returns false if another writer has changed the value in the meantime.
+type SharedValue struct {
+	client                  curator.CuratorFramework
+	path                    string
+	seedValue               []byte
+	state                   curator.State
+	lock                    sync.RWMutex
+	value                   []byte
+	stat                    zk.Stat
+	watcher                 curator.Watcher
+	connectionStateListener curator.ConnectionStateListener
+	listeners               curator.ListenerContainer
+}
+
+func NewSharedValue(client curator.CuratorFramework, path string, seedValue []byte) *SharedValue {
+	v := &SharedValue{
+		client:    client,
+		path:      path,
+		seedValue: seedValue,
+		value:     seedValue,
+	}
+
+	v.watcher = curator.NewWatcher(func(event *zk.Event) {
+		if v.state.Value() == curator.STARTED && event.Type != zk.EventNotWatching {
+			v.refresh()
+		}
+	})
+
+	v.connectionStateListener = curator.NewConnectionStateListener(func(client curator.CuratorFramework, newState curator.ConnectionState) {
+		if newState == curator.RECONNECTED && v.state.Value() == curator.STARTED {
+			v.refresh() // the watch may be lost with the session
+		}
+	})
+
+	return v
+}
+
+// Start the value, the node is created with the seed value if it doesn't exist
+func (v *SharedValue) Start() error {
+	if !v.state.Change(curator.LATENT, curator.STARTED) {
+		return fmt.Errorf("Cannot be started more than once")
+	}
+
+	v.client.ConnectionStateListenable().AddListener(v.connectionStateListener)
+
+	_, err := v.client.Create().CreatingParentsIfNeeded().ForPathWithData(v.path, v.seedValue)
+
+	if err == nil || err == zk.ErrNodeExists {
+		_, err = v.readValue()
+	}
+
+	if err != nil {
+		v.client.ConnectionStateListenable().RemoveListener(v.connectionStateListener)
+
+		v.state.Change(curator.STARTED, curator.LATENT)
+	}
+
+	return err
+}
+
+func (v *SharedValue) Close() error {
+	if v.state.Change(curator.STARTED, curator.STOPPED) {
+		v.client.ConnectionStateListenable().RemoveListener(v.connectionStateListener)
+
+		v.listeners.Clear()
+	}
+
+	return nil
+}
+
+// Return the current value
+func (v *SharedValue) GetCurrentValue() ([]byte, error) {
+	if v.state.Value() != curator.STARTED {
+		return nil, ErrSharedValueNotStarted
+	}
+
+	value, _ := v.getValue()
+
+	return value, nil
+}
+
+// Change the shared value irrespective of its previous state
+func (v *SharedValue) SetValue(newValue []byte) error {
+	if v.state.Value() != curator.STARTED {
+		return ErrSharedValueNotStarted
+	}
+
+	stat, err := v.client.SetData().ForPathWithData(v.path, newValue)
+
+	if err != nil {
+		return err
+	}
+
+	v.update(newValue, stat)
+
+	return nil
+}
+
+// Changes the shared value only if its version has not changed since this client last read it.
+// If the value has changed, the value is not set and this client's view of the value is updated.
+// i.e. if the value is not successful you can get the updated value by calling GetCurrentValue().
+func (v *SharedValue) TrySetValue(newValue []byte) (bool, error) {
+	_, version := v.getValue()
+
+	return v.trySetValue(newValue, version)
+}
+
+// Add a listener that will be notified when the value was changed by the others
+func (v *SharedValue) AddListener(listener SharedValueListener) {
+	v.listeners.Add(listener)
+}
+
+// Remove a previously added listener
+func (v *SharedValue) RemoveListener(listener SharedValueListener) {
+	v.listeners.Remove(listener)
+}
+
+// Return the cached value and its version
+func (v *SharedValue) getValue() ([]byte, int32) {
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+
+	return v.value, v.stat.Version
+}
+
+func (v *SharedValue) trySetValue(newValue []byte, version int32) (bool, error) {
+	if v.state.Value() != curator.STARTED {
+		return false, ErrSharedValueNotStarted
+	}
+
+	stat, err := v.client.SetData().WithVersion(version).ForPathWithData(v.path, newValue)
+
+	if err == zk.ErrBadVersion {
+		v.refresh()
+
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	v.update(newValue, stat)
+
+	return true, nil
+}
+
+// Update the cached value, return true if the version has changed
+func (v *SharedValue) update(value []byte, stat *zk.Stat) bool {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if stat.Mzxid <= v.stat.Mzxid {
+		return false // stale or already known
+	}
+
+	v.value = value
+	v.stat = *stat
+
+	return true
+}
+
+func (v *SharedValue) readValue() (bool, error) {
+	var stat zk.Stat
+
+	data, err := v.client.GetData().StoringStatIn(&stat).UsingWatcher(v.watcher).ForPath(v.path)
+
+	if err != nil {
+		return false, err
+	}
+
+	return v.update(data, &stat), nil
+}
+
+// Read the value and notify the changes made by the others
+func (v *SharedValue) refresh() {
+	if changed, err := v.readValue(); err != nil {
+		log.Printf("fail to read the shared value of %s, %s", v.path, err)
+	} else if changed {
+		value, _ := v.getValue()
+
+		v.listeners.ForEach(func(listener interface{}) {
+			listener.(SharedValueListener).ValueHasChanged(v, value)
+		})
+	}
+}
+
+// The value read with the version of its node, used to change the value only if the node hasn't been changed since
+type VersionedValue[T any] struct {
+	Version int32
+	Value   T
+}
+
+// Listener for changes to a shared count
+type SharedCountListener interface {
+	// Called when the shared value has changed
+	CountHasChanged(sharedCount *SharedCount, newCount int)
+}
+
+type sharedCountListenerCallback func(sharedCount *SharedCount, newCount int)
+
+type sharedCountListenerStub struct {
+	callback sharedCountListenerCallback
+}
+
+func NewSharedCountListener(callback sharedCountListenerCallback) SharedCountListener {
+	return &sharedCountListenerStub{callback}
+}
+
+func (l *sharedCountListenerStub) CountHasChanged(sharedCount *SharedCount, newCount int) {
+	l.callback(sharedCount, newCount)
+}
+
+// Manages a shared integer. All clients watching the same path will have the up-to-date value of the shared integer
+// (considering ZK's normal consistency guarantees).
+//
+// The count is stored as a big-endian int32 in the node data.
+type SharedCount struct {
+	value     *SharedValue
+	listeners curator.ListenerContainer
+}
+
+func NewSharedCount(client curator.CuratorFramework, path string, seedValue int) *SharedCount {
+	c := &SharedCount{value: NewSharedValue(client, path, encodeCount(seedValue))}
+
+	c.value.AddListener(NewSharedValueListener(func(sharedValue *SharedValue, newValue []byte) {
+		if count, err := decodeCount(newValue); err != nil {
+			log.Printf("fail to decode the shared count of %s, %s", path, err)
+		} else {
+			c.listeners.ForEach(func(listener interface{}) {
+				listener.(SharedCountListener).CountHasChanged(c, count)
+			})
+		}
+	}))
+
+	return c
+}
+
+// Start the count, the node is created with the seed value if it doesn't exist
+func (c *SharedCount) Start() error {
+	return c.value.Start()
+}
+
+func (c *SharedCount) Close() error {
+	c.listeners.Clear()
+
+	return c.value.Close()
+}
+
+// Return the current value of the count
+func (c *SharedCount) GetCount() int {
+	return c.GetVersionedValue().Value
+}
+
+// Return the current value of the count with its version, which could be given to TrySetCount
+func (c *SharedCount) GetVersionedValue() VersionedValue[int] {
+	value, version := c.value.getValue()
+
+	count, _ := decodeCount(value)
+
+	return VersionedValue[int]{version, count}
+}
+
+// Change the shared count value irrespective of its previous state
+func (c *SharedCount) SetCount(count int) error {
+	return c.value.SetValue(encodeCount(count))
+}
+
+// Changes the shared count only if its version has not changed since the previous value was read by GetVersionedValue.
+// If the count has changed, the value is not set and this client's view of the value is updated.
+// i.e. if the count is not successful you can get the updated value by calling GetVersionedValue().
+func (c *SharedCount) TrySetCount(previous VersionedValue[int], count int) (bool, error) {
+	return c.value.trySetValue(encodeCount(count), previous.Version)
+}
+
+// Add a listener that will be notified when the count was changed by the others
+func (c *SharedCount) AddListener(listener SharedCountListener) {
+	c.listeners.Add(listener)
+}
+
+// Remove a previously added listener
+func (c *SharedCount) RemoveListener(listener SharedCountListener) {
+	c.listeners.Remove(listener)
+}
+
+func encodeCount(count int) []byte {
+	data := make([]byte, 4)
+
+	binary.BigEndian.PutUint32(data, uint32(int32(count)))
+
+	return data
+}
+
+func decodeCount(data []byte) (int, error) {
+	if len(data) != 4 {
+		return 0, ErrCorruptedCount
+	}
+
+	return int(int32(binary.BigEndian.Uint32(data))), nil
+}
@@ -0,0 +1,244 @@
+package recipes
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/flier/curator.go"
+	"github.com/samuel/go-zookeeper/zk"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSharedCount(t *testing.T) {
+	Convey("Given a SharedCount", t, func() {
+		zookeeper := newFakeZookeeper()
+
+		So(zookeeper.Start(), ShouldBeNil)
+
+		defer zookeeper.Close()
+
+		client := newFakeClient(zookeeper)
+
+		So(client.Start(), ShouldBeNil)
+
+		defer client.Close()
+
+		count := NewSharedCount(client, "/counts/test", 5)
+
+		So(count.Start(), ShouldBeNil)
+		So(count.Start(), ShouldNotBeNil)
+
+		defer count.Close()
+
+		So(count.GetCount(), ShouldEqual, 5)
+
+		data, err := client.GetData().ForPath("/counts/test")
+
+		So(data, ShouldResemble, []byte{0, 0, 0, 5})
+		So(err, ShouldBeNil)
+
+		Convey("When another client changed the count", func() {
+			changes := make(chan int, 1)
+
+			count.AddListener(NewSharedCountListener(func(sharedCount *SharedCount, newCount int) {
+				changes <- newCount
+			}))
+
+			other := NewSharedCount(client, "/counts/test", 0)
+
+			So(other.Start(), ShouldBeNil)
+
+			defer other.Close()
+
+			So(other.GetCount(), ShouldEqual, 5)
+			So(other.SetCount(-42), ShouldBeNil)
+
+			Convey("Should notify the listeners", func() {
+				So(<-changes, ShouldEqual, -42)
+				So(count.GetCount(), ShouldEqual, -42)
+
+				Convey("Should fail to set the count with a stale version", func() {
+					stale := NewSharedCount(client, "/counts/test", 0)
+
+					So(stale.Start(), ShouldBeNil)
+
+					defer stale.Close()
+
+					previous := stale.GetVersionedValue()
+
+					So(previous.Value, ShouldEqual, -42)
+					So(other.SetCount(7), ShouldBeNil)
+					So(<-changes, ShouldEqual, 7)
+
+					ok, err := stale.TrySetCount(previous, 8)
+
+					So(ok, ShouldBeFalse)
+					So(err, ShouldBeNil)
+					So(stale.GetCount(), ShouldEqual, 7)
+
+					ok, err = stale.TrySetCount(stale.GetVersionedValue(), 8)
+
+					So(ok, ShouldBeTrue)
+					So(err, ShouldBeNil)
+					So(<-changes, ShouldEqual, 8)
+				})
+			})
+		})
+
+		Convey("When 10 goroutines increment the count concurrently", func() {
+			var wg sync.WaitGroup
+
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+
+				go func() {
+					defer wg.Done()
+
+					counter := NewSharedCount(client, "/counts/test", 0)
+
+					if err := counter.Start(); err != nil {
+						t.Error(err)
+
+						return
+					}
+
+					defer counter.Close()
+
+					for {
+						previous := counter.GetVersionedValue()
+
+						if ok, err := counter.TrySetCount(previous, previous.Value+1); err != nil {
+							t.Error(err)
+
+							return
+						} else if ok {
+							return
+						}
+					}
+				}()
+			}
+
+			wg.Wait()
+
+			Convey("Should count all the increments", func() {
+				data, err := client.GetData().ForPath("/counts/test")
+
+				So(err, ShouldBeNil)
+
+				n, err := decodeCount(data)
+
+				So(n, ShouldEqual, 15)
+				So(err, ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestSharedValue(t *testing.T) {
+	Convey("Given two SharedValues of a nonexistent node", t, func() {
+		zookeeper := newFakeZookeeper()
+
+		So(zookeeper.Start(), ShouldBeNil)
+
+		defer zookeeper.Close()
+
+		client := newFakeClient(zookeeper)
+
+		So(client.Start(), ShouldBeNil)
+
+		defer client.Close()
+
+		value := NewSharedValue(client, "/values/test", []byte("seed"))
+		other := NewSharedValue(client, "/values/test", []byte("other"))
+
+		_, err := value.GetCurrentValue()
+
+		So(err, ShouldEqual, ErrSharedValueNotStarted)
+
+		So(value.Start(), ShouldBeNil)
+		So(other.Start(), ShouldBeNil)
+
+		defer value.Close()
+		defer other.Close()
+
+		Convey("Should create the node with the seed value", func() {
+			data, err := value.GetCurrentValue()
+
+			So(data, ShouldResemble, []byte("seed"))
+			So(err, ShouldBeNil)
+
+			data, err = other.GetCurrentValue()
+
+			So(data, ShouldResemble, []byte("seed"))
+			So(err, ShouldBeNil)
+		})
+
+		Convey("When both values were set concurrently", func() {
+			changes := make(chan []byte, 1)
+
+			other.AddListener(NewSharedValueListener(func(sharedValue *SharedValue, newValue []byte) {
+				changes <- newValue
+			}))
+
+			ok, err := value.TrySetValue([]byte("first"))
+
+			So(ok, ShouldBeTrue)
+			So(err, ShouldBeNil)
+
+			Convey("Should notify the other value and reject its stale write", func() {
+				So(<-changes, ShouldResemble, []byte("first"))
+
+				_, version := value.getValue()
+
+				ok, err := other.trySetValue([]byte("second"), version-1)
+
+				So(ok, ShouldBeFalse)
+				So(err, ShouldBeNil)
+
+				ok, err = other.TrySetValue([]byte("second"))
+
+				So(ok, ShouldBeTrue)
+				So(err, ShouldBeNil)
+
+				data, err := client.GetData().ForPath("/values/test")
+
+				So(data, ShouldResemble, []byte("second"))
+				So(err, ShouldBeNil)
+			})
+		})
+	})
+
+	Convey("Given a SharedValue fails to read the node", t, func() {
+		mocks := newMockBuilder(t)
+
+		client := mocks.Build()
+
+		So(client.Start(), ShouldBeNil)
+
+		value := NewSharedValue(client, "/value", []byte("seed"))
+
+		mocks.conn.On("Create", "/value", []byte("seed"), int32(curator.PERSISTENT), curator.OPEN_ACL_UNSAFE).Return("", zk.ErrNodeExists).Twice()
+		mocks.conn.On("GetW", "/value").Return(nil, nil, nil, zk.ErrNoAuth).Once()
+
+		So(value.Start(), ShouldEqual, zk.ErrNoAuth)
+
+		Convey("When start it again", func() {
+			mocks.conn.On("GetW", "/value").Return([]byte("data"), &zk.Stat{Mzxid: 1}, make(chan zk.Event), nil).Once()
+
+			err := value.Start()
+
+			Convey("Should read the current value", func() {
+				So(err, ShouldBeNil)
+
+				current, err := value.GetCurrentValue()
+
+				So(err, ShouldBeNil)
+				So(current, ShouldResemble, []byte("data"))
+				So(value.Close(), ShouldBeNil)
+
+				mocks.Check(t)
+			})
+		})
+	})
+}
@@ -0,0 +1,141 @@
+package recipes
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// The holders of the shared locks in this process, keyed by the token
+var sharedLockHolders sync.Map
+
+type sharedLockHolder struct {
+	acquiring chan struct{} // serialize the acquisitions of the underlying locks
+	lock      sync.Mutex
+	owner     InterProcessLock
+	count     int
+}
+
+// Re-enter the lock if it's held by any instance with the same token
+func (h *sharedLockHolder) reenter() bool {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.count > 0 {
+		h.count++
+
+		return true
+	}
+
+	return false
+}
+
+// A lock which identity is the token shared by the instances in this process.
+//
+// The instances with the same token re-enter the lock held by any of them without blocking,
+// e.g. the locks created with the different namespace facades of a CuratorFramework.
+// The underlying lock of the first instance is held until all the acquisitions are released,
+// while the instances with different tokens queue for the underlying locks as usual.
+type SharedLock struct {
+	lock   InterProcessLock
+	token  string
+	holder *sharedLockHolder
+}
+
+func NewSharedLock(lock InterProcessLock, token string) *SharedLock {
+	holder, _ := sharedLockHolders.LoadOrStore(token, &sharedLockHolder{acquiring: make(chan struct{}, 1)})
+
+	return &SharedLock{lock, token, holder.(*sharedLockHolder)}
+}
+
+// Return the token of the lock
+func (l *SharedLock) Token() string {
+	return l.token
+}
+
+func (l *SharedLock) Acquire() (bool, error) {
+	return l.acquire(-1)
+}
+
+func (l *SharedLock) AcquireTimeout(expires time.Duration) (bool, error) {
+	return l.acquire(expires)
+}
+
+func (l *SharedLock) acquire(expires time.Duration) (bool, error) {
+	if l.holder.reenter() {
+		return true, nil
+	}
+
+	var timeout <-chan time.Time
+
+	startTime := time.Now()
+
+	if expires >= 0 {
+		timer := time.NewTimer(expires)
+
+		defer timer.Stop()
+
+		timeout = timer.C
+	}
+
+	select {
+	case l.holder.acquiring <- struct{}{}:
+		defer func() { <-l.holder.acquiring }()
+	case <-timeout:
+		return false, nil
+	}
+
+	// acquired by another instance with the same token in between
+	if l.holder.reenter() {
+		return true, nil
+	}
+
+	var acquired bool
+	var err error
+
+	if expires < 0 {
+		acquired, err = l.lock.Acquire()
+	} else if remaining := expires - time.Since(startTime); remaining > 0 {
+		acquired, err = l.lock.AcquireTimeout(remaining)
+	}
+
+	if acquired && err == nil {
+		l.holder.lock.Lock()
+		l.holder.owner = l.lock
+		l.holder.count = 1
+		l.holder.lock.Unlock()
+	}
+
+	return acquired, err
+}
+
+// Perform one release of the lock, the underlying lock is released by the last one
+func (l *SharedLock) Release() error {
+	l.holder.lock.Lock()
+
+	if l.holder.count == 0 {
+		l.holder.lock.Unlock()
+
+		return fmt.Errorf("You do not own the lock: %s", l.token)
+	}
+
+	if l.holder.count--; l.holder.count > 0 {
+		l.holder.lock.Unlock()
+
+		return nil
+	}
+
+	owner := l.holder.owner
+	l.holder.owner = nil
+	l.holder.lock.Unlock()
+
+	return owner.Release()
+}
+
+// Returns true if the lock is held by any instance with the same token in this process
+func (l *SharedLock) IsAcquiredInThisProcess() bool {
+	l.holder.lock.Lock()
+	defer l.holder.lock.Unlock()
+
+	return l.holder.count > 0
+}
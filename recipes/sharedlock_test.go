@@ -0,0 +1,98 @@
+package recipes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flier/curator.go"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSharedLock(t *testing.T) {
+	Convey("Given the shared locks created with the different clients", t, func() {
+		zookeeper := newFakeZookeeper()
+
+		So(zookeeper.Start(), ShouldBeNil)
+
+		defer zookeeper.Close()
+
+		client := newFakeClient(zookeeper)
+		other := newFakeClient(zookeeper)
+
+		So(client.Start(), ShouldBeNil)
+		So(other.Start(), ShouldBeNil)
+
+		defer client.Close()
+		defer other.Close()
+
+		newLock := func(client curator.CuratorFramework, token string) *SharedLock {
+			mutex, err := NewInterProcessMutex(client, "/shared/lock")
+
+			So(err, ShouldBeNil)
+
+			return NewSharedLock(mutex, token)
+		}
+
+		lock := newLock(client, t.Name()+"-a")
+
+		ok, err := lock.Acquire()
+
+		So(ok, ShouldBeTrue)
+		So(err, ShouldBeNil)
+
+		Convey("Should re-enter the lock with the same token", func() {
+			same := newLock(other, t.Name()+"-a")
+
+			ok, err := same.AcquireTimeout(10 * time.Millisecond)
+
+			So(ok, ShouldBeTrue)
+			So(err, ShouldBeNil)
+			So(same.IsAcquiredInThisProcess(), ShouldBeTrue)
+
+			// the underlying lock is held until the last release
+			So(lock.Release(), ShouldBeNil)
+			So(same.IsAcquiredInThisProcess(), ShouldBeTrue)
+
+			different := newLock(other, t.Name()+"-b")
+
+			ok, err = different.AcquireTimeout(10 * time.Millisecond)
+
+			So(ok, ShouldBeFalse)
+			So(err, ShouldBeNil)
+
+			So(same.Release(), ShouldBeNil)
+			So(same.IsAcquiredInThisProcess(), ShouldBeFalse)
+			So(same.Release(), ShouldNotBeNil)
+
+			ok, err = different.AcquireTimeout(time.Second)
+
+			So(ok, ShouldBeTrue)
+			So(err, ShouldBeNil)
+			So(different.Release(), ShouldBeNil)
+		})
+
+		Convey("Should queue with the different token", func() {
+			different := newLock(other, t.Name()+"-c")
+
+			acquired := make(chan bool, 1)
+
+			go func() {
+				ok, err := different.AcquireTimeout(time.Second)
+
+				if err != nil {
+					t.Error(err)
+				}
+
+				acquired <- ok
+			}()
+
+			time.Sleep(10 * time.Millisecond)
+
+			So(acquired, ShouldBeEmpty)
+			So(lock.Release(), ShouldBeNil)
+			So(<-acquired, ShouldBeTrue)
+			So(different.Release(), ShouldBeNil)
+		})
+	})
+}
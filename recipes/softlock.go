@@ -0,0 +1,317 @@
+package recipes
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/flier/curator.go"
+	"github.com/samuel/go-zookeeper/zk"
+)
+
+// The preempt message written into the lock node of the holder by a higher-priority requester.
+var PREEMPT_MESSAGE = []byte("__PREEMPT__")
+
+const DEFAULT_PREEMPTION_TIMEOUT = 30 * time.Second
+
+type PreemptionListener interface {
+	// Called when a higher-priority requester wants the lock.
+	// You should release the lock before the preemption timeout, otherwise the lock node will be deleted by the requester.
+	PreemptionRequested(forLock *SoftLock)
+}
+
+type preemptionListenerCallback func(forLock *SoftLock)
+
+type preemptionListenerStub struct {
+	callback preemptionListenerCallback
+}
+
+func NewPreemptionListener(callback preemptionListenerCallback) PreemptionListener {
+	return &preemptionListenerStub{callback}
+}
+
+func (l *preemptionListenerStub) PreemptionRequested(forLock *SoftLock) {
+	l.callback(forLock)
+}
+
+// A lock that works across processes, whose holder could be bumped by a higher-priority requester.
+//
+// The waiters are served in the FIFO order like DistributedLock, the priority is stored in the lock node.
+// A requester with a higher priority than the holder writes the preempt message into the lock node of the holder,
+// and deletes the node once the preemption timeout elapsed if the holder didn't release it.
+// The ephemeral lock node can't have a TTL, so the eviction relies on the requester,
+// the evicted holder loses the lock and is notified by the Lost() channel.
+//
+// Unlike DistributedLock, it is not re-entrant.
+type SoftLock struct {
+	client            curator.CuratorFramework
+	driver            LockInternalsDriver
+	basePath          string
+	priority          int
+	preemptionTimeout time.Duration
+	err               error
+	lock              sync.Mutex
+	lockPath          string
+	lost              chan struct{}
+	listener          PreemptionListener
+}
+
+func NewSoftLock(client curator.CuratorFramework, path string, priority int) *SoftLock {
+	return &SoftLock{
+		client:            client,
+		driver:            NewStandardLockInternalsDriver(),
+		basePath:          path,
+		priority:          priority,
+		preemptionTimeout: DEFAULT_PREEMPTION_TIMEOUT,
+		err:               curator.ValidatePath(path),
+	}
+}
+
+// Set the time given to the holder to release the lock after it was asked to
+func (l *SoftLock) WithPreemptionTimeout(timeout time.Duration) *SoftLock {
+	l.preemptionTimeout = timeout
+
+	return l
+}
+
+// The listener will be called when a higher-priority requester wants the lock
+func (l *SoftLock) MakePreemptible(listener PreemptionListener) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.listener = listener
+}
+
+// Return the priority of this lock
+func (l *SoftLock) Priority() int {
+	return l.priority
+}
+
+// Acquire the lock - blocking until it's available or the context is done,
+// the lower-priority holder will be asked to release the lock.
+func (l *SoftLock) Acquire(ctx context.Context) error {
+	if l.err != nil {
+		return l.err
+	}
+
+	if l.IsAcquiredInThisProcess() {
+		return fmt.Errorf("Lock is already acquired: %s", l.basePath)
+	}
+
+	ourPath, err := l.attemptLock(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	l.lock.Lock()
+	l.lockPath = ourPath
+	l.lost = make(chan struct{})
+	l.lock.Unlock()
+
+	l.watchPreemption(ourPath)
+
+	return nil
+}
+
+// Release the lock, it's an error if the lock has been lost by an eviction.
+func (l *SoftLock) Release() error {
+	l.lock.Lock()
+	ourPath := l.lockPath
+	l.lockPath = ""
+	l.lock.Unlock()
+
+	if len(ourPath) == 0 {
+		return fmt.Errorf("You do not own the lock: %s", l.basePath)
+	}
+
+	return l.deleteOurPath(ourPath)
+}
+
+// Returns true if the lock is acquired by this process and hasn't been lost
+func (l *SoftLock) IsAcquiredInThisProcess() bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return len(l.lockPath) > 0
+}
+
+// Return a channel that will be closed if the lock node was deleted while holding the lock,
+// e.g. evicted by a higher-priority requester or the session expired.
+func (l *SoftLock) Lost() <-chan struct{} {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return l.lost
+}
+
+func (l *SoftLock) attemptLock(ctx context.Context) (string, error) {
+	ourPath, err := l.driver.CreatesTheLock(l.client, curator.JoinPath(l.basePath, LockPrefix), []byte(strconv.Itoa(l.priority)))
+
+	if err != nil {
+		return "", err
+	}
+
+	sequenceNodeName := curator.GetNodeFromPath(ourPath)
+
+	var preempted string
+	var evict <-chan time.Time
+
+	for {
+		children, err := l.client.GetChildren().ForPath(l.basePath)
+
+		if err != nil {
+			l.deleteOurPath(ourPath)
+
+			return "", err
+		}
+
+		sort.Sort(ChildrenSorter{children, func(lhs, rhs string) bool {
+			return l.driver.FixForSorting(lhs, LockPrefix) < l.driver.FixForSorting(rhs, LockPrefix)
+		}})
+
+		results, err := l.driver.GetsTheLock(l.client, children, sequenceNodeName, 1)
+
+		if err != nil {
+			l.deleteOurPath(ourPath)
+
+			return "", err
+		} else if results.GetsTheLock {
+			return ourPath, nil
+		}
+
+		// ask the holder to release the lock once
+		if holderPath := curator.JoinPath(l.basePath, children[0]); holderPath != preempted {
+			preempted = holderPath
+			evict = nil
+
+			if ok, err := l.preempt(holderPath); err != nil {
+				l.deleteOurPath(ourPath)
+
+				return "", err
+			} else if ok {
+				evict = time.After(l.preemptionTimeout)
+			}
+		}
+
+		events := make(chan struct{}, 1)
+
+		stat, err := l.client.CheckExists().UsingWatcher(curator.NewWatcher(func(event *zk.Event) {
+			select {
+			case events <- struct{}{}:
+			default:
+			}
+		})).ForPath(curator.JoinPath(l.basePath, results.PathToWatch))
+
+		if err != nil {
+			l.deleteOurPath(ourPath)
+
+			return "", err
+		} else if stat == nil {
+			continue // the previous node has gone, check again
+		}
+
+		select {
+		case <-events:
+		case <-evict:
+			evict = nil
+
+			// the holder didn't release the lock in time
+			if err := l.client.Delete().ForPath(preempted); err != nil && err != zk.ErrNoNode {
+				l.deleteOurPath(ourPath)
+
+				return "", err
+			}
+		case <-ctx.Done():
+			l.deleteOurPath(ourPath)
+
+			return "", ctx.Err()
+		}
+	}
+}
+
+// Write the preempt message into the lock node of the holder if it has a lower priority,
+// return true if the holder has been asked to release the lock.
+func (l *SoftLock) preempt(holderPath string) (bool, error) {
+	var stat zk.Stat
+
+	data, err := l.client.GetData().StoringStatIn(&stat).ForPath(holderPath)
+
+	if err == zk.ErrNoNode {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	if bytes.Equal(data, PREEMPT_MESSAGE) {
+		return true, nil // preempted by the others
+	}
+
+	if priority, err := strconv.Atoi(string(data)); err != nil || priority >= l.priority {
+		return false, nil
+	}
+
+	if _, err := l.client.SetData().WithVersion(stat.Version).ForPathWithData(holderPath, PREEMPT_MESSAGE); err == zk.ErrNoNode {
+		return false, nil
+	} else if err == zk.ErrBadVersion {
+		return true, nil // preempted by the others in between
+	} else if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Watch the data of our node, the listener will be called if it became the preempt message,
+// and the lock is lost once the node was deleted.
+func (l *SoftLock) watchPreemption(path string) {
+	data, err := l.client.GetData().UsingWatcher(curator.NewWatcher(func(event *zk.Event) {
+		switch event.Type {
+		case zk.EventNodeDataChanged:
+			l.watchPreemption(path)
+		case zk.EventNodeDeleted:
+			l.lostLock(path)
+		}
+	})).ForPath(path)
+
+	if err == zk.ErrNoNode {
+		l.lostLock(path)
+	}
+
+	if err != nil || !bytes.Equal(data, PREEMPT_MESSAGE) {
+		return
+	}
+
+	l.lock.Lock()
+	listener := l.listener
+	held := l.lockPath == path
+	l.lock.Unlock()
+
+	if listener != nil && held {
+		go listener.PreemptionRequested(l)
+	}
+}
+
+// Give up the lock if it's still held with the deleted node
+func (l *SoftLock) lostLock(path string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.lockPath == path {
+		l.lockPath = ""
+
+		close(l.lost)
+	}
+}
+
+func (l *SoftLock) deleteOurPath(path string) error {
+	if err := l.client.Delete().ForPath(path); err == zk.ErrNoNode {
+		return nil // ignore - already deleted (possibly evicted, expired session, etc.)
+	} else {
+		return err
+	}
+}
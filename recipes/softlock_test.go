@@ -0,0 +1,91 @@
+package recipes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSoftLock(t *testing.T) {
+	Convey("Given a SoftLock held by a low-priority process", t, func() {
+		zookeeper := newFakeZookeeper()
+
+		So(zookeeper.Start(), ShouldBeNil)
+
+		defer zookeeper.Close()
+
+		client := newFakeClient(zookeeper)
+		other := newFakeClient(zookeeper)
+
+		So(client.Start(), ShouldBeNil)
+		So(other.Start(), ShouldBeNil)
+
+		defer client.Close()
+		defer other.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+
+		defer cancel()
+
+		lock := NewSoftLock(client, "/softlock/test", 1)
+
+		preempted := make(chan *SoftLock, 1)
+
+		So(lock.Acquire(ctx), ShouldBeNil)
+		So(lock.IsAcquiredInThisProcess(), ShouldBeTrue)
+		So(lock.Acquire(ctx), ShouldNotBeNil)
+
+		Convey("Should release the lock when a higher-priority process requested it", func() {
+			lock.MakePreemptible(NewPreemptionListener(func(forLock *SoftLock) {
+				preempted <- forLock
+
+				if err := forLock.Release(); err != nil {
+					t.Error(err)
+				}
+			}))
+
+			higher := NewSoftLock(other, "/softlock/test", 10)
+
+			So(higher.Acquire(ctx), ShouldBeNil)
+			So(<-preempted, ShouldEqual, lock)
+			So(lock.IsAcquiredInThisProcess(), ShouldBeFalse)
+			So(higher.Release(), ShouldBeNil)
+		})
+
+		Convey("Should evict the holder which didn't release the lock in time", func() {
+			lock.MakePreemptible(NewPreemptionListener(func(forLock *SoftLock) {
+				preempted <- forLock
+			}))
+
+			higher := NewSoftLock(other, "/softlock/test", 10).WithPreemptionTimeout(50 * time.Millisecond)
+
+			So(higher.Acquire(ctx), ShouldBeNil)
+			So(<-preempted, ShouldEqual, lock)
+
+			_, ok := <-lock.Lost()
+
+			So(ok, ShouldBeFalse)
+			So(lock.IsAcquiredInThisProcess(), ShouldBeFalse)
+			So(lock.Release(), ShouldNotBeNil)
+			So(higher.Release(), ShouldBeNil)
+		})
+
+		Convey("Should not preempt the holder with a lower or equal priority", func() {
+			lock.MakePreemptible(NewPreemptionListener(func(forLock *SoftLock) {
+				preempted <- forLock
+			}))
+
+			timeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+
+			defer cancel()
+
+			lower := NewSoftLock(other, "/softlock/test", 1)
+
+			So(lower.Acquire(timeout), ShouldEqual, context.DeadlineExceeded)
+			So(preempted, ShouldBeEmpty)
+			So(lock.Release(), ShouldBeNil)
+		})
+	})
+}
@@ -0,0 +1,208 @@
+package recipes
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/flier/curator.go"
+	"github.com/samuel/go-zookeeper/zk"
+)
+
+type PersistentWatchMode int
+
+const (
+	WATCH_DATA     PersistentWatchMode = iota // Watch the node data with GetW
+	WATCH_CHILDREN                            // Watch the node children with ChildrenW
+)
+
+const PERSISTENT_WATCHER_QUEUE_SIZE = 16
+
+// A watcher that re-registers itself after each event and after the watch has been invalidated,
+// e.g. the session expired. The watches are kept by the server on reconnection within a session,
+// so it only re-registers once all the registered watches have been fired or invalidated.
+//
+// If the node doesn't exist yet, it watches for the creation with ExistsW
+// and upgrades to the data or children watch once the node appears.
+//
+// The errors of re-registering are reported to the UnhandledErrorListeners of the framework,
+// and the registering is retried after the connection has been re-established.
+type PersistentWatcher struct {
+	client                  curator.CuratorFramework
+	path                    string
+	mode                    PersistentWatchMode
+	state                   curator.State
+	lock                    sync.Mutex
+	watchLock               sync.Mutex // serialize the registering of the watches
+	watches                 int        // the number of the registered watches which haven't been fired
+	events                  chan zk.Event
+	done                    chan struct{}
+	watcher                 curator.Watcher
+	connectionStateListener curator.ConnectionStateListener
+	curatorListener         curator.CuratorListener
+}
+
+func NewPersistentWatcher(client curator.CuratorFramework, path string, mode PersistentWatchMode) *PersistentWatcher {
+	w := &PersistentWatcher{
+		client: client,
+		path:   path,
+		mode:   mode,
+		events: make(chan zk.Event, PERSISTENT_WATCHER_QUEUE_SIZE),
+		done:   make(chan struct{}),
+	}
+
+	w.watcher = curator.NewWatcher(func(event *zk.Event) {
+		w.watchLock.Lock()
+		w.watches--
+		w.watchLock.Unlock()
+
+		// the invalidated watch is re-registered after reconnected
+		if event.Type != zk.EventNotWatching {
+			w.resetOrReport()
+		}
+
+		w.deliver(event)
+	})
+
+	w.connectionStateListener = curator.NewConnectionStateListener(func(client curator.CuratorFramework, newState curator.ConnectionState) {
+		if newState == curator.RECONNECTED {
+			w.resetOrReport()
+		}
+	})
+
+	w.curatorListener = curator.NewCuratorListener(func(client curator.CuratorFramework, event curator.CuratorEvent) error {
+		if event.Type() == curator.CLOSING {
+			w.close() // the framework clears its listeners by itself
+		}
+
+		return nil
+	})
+
+	return w
+}
+
+// Start watching the node
+func (w *PersistentWatcher) Start() error {
+	if !w.state.Change(curator.LATENT, curator.STARTED) {
+		return fmt.Errorf("Cannot be started more than once")
+	}
+
+	w.client.ConnectionStateListenable().AddListener(w.connectionStateListener)
+	w.client.CuratorListenable().AddListener(w.curatorListener)
+
+	if err := w.reset(); err != nil {
+		w.client.ConnectionStateListenable().RemoveListener(w.connectionStateListener)
+		w.client.CuratorListenable().RemoveListener(w.curatorListener)
+
+		w.state.Change(curator.STARTED, curator.LATENT)
+
+		return err
+	}
+
+	return nil
+}
+
+// Stop watching the node and close the events channel
+func (w *PersistentWatcher) Close() error {
+	if w.close() {
+		w.client.ConnectionStateListenable().RemoveListener(w.connectionStateListener)
+		w.client.CuratorListenable().RemoveListener(w.curatorListener)
+	}
+
+	return nil
+}
+
+// Return the channel that receives all the watched events
+func (w *PersistentWatcher) Events() <-chan zk.Event {
+	return w.events
+}
+
+func (w *PersistentWatcher) close() bool {
+	if !w.state.Change(curator.STARTED, curator.STOPPED) {
+		return false
+	}
+
+	close(w.done)
+
+	w.lock.Lock()
+
+	close(w.events)
+
+	w.lock.Unlock()
+
+	return true
+}
+
+func (w *PersistentWatcher) deliver(event *zk.Event) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.state.Value() != curator.STARTED {
+		return
+	}
+
+	select {
+	case w.events <- *event:
+	case <-w.done:
+	}
+}
+
+func (w *PersistentWatcher) resetOrReport() {
+	if err := w.reset(); err != nil {
+		err = fmt.Errorf("fail to re-register watcher for %s, %s", w.path, err)
+
+		if listeners := w.client.UnhandledErrorListenable(); listeners.Len() > 0 {
+			listeners.ForEach(func(listener interface{}) {
+				listener.(curator.UnhandledErrorListener).UnhandledError(err)
+			})
+		} else {
+			log.Print(err)
+		}
+	}
+}
+
+// Register the watch unless the registered watches are still alive
+func (w *PersistentWatcher) reset() error {
+	w.watchLock.Lock()
+	defer w.watchLock.Unlock()
+
+	if w.watches > 0 {
+		return nil
+	}
+
+	for w.state.Value() == curator.STARTED && w.client.State() == curator.STARTED {
+		var err error
+
+		switch w.mode {
+		case WATCH_CHILDREN:
+			_, err = w.client.GetChildren().UsingWatcher(w.watcher).ForPath(w.path)
+		default:
+			_, err = w.client.GetData().UsingWatcher(w.watcher).ForPath(w.path)
+		}
+
+		if err == nil {
+			w.watches++
+		}
+
+		if err != zk.ErrNoNode {
+			return err
+		}
+
+		stat, err := w.client.CheckExists().UsingWatcher(w.watcher).ForPath(w.path)
+
+		if err != nil {
+			return err
+		}
+
+		// watch the creation, or the data of the node created in the meantime
+		w.watches++
+
+		if stat == nil || w.mode == WATCH_DATA {
+			return nil
+		}
+
+		// the node has been created in the meantime, upgrade to the children watch
+	}
+
+	return nil
+}
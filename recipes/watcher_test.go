@@ -0,0 +1,127 @@
+package recipes
+
+import (
+	"testing"
+
+	"github.com/flier/curator.go"
+	"github.com/samuel/go-zookeeper/zk"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPersistentWatcher(t *testing.T) {
+	Convey("Given a PersistentWatcher base on a nonexistent node", t, func() {
+		mocks := newMockBuilder(t)
+
+		client := mocks.Build()
+
+		So(client.Start(), ShouldBeNil)
+
+		existsEvents := make(chan zk.Event)
+		dataEvents := make(chan zk.Event)
+
+		mocks.conn.On("GetW", "/node").Return(nil, nil, nil, zk.ErrNoNode).Once()
+		mocks.conn.On("ExistsW", "/node").Return(false, nil, existsEvents, nil).Once()
+
+		watcher := NewPersistentWatcher(client, "/node", WATCH_DATA)
+
+		So(watcher.Start(), ShouldBeNil)
+
+		Convey("When the node was created", func() {
+			mocks.conn.On("GetW", "/node").Return([]byte("data"), &zk.Stat{}, dataEvents, nil)
+
+			existsEvents <- zk.Event{Type: zk.EventNodeCreated, Path: "/node"}
+
+			Convey("Should receive the event and upgrade to a data watch", func() {
+				So((<-watcher.Events()).Type, ShouldEqual, zk.EventNodeCreated)
+
+				dataEvents <- zk.Event{Type: zk.EventNodeDataChanged, Path: "/node"}
+
+				So((<-watcher.Events()).Type, ShouldEqual, zk.EventNodeDataChanged)
+
+				So(watcher.Close(), ShouldBeNil)
+
+				_, ok := <-watcher.Events()
+
+				So(ok, ShouldBeFalse)
+
+				mocks.Check(t)
+			})
+
+			Convey("Should not re-register the live watch after reconnected", func() {
+				So((<-watcher.Events()).Type, ShouldEqual, zk.EventNodeCreated)
+
+				watcher.connectionStateListener.StateChanged(client, curator.RECONNECTED)
+
+				mocks.conn.AssertNumberOfCalls(t, "GetW", 2)
+
+				dataEvents <- zk.Event{Type: zk.EventNodeDataChanged, Path: "/node"}
+
+				So((<-watcher.Events()).Type, ShouldEqual, zk.EventNodeDataChanged)
+				So(watcher.Events(), ShouldBeEmpty)
+				So(watcher.Close(), ShouldBeNil)
+			})
+
+			Convey("Should re-register the invalidated watch after reconnected", func() {
+				So((<-watcher.Events()).Type, ShouldEqual, zk.EventNodeCreated)
+
+				dataEvents <- zk.Event{Type: zk.EventNotWatching, Path: "/node"}
+
+				So((<-watcher.Events()).Type, ShouldEqual, zk.EventNotWatching)
+
+				mocks.conn.AssertNumberOfCalls(t, "GetW", 2)
+
+				watcher.connectionStateListener.StateChanged(client, curator.RECONNECTED)
+
+				mocks.conn.AssertNumberOfCalls(t, "GetW", 3)
+
+				So(watcher.Close(), ShouldBeNil)
+			})
+		})
+
+		Convey("When fail to re-register the watch", func() {
+			errs := make(chan error, 1)
+
+			client.UnhandledErrorListenable().AddListener(curator.NewUnhandledErrorListener(func(err error) {
+				errs <- err
+			}))
+
+			mocks.conn.On("GetW", "/node").Return(nil, nil, nil, zk.ErrNoAuth).Once()
+
+			existsEvents <- zk.Event{Type: zk.EventNodeCreated, Path: "/node"}
+
+			Convey("Should report the error to the UnhandledErrorListeners", func() {
+				So(<-errs, ShouldNotBeNil)
+				So((<-watcher.Events()).Type, ShouldEqual, zk.EventNodeCreated)
+				So(watcher.Close(), ShouldBeNil)
+			})
+		})
+	})
+
+	Convey("Given a PersistentWatcher failed to start", t, func() {
+		mocks := newMockBuilder(t)
+
+		client := mocks.Build()
+
+		So(client.Start(), ShouldBeNil)
+
+		mocks.conn.On("GetW", "/node").Return(nil, nil, nil, zk.ErrNoAuth).Once()
+
+		watcher := NewPersistentWatcher(client, "/node", WATCH_DATA)
+
+		So(watcher.Start(), ShouldEqual, zk.ErrNoAuth)
+
+		Convey("When start it again", func() {
+			mocks.conn.On("GetW", "/node").Return([]byte("data"), &zk.Stat{}, make(chan zk.Event), nil).Once()
+
+			err := watcher.Start()
+
+			Convey("Should watch the node", func() {
+				So(err, ShouldBeNil)
+				So(watcher.Close(), ShouldBeNil)
+
+				mocks.Check(t)
+			})
+		})
+	})
+}
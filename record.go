@@ -0,0 +1,534 @@
+package curator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/samuel/go-zookeeper/zk"
+)
+
+var (
+	ErrReplayExhausted = errors.New("no more recorded operations to replay")
+	ErrReplayMismatch  = errors.New("the operation doesn't match the recording")
+)
+
+// The errors which are restored as is when replaying, the others are restored with their messages
+var recordableErrors = []error{
+	zk.ErrConnectionClosed,
+	zk.ErrUnknown,
+	zk.ErrAPIError,
+	zk.ErrNoNode,
+	zk.ErrNoAuth,
+	zk.ErrBadVersion,
+	zk.ErrNoChildrenForEphemerals,
+	zk.ErrNodeExists,
+	zk.ErrNotEmpty,
+	zk.ErrSessionExpired,
+	zk.ErrInvalidACL,
+	zk.ErrAuthFailed,
+	zk.ErrClosing,
+	zk.ErrNothing,
+	zk.ErrSessionMoved,
+	zk.ErrReconfigDisabled,
+	zk.ErrBadArguments,
+	zk.ErrInvalidPath,
+	ErrTTLNotSupported,
+	ErrContainerNotSupported,
+}
+
+func recordError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	return err.Error()
+}
+
+func replayError(msg string) error {
+	if msg == "" {
+		return nil
+	}
+
+	for _, err := range recordableErrors {
+		if err.Error() == msg {
+			return err
+		}
+	}
+
+	return errors.New(msg)
+}
+
+// The recorded watched event
+type EventRecord struct {
+	Type  zk.EventType `json:"type"`
+	State zk.State     `json:"state"`
+	Path  string       `json:"path,omitempty"`
+	Err   string       `json:"error,omitempty"`
+}
+
+// The recorded response of an operation in the transaction
+type MultiResponseRecord struct {
+	Stat   *zk.Stat `json:"stat,omitempty"`
+	String string   `json:"string,omitempty"`
+	Err    string   `json:"error,omitempty"`
+}
+
+// The recorded call and response of a ZooKeeper operation
+type OperationRecord struct {
+	Operation string   `json:"operation"` // the method of ZookeeperConnection, e.g. "Create" or "GetW"
+	Path      string   `json:"path,omitempty"`
+	Data      []byte   `json:"data,omitempty"`
+	Flags     int32    `json:"flags,omitempty"`
+	Version   int32    `json:"version,omitempty"`
+	TTL       int64    `json:"ttl,omitempty"`
+	ACL       []zk.ACL `json:"acl,omitempty"`
+
+	Result     string                `json:"result,omitempty"` // the created or synced path
+	Exists     bool                  `json:"exists,omitempty"`
+	ResultData []byte                `json:"result_data,omitempty"`
+	Stat       *zk.Stat              `json:"stat,omitempty"`
+	Children   []string              `json:"children,omitempty"`
+	ResultACL  []zk.ACL              `json:"result_acl,omitempty"`
+	Responses  []MultiResponseRecord `json:"responses,omitempty"`
+	Event      *EventRecord          `json:"event,omitempty"` // the event fired by the watch, if any
+	Err        string                `json:"error,omitempty"`
+}
+
+// Load the recording saved by RecordingConn.Save
+func LoadRecording(r io.Reader) ([]OperationRecord, error) {
+	var records []OperationRecord
+
+	if err := json.NewDecoder(r).Decode(&records); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// The ZooKeeper connection which records every call and response of the wrapped connection
+type RecordingConn struct {
+	conn    ZookeeperConnection
+	lock    sync.Mutex
+	records []OperationRecord
+}
+
+func NewRecordingConn(real ZookeeperConnection) *RecordingConn {
+	return &RecordingConn{conn: real}
+}
+
+// Return the operations recorded so far
+func (c *RecordingConn) Records() []OperationRecord {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return append([]OperationRecord(nil), c.records...)
+}
+
+// Save the recording as JSON, it could be loaded with LoadRecording
+func (c *RecordingConn) Save(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(c.Records())
+}
+
+func (c *RecordingConn) record(record OperationRecord) int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.records = append(c.records, record)
+
+	return len(c.records) - 1
+}
+
+// Forward the watched event and record it to the operation which set the watch
+func (c *RecordingConn) watch(index int, events <-chan zk.Event) <-chan zk.Event {
+	if events == nil {
+		return nil
+	}
+
+	forward := make(chan zk.Event, 1)
+
+	go func() {
+		defer close(forward)
+
+		for event := range events {
+			c.lock.Lock()
+			if c.records[index].Event == nil {
+				c.records[index].Event = &EventRecord{event.Type, event.State, event.Path, recordError(event.Err)}
+			}
+			c.lock.Unlock()
+
+			forward <- event
+		}
+	}()
+
+	return forward
+}
+
+func (c *RecordingConn) AddAuth(scheme string, auth []byte) error {
+	err := c.conn.AddAuth(scheme, auth)
+
+	c.record(OperationRecord{Operation: "AddAuth", Err: recordError(err)})
+
+	return err
+}
+
+func (c *RecordingConn) Close() {
+	c.conn.Close()
+}
+
+func (c *RecordingConn) Create(path string, data []byte, flags int32, acl []zk.ACL) (string, error) {
+	createdPath, err := c.conn.Create(path, data, flags, acl)
+
+	c.record(OperationRecord{Operation: "Create", Path: path, Data: data, Flags: flags, ACL: acl, Result: createdPath, Err: recordError(err)})
+
+	return createdPath, err
+}
+
+func (c *RecordingConn) CreateTTL(path string, data []byte, flags int32, acl []zk.ACL, ttl int64) (string, error) {
+	ttlConn, ok := c.conn.(TTLConnection)
+
+	if !ok {
+		return "", ErrTTLNotSupported
+	}
+
+	createdPath, err := ttlConn.CreateTTL(path, data, flags, acl, ttl)
+
+	c.record(OperationRecord{Operation: "CreateTTL", Path: path, Data: data, Flags: flags, ACL: acl, TTL: ttl, Result: createdPath, Err: recordError(err)})
+
+	return createdPath, err
+}
+
+func (c *RecordingConn) CreateContainer(path string, data []byte, acl []zk.ACL) (string, error) {
+	containerConn, ok := c.conn.(ContainerConnection)
+
+	if !ok {
+		return "", ErrContainerNotSupported
+	}
+
+	createdPath, err := containerConn.CreateContainer(path, data, acl)
+
+	c.record(OperationRecord{Operation: "CreateContainer", Path: path, Data: data, ACL: acl, Result: createdPath, Err: recordError(err)})
+
+	return createdPath, err
+}
+
+func (c *RecordingConn) Exists(path string) (bool, *zk.Stat, error) {
+	exists, stat, err := c.conn.Exists(path)
+
+	c.record(OperationRecord{Operation: "Exists", Path: path, Exists: exists, Stat: stat, Err: recordError(err)})
+
+	return exists, stat, err
+}
+
+func (c *RecordingConn) ExistsW(path string) (bool, *zk.Stat, <-chan zk.Event, error) {
+	exists, stat, events, err := c.conn.ExistsW(path)
+
+	index := c.record(OperationRecord{Operation: "ExistsW", Path: path, Exists: exists, Stat: stat, Err: recordError(err)})
+
+	return exists, stat, c.watch(index, events), err
+}
+
+func (c *RecordingConn) Delete(path string, version int32) error {
+	err := c.conn.Delete(path, version)
+
+	c.record(OperationRecord{Operation: "Delete", Path: path, Version: version, Err: recordError(err)})
+
+	return err
+}
+
+func (c *RecordingConn) Get(path string) ([]byte, *zk.Stat, error) {
+	data, stat, err := c.conn.Get(path)
+
+	c.record(OperationRecord{Operation: "Get", Path: path, ResultData: data, Stat: stat, Err: recordError(err)})
+
+	return data, stat, err
+}
+
+func (c *RecordingConn) GetW(path string) ([]byte, *zk.Stat, <-chan zk.Event, error) {
+	data, stat, events, err := c.conn.GetW(path)
+
+	index := c.record(OperationRecord{Operation: "GetW", Path: path, ResultData: data, Stat: stat, Err: recordError(err)})
+
+	return data, stat, c.watch(index, events), err
+}
+
+func (c *RecordingConn) Set(path string, data []byte, version int32) (*zk.Stat, error) {
+	stat, err := c.conn.Set(path, data, version)
+
+	c.record(OperationRecord{Operation: "Set", Path: path, Data: data, Version: version, Stat: stat, Err: recordError(err)})
+
+	return stat, err
+}
+
+func (c *RecordingConn) Children(path string) ([]string, *zk.Stat, error) {
+	children, stat, err := c.conn.Children(path)
+
+	c.record(OperationRecord{Operation: "Children", Path: path, Children: children, Stat: stat, Err: recordError(err)})
+
+	return children, stat, err
+}
+
+func (c *RecordingConn) ChildrenW(path string) ([]string, *zk.Stat, <-chan zk.Event, error) {
+	children, stat, events, err := c.conn.ChildrenW(path)
+
+	index := c.record(OperationRecord{Operation: "ChildrenW", Path: path, Children: children, Stat: stat, Err: recordError(err)})
+
+	return children, stat, c.watch(index, events), err
+}
+
+func (c *RecordingConn) GetACL(path string) ([]zk.ACL, *zk.Stat, error) {
+	acl, stat, err := c.conn.GetACL(path)
+
+	c.record(OperationRecord{Operation: "GetACL", Path: path, ResultACL: acl, Stat: stat, Err: recordError(err)})
+
+	return acl, stat, err
+}
+
+func (c *RecordingConn) SetACL(path string, acl []zk.ACL, version int32) (*zk.Stat, error) {
+	stat, err := c.conn.SetACL(path, acl, version)
+
+	c.record(OperationRecord{Operation: "SetACL", Path: path, ACL: acl, Version: version, Stat: stat, Err: recordError(err)})
+
+	return stat, err
+}
+
+func (c *RecordingConn) Multi(ops ...interface{}) ([]zk.MultiResponse, error) {
+	responses, err := c.conn.Multi(ops...)
+
+	record := OperationRecord{Operation: "Multi", Err: recordError(err)}
+
+	for _, res := range responses {
+		record.Responses = append(record.Responses, MultiResponseRecord{res.Stat, res.String, recordError(res.Error)})
+	}
+
+	c.record(record)
+
+	return responses, err
+}
+
+func (c *RecordingConn) Sync(path string) (string, error) {
+	syncedPath, err := c.conn.Sync(path)
+
+	c.record(OperationRecord{Operation: "Sync", Path: path, Result: syncedPath, Err: recordError(err)})
+
+	return syncedPath, err
+}
+
+// The ZooKeeper connection which replays the recorded responses in order
+type replayConn struct {
+	lock    sync.Mutex
+	records []OperationRecord
+	next    int
+}
+
+// Create a connection which replays the recording, the operations must be called in the recorded order.
+//
+// The recorded watched events are fired once the watches are set, the other watches never fire.
+func NewReplayConn(recording []OperationRecord) ZookeeperConnection {
+	return &replayConn{records: recording}
+}
+
+func (c *replayConn) replay(operation, path string) (*OperationRecord, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.next >= len(c.records) {
+		return nil, fmt.Errorf("%w, %s(%s)", ErrReplayExhausted, operation, path)
+	}
+
+	record := &c.records[c.next]
+
+	if record.Operation != operation || record.Path != path {
+		return nil, fmt.Errorf("%w, expected %s(%s) but got %s(%s)", ErrReplayMismatch, record.Operation, record.Path, operation, path)
+	}
+
+	c.next++
+
+	return record, nil
+}
+
+func (c *replayConn) watch(record *OperationRecord) <-chan zk.Event {
+	events := make(chan zk.Event, 1)
+
+	if record.Event != nil {
+		events <- zk.Event{Type: record.Event.Type, State: record.Event.State, Path: record.Event.Path, Err: replayError(record.Event.Err)}
+
+		close(events)
+	}
+
+	return events
+}
+
+func (c *replayConn) AddAuth(scheme string, auth []byte) error {
+	record, err := c.replay("AddAuth", "")
+
+	if err != nil {
+		return err
+	}
+
+	return replayError(record.Err)
+}
+
+func (c *replayConn) Close() {}
+
+func (c *replayConn) Create(path string, data []byte, flags int32, acl []zk.ACL) (string, error) {
+	record, err := c.replay("Create", path)
+
+	if err != nil {
+		return "", err
+	}
+
+	return record.Result, replayError(record.Err)
+}
+
+func (c *replayConn) CreateTTL(path string, data []byte, flags int32, acl []zk.ACL, ttl int64) (string, error) {
+	record, err := c.replay("CreateTTL", path)
+
+	if err != nil {
+		return "", err
+	}
+
+	return record.Result, replayError(record.Err)
+}
+
+func (c *replayConn) CreateContainer(path string, data []byte, acl []zk.ACL) (string, error) {
+	record, err := c.replay("CreateContainer", path)
+
+	if err != nil {
+		return "", err
+	}
+
+	return record.Result, replayError(record.Err)
+}
+
+func (c *replayConn) Exists(path string) (bool, *zk.Stat, error) {
+	record, err := c.replay("Exists", path)
+
+	if err != nil {
+		return false, nil, err
+	}
+
+	return record.Exists, record.Stat, replayError(record.Err)
+}
+
+func (c *replayConn) ExistsW(path string) (bool, *zk.Stat, <-chan zk.Event, error) {
+	record, err := c.replay("ExistsW", path)
+
+	if err != nil {
+		return false, nil, nil, err
+	}
+
+	return record.Exists, record.Stat, c.watch(record), replayError(record.Err)
+}
+
+func (c *replayConn) Delete(path string, version int32) error {
+	record, err := c.replay("Delete", path)
+
+	if err != nil {
+		return err
+	}
+
+	return replayError(record.Err)
+}
+
+func (c *replayConn) Get(path string) ([]byte, *zk.Stat, error) {
+	record, err := c.replay("Get", path)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return record.ResultData, record.Stat, replayError(record.Err)
+}
+
+func (c *replayConn) GetW(path string) ([]byte, *zk.Stat, <-chan zk.Event, error) {
+	record, err := c.replay("GetW", path)
+
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return record.ResultData, record.Stat, c.watch(record), replayError(record.Err)
+}
+
+func (c *replayConn) Set(path string, data []byte, version int32) (*zk.Stat, error) {
+	record, err := c.replay("Set", path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return record.Stat, replayError(record.Err)
+}
+
+func (c *replayConn) Children(path string) ([]string, *zk.Stat, error) {
+	record, err := c.replay("Children", path)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return record.Children, record.Stat, replayError(record.Err)
+}
+
+func (c *replayConn) ChildrenW(path string) ([]string, *zk.Stat, <-chan zk.Event, error) {
+	record, err := c.replay("ChildrenW", path)
+
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return record.Children, record.Stat, c.watch(record), replayError(record.Err)
+}
+
+func (c *replayConn) GetACL(path string) ([]zk.ACL, *zk.Stat, error) {
+	record, err := c.replay("GetACL", path)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return record.ResultACL, record.Stat, replayError(record.Err)
+}
+
+func (c *replayConn) SetACL(path string, acl []zk.ACL, version int32) (*zk.Stat, error) {
+	record, err := c.replay("SetACL", path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return record.Stat, replayError(record.Err)
+}
+
+func (c *replayConn) Multi(ops ...interface{}) ([]zk.MultiResponse, error) {
+	record, err := c.replay("Multi", "")
+
+	if err != nil {
+		return nil, err
+	}
+
+	var responses []zk.MultiResponse
+
+	for _, res := range record.Responses {
+		responses = append(responses, zk.MultiResponse{Stat: res.Stat, String: res.String, Error: replayError(res.Err)})
+	}
+
+	return responses, replayError(record.Err)
+}
+
+func (c *replayConn) Sync(path string) (string, error) {
+	record, err := c.replay("Sync", path)
+
+	if err != nil {
+		return "", err
+	}
+
+	return record.Result, replayError(record.Err)
+}
@@ -0,0 +1,94 @@
+package curator
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/samuel/go-zookeeper/zk"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordedResults struct {
+	created   string
+	createErr error
+	data      []byte
+	stat      *zk.Stat
+	children  []string
+	event     zk.Event
+	deleted   error
+	multiErr  error
+}
+
+func runRecordedOperations(conn ZookeeperConnection) (results recordedResults) {
+	acls := zk.WorldACL(zk.PermAll)
+
+	results.created, _ = conn.Create("/node", []byte("data"), int32(PERSISTENT), acls)
+	_, results.createErr = conn.Create("/node", []byte("data"), int32(PERSISTENT), acls)
+
+	var events <-chan zk.Event
+
+	results.data, results.stat, events, _ = conn.GetW("/node")
+
+	conn.Set("/node", []byte("new"), AnyVersion)
+
+	results.event = <-events
+	results.children, _, _ = conn.Children("/")
+	results.deleted = conn.Delete("/node", 3)
+	_, results.multiErr = conn.Multi(&zk.CheckVersionRequest{Path: "/node", Version: 3})
+
+	return
+}
+
+func TestRecordingConn(t *testing.T) {
+	zookeeper := NewFakeZookeeper()
+
+	assert.NoError(t, zookeeper.Start())
+
+	defer zookeeper.Close()
+
+	conn, _, err := zookeeper.Dial(zookeeper.ConnectString(), DEFAULT_SESSION_TIMEOUT, false)
+
+	assert.NoError(t, err)
+
+	recording := NewRecordingConn(conn)
+
+	recorded := runRecordedOperations(recording)
+
+	assert.Equal(t, "/node", recorded.created)
+	assert.Equal(t, zk.ErrNodeExists, recorded.createErr)
+	assert.Equal(t, zk.ErrBadVersion, recorded.deleted)
+	assert.Equal(t, zk.EventNodeDataChanged, recorded.event.Type)
+	assert.Len(t, recording.Records(), 7)
+
+	var buf bytes.Buffer
+
+	assert.NoError(t, recording.Save(&buf))
+
+	records, err := LoadRecording(&buf)
+
+	assert.NoError(t, err)
+	assert.Equal(t, recording.Records(), records)
+
+	replay := NewReplayConn(records)
+
+	assert.Equal(t, recorded, runRecordedOperations(replay))
+
+	_, err = replay.Sync("/node")
+
+	assert.True(t, errors.Is(err, ErrReplayExhausted))
+}
+
+func TestReplayConnMismatch(t *testing.T) {
+	replay := NewReplayConn([]OperationRecord{{Operation: "Get", Path: "/node", ResultData: []byte("data")}})
+
+	_, err := replay.Create("/node", nil, int32(PERSISTENT), zk.WorldACL(zk.PermAll))
+
+	assert.True(t, errors.Is(err, ErrReplayMismatch))
+	assert.EqualError(t, err, "the operation doesn't match the recording, expected Get(/node) but got Create(/node)")
+
+	data, _, err := replay.Get("/node")
+
+	assert.Equal(t, []byte("data"), data)
+	assert.NoError(t, err)
+}
@@ -1,9 +1,16 @@
 package curator
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
 	"math"
 	"math/rand"
 	"net"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/samuel/go-zookeeper/zk"
@@ -22,6 +29,12 @@ type RetryPolicy interface {
 	AllowRetry(retryCount int, elapsedTime time.Duration, sleeper RetrySleeper) bool
 }
 
+// Implemented by the retry policies which track the results of the operations, e.g. CircuitBreakerRetryPolicy
+type RetryResultRecorder interface {
+	// Called when an operation succeeded, maybe after some retries
+	RecordSuccess()
+}
+
 type defaultRetrySleeper struct {
 }
 
@@ -70,39 +83,177 @@ func (l *retryLoop) ShouldRetry(err error) bool {
 }
 
 func (l *retryLoop) CallWithRetry(proc func() (interface{}, error)) (interface{}, error) {
+	sleeper := &timingRetrySleeper{RetrySleeper: l.retrySleeper}
+
+	if sleeper.RetrySleeper == nil {
+		sleeper.RetrySleeper = DefaultRetrySleeper
+	}
+
+	defer func() {
+		if sleeper.total > 0 && l.tracer != nil {
+			l.tracer.AddTime("retries-sleep", sleeper.total)
+		}
+	}()
+
 	for {
 		if ret, err := proc(); err == nil || !l.ShouldRetry(err) {
+			if recorder, ok := l.retryPolicy.(RetryResultRecorder); ok && err == nil {
+				recorder.RecordSuccess()
+			}
+
 			return ret, err
 		} else {
+			if !l.retryPolicy.AllowRetry(l.retryCount, time.Now().Sub(l.startTime), sleeper) {
+				l.addCount("retries-disallowed")
+
+				return ret, err
+			}
+
 			l.retryCount++
 
-			if sleeper := l.retrySleeper; sleeper == nil {
-				sleeper = DefaultRetrySleeper
-			} else {
-				if !l.retryPolicy.AllowRetry(l.retryCount, time.Now().Sub(l.startTime), sleeper) {
-					l.tracer.AddCount("retries-disallowed", 1)
+			l.addCount("retries-allowed")
+			l.addCount("retries")
+		}
+	}
+}
 
-					return ret, err
-				} else {
-					l.tracer.AddCount("retries-allowed", 1)
-				}
+func (l *retryLoop) addCount(name string) {
+	if l.tracer != nil {
+		l.tracer.AddCount(name, 1)
+	}
+}
+
+// Call the proc in a retry loop, abort and return ctx.Err() once the context is done.
+//
+// The pending Zookeeper call can't be interrupted, its result will be discarded.
+func callWithRetryContext(ctx context.Context, loop RetryLoop, proc func() (interface{}, error)) (interface{}, error) {
+	if ctx == nil {
+		return loop.CallWithRetry(proc)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var bind func() func()
+
+	if l, ok := loop.(*retryLoop); ok {
+		l.retrySleeper = &contextRetrySleeper{l.retrySleeper, ctx}
+
+		if tracer, ok := l.tracer.(GoroutineTracerDriver); ok {
+			bind = tracer.CaptureGoroutine()
+		}
+	}
+
+	type result struct {
+		ret interface{}
+		err error
+	}
+
+	results := make(chan result, 1)
+
+	go func() {
+		if bind != nil {
+			defer bind()()
+		}
+
+		ret, err := loop.CallWithRetry(func() (interface{}, error) {
+			if err := ctx.Err(); err != nil {
+				return nil, err
 			}
+
+			return proc()
+		})
+
+		results <- result{ret, err}
+	}()
+
+	select {
+	case r := <-results:
+		if r.err != nil && ctx.Err() != nil {
+			return nil, ctx.Err()
 		}
+
+		return r.ret, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Abort the sleep once the context is done
+type contextRetrySleeper struct {
+	RetrySleeper
+
+	ctx context.Context
+}
+
+func (s *contextRetrySleeper) SleepFor(d time.Duration) error {
+	if s.RetrySleeper != nil && s.RetrySleeper != DefaultRetrySleeper {
+		if err := s.RetrySleeper.SleepFor(d); err != nil {
+			return err
+		}
+
+		return s.ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-s.ctx.Done():
+		return s.ctx.Err()
 	}
+}
+
+// Sum up the sleep time of a retry cycle
+type timingRetrySleeper struct {
+	RetrySleeper
 
-	return nil, nil
+	total time.Duration
 }
 
+func (s *timingRetrySleeper) SleepFor(d time.Duration) error {
+	s.total += d
+
+	return s.RetrySleeper.SleepFor(d)
+}
+
+// Base of the retry policies that sleep between the retries,
+// the policies only have to give the sleep duration of each attempt.
 type SleepingRetry struct {
-	RetryPolicy
+	RetrySleeper // the sleeper to use when AllowRetry isn't given one, DefaultRetrySleeper if nil
+
+	MaxRetries        int
+	BaseSleepDuration time.Duration
+
+	sleepDurationForAttempt func(retryCount int) time.Duration
+}
+
+// Create a SleepingRetry which sleeps sleepDurationForAttempt(retryCount) before each retry,
+// or always baseSleep if sleepDurationForAttempt is nil.
+func NewSleepingRetry(maxRetries int, baseSleep time.Duration, sleepDurationForAttempt func(retryCount int) time.Duration) SleepingRetry {
+	return SleepingRetry{
+		MaxRetries:              maxRetries,
+		BaseSleepDuration:       baseSleep,
+		sleepDurationForAttempt: sleepDurationForAttempt,
+	}
+}
+
+// Return the sleep duration before the given retry
+func (r *SleepingRetry) SleepDurationForAttempt(retryCount int) time.Duration {
+	if r.sleepDurationForAttempt == nil {
+		return r.BaseSleepDuration
+	}
 
-	N            int
-	getSleepTime func(retryCount int, elapsedTime time.Duration) time.Duration
+	return r.sleepDurationForAttempt(retryCount)
 }
 
 func (r *SleepingRetry) AllowRetry(retryCount int, elapsedTime time.Duration, sleeper RetrySleeper) bool {
-	if retryCount < r.N {
-		if err := sleeper.SleepFor(r.getSleepTime(retryCount, elapsedTime)); err != nil {
+	if retryCount < r.MaxRetries {
+		if err := r.sleeper(sleeper).SleepFor(r.SleepDurationForAttempt(retryCount)); err != nil {
 			return false
 		}
 
@@ -112,17 +263,35 @@ func (r *SleepingRetry) AllowRetry(retryCount int, elapsedTime time.Duration, sl
 	return false
 }
 
-// Retry policy that retries a max number of times
+// Return the maximum number of retries
+func (r *SleepingRetry) RetryCount() int {
+	return r.MaxRetries
+}
+
+func (r *SleepingRetry) sleeper(sleeper RetrySleeper) RetrySleeper {
+	if sleeper != nil {
+		return sleeper
+	} else if r.RetrySleeper != nil {
+		return r.RetrySleeper
+	}
+
+	return DefaultRetrySleeper
+}
+
+// Retry policy that retries a max number of times, doubling the sleep time on each retry up to maxSleep
 type RetryNTimes struct {
 	SleepingRetry
 }
 
-func NewRetryNTimes(n int, sleepBetweenRetries time.Duration) *RetryNTimes {
+func NewRetryNTimes(maxRetries int, baseSleep, maxSleep time.Duration) RetryPolicy {
+	return newRetryNTimes(maxRetries, baseSleep, maxSleep)
+}
+
+func newRetryNTimes(maxRetries int, baseSleep, maxSleep time.Duration) *RetryNTimes {
 	return &RetryNTimes{
-		SleepingRetry: SleepingRetry{
-			N:            n,
-			getSleepTime: func(retryCount int, elapsedTime time.Duration) time.Duration { return sleepBetweenRetries },
-		},
+		SleepingRetry: NewSleepingRetry(maxRetries, baseSleep, func(retryCount int) time.Duration {
+			return backoffSleepTime(baseSleep, maxSleep, retryCount)
+		}),
 	}
 }
 
@@ -133,7 +302,7 @@ type RetryOneTime struct {
 
 func NewRetryOneTime(sleepBetweenRetry time.Duration) *RetryOneTime {
 	return &RetryOneTime{
-		*NewRetryNTimes(1, sleepBetweenRetry),
+		*newRetryNTimes(1, sleepBetweenRetry, sleepBetweenRetry),
 	}
 }
 
@@ -142,29 +311,87 @@ const (
 	DEFAULT_MAX_SLEEP time.Duration = time.Duration(math.MaxInt32 * int64(time.Second))
 )
 
-// Retry policy that retries a set number of times with increasing sleep time between retries
+// Retry policy that sleeps baseSleep * 2^retryCount between retries, clamped to maxSleep,
+// with a random jitter of +/-10% to avoid the synchronized reconnect storms.
 type ExponentialBackoffRetry struct {
 	SleepingRetry
+
+	maxSleep  time.Duration
+	nextFloat func() float64
 }
 
-func NewExponentialBackoffRetry(baseSleepTime time.Duration, maxRetries int, maxSleep time.Duration) *ExponentialBackoffRetry {
+func NewExponentialBackoffRetry(baseSleep, maxSleep time.Duration, maxRetries int) RetryPolicy {
 	if maxRetries > MAX_RETRIES_LIMIT {
 		maxRetries = MAX_RETRIES_LIMIT
 	}
 
-	return &ExponentialBackoffRetry{
-		SleepingRetry: SleepingRetry{
-			N: maxRetries,
-			getSleepTime: func(retryCount int, elapsedTime time.Duration) time.Duration {
-				sleepTime := time.Duration(int64(baseSleepTime) * rand.Int63n(1<<uint(retryCount)))
+	r := &ExponentialBackoffRetry{maxSleep: maxSleep, nextFloat: rand.Float64}
+
+	r.SleepingRetry = NewSleepingRetry(maxRetries, baseSleep, r.sleepTime)
+
+	return r
+}
 
-				if sleepTime > maxSleep {
-					sleepTime = maxSleep
-				}
+// Use the given random source for the jitter, e.g. for the deterministic tests
+func (r *ExponentialBackoffRetry) UsingRandom(random *rand.Rand) *ExponentialBackoffRetry {
+	r.nextFloat = random.Float64
 
-				return sleepTime
-			},
-		}}
+	return r
+}
+
+func (r *ExponentialBackoffRetry) sleepTime(retryCount int) time.Duration {
+	sleepTime := backoffSleepTime(r.BaseSleepDuration, r.maxSleep, retryCount)
+
+	sleepTime += time.Duration((r.nextFloat()*0.2 - 0.1) * float64(sleepTime))
+
+	if sleepTime > r.maxSleep {
+		sleepTime = r.maxSleep
+	}
+
+	return sleepTime
+}
+
+// Retry policy that doubles the sleep time between retries up to maxSleep,
+// and stops once maxElapsed passed since the policy has been created.
+type BoundedExponentialBackoffRetry struct {
+	SleepingRetry
+
+	operationStart time.Time
+	maxElapsed     time.Duration
+}
+
+func NewBoundedExponentialBackoffRetry(baseSleep, maxSleep, maxElapsed time.Duration) *BoundedExponentialBackoffRetry {
+	return &BoundedExponentialBackoffRetry{
+		SleepingRetry: NewSleepingRetry(MAX_RETRIES_LIMIT, baseSleep, func(retryCount int) time.Duration {
+			return backoffSleepTime(baseSleep, maxSleep, retryCount)
+		}),
+		operationStart: time.Now(),
+		maxElapsed:     maxElapsed,
+	}
+}
+
+func (r *BoundedExponentialBackoffRetry) AllowRetry(retryCount int, elapsedTime time.Duration, sleeper RetrySleeper) bool {
+	return r.AllowRetryWithClock(retryCount, elapsedTime, sleeper, time.Now)
+}
+
+// Same as AllowRetry but check the deadline with the given clock
+func (r *BoundedExponentialBackoffRetry) AllowRetryWithClock(retryCount int, elapsedTime time.Duration, sleeper RetrySleeper, now func() time.Time) bool {
+	return now().Sub(r.operationStart) < r.maxElapsed && r.SleepingRetry.AllowRetry(retryCount, elapsedTime, sleeper)
+}
+
+// Return baseSleep * 2^retryCount, clamped to maxSleep
+func backoffSleepTime(baseSleep, maxSleep time.Duration, retryCount int) time.Duration {
+	sleepTime := baseSleep
+
+	for i := 0; i < retryCount && sleepTime < maxSleep; i++ {
+		sleepTime *= 2
+	}
+
+	if sleepTime > maxSleep {
+		sleepTime = maxSleep
+	}
+
+	return sleepTime
 }
 
 // A retry policy that retries until a given amount of time elapses
@@ -176,10 +403,7 @@ type RetryUntilElapsed struct {
 
 func NewRetryUntilElapsed(maxElapsedTime, sleepBetweenRetries time.Duration) *RetryUntilElapsed {
 	return &RetryUntilElapsed{
-		SleepingRetry: SleepingRetry{
-			N:            math.MaxInt64,
-			getSleepTime: func(retryCount int, elapsedTime time.Duration) time.Duration { return sleepBetweenRetries },
-		},
+		SleepingRetry:  NewSleepingRetry(math.MaxInt64, sleepBetweenRetries, nil),
 		maxElapsedTime: maxElapsedTime,
 	}
 }
@@ -187,3 +411,360 @@ func NewRetryUntilElapsed(maxElapsedTime, sleepBetweenRetries time.Duration) *Re
 func (r *RetryUntilElapsed) AllowRetry(retryCount int, elapsedTime time.Duration, sleeper RetrySleeper) bool {
 	return elapsedTime < r.maxElapsedTime && r.SleepingRetry.AllowRetry(retryCount, elapsedTime, sleeper)
 }
+
+// A retry policy that never stops retrying, sleeping between the attempts,
+// unless the optional context has been cancelled
+type RetryForever struct {
+	SleepingRetry
+
+	ctx context.Context
+}
+
+func NewRetryForever(sleepBetweenRetries time.Duration) *RetryForever {
+	return NewRetryForeverWithContext(nil, sleepBetweenRetries)
+}
+
+func NewRetryForeverWithContext(ctx context.Context, sleepBetweenRetries time.Duration) *RetryForever {
+	return &RetryForever{
+		SleepingRetry: NewSleepingRetry(math.MaxInt64, sleepBetweenRetries, nil),
+		ctx:           ctx,
+	}
+}
+
+func (r *RetryForever) AllowRetry(retryCount int, elapsedTime time.Duration, sleeper RetrySleeper) bool {
+	if r.cancelled() {
+		return false
+	}
+
+	if err := r.sleeper(sleeper).SleepFor(r.SleepDurationForAttempt(retryCount)); err != nil {
+		return false
+	}
+
+	return !r.cancelled()
+}
+
+func (r *RetryForever) cancelled() bool {
+	return r.ctx != nil && r.ctx.Err() != nil
+}
+
+const (
+	TRACE_CIRCUIT_OPEN      = "circuit_open"
+	TRACE_CIRCUIT_HALF_OPEN = "circuit_half_open"
+	TRACE_CIRCUIT_CLOSED    = "circuit_closed"
+)
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// A retry policy that stops retrying for a while once too many consecutive failures have been seen.
+//
+// The failures are delegated to the inner policy while the circuit is closed, the circuit opens after maxFailures
+// consecutive failures and disallows the retries without sleeping. After resetTimeout, the circuit becomes half-open
+// and allows one more try, it closes again on success or opens again on failure.
+//
+// The policy should be shared by the operations, the successes are reported by the retry loop.
+type CircuitBreakerRetryPolicy struct {
+	inner        RetryPolicy
+	maxFailures  int
+	resetTimeout time.Duration
+	tracer       TracerDriver
+	now          func() time.Time
+	lock         sync.Mutex
+	state        circuitState
+	failures     int
+	openedAt     time.Time
+}
+
+func NewCircuitBreakerRetryPolicy(maxFailures int, resetTimeout time.Duration, inner RetryPolicy) *CircuitBreakerRetryPolicy {
+	return &CircuitBreakerRetryPolicy{
+		inner:        inner,
+		maxFailures:  maxFailures,
+		resetTimeout: resetTimeout,
+		now:          time.Now,
+	}
+}
+
+// Report the state transitions of the circuit to the tracer
+func (p *CircuitBreakerRetryPolicy) UsingTracer(tracer TracerDriver) *CircuitBreakerRetryPolicy {
+	p.tracer = tracer
+
+	return p
+}
+
+func (p *CircuitBreakerRetryPolicy) AllowRetry(retryCount int, elapsedTime time.Duration, sleeper RetrySleeper) bool {
+	p.lock.Lock()
+
+	switch p.state {
+	case circuitOpen:
+		if p.now().Sub(p.openedAt) < p.resetTimeout {
+			p.lock.Unlock()
+
+			return false
+		}
+
+		p.setState(circuitHalfOpen, TRACE_CIRCUIT_HALF_OPEN)
+
+	case circuitHalfOpen:
+		// the trial failed
+		p.open()
+		p.lock.Unlock()
+
+		return false
+
+	default:
+		if p.failures++; p.failures >= p.maxFailures {
+			p.open()
+			p.lock.Unlock()
+
+			return false
+		}
+	}
+
+	p.lock.Unlock()
+
+	return p.inner.AllowRetry(retryCount, elapsedTime, sleeper)
+}
+
+func (p *CircuitBreakerRetryPolicy) RecordSuccess() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.failures = 0
+
+	if p.state != circuitClosed {
+		p.setState(circuitClosed, TRACE_CIRCUIT_CLOSED)
+	}
+}
+
+// Return true if the circuit is open, the half-open circuit is not counted
+func (p *CircuitBreakerRetryPolicy) IsOpen() bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.state == circuitOpen
+}
+
+func (p *CircuitBreakerRetryPolicy) open() {
+	p.failures = 0
+	p.openedAt = p.now()
+
+	p.setState(circuitOpen, TRACE_CIRCUIT_OPEN)
+}
+
+func (p *CircuitBreakerRetryPolicy) setState(state circuitState, trace string) {
+	p.state = state
+
+	if p.tracer != nil {
+		p.tracer.AddCount(trace, 1)
+	}
+}
+
+// The configuration of DynamicRetryPolicy stored in the node as JSON, e.g.
+//
+//	{"type": "exponential", "max_retries": 3, "base_sleep": "100ms", "max_sleep": "10s"}
+type RetryPolicyConfig struct {
+	Type       string `json:"type"` // "exponential" (default), "n_times", "one_time", "until_elapsed" or "forever"
+	MaxRetries int    `json:"max_retries"`
+	BaseSleep  string `json:"base_sleep"`
+	MaxSleep   string `json:"max_sleep"`
+	MaxElapsed string `json:"max_elapsed"`
+}
+
+// Create the retry policy described by the configuration
+func (c *RetryPolicyConfig) NewRetryPolicy() (RetryPolicy, error) {
+	var durations [3]time.Duration
+
+	for i, value := range []string{c.BaseSleep, c.MaxSleep, c.MaxElapsed} {
+		if len(value) > 0 {
+			d, err := time.ParseDuration(value)
+
+			if err != nil {
+				return nil, err
+			}
+
+			durations[i] = d
+		}
+	}
+
+	baseSleep, maxSleep, maxElapsed := durations[0], durations[1], durations[2]
+
+	switch c.Type {
+	case "", "exponential":
+		if maxSleep == 0 {
+			maxSleep = math.MaxInt64
+		}
+
+		return NewExponentialBackoffRetry(baseSleep, maxSleep, c.MaxRetries), nil
+	case "n_times":
+		if maxSleep == 0 {
+			maxSleep = baseSleep
+		}
+
+		return NewRetryNTimes(c.MaxRetries, baseSleep, maxSleep), nil
+	case "one_time":
+		return NewRetryOneTime(baseSleep), nil
+	case "until_elapsed":
+		return NewRetryUntilElapsed(maxElapsed, baseSleep), nil
+	case "forever":
+		return NewRetryForever(baseSleep), nil
+	default:
+		return nil, fmt.Errorf("unknown retry policy `%s`", c.Type)
+	}
+}
+
+// A retry policy that delegates to the policy configured in a node, which is reloaded whenever the node changed.
+//
+// The default policy is used until the policy is started, or if the node is deleted or the configuration is invalid.
+type DynamicRetryPolicy struct {
+	client        CuratorFramework
+	configPath    string
+	defaultPolicy RetryPolicy
+	current       atomic.Value // retryPolicyHolder
+	state         State
+	done          chan struct{}
+	wg            sync.WaitGroup
+}
+
+type retryPolicyHolder struct {
+	RetryPolicy
+}
+
+func NewDynamicRetryPolicy(client CuratorFramework, configPath string, defaultPolicy RetryPolicy) *DynamicRetryPolicy {
+	p := &DynamicRetryPolicy{
+		client:        client,
+		configPath:    configPath,
+		defaultPolicy: defaultPolicy,
+		done:          make(chan struct{}),
+	}
+
+	p.current.Store(retryPolicyHolder{defaultPolicy})
+
+	return p
+}
+
+// Read the configuration and keep watching it until closed
+func (p *DynamicRetryPolicy) Start() error {
+	if !p.state.Change(LATENT, STARTED) {
+		return errors.New("Cannot be started more than once")
+	}
+
+	events, err := p.read()
+
+	if err != nil {
+		p.state.Change(STARTED, LATENT)
+
+		return err
+	}
+
+	p.wg.Add(1)
+
+	go func() {
+		defer p.wg.Done()
+
+		p.watch(events)
+	}()
+
+	return nil
+}
+
+func (p *DynamicRetryPolicy) Close() error {
+	if p.state.Change(STARTED, STOPPED) {
+		close(p.done)
+
+		p.wg.Wait()
+	}
+
+	return nil
+}
+
+// Return the policy in use
+func (p *DynamicRetryPolicy) Current() RetryPolicy {
+	return p.current.Load().(retryPolicyHolder).RetryPolicy
+}
+
+func (p *DynamicRetryPolicy) AllowRetry(retryCount int, elapsedTime time.Duration, sleeper RetrySleeper) bool {
+	return p.Current().AllowRetry(retryCount, elapsedTime, sleeper)
+}
+
+func (p *DynamicRetryPolicy) RecordSuccess() {
+	if recorder, ok := p.Current().(RetryResultRecorder); ok {
+		recorder.RecordSuccess()
+	}
+}
+
+// Re-read the node whenever it changed, until the policy is closed
+func (p *DynamicRetryPolicy) watch(events <-chan struct{}) {
+	for {
+		select {
+		case <-events:
+		case <-p.done:
+			return
+		}
+
+		for {
+			var err error
+
+			if events, err = p.read(); err == nil {
+				break
+			}
+
+			select {
+			case <-time.After(DEFAULT_CONFIG_RETRY_INTERVAL):
+			case <-p.done:
+				return
+			}
+		}
+	}
+}
+
+// Read the node and set a watch on it, the default policy is used if the node doesn't exist or is invalid
+func (p *DynamicRetryPolicy) read() (<-chan struct{}, error) {
+	events := make(chan struct{}, 1)
+
+	watcher := NewWatcher(func(event *zk.Event) {
+		select {
+		case events <- struct{}{}:
+		default:
+		}
+	})
+
+	data, err := p.client.GetData().UsingWatcher(watcher).ForPath(p.configPath)
+
+	if err == zk.ErrNoNode {
+		stat, err := p.client.CheckExists().UsingWatcher(watcher).ForPath(p.configPath)
+
+		if err != nil {
+			return nil, err
+		} else if stat != nil {
+			return p.read() // created in between
+		}
+
+		p.current.Store(retryPolicyHolder{p.defaultPolicy})
+
+		return events, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var config RetryPolicyConfig
+	var policy RetryPolicy
+
+	if err = json.Unmarshal(data, &config); err == nil {
+		policy, err = config.NewRetryPolicy()
+	}
+
+	if err != nil {
+		slog.Error("invalid retry policy config, fall back to the default policy", "path", p.configPath, "err", err)
+
+		policy = p.defaultPolicy
+	}
+
+	p.current.Store(retryPolicyHolder{policy})
+
+	return events, nil
+}
@@ -1,6 +1,12 @@
 package curator
 
 import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"reflect"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -11,7 +17,7 @@ import (
 
 func TestRetryLoop(t *testing.T) {
 	d := 3 * time.Second
-	p := NewRetryNTimes(3, d)
+	p := NewRetryNTimes(3, d, d)
 	sleeper := &mockRetrySleeper{}
 	tracer := &mockTracerDriver{}
 
@@ -26,6 +32,8 @@ func TestRetryLoop(t *testing.T) {
 
 	sleeper.On("SleepFor", d).Return(nil).Times(2)
 	tracer.On("AddCount", "retries-allowed", 1).Return().Twice()
+	tracer.On("AddCount", "retries", 1).Return().Twice()
+	tracer.On("AddTime", "retries-sleep", 2*d).Return().Once()
 
 	_, err := retryLoop.CallWithRetry(func() (interface{}, error) {
 		return nil, errors[retryLoop.retryCount]
@@ -49,7 +57,7 @@ func TestRetryLoop(t *testing.T) {
 
 func TestRetryNTimes(t *testing.T) {
 	d := 3 * time.Second
-	p := NewRetryNTimes(3, d)
+	p := NewRetryNTimes(3, d, d)
 	s := &mockRetrySleeper{}
 
 	assert.NotNil(t, p)
@@ -64,6 +72,109 @@ func TestRetryNTimes(t *testing.T) {
 	s.AssertExpectations(t)
 }
 
+func TestRetryNTimesWithBackoff(t *testing.T) {
+	d := 1 * time.Second
+	p := NewRetryNTimes(4, d, 5*time.Second).(*RetryNTimes)
+	s := &mockRetrySleeper{}
+
+	assert.NotNil(t, p)
+	assert.Equal(t, 4, p.RetryCount())
+
+	s.On("SleepFor", 1*d).Return(nil).Once()
+	s.On("SleepFor", 2*d).Return(nil).Once()
+	s.On("SleepFor", 4*d).Return(nil).Once()
+	s.On("SleepFor", 5*d).Return(nil).Once()
+
+	assert.True(t, p.AllowRetry(0, 0, s))
+	assert.True(t, p.AllowRetry(1, 0, s))
+	assert.True(t, p.AllowRetry(2, 0, s))
+	assert.True(t, p.AllowRetry(3, 0, s))
+	assert.False(t, p.AllowRetry(4, 0, s))
+
+	s.AssertExpectations(t)
+
+	// zero retries
+	p = NewRetryNTimes(0, d, 5*time.Second).(*RetryNTimes)
+
+	assert.False(t, p.AllowRetry(0, 0, s))
+
+	// the retry loop stops once the retries exhausted
+	tracer := &mockTracerDriver{}
+	retryLoop := newRetryLoop(p, tracer)
+
+	tracer.On("AddCount", "retries-disallowed", 1).Return().Once()
+
+	_, err := retryLoop.CallWithRetry(func() (interface{}, error) {
+		return nil, zk.ErrSessionExpired
+	})
+
+	assert.Equal(t, zk.ErrSessionExpired, err)
+	assert.Equal(t, 0, retryLoop.retryCount)
+
+	tracer.AssertExpectations(t)
+}
+
+func TestExponentialBackoffRetryWithJitter(t *testing.T) {
+	d := 100 * time.Millisecond
+	max := 1 * time.Second
+	p := NewExponentialBackoffRetry(d, max, 5).(*ExponentialBackoffRetry).UsingRandom(rand.New(rand.NewSource(1)))
+
+	assert.NotNil(t, p)
+	assert.Equal(t, 5, p.RetryCount())
+
+	for i := 0; i < 100; i++ {
+		for n, sleep := range []time.Duration{d, 2 * d, 4 * d, 8 * d} {
+			sleepTime := p.SleepDurationForAttempt(n)
+
+			assert.True(t, sleepTime >= sleep*9/10, "sleep %s shorter than %s", sleepTime, sleep)
+			assert.True(t, sleepTime <= sleep*11/10, "sleep %s longer than %s", sleepTime, sleep)
+		}
+
+		// clamped to the max sleep time instead of being skipped
+		sleepTime := p.SleepDurationForAttempt(4)
+
+		assert.True(t, sleepTime >= max*9/10 && sleepTime <= max, "sleep %s out of range", sleepTime)
+	}
+
+	// the same seed gives the same sleep times
+	p1 := NewExponentialBackoffRetry(d, max, 5).(*ExponentialBackoffRetry).UsingRandom(rand.New(rand.NewSource(42)))
+	p2 := NewExponentialBackoffRetry(d, max, 5).(*ExponentialBackoffRetry).UsingRandom(rand.New(rand.NewSource(42)))
+
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, p1.SleepDurationForAttempt(i), p2.SleepDurationForAttempt(i))
+	}
+
+	// the retries are limited
+	assert.Equal(t, MAX_RETRIES_LIMIT, NewExponentialBackoffRetry(d, max, MAX_RETRIES_LIMIT+1).(*ExponentialBackoffRetry).RetryCount())
+}
+
+func TestBoundedExponentialBackoffRetry(t *testing.T) {
+	d := 1 * time.Second
+	p := NewBoundedExponentialBackoffRetry(d, 4*d, 30*time.Second)
+	s := &mockRetrySleeper{}
+
+	assert.NotNil(t, p)
+	assert.Equal(t, MAX_RETRIES_LIMIT, p.RetryCount())
+
+	start := p.operationStart
+
+	s.On("SleepFor", 1*d).Return(nil).Once()
+	s.On("SleepFor", 2*d).Return(nil).Once()
+	s.On("SleepFor", 4*d).Return(nil).Once()
+
+	assert.True(t, p.AllowRetryWithClock(0, 0, s, func() time.Time { return start }))
+	assert.True(t, p.AllowRetryWithClock(1, 0, s, func() time.Time { return start.Add(10 * time.Second) }))
+	assert.True(t, p.AllowRetryWithClock(2, 0, s, func() time.Time { return start.Add(29 * time.Second) }))
+
+	// the deadline exceeded
+	assert.False(t, p.AllowRetryWithClock(3, 0, s, func() time.Time { return start.Add(30 * time.Second) }))
+
+	// the retries exhausted
+	assert.False(t, p.AllowRetryWithClock(MAX_RETRIES_LIMIT, 0, s, func() time.Time { return start }))
+
+	s.AssertExpectations(t)
+}
+
 func TestRetryOneTime(t *testing.T) {
 	d := 3 * time.Second
 	p := NewRetryOneTime(d)
@@ -81,7 +192,7 @@ func TestRetryOneTime(t *testing.T) {
 
 func TestExponentialBackoffRetry(t *testing.T) {
 	d := 3 * time.Second
-	p := NewExponentialBackoffRetry(d, 3, 9*time.Second)
+	p := NewExponentialBackoffRetry(d, 9*time.Second, 3)
 	s := &mockRetrySleeper{}
 
 	assert.NotNil(t, p)
@@ -93,9 +204,9 @@ func TestExponentialBackoffRetry(t *testing.T) {
 	assert.True(t, p.AllowRetry(2, 0, s))
 	assert.False(t, p.AllowRetry(3, 0, s))
 
-	assert.True(t, s.Calls[0].Arguments.Get(0).(time.Duration) < 1*d)
-	assert.True(t, s.Calls[1].Arguments.Get(0).(time.Duration) < 2*d)
-	assert.True(t, s.Calls[2].Arguments.Get(0).(time.Duration) < 4*d)
+	assert.True(t, s.Calls[0].Arguments.Get(0).(time.Duration) <= d*11/10)
+	assert.True(t, s.Calls[1].Arguments.Get(0).(time.Duration) <= 2*d*11/10)
+	assert.True(t, s.Calls[2].Arguments.Get(0).(time.Duration) <= 9*time.Second)
 
 	s.AssertExpectations(t)
 }
@@ -116,3 +227,332 @@ func TestRetryUntilElapsed(t *testing.T) {
 
 	s.AssertExpectations(t)
 }
+
+func TestRetryForever(t *testing.T) {
+	d := 3 * time.Second
+	p := NewRetryForever(d)
+	s := &mockRetrySleeper{}
+
+	assert.NotNil(t, p)
+
+	s.On("SleepFor", d).Return(nil).Times(3)
+
+	assert.True(t, p.AllowRetry(0, 0, s))
+	assert.True(t, p.AllowRetry(MAX_RETRIES_LIMIT, 0, s))
+	assert.True(t, p.AllowRetry(math.MaxInt32, time.Hour, s))
+
+	s.AssertExpectations(t)
+
+	// the retry loop counts each retry
+	tracer := &mockTracerDriver{}
+	retryLoop := newRetryLoop(p, tracer)
+	retryLoop.retrySleeper = s
+
+	s.On("SleepFor", d).Return(nil).Times(5)
+	tracer.On("AddCount", "retries-allowed", 1).Return().Times(5)
+	tracer.On("AddCount", "retries", 1).Return().Times(5)
+	tracer.On("AddTime", "retries-sleep", 5*d).Return().Once()
+
+	_, err := retryLoop.CallWithRetry(func() (interface{}, error) {
+		if retryLoop.retryCount < 5 {
+			return nil, zk.ErrSessionExpired
+		}
+
+		return nil, nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 5, retryLoop.retryCount)
+
+	s.AssertExpectations(t)
+	tracer.AssertExpectations(t)
+
+	// stop retrying once the context cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+
+	p = NewRetryForeverWithContext(ctx, d)
+	s = &mockRetrySleeper{}
+
+	s.On("SleepFor", d).Return(nil).Run(func(args mock.Arguments) { cancel() }).Once()
+
+	assert.False(t, p.AllowRetry(0, 0, s))
+	assert.False(t, p.AllowRetry(1, 0, s))
+
+	s.AssertExpectations(t)
+}
+
+func TestSleepingRetry(t *testing.T) {
+	d := 1 * time.Second
+
+	// a custom policy only gives the sleep duration of each attempt
+	type linearRetry struct {
+		SleepingRetry
+	}
+
+	p := &linearRetry{NewSleepingRetry(3, d, func(retryCount int) time.Duration {
+		return time.Duration(retryCount+1) * d
+	})}
+	s := &mockRetrySleeper{}
+
+	assert.Equal(t, 3, p.RetryCount())
+	assert.Equal(t, 2*d, p.SleepDurationForAttempt(1))
+
+	s.On("SleepFor", 1*d).Return(nil).Once()
+	s.On("SleepFor", 2*d).Return(nil).Once()
+	s.On("SleepFor", 3*d).Return(errors.New("interrupted")).Once()
+
+	assert.True(t, p.AllowRetry(0, 0, s))
+	assert.True(t, p.AllowRetry(1, 0, s))
+	assert.False(t, p.AllowRetry(2, 0, s))
+	assert.False(t, p.AllowRetry(3, 0, s))
+
+	s.AssertExpectations(t)
+
+	// without the sleep duration function, always sleep the base duration with the embedded sleeper
+	fixed := NewSleepingRetry(2, d, nil)
+	s = &mockRetrySleeper{}
+	fixed.RetrySleeper = s
+
+	s.On("SleepFor", d).Return(nil).Twice()
+
+	assert.Equal(t, d, fixed.SleepDurationForAttempt(1))
+	assert.True(t, fixed.AllowRetry(0, 0, nil))
+	assert.True(t, fixed.AllowRetry(1, 0, nil))
+	assert.False(t, fixed.AllowRetry(2, 0, nil))
+
+	s.AssertExpectations(t)
+}
+
+func TestRetryLoopWithContext(t *testing.T) {
+	// the cancelled context stops the retry loop
+	ctx, cancel := context.WithCancel(context.Background())
+
+	cancel()
+
+	called := false
+
+	_, err := callWithRetryContext(ctx, newRetryLoop(NewRetryNTimes(3, time.Second, time.Second), nil), func() (interface{}, error) {
+		called = true
+
+		return nil, nil
+	})
+
+	assert.Equal(t, context.Canceled, err)
+	assert.False(t, called)
+
+	// the context expires during the sleep between retries
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+
+	defer cancel()
+
+	start := time.Now()
+	var times int32
+
+	_, err = callWithRetryContext(ctx, newRetryLoop(NewRetryNTimes(3, time.Minute, time.Minute), nil), func() (interface{}, error) {
+		atomic.AddInt32(&times, 1)
+
+		return nil, zk.ErrSessionExpired
+	})
+
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&times))
+	assert.True(t, time.Since(start) < time.Minute)
+
+	// the result of the pending call is discarded
+	ctx, cancel = context.WithCancel(context.Background())
+	release := make(chan struct{})
+
+	_, err = callWithRetryContext(ctx, newRetryLoop(NewRetryNTimes(3, time.Second, time.Second), nil), func() (interface{}, error) {
+		cancel()
+
+		<-release
+
+		return "result", nil
+	})
+
+	close(release)
+
+	assert.Equal(t, context.Canceled, err)
+
+	// without context
+	ret, err := callWithRetryContext(nil, newRetryLoop(NewRetryNTimes(3, time.Second, time.Second), nil), func() (interface{}, error) {
+		return "result", nil
+	})
+
+	assert.Equal(t, "result", ret)
+	assert.NoError(t, err)
+}
+
+func TestCircuitBreakerRetryPolicy(t *testing.T) {
+	d := time.Second
+	s := &mockRetrySleeper{}
+	tracer := &mockTracerDriver{}
+	now := time.Now()
+
+	p := NewCircuitBreakerRetryPolicy(3, time.Minute, NewRetryNTimes(10, d, d)).UsingTracer(tracer)
+
+	p.now = func() time.Time { return now }
+
+	// delegate to the inner policy until too many consecutive failures
+	s.On("SleepFor", d).Return(nil).Times(5)
+	tracer.On("AddCount", TRACE_CIRCUIT_OPEN, 1).Return().Twice()
+
+	assert.True(t, p.AllowRetry(0, 0, s))
+	p.RecordSuccess()
+	assert.True(t, p.AllowRetry(0, 0, s))
+	assert.True(t, p.AllowRetry(1, 0, s))
+	assert.False(t, p.AllowRetry(2, 0, s))
+	assert.True(t, p.IsOpen())
+
+	// fail fast without sleeping while the circuit is open
+	assert.False(t, p.AllowRetry(0, 0, s))
+
+	// try once after the reset timeout, and open again on failure
+	tracer.On("AddCount", TRACE_CIRCUIT_HALF_OPEN, 1).Return().Twice()
+
+	now = now.Add(time.Minute)
+
+	assert.True(t, p.AllowRetry(0, 0, s))
+	assert.False(t, p.IsOpen())
+	assert.False(t, p.AllowRetry(1, 0, s))
+	assert.True(t, p.IsOpen())
+
+	// close again on success
+	tracer.On("AddCount", TRACE_CIRCUIT_CLOSED, 1).Return().Once()
+
+	now = now.Add(time.Minute)
+
+	assert.True(t, p.AllowRetry(0, 0, s))
+
+	retryLoop := newRetryLoop(p, nil)
+
+	_, err := retryLoop.CallWithRetry(func() (interface{}, error) { return nil, nil })
+
+	assert.NoError(t, err)
+	assert.False(t, p.IsOpen())
+
+	s.AssertExpectations(t)
+	tracer.AssertExpectations(t)
+}
+
+func TestDynamicRetryPolicy(t *testing.T) {
+	zookeeper := NewFakeZookeeper()
+
+	assert.NoError(t, zookeeper.Start())
+
+	defer zookeeper.Close()
+
+	builder := &CuratorFrameworkBuilder{
+		ZookeeperDialer:   zookeeper,
+		SessionTimeout:    DEFAULT_SESSION_TIMEOUT,
+		ConnectionTimeout: DEFAULT_CONNECTION_TIMEOUT,
+		RetryPolicy:       NewRetryOneTime(0),
+	}
+
+	client := builder.ConnectString(zookeeper.ConnectString()).Build()
+
+	assert.NoError(t, client.Start())
+
+	defer client.Close()
+
+	defaultPolicy := NewRetryOneTime(0)
+
+	sleeping := func(policy RetryPolicy) (reflect.Type, int, time.Duration) {
+		retry := policy.(interface {
+			RetryCount() int
+			SleepDurationForAttempt(retryCount int) time.Duration
+		})
+
+		return reflect.TypeOf(policy), retry.RetryCount(), retry.SleepDurationForAttempt(1)
+	}
+
+	p := NewDynamicRetryPolicy(client, "/config/retry", defaultPolicy)
+
+	assert.Equal(t, defaultPolicy, p.Current())
+	assert.NoError(t, p.Start())
+	assert.Error(t, p.Start())
+
+	defer p.Close()
+
+	// the default policy is used until the node is created
+	assert.Equal(t, defaultPolicy, p.Current())
+
+	_, err := client.Create().CreatingParentsIfNeeded().ForPathWithData("/config/retry", []byte(`{"type": "n_times", "max_retries": 5, "base_sleep": "10ms"}`))
+
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return p.Current() != defaultPolicy }, time.Second, time.Millisecond)
+
+	policyType, retries, sleep := sleeping(p.Current())
+
+	assert.Equal(t, reflect.TypeOf(&RetryNTimes{}), policyType)
+	assert.Equal(t, 5, retries)
+	assert.Equal(t, 10*time.Millisecond, sleep)
+
+	// hot-reload the policy
+	_, err = client.SetData().ForPathWithData("/config/retry", []byte(`{"max_retries": 3, "base_sleep": "100ms", "max_sleep": "1s"}`))
+
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		policyType, _, _ := sleeping(p.Current())
+
+		return policyType == reflect.TypeOf(&ExponentialBackoffRetry{})
+	}, time.Second, time.Millisecond)
+
+	_, retries, _ = sleeping(p.Current())
+
+	assert.Equal(t, 3, retries)
+
+	// fall back to the default policy on the invalid config
+	_, err = client.SetData().ForPathWithData("/config/retry", []byte(`{"type": "unknown"}`))
+
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return p.Current() == defaultPolicy }, time.Second, time.Millisecond)
+
+	_, err = client.SetData().ForPathWithData("/config/retry", []byte(`{"type": "one_time", "base_sleep": "1s"}`))
+
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return p.Current() != defaultPolicy }, time.Second, time.Millisecond)
+
+	// or the deleted node
+	assert.NoError(t, client.Delete().ForPath("/config/retry"))
+	assert.Eventually(t, func() bool { return p.Current() == defaultPolicy }, time.Second, time.Millisecond)
+
+	sleeper := &mockRetrySleeper{}
+
+	sleeper.On("SleepFor", time.Duration(0)).Return(nil).Once()
+
+	assert.True(t, p.AllowRetry(0, 0, sleeper))
+	assert.NoError(t, p.Close())
+
+	sleeper.AssertExpectations(t)
+}
+
+func TestDynamicRetryPolicyStartFailed(t *testing.T) {
+	zookeeper := NewFakeZookeeper()
+
+	assert.NoError(t, zookeeper.Start())
+
+	defer zookeeper.Close()
+
+	builder := &CuratorFrameworkBuilder{
+		ZookeeperDialer:   zookeeper,
+		SessionTimeout:    DEFAULT_SESSION_TIMEOUT,
+		ConnectionTimeout: DEFAULT_CONNECTION_TIMEOUT,
+		RetryPolicy:       NewRetryOneTime(0),
+	}
+
+	client := builder.ConnectString(zookeeper.ConnectString()).Build()
+
+	p := NewDynamicRetryPolicy(client, "/config/retry", NewRetryOneTime(0))
+
+	// the client is not started yet
+	assert.Error(t, p.Start())
+
+	assert.NoError(t, client.Start())
+
+	defer client.Close()
+
+	// the policy stays latent and can be started again
+	assert.NoError(t, p.Start())
+	assert.NoError(t, p.Close())
+}
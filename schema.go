@@ -0,0 +1,293 @@
+package curator
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/samuel/go-zookeeper/zk"
+)
+
+// Whether a feature is allowed by the schema
+type Allowance int
+
+const (
+	ALLOWANCE_CAN Allowance = iota
+	ALLOWANCE_MUST
+	ALLOWANCE_CANNOT
+)
+
+// The operations which could be allowed by the schema
+type SchemaOperation int
+
+const (
+	SCHEMA_CREATE SchemaOperation = 1 << iota
+	SCHEMA_DELETE
+	SCHEMA_CHECK_EXISTS
+	SCHEMA_GET_DATA
+	SCHEMA_SET_DATA
+	SCHEMA_GET_CHILDREN
+	SCHEMA_GET_ACL
+	SCHEMA_SET_ACL
+	SCHEMA_SYNC
+
+	SCHEMA_READ_OPERATIONS = SCHEMA_CHECK_EXISTS | SCHEMA_GET_DATA | SCHEMA_GET_CHILDREN | SCHEMA_GET_ACL | SCHEMA_SYNC
+	SCHEMA_ALL_OPERATIONS  = SCHEMA_READ_OPERATIONS | SCHEMA_CREATE | SCHEMA_DELETE | SCHEMA_SET_DATA | SCHEMA_SET_ACL
+)
+
+var schemaOperationNames = map[SchemaOperation]string{
+	SCHEMA_CREATE:       "create",
+	SCHEMA_DELETE:       "delete",
+	SCHEMA_CHECK_EXISTS: "checkExists",
+	SCHEMA_GET_DATA:     "getData",
+	SCHEMA_SET_DATA:     "setData",
+	SCHEMA_GET_CHILDREN: "getChildren",
+	SCHEMA_GET_ACL:      "getACL",
+	SCHEMA_SET_ACL:      "setACL",
+	SCHEMA_SYNC:         "sync",
+}
+
+func (op SchemaOperation) String() string {
+	var names []string
+
+	for i := SCHEMA_CREATE; i <= SCHEMA_SYNC; i <<= 1 {
+		if op&i != 0 {
+			names = append(names, schemaOperationNames[i])
+		}
+	}
+
+	return strings.Join(names, "|")
+}
+
+// Describe the constraints of the nodes which paths match the schema
+type Schema struct {
+	Name          string
+	Path          string         // the exact path, or the glob pattern of the path, e.g. "/services/*/instance-*" (`*` doesn't match the separator)
+	PathRegex     *regexp.Regexp // the regular expression which should match the whole path, used if Path is empty
+	Documentation string
+	Operations    SchemaOperation // the allowed operations, all the operations are allowed if zero
+	ACLs          []zk.ACL        // the ACLs which must be included when the nodes are created or their ACLs are set
+	Ephemeral     Allowance
+	Sequential    Allowance
+
+	pathRegex *regexp.Regexp
+}
+
+// The schema which allows everything, used for the paths without a matching schema
+var DefaultSchema = Schema{Name: "default", Documentation: "Default schema"}
+
+// The error returned when an operation violates the schema
+type SchemaViolationError struct {
+	Schema    *Schema
+	Path      string
+	Violation string
+}
+
+func (e *SchemaViolationError) Error() string {
+	if e.Schema == nil {
+		return fmt.Sprintf("schema violation at path `%s`, %s", e.Path, e.Violation)
+	}
+
+	return fmt.Sprintf("schema `%s` violation at path `%s`, %s", e.Schema.Name, e.Path, e.Violation)
+}
+
+func (s *Schema) matches(nodePath string) bool {
+	if len(s.Path) > 0 {
+		if strings.ContainsAny(s.Path, "*?[") {
+			matched, _ := path.Match(s.Path, nodePath)
+
+			return matched
+		}
+
+		return s.Path == nodePath
+	}
+
+	return s.pathRegex != nil && s.pathRegex.MatchString(nodePath)
+}
+
+func (s *Schema) violation(nodePath, format string, args ...interface{}) error {
+	return &SchemaViolationError{s, nodePath, fmt.Sprintf(format, args...)}
+}
+
+// Validate the operation on the path
+func (s *Schema) ValidateOperation(nodePath string, operation SchemaOperation) error {
+	if s.Operations != 0 && s.Operations&operation != operation {
+		return s.violation(nodePath, "%s is not allowed", operation)
+	}
+
+	return nil
+}
+
+// Validate the creation of the node
+func (s *Schema) ValidateCreate(nodePath string, mode CreateMode, acls []zk.ACL) error {
+	if err := s.ValidateOperation(nodePath, SCHEMA_CREATE); err != nil {
+		return err
+	}
+
+	if err := s.validateAllowance(nodePath, "ephemeral", s.Ephemeral, mode.IsEphemeral()); err != nil {
+		return err
+	}
+
+	if err := s.validateAllowance(nodePath, "sequential", s.Sequential, mode.IsSequential()); err != nil {
+		return err
+	}
+
+	return s.validateACLs(nodePath, acls)
+}
+
+// Validate the ACLs set on the node
+func (s *Schema) ValidateSetACL(nodePath string, acls []zk.ACL) error {
+	if err := s.ValidateOperation(nodePath, SCHEMA_SET_ACL); err != nil {
+		return err
+	}
+
+	return s.validateACLs(nodePath, acls)
+}
+
+func (s *Schema) validateAllowance(nodePath, feature string, allowance Allowance, enabled bool) error {
+	switch {
+	case allowance == ALLOWANCE_MUST && !enabled:
+		return s.violation(nodePath, "the node must be %s", feature)
+	case allowance == ALLOWANCE_CANNOT && enabled:
+		return s.violation(nodePath, "the node cannot be %s", feature)
+	}
+
+	return nil
+}
+
+func (s *Schema) validateACLs(nodePath string, acls []zk.ACL) error {
+	for _, required := range s.ACLs {
+		found := false
+
+		for _, acl := range acls {
+			if acl == required {
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			return s.violation(nodePath, "missing the required ACL %s:%s with permissions %d", required.Scheme, required.ID, required.Perms)
+		}
+	}
+
+	return nil
+}
+
+// A set of schemas which validate the operations of the framework
+type SchemaSet struct {
+	schemas          []*Schema
+	useDefaultSchema bool
+}
+
+// Create a schema set, the paths without a matching schema are validated by the DefaultSchema
+func NewSchemaSet(schemas ...Schema) *SchemaSet {
+	return newSchemaSet(schemas, true)
+}
+
+// Create a schema set which rejects the paths without a matching schema
+func NewStrictSchemaSet(schemas ...Schema) *SchemaSet {
+	return newSchemaSet(schemas, false)
+}
+
+func newSchemaSet(schemas []Schema, useDefaultSchema bool) *SchemaSet {
+	s := &SchemaSet{useDefaultSchema: useDefaultSchema}
+
+	for i := range schemas {
+		schema := schemas[i]
+
+		if schema.PathRegex != nil {
+			schema.pathRegex = regexp.MustCompile("^(?:" + schema.PathRegex.String() + ")$")
+		}
+
+		s.schemas = append(s.schemas, &schema)
+	}
+
+	return s
+}
+
+// Return the first schema which matches the path, or nil if no schema matches
+func (s *SchemaSet) GetSchema(nodePath string) *Schema {
+	for _, schema := range s.schemas {
+		if schema.matches(nodePath) {
+			return schema
+		}
+	}
+
+	if s.useDefaultSchema {
+		return &DefaultSchema
+	}
+
+	return nil
+}
+
+func (s *SchemaSet) schemaFor(nodePath string) (*Schema, error) {
+	if schema := s.GetSchema(nodePath); schema != nil {
+		return schema, nil
+	}
+
+	return nil, &SchemaViolationError{Path: nodePath, Violation: "no schema matches the path"}
+}
+
+// Validate the operation on the path with the matching schema
+func (s *SchemaSet) ValidateOperation(nodePath string, operation SchemaOperation) error {
+	schema, err := s.schemaFor(nodePath)
+
+	if err != nil {
+		return err
+	}
+
+	return schema.ValidateOperation(nodePath, operation)
+}
+
+// Validate the creation of the node with the matching schema
+func (s *SchemaSet) ValidateCreate(nodePath string, mode CreateMode, acls []zk.ACL) error {
+	schema, err := s.schemaFor(nodePath)
+
+	if err != nil {
+		return err
+	}
+
+	return schema.ValidateCreate(nodePath, mode, acls)
+}
+
+// Validate the ACLs set on the node with the matching schema
+func (s *SchemaSet) ValidateSetACL(nodePath string, acls []zk.ACL) error {
+	schema, err := s.schemaFor(nodePath)
+
+	if err != nil {
+		return err
+	}
+
+	return schema.ValidateSetACL(nodePath, acls)
+}
+
+func (c *curatorFramework) validateSchema(givenPath string, operation SchemaOperation) error {
+	if c.schemaSet == nil {
+		return nil
+	}
+
+	return c.schemaSet.ValidateOperation(c.namespace.fixForNamespaceWithoutEnsure(givenPath, false), operation)
+}
+
+func (c *curatorFramework) validateCreateSchema(givenPath string, mode CreateMode, acling *acling) error {
+	if c.schemaSet == nil {
+		return nil
+	}
+
+	nodePath := c.namespace.fixForNamespaceWithoutEnsure(givenPath, false)
+
+	return c.schemaSet.ValidateCreate(nodePath, mode, acling.getAclList(nodePath))
+}
+
+func (c *curatorFramework) validateSetACLSchema(givenPath string, acling *acling) error {
+	if c.schemaSet == nil {
+		return nil
+	}
+
+	nodePath := c.namespace.fixForNamespaceWithoutEnsure(givenPath, false)
+
+	return c.schemaSet.ValidateSetACL(nodePath, acling.getAclList(nodePath))
+}
@@ -0,0 +1,111 @@
+package curator
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/samuel/go-zookeeper/zk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemaSet(t *testing.T) {
+	adminACL := zk.ACL{Perms: zk.PermAll, Scheme: "digest", ID: "admin:secret"}
+
+	schemas := NewSchemaSet(
+		Schema{Name: "config", Path: "/config", Operations: SCHEMA_READ_OPERATIONS | SCHEMA_SET_DATA},
+		Schema{Name: "instances", Path: "/services/*", Ephemeral: ALLOWANCE_MUST, ACLs: []zk.ACL{adminACL}},
+		Schema{Name: "locks", PathRegex: regexp.MustCompile(`/locks/lock-\d+`), Sequential: ALLOWANCE_MUST, Ephemeral: ALLOWANCE_CANNOT},
+	)
+
+	assert.Equal(t, "config", schemas.GetSchema("/config").Name)
+	assert.Equal(t, "instances", schemas.GetSchema("/services/web").Name)
+	assert.Equal(t, &DefaultSchema, schemas.GetSchema("/services/web/child"))
+	assert.Equal(t, "locks", schemas.GetSchema("/locks/lock-0000000001").Name)
+	assert.Equal(t, &DefaultSchema, schemas.GetSchema("/locks/lock-0000000001/child"))
+
+	assert.NoError(t, schemas.ValidateOperation("/config", SCHEMA_GET_DATA))
+	assert.NoError(t, schemas.ValidateOperation("/config", SCHEMA_SET_DATA))
+	assert.EqualError(t, schemas.ValidateOperation("/config", SCHEMA_DELETE), "schema `config` violation at path `/config`, delete is not allowed")
+	assert.NoError(t, schemas.ValidateOperation("/other", SCHEMA_DELETE))
+
+	assert.NoError(t, schemas.ValidateCreate("/services/web", EPHEMERAL, []zk.ACL{adminACL}))
+	assert.EqualError(t, schemas.ValidateCreate("/services/web", PERSISTENT, []zk.ACL{adminACL}), "schema `instances` violation at path `/services/web`, the node must be ephemeral")
+	assert.EqualError(t, schemas.ValidateCreate("/services/web", EPHEMERAL, zk.WorldACL(zk.PermAll)), "schema `instances` violation at path `/services/web`, missing the required ACL digest:admin:secret with permissions 31")
+	assert.NoError(t, schemas.ValidateSetACL("/services/web", []zk.ACL{adminACL, zk.WorldACL(zk.PermRead)[0]}))
+
+	assert.NoError(t, schemas.ValidateCreate("/locks/lock-0000000001", PERSISTENT_SEQUENTIAL, nil))
+	assert.EqualError(t, schemas.ValidateCreate("/locks/lock-0000000001", EPHEMERAL_SEQUENTIAL, nil), "schema `locks` violation at path `/locks/lock-0000000001`, the node cannot be ephemeral")
+
+	strict := NewStrictSchemaSet(Schema{Name: "config", Path: "/config"})
+
+	assert.Nil(t, strict.GetSchema("/other"))
+	assert.NoError(t, strict.ValidateOperation("/config", SCHEMA_DELETE))
+	assert.EqualError(t, strict.ValidateOperation("/other", SCHEMA_GET_DATA), "schema violation at path `/other`, no schema matches the path")
+}
+
+func TestSchemaOperationString(t *testing.T) {
+	assert.Equal(t, "create|delete", (SCHEMA_CREATE | SCHEMA_DELETE).String())
+	assert.Equal(t, "checkExists|getData|getChildren|getACL|sync", SCHEMA_READ_OPERATIONS.String())
+}
+
+func TestFrameworkWithSchemaSet(t *testing.T) {
+	zookeeper := NewFakeZookeeper()
+
+	assert.NoError(t, zookeeper.Start())
+
+	defer zookeeper.Close()
+
+	builder := &CuratorFrameworkBuilder{
+		ZookeeperDialer:   zookeeper,
+		Namespace:         "app",
+		SessionTimeout:    DEFAULT_SESSION_TIMEOUT,
+		ConnectionTimeout: DEFAULT_CONNECTION_TIMEOUT,
+		RetryPolicy:       NewRetryOneTime(0),
+		SchemaSet: NewStrictSchemaSet(
+			Schema{Name: "config", Path: "/app/config", Operations: SCHEMA_READ_OPERATIONS | SCHEMA_CREATE | SCHEMA_SET_DATA},
+			Schema{Name: "services", Path: "/app/services"},
+			Schema{Name: "instances", Path: "/app/services/*", Ephemeral: ALLOWANCE_MUST},
+		),
+	}
+
+	client := builder.ConnectString(zookeeper.ConnectString()).Build()
+
+	assert.NoError(t, client.Start())
+
+	defer client.Close()
+
+	_, err := client.Create().ForPathWithData("/config", []byte("data"))
+
+	assert.NoError(t, err)
+
+	err = client.Delete().ForPath("/config")
+
+	var violation *SchemaViolationError
+
+	assert.True(t, errors.As(err, &violation))
+	assert.Equal(t, "config", violation.Schema.Name)
+	assert.Equal(t, "/app/config", violation.Path)
+
+	_, err = client.InTransaction().Delete().ForPath("/config").Commit()
+
+	assert.True(t, errors.As(err, &violation))
+
+	stat, err := client.CheckExists().ForPath("/config")
+
+	assert.NotNil(t, stat)
+	assert.NoError(t, err)
+
+	_, err = client.Create().CreatingParentsIfNeeded().ForPath("/services/web")
+
+	assert.EqualError(t, err, "schema `instances` violation at path `/app/services/web`, the node must be ephemeral")
+
+	path, err := client.Create().CreatingParentsIfNeeded().WithMode(EPHEMERAL).ForPath("/services/web")
+
+	assert.Equal(t, "/services/web", path)
+	assert.NoError(t, err)
+
+	_, err = client.GetData().ForPath("/other")
+
+	assert.EqualError(t, err, "schema violation at path `/app/other`, no schema matches the path")
+}
@@ -1,9 +1,11 @@
 package curator
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
+	"log/slog"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -16,6 +18,9 @@ const MAX_BACKGROUND_ERRORS = 10
 var (
 	ErrConnectionLoss = errors.New("connection loss")
 	ErrTimeout        = errors.New("timeout")
+
+	// It wraps ErrTimeout
+	ErrTimeoutWaitingForConnection = fmt.Errorf("%w waiting for connection", ErrTimeout)
 )
 
 type zookeeperHelper interface {
@@ -397,6 +402,7 @@ type connectionStateManager struct {
 	initialConnectMessageSent AtomicBool
 	events                    chan ConnectionState
 	QueueSize                 int
+	errorHandler              func(err error)
 }
 
 func newConnectionStateManager(client CuratorFramework) *connectionStateManager {
@@ -486,17 +492,34 @@ func (m *connectionStateManager) AddStateChange(newConnectionState ConnectionSta
 }
 
 func (m *connectionStateManager) BlockUntilConnected(maxWaitTime time.Duration) error {
-	if m.currentConnectionState.Connected() {
-		return nil
+	ctx := context.Background()
+
+	if maxWaitTime > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, maxWaitTime)
+
+		defer cancel()
 	}
 
-	c := make(chan ConnectionState)
+	if err := m.BlockUntilConnectedWithContext(ctx); err == context.DeadlineExceeded {
+		return ErrTimeoutWaitingForConnection
+	} else {
+		return err
+	}
+}
 
-	defer close(c)
+// Block until connected or the context is done
+func (m *connectionStateManager) BlockUntilConnectedWithContext(ctx context.Context) error {
+	c := make(chan ConnectionState, 1)
 
+	// never block the listeners fan-out, even after the waiting has been timed out
 	listener := NewConnectionStateListener(func(client CuratorFramework, newState ConnectionState) {
 		if newState.Connected() {
-			c <- newState
+			select {
+			case c <- newState:
+			default:
+			}
 		}
 	})
 
@@ -504,19 +527,16 @@ func (m *connectionStateManager) BlockUntilConnected(maxWaitTime time.Duration)
 
 	defer m.listeners.RemoveListener(listener)
 
-	if maxWaitTime > 0 {
-		timer := time.NewTimer(maxWaitTime)
-
-		select {
-		case <-c:
-			return nil
-		case <-timer.C:
-			return ErrTimeout
-		}
-	} else {
-		<-c
+	// the listener is added before checking, to avoid missing the state change
+	if m.Connected() {
+		return nil
+	}
 
+	select {
+	case <-c:
 		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 }
 
@@ -527,6 +547,13 @@ func (m *connectionStateManager) Connected() bool {
 	return m.currentConnectionState.Connected()
 }
 
+func (m *connectionStateManager) CurrentConnectionState() ConnectionState {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.currentConnectionState
+}
+
 func (m *connectionStateManager) postState(state ConnectionState) {
 	defer func() {
 		recover() // channel closed
@@ -556,8 +583,23 @@ func (m *connectionStateManager) processEvents() {
 			return // queue closed
 		} else {
 			m.listeners.ForEach(func(listener interface{}) {
+				defer m.recoverPanic()
+
 				listener.(ConnectionStateListener).StateChanged(m.client, newState)
 			})
 		}
 	}
 }
+
+// Report the panic of a listener without breaking the fan-out
+func (m *connectionStateManager) recoverPanic() {
+	if v := recover(); v != nil {
+		err := fmt.Errorf("panic in connection state listener, %v", v)
+
+		if m.errorHandler != nil {
+			m.errorHandler(err)
+		} else {
+			slog.Error("unhandled error", "err", err)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package curator
 
 import (
+	"context"
+	"errors"
 	"sync"
 	"testing"
 	"time"
@@ -526,10 +528,29 @@ func (s *ConnectionStateManagerTestSuite) TestBlockUntilConnectedTimeouted() {
 	go func() {
 		defer wc.Done()
 
-		assert.Equal(s.T(), ErrTimeout, s.state.BlockUntilConnected(100*time.Millisecond))
+		err := s.state.BlockUntilConnected(100 * time.Millisecond)
+
+		assert.Equal(s.T(), ErrTimeoutWaitingForConnection, err)
+		assert.True(s.T(), errors.Is(err, ErrTimeout))
 	}()
 
 	wc.Wait()
 
 	assert.Equal(s.T(), UNKNOWN, s.state.currentConnectionState)
 }
+
+func (s *ConnectionStateManagerTestSuite) TestBlockUntilConnectedWithContext() {
+	assert.NoError(s.T(), s.state.Start())
+
+	defer s.state.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	cancel()
+
+	assert.Equal(s.T(), context.Canceled, s.state.BlockUntilConnectedWithContext(ctx))
+
+	s.state.AddStateChange(CONNECTED)
+
+	assert.NoError(s.T(), s.state.BlockUntilConnectedWithContext(context.Background()))
+}
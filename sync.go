@@ -1,15 +1,27 @@
 package curator
 
+import (
+	"fmt"
+)
+
 type syncBuilder struct {
 	client        *curatorFramework
 	backgrounding backgrounding
 }
 
 func (b *syncBuilder) ForPath(givenPath string) (string, error) {
+	if err := b.client.checkStarted(); err != nil {
+		return "", err
+	}
+
+	if err := b.client.validateSchema(givenPath, SCHEMA_SYNC); err != nil {
+		return "", err
+	}
+
 	adjustedPath := b.client.fixForNamespace(givenPath, false)
 
 	if b.backgrounding.inBackground {
-		go b.pathInBackground(adjustedPath, givenPath)
+		b.client.goSafely("syncBuilder.pathInBackground", func() { b.pathInBackground(adjustedPath, givenPath) })
 
 		return givenPath, nil
 	} else {
@@ -24,27 +36,26 @@ func (b *syncBuilder) pathInBackground(path string, givenPath string) {
 
 	syncPath, err := b.pathInForeground(path)
 
-	if b.backgrounding.callback != nil {
-		event := &curatorEvent{
-			eventType: SYNC,
-			err:       err,
-			path:      b.client.unfixForNamespace(syncPath),
-			context:   b.backgrounding.context,
-		}
+	event := &curatorEvent{
+		eventType: SYNC,
+		err:       err,
+		path:      syncPath,
+		context:   b.backgrounding.context,
+	}
 
-		if err != nil {
-			event.path = givenPath
-		}
+	if err != nil {
+		event.path = givenPath
+	}
 
-		event.name = GetNodeFromPath(event.path)
+	event.name = GetNodeFromPath(event.path)
 
-		b.backgrounding.callback(b.client, event)
-	}
+	b.client.processBackgroundEvent(b.backgrounding.callback, event)
 }
 
 func (b *syncBuilder) pathInForeground(path string) (string, error) {
 	zkClient := b.client.ZookeeperClient()
 
+	// the sync time is traced as TRACE_SYNC by the connection
 	result, err := zkClient.NewRetryLoop().CallWithRetry(func() (interface{}, error) {
 		if conn, err := zkClient.Conn(); err != nil {
 			return nil, err
@@ -53,9 +64,11 @@ func (b *syncBuilder) pathInForeground(path string) (string, error) {
 		}
 	})
 
-	syncPath, _ := result.(string)
+	if syncPath, _ := result.(string); err == nil && syncPath != path {
+		err = fmt.Errorf("sync path mismatch, expected %s but got %s", b.client.unfixForNamespace(path), b.client.unfixForNamespace(syncPath))
+	}
 
-	return b.client.unfixForNamespace(syncPath), err
+	return b.client.unfixForNamespace(path), err
 }
 
 func (b *syncBuilder) InBackground() SyncBuilder {
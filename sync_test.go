@@ -6,6 +6,7 @@ import (
 
 	"github.com/samuel/go-zookeeper/zk"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -28,6 +29,35 @@ func (s *SyncBuilderTestSuite) TestSync() {
 	})
 }
 
+func (s *SyncBuilderTestSuite) TestSyncPath() {
+	s.WithNamespace("parent", func(client CuratorFramework, conn *mockConn) {
+		tracer := &mockTracerDriver{}
+
+		client.ZookeeperClient().(*curatorZookeeperClient).TracerDriver = tracer
+
+		conn.On("Exists", "/parent").Return(true, nil, nil).Once()
+		conn.On("Sync", "/parent/child").Return("/parent/child", nil).Once()
+		conn.On("Sync", "/parent/other").Return("/parent/child", nil).Once()
+		tracer.On("AddTime", TRACE_SYNC, mock.Anything).Return().Twice()
+		tracer.On("AddCount", TRACE_SYNC, 1).Return().Twice()
+		tracer.On("AddTime", TRACE_EXISTS, mock.Anything).Return().Once()
+		tracer.On("AddCount", TRACE_EXISTS, 1).Return().Once()
+
+		path, err := client.Sync().ForPath("/child")
+
+		assert.Equal(s.T(), "/child", path)
+		assert.NoError(s.T(), err)
+
+		// the synced path doesn't match the requested one
+		path, err = client.Sync().ForPath("/other")
+
+		assert.Equal(s.T(), "/other", path)
+		assert.EqualError(s.T(), err, "sync path mismatch, expected /other but got /child")
+
+		tracer.AssertExpectations(s.T())
+	})
+}
+
 func (s *SyncBuilderTestSuite) TestNamespace() {
 	s.WithNamespace("parent", func(client CuratorFramework, conn *mockConn) {
 		conn.On("Exists", "/parent").Return(true, nil, nil).Once()
@@ -3,6 +3,8 @@ package curator
 import (
 	"sync"
 	"time"
+
+	"github.com/samuel/go-zookeeper/zk"
 )
 
 // Mechanism for timing methods and recording counters
@@ -77,3 +79,235 @@ func (t *timeTracer) Commit() {
 func (t *timeTracer) CommitAt(tm time.Time) {
 	t.driver.AddTime(t.name, tm.Sub(t.startTime))
 }
+
+// The names of the traces recorded for each ZooKeeper operation,
+// the failed operations are also counted with the "_error" suffix.
+const (
+	TRACE_ADD_AUTH = "curator_add_auth"
+	TRACE_CREATE   = "curator_create"
+	TRACE_EXISTS   = "curator_exists"
+	TRACE_DELETE   = "curator_delete"
+	TRACE_GET      = "curator_get"
+	TRACE_SET      = "curator_set"
+	TRACE_CHILDREN = "curator_children"
+	TRACE_GET_ACL  = "curator_get_acl"
+	TRACE_SET_ACL  = "curator_set_acl"
+	TRACE_MULTI    = "curator_multi"
+	TRACE_SYNC     = "curator_sync"
+
+	TRACE_REMOVE_WATCH = "curator_remove_watch"
+	TRACE_RECONFIG     = "curator_reconfig"
+
+	TRACE_ERROR_SUFFIX = "_error"
+)
+
+// The trace of a ZooKeeper operation
+type OperationTrace struct {
+	Name    string        // the trace name of the operation, e.g. TRACE_CREATE
+	Path    string        // the path of the node, or empty if the operation doesn't target a node
+	Version int32         // the expected version of the node, or AnyVersion
+	Elapsed time.Duration // the round-trip time of the operation
+	Err     error         // the error returned by the operation
+}
+
+// The TracerDriver which is able to record the details of the ZooKeeper operations
+type OperationTracerDriver interface {
+	TracerDriver
+
+	// Record the given operation, it replaces AddTime for the ZooKeeper operations
+	AddOperation(trace *OperationTrace)
+}
+
+// The TracerDriver which keeps its state per goroutine, e.g. the parent span of the traces.
+//
+// The operations with a context run in another goroutine, the state of the caller
+// is captured and bound to that goroutine while the operation is performed.
+type GoroutineTracerDriver interface {
+	TracerDriver
+
+	// Capture the state bound to the current goroutine,
+	// the returned function binds it to the calling goroutine and returns a function to unbind it.
+	CaptureGoroutine() (bind func() (unbind func()))
+}
+
+// The ZooKeeper connection which records the round-trip time and the count of each operation
+type tracingConnection struct {
+	conn   ZookeeperConnection
+	driver TracerDriver
+}
+
+func newTracingConnection(conn ZookeeperConnection, driver TracerDriver) *tracingConnection {
+	return &tracingConnection{conn, driver}
+}
+
+func (c *tracingConnection) trace(name, path string, version int32, startTime time.Time, err error) {
+	elapsed := time.Since(startTime)
+
+	if driver, ok := c.driver.(OperationTracerDriver); ok {
+		driver.AddOperation(&OperationTrace{Name: name, Path: path, Version: version, Elapsed: elapsed, Err: err})
+	} else {
+		c.driver.AddTime(name, elapsed)
+	}
+
+	c.driver.AddCount(name, 1)
+
+	if err != nil {
+		c.driver.AddCount(name+TRACE_ERROR_SUFFIX, 1)
+	}
+}
+
+func (c *tracingConnection) AddAuth(scheme string, auth []byte) (err error) {
+	defer func(startTime time.Time) { c.trace(TRACE_ADD_AUTH, "", AnyVersion, startTime, err) }(time.Now())
+
+	return c.conn.AddAuth(scheme, auth)
+}
+
+func (c *tracingConnection) Close() {
+	c.conn.Close()
+}
+
+func (c *tracingConnection) Create(path string, data []byte, flags int32, acl []zk.ACL) (createdPath string, err error) {
+	defer func(startTime time.Time) { c.trace(TRACE_CREATE, path, AnyVersion, startTime, err) }(time.Now())
+
+	return c.conn.Create(path, data, flags, acl)
+}
+
+func (c *tracingConnection) CreateTTL(path string, data []byte, flags int32, acl []zk.ACL, ttl int64) (createdPath string, err error) {
+	ttlConn, ok := c.conn.(TTLConnection)
+
+	if !ok {
+		return "", ErrTTLNotSupported
+	}
+
+	defer func(startTime time.Time) { c.trace(TRACE_CREATE, path, AnyVersion, startTime, err) }(time.Now())
+
+	return ttlConn.CreateTTL(path, data, flags, acl, ttl)
+}
+
+func (c *tracingConnection) CreateContainer(path string, data []byte, acl []zk.ACL) (createdPath string, err error) {
+	containerConn, ok := c.conn.(ContainerConnection)
+
+	if !ok {
+		return "", ErrContainerNotSupported
+	}
+
+	defer func(startTime time.Time) { c.trace(TRACE_CREATE, path, AnyVersion, startTime, err) }(time.Now())
+
+	return containerConn.CreateContainer(path, data, acl)
+}
+
+func (c *tracingConnection) Exists(path string) (exists bool, stat *zk.Stat, err error) {
+	defer func(startTime time.Time) { c.trace(TRACE_EXISTS, path, AnyVersion, startTime, err) }(time.Now())
+
+	return c.conn.Exists(path)
+}
+
+func (c *tracingConnection) ExistsW(path string) (exists bool, stat *zk.Stat, events <-chan zk.Event, err error) {
+	defer func(startTime time.Time) { c.trace(TRACE_EXISTS, path, AnyVersion, startTime, err) }(time.Now())
+
+	return c.conn.ExistsW(path)
+}
+
+func (c *tracingConnection) Delete(path string, version int32) (err error) {
+	defer func(startTime time.Time) { c.trace(TRACE_DELETE, path, version, startTime, err) }(time.Now())
+
+	return c.conn.Delete(path, version)
+}
+
+func (c *tracingConnection) Get(path string) (data []byte, stat *zk.Stat, err error) {
+	defer func(startTime time.Time) { c.trace(TRACE_GET, path, AnyVersion, startTime, err) }(time.Now())
+
+	return c.conn.Get(path)
+}
+
+func (c *tracingConnection) GetW(path string) (data []byte, stat *zk.Stat, events <-chan zk.Event, err error) {
+	defer func(startTime time.Time) { c.trace(TRACE_GET, path, AnyVersion, startTime, err) }(time.Now())
+
+	return c.conn.GetW(path)
+}
+
+func (c *tracingConnection) Set(path string, data []byte, version int32) (stat *zk.Stat, err error) {
+	defer func(startTime time.Time) { c.trace(TRACE_SET, path, version, startTime, err) }(time.Now())
+
+	return c.conn.Set(path, data, version)
+}
+
+func (c *tracingConnection) Children(path string) (children []string, stat *zk.Stat, err error) {
+	defer func(startTime time.Time) { c.trace(TRACE_CHILDREN, path, AnyVersion, startTime, err) }(time.Now())
+
+	return c.conn.Children(path)
+}
+
+func (c *tracingConnection) ChildrenW(path string) (children []string, stat *zk.Stat, events <-chan zk.Event, err error) {
+	defer func(startTime time.Time) { c.trace(TRACE_CHILDREN, path, AnyVersion, startTime, err) }(time.Now())
+
+	return c.conn.ChildrenW(path)
+}
+
+func (c *tracingConnection) GetACL(path string) (acl []zk.ACL, stat *zk.Stat, err error) {
+	defer func(startTime time.Time) { c.trace(TRACE_GET_ACL, path, AnyVersion, startTime, err) }(time.Now())
+
+	return c.conn.GetACL(path)
+}
+
+func (c *tracingConnection) SetACL(path string, acl []zk.ACL, version int32) (stat *zk.Stat, err error) {
+	defer func(startTime time.Time) { c.trace(TRACE_SET_ACL, path, version, startTime, err) }(time.Now())
+
+	return c.conn.SetACL(path, acl, version)
+}
+
+func (c *tracingConnection) Multi(ops ...interface{}) (responses []zk.MultiResponse, err error) {
+	defer func(startTime time.Time) { c.trace(TRACE_MULTI, "", AnyVersion, startTime, err) }(time.Now())
+
+	return c.conn.Multi(ops...)
+}
+
+func (c *tracingConnection) Sync(path string) (syncedPath string, err error) {
+	defer func(startTime time.Time) { c.trace(TRACE_SYNC, path, AnyVersion, startTime, err) }(time.Now())
+
+	return c.conn.Sync(path)
+}
+
+func (c *tracingConnection) Reconfig(members []string, version int64) (stat *zk.Stat, err error) {
+	reconfigConn, ok := c.conn.(ReconfigConnection)
+
+	if !ok {
+		return nil, ErrReconfigNotSupported
+	}
+
+	defer func(startTime time.Time) { c.trace(TRACE_RECONFIG, ZOOKEEPER_CONFIG_NODE, AnyVersion, startTime, err) }(time.Now())
+
+	return reconfigConn.Reconfig(members, version)
+}
+
+func (c *tracingConnection) IncrementalReconfig(joining, leaving []string, version int64) (stat *zk.Stat, err error) {
+	reconfigConn, ok := c.conn.(ReconfigConnection)
+
+	if !ok {
+		return nil, ErrReconfigNotSupported
+	}
+
+	defer func(startTime time.Time) { c.trace(TRACE_RECONFIG, ZOOKEEPER_CONFIG_NODE, AnyVersion, startTime, err) }(time.Now())
+
+	return reconfigConn.IncrementalReconfig(joining, leaving, version)
+}
+
+func (c *tracingConnection) Server() string {
+	if conn, ok := c.conn.(ServerConnection); ok {
+		return conn.Server()
+	}
+
+	return ""
+}
+
+func (c *tracingConnection) RemoveWatch(path string, watcherType WatcherType) (err error) {
+	removal, ok := c.conn.(WatchRemovalConnection)
+
+	if !ok {
+		return ErrWatchRemovalNotSupported
+	}
+
+	defer func(startTime time.Time) { c.trace(TRACE_REMOVE_WATCH, path, AnyVersion, startTime, err) }(time.Now())
+
+	return removal.RemoveWatch(path, watcherType)
+}
@@ -1,11 +1,14 @@
 package curator
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
 
+	"github.com/samuel/go-zookeeper/zk"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestDefaultTracerDriver(t *testing.T) {
@@ -39,3 +42,95 @@ func TestTimeTrace(t *testing.T) {
 
 	d.AssertExpectations(t)
 }
+
+func TestTracingConnection(t *testing.T) {
+	d := &mockTracerDriver{}
+	conn := &mockConn{}
+
+	conn.On("Create", "/node", []byte("data"), int32(PERSISTENT), zk.WorldACL(zk.PermAll)).Return("/node", nil).Once()
+	conn.On("Get", "/node").Return(nil, nil, zk.ErrNoNode).Once()
+
+	d.On("AddTime", TRACE_CREATE, mock.Anything).Return().Once()
+	d.On("AddCount", TRACE_CREATE, 1).Return().Once()
+	d.On("AddTime", TRACE_GET, mock.Anything).Return().Once()
+	d.On("AddCount", TRACE_GET, 1).Return().Once()
+	d.On("AddCount", TRACE_GET+TRACE_ERROR_SUFFIX, 1).Return().Once()
+
+	tracingConn := newTracingConnection(conn, d)
+
+	path, err := tracingConn.Create("/node", []byte("data"), int32(PERSISTENT), zk.WorldACL(zk.PermAll))
+
+	assert.Equal(t, "/node", path)
+	assert.NoError(t, err)
+
+	data, stat, err := tracingConn.Get("/node")
+
+	assert.Nil(t, data)
+	assert.Nil(t, stat)
+	assert.Equal(t, zk.ErrNoNode, err)
+
+	conn.AssertExpectations(t)
+	d.AssertExpectations(t)
+}
+
+type operationTracerDriver struct {
+	mockTracerDriver
+
+	operations []*OperationTrace
+}
+
+func (d *operationTracerDriver) AddOperation(trace *OperationTrace) {
+	d.operations = append(d.operations, trace)
+}
+
+func TestOperationTracerDriver(t *testing.T) {
+	d := &operationTracerDriver{}
+	conn := &mockConn{}
+
+	conn.On("Delete", "/node", int32(3)).Return(zk.ErrBadVersion).Once()
+
+	d.On("AddCount", TRACE_DELETE, 1).Return().Once()
+	d.On("AddCount", TRACE_DELETE+TRACE_ERROR_SUFFIX, 1).Return().Once()
+
+	assert.Equal(t, zk.ErrBadVersion, newTracingConnection(conn, d).Delete("/node", 3))
+
+	if assert.Len(t, d.operations, 1) {
+		assert.Equal(t, TRACE_DELETE, d.operations[0].Name)
+		assert.Equal(t, "/node", d.operations[0].Path)
+		assert.Equal(t, int32(3), d.operations[0].Version)
+		assert.Equal(t, zk.ErrBadVersion, d.operations[0].Err)
+	}
+
+	conn.AssertExpectations(t)
+	d.AssertExpectations(t)
+}
+
+type goroutineTracerDriver struct {
+	mockTracerDriver
+
+	bound   chan string
+	unbound chan string
+}
+
+func (d *goroutineTracerDriver) CaptureGoroutine() func() func() {
+	state := "caller"
+
+	return func() func() {
+		d.bound <- state
+
+		return func() { d.unbound <- state }
+	}
+}
+
+func TestGoroutineTracerDriver(t *testing.T) {
+	d := &goroutineTracerDriver{bound: make(chan string, 1), unbound: make(chan string, 1)}
+
+	// the state of the caller is bound to the goroutine performing the operation
+	ret, err := callWithRetryContext(context.Background(), newRetryLoop(NewRetryOneTime(0), d), func() (interface{}, error) {
+		return <-d.bound, nil
+	})
+
+	assert.Equal(t, "caller", ret)
+	assert.NoError(t, err)
+	assert.Equal(t, "caller", <-d.unbound)
+}
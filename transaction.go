@@ -1,9 +1,14 @@
 package curator
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/samuel/go-zookeeper/zk"
 )
 
+var ErrEmptyTransaction = errors.New("transaction has no operations")
+
 // Transactional/atomic operations.
 //
 // The general form for this interface is:
@@ -33,6 +38,9 @@ type Transaction interface {
 
 	// Start a check builder in the transaction
 	Check() TransactionCheckBuilder
+
+	// Panic instead of returning the error when the transaction is committed, for the setup code.
+	MustSucceed() Transaction
 }
 
 // Transaction operation types
@@ -71,8 +79,10 @@ type TransactionBridge interface {
 }
 
 type curatorTransaction struct {
-	client     *curatorFramework
-	operations []interface{}
+	client      *curatorFramework
+	operations  []interface{}
+	err         error // the first error raised while building the operations
+	mustSucceed bool
 }
 
 func (t *curatorTransaction) Create() TransactionCreateBuilder {
@@ -91,11 +101,43 @@ func (t *curatorTransaction) Check() TransactionCheckBuilder {
 	return &transactionCheckBuilder{transaction: t, version: AnyVersion}
 }
 
+func (t *curatorTransaction) MustSucceed() Transaction {
+	t.mustSucceed = true
+
+	return t
+}
+
 func (t *curatorTransaction) And() TransactionFinal {
 	return t
 }
 
+func (t *curatorTransaction) setError(err error) {
+	if t.err == nil {
+		t.err = err
+	}
+}
+
 func (t *curatorTransaction) Commit() ([]TransactionResult, error) {
+	results, err := t.commit()
+
+	if err != nil && t.mustSucceed {
+		panic(fmt.Errorf("fail to commit the transaction, %w", err))
+	}
+
+	return results, err
+}
+
+func (t *curatorTransaction) commit() ([]TransactionResult, error) {
+	if err := t.client.checkStarted(); err != nil {
+		return nil, err
+	}
+
+	if t.err != nil {
+		return nil, t.err
+	} else if len(t.operations) == 0 {
+		return nil, ErrEmptyTransaction
+	}
+
 	zkClient := t.client.ZookeeperClient()
 
 	result, err := zkClient.NewRetryLoop().CallWithRetry(func() (interface{}, error) {
@@ -152,18 +194,30 @@ func (b *transactionCreateBuilder) ForPath(path string) TransactionBridge {
 }
 
 func (b *transactionCreateBuilder) ForPathWithData(path string, payload []byte) TransactionBridge {
-	var data []byte
+	if err := b.transaction.client.validateCreateSchema(path, b.createMode, &b.acling); err != nil {
+		b.transaction.setError(err)
+	}
+
+	data, err := b.transaction.client.mutateCreate(path, payload)
+
+	if err != nil {
+		b.transaction.setError(err)
+	}
 
 	if b.compress {
-		data, _ = b.transaction.client.compressionProvider.Compress(path, payload)
-	} else {
-		data = payload
+		if compressed, err := b.transaction.client.compressionProvider.Compress(path, data); err != nil {
+			b.transaction.setError(err)
+		} else {
+			data = compressed
+		}
 	}
 
+	adjustedPath := b.transaction.client.fixForNamespace(path, b.createMode.IsSequential())
+
 	b.transaction.operations = append(b.transaction.operations, &zk.CreateRequest{
-		Path:  b.transaction.client.fixForNamespace(path, false),
+		Path:  adjustedPath,
 		Data:  data,
-		Acl:   b.acling.getAclList(path),
+		Acl:   b.acling.getAclList(adjustedPath),
 		Flags: int32(b.createMode),
 	})
 
@@ -194,6 +248,10 @@ type transactionDeleteBuilder struct {
 }
 
 func (b *transactionDeleteBuilder) ForPath(path string) TransactionBridge {
+	if err := b.transaction.client.validateSchema(path, SCHEMA_DELETE); err != nil {
+		b.transaction.setError(err)
+	}
+
 	b.transaction.operations = append(b.transaction.operations, &zk.DeleteRequest{
 		Path:    b.transaction.client.fixForNamespace(path, false),
 		Version: b.version,
@@ -219,12 +277,22 @@ func (b *transactionSetDataBuilder) ForPath(path string) TransactionBridge {
 }
 
 func (b *transactionSetDataBuilder) ForPathWithData(path string, payload []byte) TransactionBridge {
-	var data []byte
+	if err := b.transaction.client.validateSchema(path, SCHEMA_SET_DATA); err != nil {
+		b.transaction.setError(err)
+	}
+
+	data, err := b.transaction.client.mutateSet(path, payload)
+
+	if err != nil {
+		b.transaction.setError(err)
+	}
 
 	if b.compress {
-		data, _ = b.transaction.client.compressionProvider.Compress(path, payload)
-	} else {
-		data = payload
+		if compressed, err := b.transaction.client.compressionProvider.Compress(path, data); err != nil {
+			b.transaction.setError(err)
+		} else {
+			data = compressed
+		}
 	}
 
 	b.transaction.operations = append(b.transaction.operations, &zk.SetDataRequest{
@@ -254,6 +322,10 @@ type transactionCheckBuilder struct {
 }
 
 func (b *transactionCheckBuilder) ForPath(path string) TransactionBridge {
+	if err := b.transaction.client.validateSchema(path, SCHEMA_CHECK_EXISTS); err != nil {
+		b.transaction.setError(err)
+	}
+
 	b.transaction.operations = append(b.transaction.operations, &zk.CheckVersionRequest{
 		Path:    b.transaction.client.fixForNamespace(path, false),
 		Version: b.version,
@@ -74,3 +74,85 @@ func TestTransaction(t *testing.T) {
 		})
 	})
 }
+
+func TestEmptyTransaction(t *testing.T) {
+	newMockContainer().Test(t, func(client CuratorFramework, conn *mockConn) {
+		results, err := client.InTransaction().(TransactionFinal).Commit()
+
+		assert.Nil(t, results)
+		assert.Equal(t, ErrEmptyTransaction, err)
+		assert.Empty(t, conn.operations)
+	})
+}
+
+func TestTransactionCompressionError(t *testing.T) {
+	newMockContainer().Test(t, func(client CuratorFramework, conn *mockConn, compress *mockCompressionProvider, acls []zk.ACL) {
+		compress.On("Compress", "/node", []byte("data")).Return(nil, zk.ErrAPIError).Once()
+
+		results, err := client.InTransaction().
+			Create().WithACL(acls...).Compressed().ForPathWithData("/node", []byte("data")).
+			Commit()
+
+		assert.Nil(t, results)
+		assert.Equal(t, zk.ErrAPIError, err)
+		assert.Empty(t, conn.operations)
+	})
+}
+
+func TestTransactionMustSucceed(t *testing.T) {
+	newMockContainer().Test(t, func(client CuratorFramework, conn *mockConn, acls []zk.ACL) {
+		conn.On("Multi", mock.Anything).Return([]zk.MultiResponse{{Error: zk.ErrNodeExists}}, zk.ErrNodeExists).Once()
+
+		assert.PanicsWithError(t, "fail to commit the transaction, "+zk.ErrNodeExists.Error(), func() {
+			client.InTransaction().MustSucceed().Create().WithACL(acls...).ForPathWithData("/node", []byte("data")).Commit()
+		})
+	})
+}
+
+func TestTransactionRollback(t *testing.T) {
+	zookeeper := NewFakeZookeeper()
+
+	assert.NoError(t, zookeeper.Start())
+
+	defer zookeeper.Close()
+
+	builder := &CuratorFrameworkBuilder{
+		ZookeeperDialer:   zookeeper,
+		Namespace:         "parent",
+		SessionTimeout:    DEFAULT_SESSION_TIMEOUT,
+		ConnectionTimeout: DEFAULT_CONNECTION_TIMEOUT,
+		RetryPolicy:       NewRetryOneTime(0),
+	}
+
+	client := builder.ConnectString(zookeeper.ConnectString()).Build()
+
+	assert.NoError(t, client.Start())
+
+	defer client.Close()
+
+	_, err := client.Create().ForPathWithData("/node", []byte("data"))
+
+	assert.NoError(t, err)
+
+	results, err := client.InTransaction().
+		SetData().ForPathWithData("/node", []byte("new")).
+		Create().ForPath("/missing/child").
+		Commit()
+
+	assert.Equal(t, zk.ErrNoNode, err)
+	assert.Len(t, results, 2)
+
+	data, err := client.GetData().ForPath("/node")
+
+	assert.Equal(t, []byte("data"), data)
+	assert.NoError(t, err)
+
+	results, err = client.InTransaction().
+		Check().WithVersion(0).ForPath("/node").
+		SetData().WithVersion(0).ForPathWithData("/node", []byte("new")).
+		Commit()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "/parent/node", results[1].ForPath)
+	assert.Equal(t, int32(1), results[1].ResultStat.Version)
+}
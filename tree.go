@@ -0,0 +1,337 @@
+package curator
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/samuel/go-zookeeper/zk"
+)
+
+// The max number of the nodes created in one transaction by CuratorFramework.CopyTree,
+// the transaction is also limited to DEFAULT_MAX_REQUEST_SIZE bytes
+const DEFAULT_COPY_BATCH_SIZE = 100
+
+// Visit the nodes of the subtree walked by CuratorFramework.WalkTree
+type TreeVisitor interface {
+	// Called with the path without the namespace, stop walking the tree if it returns an error
+	VisitNode(path string, data []byte, stat *zk.Stat) error
+}
+
+type treeVisitorCallback func(path string, data []byte, stat *zk.Stat) error
+
+type treeVisitorStub struct {
+	callback treeVisitorCallback
+}
+
+func NewTreeVisitor(callback treeVisitorCallback) TreeVisitor {
+	return &treeVisitorStub{callback}
+}
+
+func (v *treeVisitorStub) VisitNode(path string, data []byte, stat *zk.Stat) error {
+	return v.callback(path, data, stat)
+}
+
+type walkOptions struct {
+	maxDepth int
+}
+
+type WalkOption func(options *walkOptions)
+
+// Only visit the nodes at most depth levels below the root of the subtree, the root itself is at depth 0
+func WithMaxDepth(depth int) WalkOption {
+	return func(options *walkOptions) { options.maxDepth = depth }
+}
+
+func (c *curatorFramework) WalkTree(path string, visitor TreeVisitor, options ...WalkOption) error {
+	if err := c.checkStarted(); err != nil {
+		return err
+	}
+
+	opts := walkOptions{maxDepth: -1}
+
+	for _, option := range options {
+		option(&opts)
+	}
+
+	type treeNode struct {
+		path  string
+		depth int
+	}
+
+	queue := []treeNode{{path, 0}}
+
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+
+		var stat zk.Stat
+
+		data, err := c.GetData().StoringStatIn(&stat).ForPath(node.path)
+
+		if err == zk.ErrNoNode && node.depth > 0 {
+			continue // deleted while walking the tree
+		} else if err != nil {
+			return err
+		}
+
+		if err := visitor.VisitNode(node.path, data, &stat); err != nil {
+			return err
+		}
+
+		if opts.maxDepth >= 0 && node.depth >= opts.maxDepth {
+			continue
+		}
+
+		children, err := c.GetChildren().ForPath(node.path)
+
+		if err == zk.ErrNoNode {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		for _, child := range children {
+			queue = append(queue, treeNode{JoinPath(node.path, child), node.depth + 1})
+		}
+	}
+
+	return nil
+}
+
+type copyOptions struct {
+	overwrite bool
+}
+
+type CopyOption func(options *copyOptions)
+
+// Delete the existing destination subtree before copying, instead of failing with zk.ErrNodeExists
+func Overwrite() CopyOption {
+	return func(options *copyOptions) { options.overwrite = true }
+}
+
+func (c *curatorFramework) CopyTree(src, dst string, options ...CopyOption) error {
+	if err := c.checkStarted(); err != nil {
+		return err
+	}
+
+	var opts copyOptions
+
+	for _, option := range options {
+		option(&opts)
+	}
+
+	var nodes []copiedNode
+
+	// read the whole subtree depth-first before creating any node, in case dst is under src
+	var read func(path string) error
+
+	read = func(path string) error {
+		data, err := c.GetData().ForPath(path)
+
+		if err == zk.ErrNoNode && len(nodes) > 0 {
+			return nil // deleted while reading the tree
+		} else if err != nil {
+			return err
+		}
+
+		acls, err := c.GetACL().ForPath(path)
+
+		if err == zk.ErrNoNode {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		nodes = append(nodes, copiedNode{JoinPath(dst, strings.TrimPrefix(path, strings.TrimSuffix(src, PATH_SEPARATOR))), data, acls})
+
+		children, err := c.GetChildren().ForPath(path)
+
+		if err == zk.ErrNoNode {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		for _, child := range children {
+			if err := read(JoinPath(path, child)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if err := read(src); err != nil {
+		return err
+	}
+
+	if opts.overwrite {
+		if err := c.Delete().DeletingChildrenIfNeeded().ForPath(dst); err != nil && err != zk.ErrNoNode {
+			return err
+		}
+	}
+
+	// the ephemeral nodes are copied as the persistent nodes, which are not bound to the session
+	if _, err := c.Create().CreatingParentsIfNeeded().WithACL(nodes[0].acls...).ForPathWithData(nodes[0].path, nodes[0].data); err != nil {
+		return err
+	}
+
+	for _, batch := range c.splitCopiedNodes(nodes[1:], DEFAULT_COPY_BATCH_SIZE, DEFAULT_MAX_REQUEST_SIZE) {
+		var transaction Transaction = c.InTransaction()
+
+		for _, node := range batch {
+			transaction = transaction.Create().WithACL(node.acls...).ForPathWithData(node.path, node.data).And()
+		}
+
+		if _, err := transaction.(TransactionFinal).Commit(); err != nil {
+			// the copied nodes are removed, but the overwritten destination can't be restored
+			c.Delete().DeletingChildrenIfNeeded().ForPath(dst)
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+type copiedNode struct {
+	path string
+	data []byte
+	acls []zk.ACL
+}
+
+// Split the nodes into the batches limited by the number of the nodes and the estimated size of the multi request
+func (c *curatorFramework) splitCopiedNodes(nodes []copiedNode, maxNodes, maxRequestSize int) [][]copiedNode {
+	var batches [][]copiedNode
+
+	for len(nodes) > 0 {
+		n := 0
+		size := multiHeaderSize
+
+		for ; n < len(nodes) && n < maxNodes; n++ {
+			node := nodes[n]
+
+			size += estimateCreateRequestSize(c.fixForNamespace(node.path, false), node.data, node.acls)
+
+			if size > maxRequestSize && n > 0 {
+				break // the oversize node is still sent alone, and rejected by the server
+			}
+		}
+
+		batches = append(batches, nodes[:n])
+		nodes = nodes[n:]
+	}
+
+	return batches
+}
+
+// The difference between two subtrees, the paths are relative to the roots of the subtrees
+type TreeDiff struct {
+	Added    []string `json:"added"`    // only in the second subtree
+	Removed  []string `json:"removed"`  // only in the first subtree
+	Modified []string `json:"modified"` // with different data or ACLs
+}
+
+// Return true if the subtrees are the same
+func (d *TreeDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Modified) == 0
+}
+
+type diffOptions struct {
+	ignoredSuffixes []string
+}
+
+type DiffOption func(options *diffOptions)
+
+// Ignore the nodes which relative path ends with any of the suffixes, e.g. the frequently updated timestamp nodes
+func IgnoreSuffixes(suffixes ...string) DiffOption {
+	return func(options *diffOptions) { options.ignoredSuffixes = append(options.ignoredSuffixes, suffixes...) }
+}
+
+func (o *diffOptions) ignored(path string) bool {
+	for _, suffix := range o.ignoredSuffixes {
+		if strings.HasSuffix(path, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type treeNodeSnapshot struct {
+	data []byte
+	acls []zk.ACL
+}
+
+// Read the data and ACLs of the nodes in the subtree, keyed by the relative path
+func (c *curatorFramework) snapshotTree(root string) (map[string]*treeNodeSnapshot, error) {
+	nodes := make(map[string]*treeNodeSnapshot)
+
+	err := c.WalkTree(root, NewTreeVisitor(func(path string, data []byte, stat *zk.Stat) error {
+		acls, err := c.GetACL().ForPath(path)
+
+		if err == zk.ErrNoNode {
+			return nil // deleted while walking the tree
+		} else if err != nil {
+			return err
+		}
+
+		nodes[JoinPath(PATH_SEPARATOR, strings.TrimPrefix(path, strings.TrimSuffix(root, PATH_SEPARATOR)))] = &treeNodeSnapshot{data, acls}
+
+		return nil
+	}))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return nodes, nil
+}
+
+func (c *curatorFramework) Diff(path1, path2 string, options ...DiffOption) (*TreeDiff, error) {
+	var opts diffOptions
+
+	for _, option := range options {
+		option(&opts)
+	}
+
+	nodes1, err := c.snapshotTree(path1)
+
+	if err != nil {
+		return nil, err
+	}
+
+	nodes2, err := c.snapshotTree(path2)
+
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &TreeDiff{}
+
+	for path, node1 := range nodes1 {
+		if opts.ignored(path) {
+			continue
+		}
+
+		if node2, exists := nodes2[path]; !exists {
+			diff.Removed = append(diff.Removed, path)
+		} else if !bytes.Equal(node1.data, node2.data) || !reflect.DeepEqual(node1.acls, node2.acls) {
+			diff.Modified = append(diff.Modified, path)
+		}
+	}
+
+	for path := range nodes2 {
+		if _, exists := nodes1[path]; !exists && !opts.ignored(path) {
+			diff.Added = append(diff.Added, path)
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Modified)
+
+	return diff, nil
+}
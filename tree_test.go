@@ -0,0 +1,299 @@
+package curator
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/samuel/go-zookeeper/zk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWalkTree(t *testing.T) {
+	zookeeper := NewFakeZookeeper()
+
+	assert.NoError(t, zookeeper.Start())
+
+	defer zookeeper.Close()
+
+	builder := &CuratorFrameworkBuilder{
+		ZookeeperDialer:   zookeeper,
+		Namespace:         "app",
+		SessionTimeout:    DEFAULT_SESSION_TIMEOUT,
+		ConnectionTimeout: DEFAULT_CONNECTION_TIMEOUT,
+		RetryPolicy:       NewRetryOneTime(0),
+	}
+
+	client := builder.ConnectString(zookeeper.ConnectString()).Build()
+
+	assert.Equal(t, ErrClientNotStarted, client.WalkTree("/", NewTreeVisitor(nil)))
+	assert.NoError(t, client.Start())
+
+	defer client.Close()
+
+	for _, path := range []string{"/root/a/x", "/root/b"} {
+		_, err := client.Create().CreatingParentsIfNeeded().ForPathWithData(path, []byte(path))
+
+		assert.NoError(t, err)
+	}
+
+	var paths []string
+
+	visitor := NewTreeVisitor(func(path string, data []byte, stat *zk.Stat) error {
+		paths = append(paths, path)
+
+		return nil
+	})
+
+	// breadth-first without the namespace
+	assert.NoError(t, client.WalkTree("/root", visitor))
+	assert.Equal(t, []string{"/root", "/root/a", "/root/b", "/root/a/x"}, paths)
+
+	paths = nil
+
+	assert.NoError(t, client.WalkTree("/root", visitor, WithMaxDepth(1)))
+	assert.Equal(t, []string{"/root", "/root/a", "/root/b"}, paths)
+
+	// the data and stat of the node
+	assert.NoError(t, client.WalkTree("/root/b", NewTreeVisitor(func(path string, data []byte, stat *zk.Stat) error {
+		assert.Equal(t, []byte("/root/b"), data)
+		assert.Equal(t, int32(0), stat.NumChildren)
+
+		return nil
+	})))
+
+	// stop walking the tree on error
+	errStop := errors.New("stop")
+
+	paths = nil
+
+	assert.Equal(t, errStop, client.WalkTree("/root", NewTreeVisitor(func(path string, data []byte, stat *zk.Stat) error {
+		paths = append(paths, path)
+
+		if path == "/root/a" {
+			return errStop
+		}
+
+		return nil
+	})))
+	assert.Equal(t, []string{"/root", "/root/a"}, paths)
+
+	assert.Equal(t, zk.ErrNoNode, client.WalkTree("/missing", visitor))
+}
+
+func TestCopyTree(t *testing.T) {
+	zookeeper := NewFakeZookeeper()
+
+	assert.NoError(t, zookeeper.Start())
+
+	defer zookeeper.Close()
+
+	builder := &CuratorFrameworkBuilder{
+		ZookeeperDialer:   zookeeper,
+		Namespace:         "app",
+		SessionTimeout:    DEFAULT_SESSION_TIMEOUT,
+		ConnectionTimeout: DEFAULT_CONNECTION_TIMEOUT,
+		RetryPolicy:       NewRetryOneTime(0),
+	}
+
+	client := builder.ConnectString(zookeeper.ConnectString()).Build()
+
+	assert.NoError(t, client.Start())
+
+	defer client.Close()
+
+	acls := zk.DigestACL(zk.PermAll, "user", "password")
+
+	_, err := client.Create().CreatingParentsIfNeeded().ForPathWithData("/src/a/x", []byte("x"))
+
+	assert.NoError(t, err)
+
+	_, err = client.Create().WithACL(acls...).ForPathWithData("/src/b", []byte("b"))
+
+	assert.NoError(t, err)
+
+	_, err = client.Create().WithMode(EPHEMERAL).ForPathWithData("/src/e", []byte("e"))
+
+	assert.NoError(t, err)
+
+	assert.NoError(t, client.CopyTree("/src", "/backup/dst"))
+
+	copied := make(map[string]string)
+
+	assert.NoError(t, client.WalkTree("/backup/dst", NewTreeVisitor(func(path string, data []byte, stat *zk.Stat) error {
+		copied[path] = string(data)
+
+		// the ephemeral nodes are copied as the persistent nodes
+		assert.Equal(t, int64(0), stat.EphemeralOwner)
+
+		return nil
+	})))
+	assert.Equal(t, map[string]string{
+		"/backup/dst":     "",
+		"/backup/dst/a":   "",
+		"/backup/dst/a/x": "x",
+		"/backup/dst/b":   "b",
+		"/backup/dst/e":   "e",
+	}, copied)
+
+	copiedACLs, err := client.GetACL().ForPath("/backup/dst/b")
+
+	assert.Equal(t, acls, copiedACLs)
+	assert.NoError(t, err)
+
+	// the destination exists
+	assert.Equal(t, zk.ErrNodeExists, client.CopyTree("/src/a", "/backup/dst"))
+
+	assert.NoError(t, client.CopyTree("/src/a", "/backup/dst", Overwrite()))
+
+	children, err := client.GetChildren().ForPath("/backup/dst")
+
+	assert.Equal(t, []string{"x"}, children)
+	assert.NoError(t, err)
+}
+
+func TestCopyTreeBatches(t *testing.T) {
+	zookeeper := NewFakeZookeeper()
+
+	assert.NoError(t, zookeeper.Start())
+
+	defer zookeeper.Close()
+
+	var multiCalls []int
+
+	failAt := 0
+
+	policy := InjectionPolicyFunc(func(operation string, call int) (time.Duration, error) {
+		if operation != "Multi" {
+			return 0, nil
+		}
+
+		multiCalls = append(multiCalls, call)
+
+		if call == failAt {
+			return 0, zk.ErrNoAuth
+		}
+
+		return 0, nil
+	})
+
+	builder := &CuratorFrameworkBuilder{
+		ZookeeperDialer: NewZookeeperDialer(func(connString string, sessionTimeout time.Duration, canBeReadOnly bool) (ZookeeperConnection, <-chan zk.Event, error) {
+			conn, events, err := zookeeper.Dial(connString, sessionTimeout, canBeReadOnly)
+
+			if err != nil {
+				return nil, nil, err
+			}
+
+			return NewErrorInjector(conn, policy), events, nil
+		}),
+		SessionTimeout:    DEFAULT_SESSION_TIMEOUT,
+		ConnectionTimeout: DEFAULT_CONNECTION_TIMEOUT,
+		RetryPolicy:       NewRetryOneTime(0),
+	}
+
+	client := builder.ConnectString(zookeeper.ConnectString()).Build()
+
+	assert.NoError(t, client.Start())
+
+	defer client.Close()
+
+	// two nodes fit in a request of DEFAULT_MAX_REQUEST_SIZE bytes
+	data := make([]byte, DEFAULT_MAX_REQUEST_SIZE/3)
+
+	for _, path := range []string{"/src/a", "/src/b", "/src/c"} {
+		_, err := client.Create().CreatingParentsIfNeeded().ForPathWithData(path, data)
+
+		assert.NoError(t, err)
+	}
+
+	assert.NoError(t, client.CopyTree("/src", "/dst"))
+	assert.Equal(t, []int{1, 2}, multiCalls)
+
+	children, err := client.GetChildren().ForPath("/dst")
+
+	assert.Len(t, children, 3)
+	assert.NoError(t, err)
+
+	// the copied nodes are deleted when a batch failed
+	failAt = 4
+
+	assert.Equal(t, zk.ErrNoAuth, client.CopyTree("/src", "/failed"))
+
+	stat, err := client.CheckExists().ForPath("/failed")
+
+	assert.Nil(t, stat)
+	assert.NoError(t, err)
+}
+
+func TestDiff(t *testing.T) {
+	zookeeper := NewFakeZookeeper()
+
+	assert.NoError(t, zookeeper.Start())
+
+	defer zookeeper.Close()
+
+	builder := &CuratorFrameworkBuilder{
+		ZookeeperDialer:   zookeeper,
+		Namespace:         "app",
+		SessionTimeout:    DEFAULT_SESSION_TIMEOUT,
+		ConnectionTimeout: DEFAULT_CONNECTION_TIMEOUT,
+		RetryPolicy:       NewRetryOneTime(0),
+	}
+
+	client := builder.ConnectString(zookeeper.ConnectString()).Build()
+
+	assert.NoError(t, client.Start())
+
+	defer client.Close()
+
+	for path, data := range map[string]string{
+		"/v1/same":      "same",
+		"/v1/data":      "old",
+		"/v1/acl":       "acl",
+		"/v1/removed":   "removed",
+		"/v1/timestamp": "1",
+		"/v2/same":      "same",
+		"/v2/data":      "new",
+		"/v2/added/x":   "added",
+		"/v2/timestamp": "2",
+	} {
+		_, err := client.Create().CreatingParentsIfNeeded().ForPathWithData(path, []byte(data))
+
+		assert.NoError(t, err)
+	}
+
+	_, err := client.Create().WithACL(zk.DigestACL(zk.PermAll, "user", "password")...).ForPathWithData("/v2/acl", []byte("acl"))
+
+	assert.NoError(t, err)
+
+	diff, err := client.Diff("/v1", "/v2")
+
+	assert.Equal(t, &TreeDiff{
+		Added:    []string{"/added", "/added/x"},
+		Removed:  []string{"/removed"},
+		Modified: []string{"/acl", "/data", "/timestamp"},
+	}, diff)
+	assert.NoError(t, err)
+
+	diff, err = client.Diff("/v1", "/v2", IgnoreSuffixes("/timestamp", "/removed"))
+
+	assert.Equal(t, []string{"/acl", "/data"}, diff.Modified)
+	assert.Empty(t, diff.Removed)
+	assert.NoError(t, err)
+
+	data, err := json.Marshal(diff)
+
+	assert.Equal(t, `{"added":["/added","/added/x"],"removed":null,"modified":["/acl","/data"]}`, string(data))
+	assert.NoError(t, err)
+
+	diff, err = client.Diff("/v1/same", "/v2/same")
+
+	assert.True(t, diff.IsEmpty())
+	assert.NoError(t, err)
+
+	_, err = client.Diff("/v1", "/missing")
+
+	assert.Equal(t, zk.ErrNoNode, err)
+}
@@ -0,0 +1,81 @@
+package curator
+
+import (
+	"fmt"
+
+	"github.com/samuel/go-zookeeper/zk"
+)
+
+// The version of a node when it was read, used to update the node only if it hasn't been changed since.
+type OptimisticLock struct {
+	Path    string
+	Version int32
+}
+
+// Returned by VersionedSet when the node has been changed since it was read
+type StaleVersionError struct {
+	Path     string
+	Expected int32
+	Actual   int32
+}
+
+func (e *StaleVersionError) Error() string {
+	return fmt.Sprintf("stale version at path `%s`, expected %d but got %d", e.Path, e.Expected, e.Actual)
+}
+
+// The stale version is reported by ZooKeeper as zk.ErrBadVersion
+func (e *StaleVersionError) Unwrap() error {
+	return zk.ErrBadVersion
+}
+
+// Get the deserialized value of the node, with the OptimisticLock to update it later
+func VersionedGet[T any](client CuratorFramework, path string, deserialize func([]byte) (T, error)) (T, *OptimisticLock, error) {
+	var value T
+	var stat zk.Stat
+
+	data, err := client.GetData().StoringStatIn(&stat).ForPath(path)
+
+	if err != nil {
+		return value, nil, err
+	}
+
+	if value, err = deserialize(data); err != nil {
+		return value, nil, err
+	}
+
+	return value, &OptimisticLock{path, stat.Version}, nil
+}
+
+// Set the serialized value of the node if its version still matches the OptimisticLock,
+// the lock is updated to the new version, so it can be used for the next update.
+//
+// The lock must be read from the same path, the version of another node is meaningless.
+func VersionedSet[T any](client CuratorFramework, path string, value T, serialize func(T) ([]byte, error), lock *OptimisticLock) error {
+	if lock.Path != path {
+		return fmt.Errorf("optimistic lock path mismatch, expected %s but got %s", path, lock.Path)
+	}
+
+	data, err := serialize(value)
+
+	if err != nil {
+		return err
+	}
+
+	stat, err := client.SetData().WithVersion(lock.Version).ForPathWithData(path, data)
+
+	if err == zk.ErrBadVersion {
+		actual := int32(-1)
+
+		if stat, err := client.CheckExists().ForPath(path); err == nil && stat != nil {
+			actual = stat.Version
+		}
+
+		return &StaleVersionError{path, lock.Version, actual}
+	} else if err != nil {
+		return err
+	}
+
+	lock.Version = stat.Version
+
+	return nil
+}
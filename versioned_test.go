@@ -0,0 +1,85 @@
+package curator
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/samuel/go-zookeeper/zk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionedGetSet(t *testing.T) {
+	zookeeper := NewFakeZookeeper()
+
+	assert.NoError(t, zookeeper.Start())
+
+	defer zookeeper.Close()
+
+	builder := &CuratorFrameworkBuilder{
+		ZookeeperDialer:   zookeeper,
+		SessionTimeout:    DEFAULT_SESSION_TIMEOUT,
+		ConnectionTimeout: DEFAULT_CONNECTION_TIMEOUT,
+		RetryPolicy:       NewRetryOneTime(0),
+	}
+
+	client := builder.ConnectString(zookeeper.ConnectString()).Build()
+
+	assert.NoError(t, client.Start())
+
+	defer client.Close()
+
+	_, err := client.Create().ForPathWithData("/counter", []byte("1"))
+
+	assert.NoError(t, err)
+
+	serialize := func(n int) ([]byte, error) { return []byte(strconv.Itoa(n)), nil }
+	deserialize := func(data []byte) (int, error) { return strconv.Atoi(string(data)) }
+
+	value, lock, err := VersionedGet(client, "/counter", deserialize)
+
+	assert.Equal(t, 1, value)
+	assert.Equal(t, &OptimisticLock{"/counter", 0}, lock)
+	assert.NoError(t, err)
+
+	// the lock is updated to the new version
+	assert.NoError(t, VersionedSet(client, "/counter", value+1, serialize, lock))
+	assert.Equal(t, int32(1), lock.Version)
+
+	// the node changed by the others
+	_, err = client.SetData().ForPathWithData("/counter", []byte("10"))
+
+	assert.NoError(t, err)
+
+	err = VersionedSet(client, "/counter", value+2, serialize, lock)
+
+	assert.Equal(t, &StaleVersionError{"/counter", 1, 2}, err)
+	assert.EqualError(t, err, "stale version at path `/counter`, expected 1 but got 2")
+	assert.True(t, errors.Is(err, zk.ErrBadVersion))
+
+	// the lock of another node
+	_, err = client.Create().ForPathWithData("/other", []byte("1"))
+
+	assert.NoError(t, err)
+
+	_, otherLock, err := VersionedGet(client, "/other", deserialize)
+
+	assert.NoError(t, err)
+	assert.EqualError(t, VersionedSet(client, "/counter", value+2, serialize, otherLock),
+		"optimistic lock path mismatch, expected /counter but got /other")
+
+	data, err := client.GetData().ForPath("/counter")
+
+	assert.Equal(t, []byte("10"), data)
+	assert.NoError(t, err)
+
+	// the deserialize error
+	_, err = client.Create().ForPathWithData("/invalid", []byte("NaN"))
+
+	assert.NoError(t, err)
+
+	_, lock, err = VersionedGet(client, "/invalid", deserialize)
+
+	assert.Nil(t, lock)
+	assert.Error(t, err)
+}
@@ -1,6 +1,8 @@
 package curator
 
 import (
+	"errors"
+	"reflect"
 	"sync"
 
 	"github.com/samuel/go-zookeeper/zk"
@@ -22,6 +24,35 @@ func (w *simpleWatcher) process(event *zk.Event) {
 	w.Func(event)
 }
 
+// Decide whether the watched event should be delivered to the watcher
+type WatchEventFilter func(event *zk.Event) bool
+
+// Only accept the events of the given types
+func WatchEventTypes(types ...zk.EventType) WatchEventFilter {
+	return func(event *zk.Event) bool {
+		for _, t := range types {
+			if event.Type == t {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// Wrap the watcher to discard the events rejected by any of the filters
+func NewFilteredWatcher(watcher Watcher, filters ...WatchEventFilter) Watcher {
+	return NewWatcher(func(event *zk.Event) {
+		for _, filter := range filters {
+			if !filter(event) {
+				return
+			}
+		}
+
+		watcher.process(event)
+	})
+}
+
 type Watchers struct {
 	lock     sync.Mutex
 	watchers []Watcher
@@ -75,3 +106,295 @@ func (w *Watchers) Watch(events <-chan zk.Event) {
 		}
 	}
 }
+
+var ErrNoWatchedEvent = errors.New("all the channels are closed without any event")
+
+// Fan-in the events of the channels into one, which is closed after all the channels are closed
+func MergeWatchers(channels ...<-chan zk.Event) <-chan zk.Event {
+	merged := make(chan zk.Event)
+
+	var wg sync.WaitGroup
+
+	wg.Add(len(channels))
+
+	for _, events := range channels {
+		go func(events <-chan zk.Event) {
+			defer wg.Done()
+
+			for event := range events {
+				merged <- event
+			}
+		}(events)
+	}
+
+	go func() {
+		wg.Wait()
+
+		close(merged)
+	}()
+
+	return merged
+}
+
+// Wait for the first event from any of the channels, the others are no longer received,
+// returns ErrNoWatchedEvent if all the channels are closed without any event.
+func FirstEventFrom(channels ...<-chan zk.Event) (zk.Event, error) {
+	cases := make([]reflect.SelectCase, len(channels))
+
+	for i, events := range channels {
+		cases[i] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(events)}
+	}
+
+	for remaining := len(cases); remaining > 0; remaining-- {
+		chosen, value, ok := reflect.Select(cases)
+
+		if ok {
+			return value.Interface().(zk.Event), nil
+		}
+
+		cases[chosen].Chan = reflect.Value{} // never chosen again
+	}
+
+	return zk.Event{}, ErrNoWatchedEvent
+}
+
+// The type of the watches to remove
+type WatcherType int32
+
+const (
+	WATCHER_TYPE_CHILDREN WatcherType = 1
+	WATCHER_TYPE_DATA     WatcherType = 2
+	WATCHER_TYPE_ANY      WatcherType = 3
+)
+
+var (
+	ErrWatchNotRegistered       = errors.New("the watch is not registered")
+	ErrWatchRemovalNotSupported = errors.New("the connection doesn't support to remove the watches")
+)
+
+// The connection which is able to remove the watches from the server (ZooKeeper 3.6+)
+type WatchRemovalConnection interface {
+	// Remove the watches of the given type on the path set by this connection
+	RemoveWatch(path string, watcherType WatcherType) error
+}
+
+// The handle of a watch set by the builders, which could be removed explicitly
+//
+//	var registration WatchRegistration
+//
+//	data, err := client.GetData().UsingWatcher(watcher).StoringWatchRegistrationIn(&registration).ForPath(path)
+//
+//	registration.Remove()
+type WatchRegistration struct {
+	lock        sync.Mutex
+	client      *curatorFramework
+	path        string
+	watcherType WatcherType
+	done        chan struct{}
+}
+
+// The path of the watched node, with the namespace
+func (r *WatchRegistration) Path() string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.path
+}
+
+func (r *WatchRegistration) register(client *curatorFramework, path string, watcherType WatcherType) <-chan struct{} {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.client = client
+	r.path = path
+	r.watcherType = watcherType
+	r.done = make(chan struct{})
+
+	return r.done
+}
+
+// Stop delivering the events of the watch, and remove it from the server if the connection supports it.
+func (r *WatchRegistration) Remove() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.done == nil {
+		return ErrWatchNotRegistered
+	}
+
+	select {
+	case <-r.done:
+		return nil // already removed
+	default:
+		close(r.done)
+	}
+
+	conn, err := r.client.ZookeeperClient().Conn()
+
+	if err != nil {
+		return err
+	}
+
+	if removal, ok := conn.(WatchRemovalConnection); ok {
+		if err := removal.RemoveWatch(r.path, r.watcherType); err != nil && err != ErrWatchRemovalNotSupported {
+			return err
+		}
+	}
+
+	return nil
+}
+
+const DEFAULT_EVENT_BUS_BUFFER_SIZE = 16
+
+// Fan-out the watched events to all the subscribers.
+//
+// Each event is delivered to the subscribers without blocking,
+// the subscriber which buffer is full will be dropped and its channel closed.
+type EventBus struct {
+	lock        sync.Mutex
+	bufferSize  int
+	subscribers []chan zk.Event
+	closed      bool
+}
+
+func NewEventBus() *EventBus {
+	return NewEventBusWithBufferSize(DEFAULT_EVENT_BUS_BUFFER_SIZE)
+}
+
+func NewEventBusWithBufferSize(bufferSize int) *EventBus {
+	return &EventBus{bufferSize: bufferSize}
+}
+
+// Return a new channel that receives the events published after the subscription
+func (b *EventBus) Subscribe() <-chan zk.Event {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	c := make(chan zk.Event, b.bufferSize)
+
+	if b.closed {
+		close(c)
+	} else {
+		b.subscribers = append(b.subscribers, c)
+	}
+
+	return c
+}
+
+// Remove the subscriber and close its channel
+func (b *EventBus) Unsubscribe(c <-chan zk.Event) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	for i, subscriber := range b.subscribers {
+		if subscriber == c {
+			b.subscribers = append(b.subscribers[:i], b.subscribers[i+1:]...)
+
+			close(subscriber)
+
+			return
+		}
+	}
+}
+
+// Deliver the event to all the subscribers, the slow subscribers will be dropped
+func (b *EventBus) Publish(event zk.Event) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	subscribers := b.subscribers[:0]
+
+	for _, subscriber := range b.subscribers {
+		select {
+		case subscriber <- event:
+			subscribers = append(subscribers, subscriber)
+		default:
+			close(subscriber)
+		}
+	}
+
+	b.subscribers = subscribers
+}
+
+// Close the channels of all the subscribers
+func (b *EventBus) Close() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	b.closed = true
+
+	for _, subscriber := range b.subscribers {
+		close(subscriber)
+	}
+
+	b.subscribers = nil
+}
+
+const DEFAULT_WATCHED_EVENTS_BUFFER_SIZE = 100
+
+// Forward the raw watched events to the registered channels.
+//
+// The events are sent without blocking, so a full channel misses the events instead of stalling the event pump.
+type watchedEventChannels struct {
+	lock     sync.Mutex
+	channels []chan<- zk.Event
+	owned    []chan zk.Event // created by the framework and closed with it
+	closed   bool
+}
+
+func (c *watchedEventChannels) Add(ch chan<- zk.Event) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.channels = append(c.channels, ch)
+}
+
+func (c *watchedEventChannels) New(bufferSize int) <-chan zk.Event {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	ch := make(chan zk.Event, bufferSize)
+
+	if c.closed {
+		close(ch)
+	} else {
+		c.channels = append(c.channels, ch)
+		c.owned = append(c.owned, ch)
+	}
+
+	return ch
+}
+
+func (c *watchedEventChannels) Publish(event zk.Event) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for _, ch := range c.channels {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+func (c *watchedEventChannels) Close() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.closed {
+		return
+	}
+
+	c.closed = true
+
+	for _, ch := range c.owned {
+		close(ch)
+	}
+
+	c.channels = nil
+	c.owned = nil
+}
@@ -66,3 +66,187 @@ func TestWatchers(t *testing.T) {
 	assert.Equal(t, 1, len(events[2]))
 	assert.Equal(t, &evt, events[0][1])
 }
+
+func TestEventBus(t *testing.T) {
+	bus := NewEventBusWithBufferSize(1)
+
+	s1 := bus.Subscribe()
+	s2 := bus.Subscribe()
+	s3 := bus.Subscribe()
+
+	bus.Unsubscribe(s3)
+
+	_, ok := <-s3
+
+	assert.False(t, ok)
+
+	bus.Publish(zk.Event{Type: zk.EventNodeCreated, Path: "/node"})
+
+	assert.Equal(t, zk.Event{Type: zk.EventNodeCreated, Path: "/node"}, <-s1)
+
+	// the slow subscriber will be dropped
+	bus.Publish(zk.Event{Type: zk.EventNodeDeleted, Path: "/node"})
+
+	assert.Equal(t, zk.Event{Type: zk.EventNodeDeleted, Path: "/node"}, <-s1)
+	assert.Equal(t, zk.Event{Type: zk.EventNodeCreated, Path: "/node"}, <-s2)
+
+	_, ok = <-s2
+
+	assert.False(t, ok)
+
+	bus.Close()
+	bus.Close()
+
+	_, ok = <-s1
+
+	assert.False(t, ok)
+
+	// subscribe a closed bus
+	_, ok = <-bus.Subscribe()
+
+	assert.False(t, ok)
+}
+
+func TestWatchedEventChannels(t *testing.T) {
+	channels := &watchedEventChannels{}
+
+	owned := channels.New(1)
+	registered := make(chan zk.Event, 1)
+
+	channels.Add(registered)
+
+	channels.Publish(zk.Event{Type: zk.EventNodeCreated, Path: "/node"})
+
+	// the full channels miss the events without blocking
+	channels.Publish(zk.Event{Type: zk.EventNodeDeleted, Path: "/node"})
+
+	assert.Equal(t, zk.Event{Type: zk.EventNodeCreated, Path: "/node"}, <-owned)
+	assert.Equal(t, zk.Event{Type: zk.EventNodeCreated, Path: "/node"}, <-registered)
+
+	channels.Close()
+	channels.Close()
+
+	// only the owned channels are closed
+	_, ok := <-owned
+
+	assert.False(t, ok)
+	assert.Empty(t, registered)
+
+	_, ok = <-channels.New(1)
+
+	assert.False(t, ok)
+}
+
+func TestFilteredWatcher(t *testing.T) {
+	var events []zk.EventType
+
+	w := NewFilteredWatcher(NewWatcher(func(event *zk.Event) {
+		events = append(events, event.Type)
+	}), WatchEventTypes(zk.EventNodeDataChanged, zk.EventNodeDeleted), func(event *zk.Event) bool {
+		return event.Path == "/node"
+	})
+
+	w.process(&zk.Event{Type: zk.EventNodeChildrenChanged, Path: "/node"})
+	w.process(&zk.Event{Type: zk.EventNodeDataChanged, Path: "/node"})
+	w.process(&zk.Event{Type: zk.EventNodeDataChanged, Path: "/other"})
+	w.process(&zk.Event{Type: zk.EventNodeDeleted, Path: "/node"})
+
+	assert.Equal(t, []zk.EventType{zk.EventNodeDataChanged, zk.EventNodeDeleted}, events)
+}
+
+func TestWatchRegistrationRemoval(t *testing.T) {
+	zookeeper := NewFakeZookeeper()
+
+	assert.NoError(t, zookeeper.Start())
+
+	defer zookeeper.Close()
+
+	builder := &CuratorFrameworkBuilder{
+		ZookeeperDialer:   zookeeper,
+		SessionTimeout:    DEFAULT_SESSION_TIMEOUT,
+		ConnectionTimeout: DEFAULT_CONNECTION_TIMEOUT,
+		RetryPolicy:       NewRetryOneTime(0),
+	}
+
+	client := builder.ConnectString(zookeeper.ConnectString()).Build()
+
+	assert.NoError(t, client.Start())
+
+	defer client.Close()
+
+	_, err := client.Create().ForPath("/parent")
+
+	assert.NoError(t, err)
+
+	var registration WatchRegistration
+
+	events := make(chan *zk.Event, 1)
+
+	_, err = client.GetChildren().UsingWatcher(NewWatcher(func(event *zk.Event) { events <- event })).
+		StoringWatchRegistrationIn(&registration).ForPath("/parent")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, zookeeper.WatchCount())
+
+	assert.NoError(t, registration.Remove())
+
+	// the watch has been removed from the server
+	assert.Zero(t, zookeeper.WatchCount())
+
+	_, err = client.Create().ForPath("/parent/child")
+
+	assert.NoError(t, err)
+
+	select {
+	case event := <-events:
+		t.Errorf("unexpected event %v", event)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestMergeWatchers(t *testing.T) {
+	c1 := make(chan zk.Event)
+	c2 := make(chan zk.Event)
+
+	merged := MergeWatchers(c1, c2)
+
+	go func() { c1 <- zk.Event{Path: "/a"} }()
+
+	assert.Equal(t, zk.Event{Path: "/a"}, <-merged)
+
+	// closing one of the channels keeps the merged channel open
+	close(c1)
+
+	go func() { c2 <- zk.Event{Path: "/b"} }()
+
+	event, ok := <-merged
+
+	assert.Equal(t, zk.Event{Path: "/b"}, event)
+	assert.True(t, ok)
+
+	close(c2)
+
+	_, ok = <-merged
+
+	assert.False(t, ok)
+}
+
+func TestFirstEventFrom(t *testing.T) {
+	c1 := make(chan zk.Event)
+	c2 := make(chan zk.Event, 1)
+
+	close(c1)
+
+	c2 <- zk.Event{Path: "/b"}
+
+	event, err := FirstEventFrom(c1, c2)
+
+	assert.Equal(t, zk.Event{Path: "/b"}, event)
+	assert.NoError(t, err)
+
+	close(c2)
+
+	_, err = FirstEventFrom(c1, c2)
+
+	assert.Equal(t, ErrNoWatchedEvent, err)
+}